@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -31,6 +32,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/component-base/config"
 	"k8s.io/component-base/config/options"
 	"k8s.io/klog/v2"
@@ -38,9 +40,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/yaml"
 
 	cpmscontroller "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/controllers/controlplanemachineset"
 	cpmsgeneratorcontroller "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/controllers/controlplanemachinesetgenerator"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
 	cpmswebhook "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/webhooks/controlplanemachineset"
 
@@ -54,6 +58,12 @@ import (
 const (
 	// defaultLeaderElectionID is the default name to use for the leader election resource.
 	defaultLeaderElectionID = "control-plane-machine-set-leader"
+
+	// clusterControlPlaneMachineSetName is the name of the ControlPlaneMachineSet managed by the operator.
+	clusterControlPlaneMachineSetName = "cluster"
+
+	// tracerProviderShutdownTimeout is how long to wait for the recorded spans to be exported once the manager stops.
+	tracerProviderShutdownTimeout = 5 * time.Second
 )
 
 const (
@@ -76,6 +86,15 @@ func main() { //nolint:funlen,cyclop
 		webhookPort      int
 		managedNamespace string
 
+		rolloutStuckTimeout             time.Duration
+		replacementProvisioningDeadline time.Duration
+
+		maxConcurrentReconciles int
+		auditEvents             bool
+
+		validateFile string
+		diagnostics  bool
+
 		leaderElectionConfig = config.LeaderElectionConfiguration{
 			LeaderElect:  true,
 			ResourceName: defaultLeaderElectionID,
@@ -89,6 +108,12 @@ func main() { //nolint:funlen,cyclop
 	pflag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	pflag.IntVar(&webhookPort, "webhook-port", 9443, "Webhook Server port, enabled by default at port 9443. Set to 0 to disable webhooks.")
 	pflag.StringVar(&managedNamespace, "namespace", "openshift-machine-api", "The namespace for managed objects, where the machines and control plane machine set will operate.")
+	pflag.DurationVar(&rolloutStuckTimeout, "rollout-stuck-timeout", 0, "The duration after which a rolling update that has not updated any further machines marks the operator as degraded. Set to 0 to disable.")
+	pflag.DurationVar(&replacementProvisioningDeadline, "replacement-provisioning-deadline", 0, "The duration after its creation within which a replacement machine must be provisioned. A replacement that is not provisioned in time is deleted and created again. Set to 0 to disable.")
+	pflag.BoolVar(&auditEvents, "audit-events", false, "Emit an audit event on the control plane machine set for each machine created or deleted, recording when and why it was created or deleted.")
+	pflag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1, "The maximum number of control plane machine set reconciles that may run at the same time.")
+	pflag.StringVar(&validateFile, "validate-file", "", "Path to a proposed ControlPlaneMachineSet manifest. When set, the operator does not start, and instead prints which control plane machines would need an update if the manifest were applied.")
+	pflag.BoolVar(&diagnostics, "diagnostics", false, "When set, the operator does not start, and instead prints a JSON snapshot of the control plane machine set, its machines, their computed machine information, the failure domain mapping, the etcd member mapping and the current conditions, for inclusion in a support bundle.")
 	options.BindLeaderElectionFlags(&leaderElectionConfig, pflag.CommandLine)
 
 	klog.InitFlags(flag.CommandLine)
@@ -100,6 +125,25 @@ func main() { //nolint:funlen,cyclop
 	ctrl.SetLogger(logger)
 
 	cfg := ctrl.GetConfigOrDie()
+
+	if validateFile != "" {
+		if err := validateControlPlaneMachineSet(ctrl.SetupSignalHandler(), logger, cfg, scheme, validateFile, managedNamespace); err != nil {
+			setupLog.Error(err, "unable to validate control plane machine set", "file", validateFile)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if diagnostics {
+		if err := collectDiagnostics(ctrl.SetupSignalHandler(), logger, cfg, scheme, managedNamespace); err != nil {
+			setupLog.Error(err, "unable to collect diagnostics")
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	le := util.GetLeaderElectionDefaults(cfg, configv1.LeaderElection{
 		Disable:       !leaderElectionConfig.LeaderElect,
 		RenewDeadline: leaderElectionConfig.RenewDeadline,
@@ -127,6 +171,13 @@ func main() { //nolint:funlen,cyclop
 		os.Exit(1)
 	}
 
+	// The tracer provider is configured through the standard OTEL_* environment variables.
+	tracerProvider, shutdownTracerProvider, err := util.NewTracerProviderFromEnv(logger.WithName("tracing"))
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	// Define an uncached client.
 	// More resource intensive than the default client,
 	// is to be used only in situations where we want to avoid the cache.
@@ -145,6 +196,14 @@ func main() { //nolint:funlen,cyclop
 		Namespace:      managedNamespace,
 		OperatorName:   "control-plane-machine-set",
 		ReleaseVersion: getReleaseVersion(setupLog),
+
+		RolloutStuckTimeout:             rolloutStuckTimeout,
+		ReplacementProvisioningDeadline: replacementProvisioningDeadline,
+		MaxConcurrentReconciles:         maxConcurrentReconciles,
+		AuditEvents:                     auditEvents,
+		Recorder:                        mgr.GetEventRecorderFor("control-plane-machine-set-operator"),
+		EtcdMembers:                     providers.NewEtcdEndpointsMemberLister(uncachedClient),
+		TracerProvider:                  tracerProvider,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ControlPlaneMachineSet")
 		os.Exit(1)
@@ -160,7 +219,9 @@ func main() { //nolint:funlen,cyclop
 	}
 
 	if webhookPort != 0 {
-		if err := (&cpmswebhook.ControlPlaneMachineSetWebhook{}).SetupWebhookWithManager(mgr, logger); err != nil {
+		if err := (&cpmswebhook.ControlPlaneMachineSetWebhook{
+			Namespace: managedNamespace,
+		}).SetupWebhookWithManager(mgr, logger); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ControlPlaneMachineSet")
 			os.Exit(1)
 		}
@@ -180,8 +241,18 @@ func main() { //nolint:funlen,cyclop
 
 	setupLog.Info("starting manager")
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		setupLog.Error(err, "problem running manager")
+	startErr := mgr.Start(ctrl.SetupSignalHandler())
+
+	// Flush the spans recorded before the manager stopped.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), tracerProviderShutdownTimeout)
+	if err := shutdownTracerProvider(shutdownCtx); err != nil {
+		setupLog.Error(err, "unable to shut down tracing")
+	}
+
+	cancel()
+
+	if startErr != nil {
+		setupLog.Error(startErr, "problem running manager")
 		os.Exit(1)
 	}
 }
@@ -208,6 +279,68 @@ func setupScheme(scheme *runtime.Scheme) error {
 	return nil
 }
 
+// validateControlPlaneMachineSet loads the ControlPlaneMachineSet from the file provided and
+// prints, for each control plane machine index, whether the machine would need to be updated
+// if the ControlPlaneMachineSet were applied to the cluster.
+func validateControlPlaneMachineSet(ctx context.Context, logger logr.Logger, cfg *rest.Config, scheme *runtime.Scheme, path, namespace string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read file: %w", err)
+	}
+
+	cpms := &machinev1.ControlPlaneMachineSet{}
+	if err := yaml.UnmarshalStrict(data, cpms); err != nil {
+		return fmt.Errorf("unable to decode control plane machine set: %w", err)
+	}
+
+	if cpms.Namespace == "" {
+		cpms.Namespace = namespace
+	}
+
+	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to set up client: %w", err)
+	}
+
+	machineInfos, err := providers.ValidateControlPlaneMachineSet(ctx, logger, cl, cpms)
+	if err != nil {
+		return fmt.Errorf("unable to compute machine infos: %w", err)
+	}
+
+	if err := providers.WriteValidationResults(os.Stdout, machineInfos); err != nil {
+		return fmt.Errorf("unable to write validation results: %w", err)
+	}
+
+	return nil
+}
+
+// collectDiagnostics prints a diagnostic snapshot of the cluster ControlPlaneMachineSet
+// and the control plane machines, as a single JSON document.
+func collectDiagnostics(ctx context.Context, logger logr.Logger, cfg *rest.Config, scheme *runtime.Scheme, namespace string) error {
+	cl, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("unable to set up client: %w", err)
+	}
+
+	cpms := &machinev1.ControlPlaneMachineSet{}
+	cpmsKey := client.ObjectKey{Namespace: namespace, Name: clusterControlPlaneMachineSetName}
+
+	if err := cl.Get(ctx, cpmsKey, cpms); err != nil {
+		return fmt.Errorf("unable to get control plane machine set: %w", err)
+	}
+
+	diagnostics, err := providers.CollectDiagnostics(ctx, logger, cl, cpms, providers.NewEtcdEndpointsMemberLister(cl))
+	if err != nil {
+		return fmt.Errorf("unable to collect diagnostics: %w", err)
+	}
+
+	if err := providers.WriteDiagnostics(os.Stdout, diagnostics); err != nil {
+		return fmt.Errorf("unable to write diagnostics: %w", err)
+	}
+
+	return nil
+}
+
 func getReleaseVersion(setupLog logr.Logger) string {
 	releaseVersion := os.Getenv(releaseVersionEnvVariableName)
 	if len(releaseVersion) == 0 {
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// auditReasonPrefix is the prefix of the reason of every audit event, so that the audit events can be
+	// selected from the other events emitted on the ControlPlaneMachineSet.
+	auditReasonPrefix = "Audit"
+
+	// reasonAuditMachineCreated is the reason of the audit event emitted when a Machine is created.
+	reasonAuditMachineCreated = auditReasonPrefix + "MachineCreated"
+
+	// reasonAuditMachineDeleted is the reason of the audit event emitted when a Machine is deleted.
+	reasonAuditMachineDeleted = auditReasonPrefix + "MachineDeleted"
+)
+
+// auditRecord is the structured record of a Machine created or deleted by the ControlPlaneMachineSet.
+// It is emitted, encoded as JSON, as the message of an audit event.
+type auditRecord struct {
+	UpdatePlanAction `json:",inline"`
+
+	// Time is the time, in RFC3339 format, at which the action was taken.
+	Time string `json:"time"`
+
+	// Reason explains why the action was taken.
+	Reason string `json:"reason"`
+
+	// Diff is the difference between the Machine and the desired configuration that justified the action,
+	// when the action was taken to replace an outdated Machine.
+	Diff []string `json:"diff,omitempty"`
+}
+
+// recordAuditEvents emits an audit event for each action taken during the reconcile, when audit events are enabled.
+// The machineInfos are those observed before the actions were taken, and are used to explain each action.
+func (r *ControlPlaneMachineSetReconciler) recordAuditEvents(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, actions []UpdatePlanAction, machineInfos map[int32][]machineproviders.MachineInfo) {
+	if !r.AuditEvents || r.Recorder == nil {
+		return
+	}
+
+	now := r.getClock().Now().UTC().Format(time.RFC3339)
+
+	for _, action := range actions {
+		record := auditRecord{UpdatePlanAction: action, Time: now}
+		eventReason := reasonAuditMachineCreated
+
+		switch action.Action {
+		case UpdatePlanActionCreate:
+			record.Reason, record.Diff = explainCreate(machineInfos[action.Index])
+		case UpdatePlanActionDelete:
+			eventReason = reasonAuditMachineDeleted
+			record.Reason, record.Diff = explainDelete(machineInfos[action.Index], action.Machine)
+		}
+
+		message, err := json.Marshal(record)
+		if err != nil {
+			// The record only contains strings and integers, so this should never happen.
+			logger.Error(fmt.Errorf("error marshalling audit record: %w", err), "Unable to emit audit event")
+
+			continue
+		}
+
+		r.Recorder.Event(cpms, corev1.EventTypeNormal, eventReason, string(message))
+	}
+}
+
+// explainCreate returns why a Machine was created for an index with the given Machines.
+func explainCreate(machines []machineproviders.MachineInfo) (string, []string) {
+	for _, machine := range machines {
+		if machine.NeedsUpdate && machine.MachineRef != nil {
+			return fmt.Sprintf("replacing machine %s, which needs an update", machine.MachineRef.ObjectMeta.Name), machine.Diff
+		}
+	}
+
+	for _, machine := range machines {
+		if machine.MachineRef != nil && isDeletedMachine(machine) {
+			return fmt.Sprintf("replacing machine %s, which is being deleted", machine.MachineRef.ObjectMeta.Name), nil
+		}
+	}
+
+	return "index has no machine", nil
+}
+
+// explainDelete returns why the named Machine was deleted from an index with the given Machines.
+func explainDelete(machines []machineproviders.MachineInfo, machineName string) (string, []string) {
+	for _, machine := range machines {
+		if machine.MachineRef == nil || machine.MachineRef.ObjectMeta.Name != machineName {
+			continue
+		}
+
+		if machine.NeedsUpdate {
+			return "machine needs an update", machine.Diff
+		}
+
+		return "machine is in excess of the single machine required for the index", nil
+	}
+
+	return "machine was not observed before it was deleted", nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("recordAuditEvents", func() {
+	var logger testutils.TestLogger
+	var recorder *record.FakeRecorder
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	outdatedMachine := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").
+		WithNeedsUpdate(true).WithDiff([]string{"InstanceType: m6i.xlarge != different"}).Build()
+
+	outdatedMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachine},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	replacedMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {
+			outdatedMachine,
+			updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+		},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+		planRecorder := newUpdatePlanRecorder(mockMachineProvider, machineInfos)
+
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, planRecorder, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		reconciler.recordAuditEvents(logger.Logger(), cpms, planRecorder.actions, machineInfos)
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		recorder = record.NewFakeRecorder(10)
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace:   "test",
+			Recorder:    recorder,
+			AuditEvents: true,
+			clock:       clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)),
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(outdatedMachineInfos), nil).AnyTimes()
+	})
+
+	Context("when an outdated machine is replaced by a rolling update", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(outdatedMachineInfos)
+		})
+
+		It("records the creation of the replacement with the diff of the outdated machine", func() {
+			Expect(recorder.Events).To(Receive(Equal(fmt.Sprintf("%s %s %s", corev1.EventTypeNormal, reasonAuditMachineCreated,
+				`{"action":"Create","index":1,"time":"2023-06-01T12:00:00Z","reason":"replacing machine machine-1, which needs an update","diff":["InstanceType: m6i.xlarge != different"]}`))))
+			Expect(recorder.Events).ToNot(Receive())
+		})
+
+		Context("and the replacement then becomes ready", func() {
+			BeforeEach(func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), outdatedMachine.MachineRef).Return(nil).Times(1)
+
+				<-recorder.Events
+				reconcileUpdates(replacedMachineInfos)
+			})
+
+			It("records the deletion of the outdated machine with its diff", func() {
+				Expect(recorder.Events).To(Receive(HavePrefix(fmt.Sprintf("%s %s", corev1.EventTypeWarning, reasonRemovingReplacedMachine))))
+				Expect(recorder.Events).To(Receive(Equal(fmt.Sprintf("%s %s %s", corev1.EventTypeNormal, reasonAuditMachineDeleted,
+					`{"action":"Delete","index":1,"machine":"machine-1","time":"2023-06-01T12:00:00Z","reason":"machine needs an update","diff":["InstanceType: m6i.xlarge != different"]}`))))
+			})
+		})
+	})
+
+	Context("when audit events are not enabled", func() {
+		BeforeEach(func() {
+			reconciler.AuditEvents = false
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+			reconcileUpdates(outdatedMachineInfos)
+		})
+
+		It("does not emit an audit event", func() {
+			Expect(recorder.Events).ToNot(Receive())
+		})
+	})
+})
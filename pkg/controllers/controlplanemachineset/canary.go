@@ -0,0 +1,175 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isCanaryEnabled returns true when the ControlPlaneMachineSet requests that a RollingUpdate replaces a single
+// index and then waits for approval before replacing the remaining indexes.
+func isCanaryEnabled(cpms *machinev1.ControlPlaneMachineSet) bool {
+	return cpms.GetAnnotations()[canaryAnnotation] == "true"
+}
+
+// getCanaryIndex returns the index chosen as the canary for the current rollout, if one has been chosen.
+func getCanaryIndex(cpms *machinev1.ControlPlaneMachineSet) (int32, bool, error) {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading canary index: %w", err)
+	}
+
+	if state.CanaryIndex == nil {
+		return 0, false, nil
+	}
+
+	return *state.CanaryIndex, true, nil
+}
+
+// isCanaryApproved returns true when the canary approval annotation has been changed since the canary was chosen.
+func isCanaryApproved(cpms *machinev1.ControlPlaneMachineSet) (bool, error) {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return false, fmt.Errorf("error reading canary approval: %w", err)
+	}
+
+	return cpms.GetAnnotations()[canaryApprovalAnnotation] != state.CanaryApprovalObserved, nil
+}
+
+// startCanary records the index chosen as the canary, along with the value of the approval annotation at this time,
+// so that a later change to the approval annotation can be recognised as approving the canary.
+func startCanary(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, idx int32) error {
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.CanaryIndex = &idx
+		state.CanaryApprovalObserved = cpms.GetAnnotations()[canaryApprovalAnnotation]
+	}); err != nil {
+		return fmt.Errorf("error recording canary index: %w", err)
+	}
+
+	logger.V(2).WithValues("index", idx).Info(startingCanary)
+
+	return nil
+}
+
+// clearCanary removes the record of the canary once the rollout has completed, or canary mode has been disabled.
+func clearCanary(cpms *machinev1.ControlPlaneMachineSet) error {
+	meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionCanaryComplete)
+
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.CanaryIndex = nil
+		state.CanaryApprovalObserved = ""
+	}); err != nil {
+		return fmt.Errorf("error clearing canary index: %w", err)
+	}
+
+	return nil
+}
+
+// holdForCanary returns true when the index must not start its replacement because the canary index has not yet
+// been approved. The first index to start its replacement while canary mode is enabled becomes the canary.
+func holdForCanary(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, idx int32) (bool, error) {
+	if !isCanaryEnabled(cpms) {
+		return false, nil
+	}
+
+	canaryIdx, ok, err := getCanaryIndex(cpms)
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		return false, startCanary(logger, cpms, idx)
+	}
+
+	approved, err := isCanaryApproved(cpms)
+	if err != nil {
+		return false, err
+	}
+
+	if canaryIdx == idx || approved {
+		return false, nil
+	}
+
+	logger.V(2).WithValues("index", idx, "canaryIndex", canaryIdx).Info(waitingForCanaryApproval)
+
+	return true, nil
+}
+
+// reconcileCanary sets the CanaryComplete condition to reflect the progress of the canary index.
+// Once no Machine needs replacement, the rollout is complete and the record of the canary is removed.
+func reconcileCanary(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) error {
+	canaryIdx, ok, err := getCanaryIndex(cpms)
+	if err != nil {
+		return err
+	}
+
+	if !isCanaryEnabled(cpms) || !ok {
+		return clearCanary(cpms)
+	}
+
+	rolloutComplete := true
+	canaryComplete := true
+
+	for _, indexToMachines := range sortedIndexedMs {
+		if hasAny(needReplacementMachines(indexToMachines.machineInfos)) {
+			rolloutComplete = false
+
+			if indexToMachines.index == canaryIdx {
+				canaryComplete = false
+			}
+		}
+	}
+
+	if rolloutComplete {
+		return clearCanary(cpms)
+	}
+
+	approved, err := isCanaryApproved(cpms)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionCanaryComplete,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: cpms.Generation,
+	}
+
+	switch {
+	case !canaryComplete:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reasonCanaryInProgress
+		condition.Message = fmt.Sprintf("Canary index %d is being updated", canaryIdx)
+	case approved:
+		condition.Reason = reasonCanaryApproved
+		condition.Message = fmt.Sprintf("Canary index %d has been approved, updating the remaining indexes", canaryIdx)
+	default:
+		condition.Reason = reasonAwaitingApproval
+		condition.Message = fmt.Sprintf("Canary index %d has been updated, awaiting approval: change the %s annotation to continue",
+			canaryIdx, canaryApprovalAnnotation)
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, condition)
+
+	return nil
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("reconcileMachineUpdates with a canary rollout", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	// Index 0 has been replaced, while indexes 1 and 2 have not yet started their replacement.
+	canaryCompleteMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachine(0, "machine-replacement-0")},
+		1: {outdatedMachine(1, "machine-1")},
+		2: {outdatedMachine(2, "machine-2")},
+	}
+
+	// expectReplacement expects a replacement Machine to be created for the index, once no existing replacement
+	// is found by the uncached machine provider.
+	expectReplacement := func(idx int32, machineInfos map[int32][]machineproviders.MachineInfo) {
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), idx).Return("", nil).Times(1)
+	}
+
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{canaryAnnotation: "true"})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the rollout starts", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachine(0, "machine-0")},
+				1: {outdatedMachine(1, "machine-1")},
+				2: {outdatedMachine(2, "machine-2")},
+			}
+
+			expectReplacement(0, machineInfos)
+			reconcileUpdates(machineInfos)
+		})
+
+		It("records the first index as the canary", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(SatisfyAll(
+				HaveField("CanaryIndex", HaveValue(Equal(int32(0)))),
+				HaveField("CanaryApprovalObserved", BeEmpty()),
+			))
+		})
+
+		It("reports that the canary is in progress", func() {
+			Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+				Type:    conditionCanaryComplete,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonCanaryInProgress,
+				Message: "Canary index 0 is being updated",
+			})))
+		})
+	})
+
+	Context("when the canary index has been replaced", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			cpms.SetAnnotations(map[string]string{
+				canaryAnnotation: "true",
+				machineproviders.PersistedStateAnnotation: persistedStateAnnotation(machineproviders.PersistedState{
+					CanaryIndex: pointer.Int32(0),
+				}),
+			})
+
+			reconcileUpdates(canaryCompleteMachineInfos)
+		})
+
+		It("reports that the canary is awaiting approval", func() {
+			Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+				Type:   conditionCanaryComplete,
+				Status: metav1.ConditionTrue,
+				Reason: reasonAwaitingApproval,
+				Message: "Canary index 0 has been updated, awaiting approval: " +
+					"change the controlplanemachineset.machine.openshift.io/canary-approval annotation to continue",
+			})))
+		})
+
+		It("logs that the next index is waiting for approval", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(1),
+					"canaryIndex", int32(0),
+				},
+				Message: waitingForCanaryApproval,
+			}))
+		})
+
+		Context("and the canary is then approved", func() {
+			BeforeEach(func() {
+				expectReplacement(1, canaryCompleteMachineInfos)
+
+				annotations := cpms.GetAnnotations()
+				annotations[canaryApprovalAnnotation] = "1"
+				cpms.SetAnnotations(annotations)
+
+				reconcileUpdates(canaryCompleteMachineInfos)
+			})
+
+			It("reports that the canary has been approved", func() {
+				Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+					Type:    conditionCanaryComplete,
+					Status:  metav1.ConditionTrue,
+					Reason:  reasonCanaryApproved,
+					Message: "Canary index 0 has been approved, updating the remaining indexes",
+				})))
+			})
+		})
+	})
+
+	Context("when the rollout has completed", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			cpms.SetAnnotations(map[string]string{
+				canaryAnnotation:         "true",
+				canaryApprovalAnnotation: "1",
+				machineproviders.PersistedStateAnnotation: persistedStateAnnotation(machineproviders.PersistedState{
+					CanaryIndex: pointer.Int32(0),
+				}),
+			})
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionCanaryComplete,
+				Status: metav1.ConditionTrue,
+				Reason: reasonCanaryApproved,
+			})
+
+			reconcileUpdates(map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachine(0, "machine-replacement-0")},
+				1: {updatedMachine(1, "machine-replacement-1")},
+				2: {updatedMachine(2, "machine-replacement-2")},
+			})
+		})
+
+		It("clears the record of the canary", func() {
+			Expect(cpms.GetAnnotations()).To(Equal(map[string]string{
+				canaryAnnotation:         "true",
+				canaryApprovalAnnotation: "1",
+			}))
+		})
+
+		It("removes the CanaryComplete condition", func() {
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// cancellingReplacement is a log message used to inform the user that a replacement Machine that is not yet
+	// ready is being deleted because the roll has been cancelled.
+	cancellingReplacement = "Roll cancelled, deleting replacement machine that is not yet ready"
+
+	// rollCancelled is a log message used to inform the user that no Machine is being created or deleted because
+	// the roll has been cancelled.
+	rollCancelled = "Roll cancelled, no machines are created or deleted until the cancel annotation is removed"
+
+	// reasonReplacementCancelled is the reason of the event emitted when a replacement Machine is deleted because
+	// the roll has been cancelled.
+	reasonReplacementCancelled = "ReplacementCancelled"
+)
+
+// isRollCancelled returns true when the ControlPlaneMachineSet requests that the in-flight roll is cancelled.
+func isRollCancelled(cpms *machinev1.ControlPlaneMachineSet) bool {
+	return cpms.GetAnnotations()[cancelRollAnnotation] == "true"
+}
+
+// cancellableReplacements returns the replacement Machines that may be deleted to cancel the roll.
+// A replacement may only be deleted while it is not yet ready, and while its index has a ready Machine that is not
+// being deleted. This means that cancelling never deletes a healthy Machine, and never leaves an index without one.
+// Once a replacement is ready, it is kept, as the etcd operator may already have moved a member onto it.
+func cancellableReplacements(machineInfos map[int32][]machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	replacements := []machineproviders.MachineInfo{}
+
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		machines := indexToMachines.machineInfos
+
+		if isEmpty(readyMachines(nonDeletedMachines(machines))) {
+			continue
+		}
+
+		replacements = append(replacements, pendingMachines(machines)...)
+	}
+
+	return replacements
+}
+
+// reconcileRollCancellation sets the RollCancelled condition, and returns true when no Machine may be created or
+// deleted because the roll has been cancelled. When cancelled, any replacement that is not yet ready is deleted,
+// so that each index returns to its old Machine. Like any other deletion, the replacements are deleted one at a time,
+// each once the deletion before it has been observed.
+func (r *ControlPlaneMachineSetReconciler) reconcileRollCancellation(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (bool, error) {
+	if !isRollCancelled(cpms) {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionRollCancelled)
+
+		return false, nil
+	}
+
+	r.observeDeletions(cpms, machineInfos)
+
+	cancelled := []string{}
+
+	for _, replacement := range cancellableReplacements(machineInfos) {
+		mLogger := logger.WithValues("index", replacement.Index, "namespace", r.Namespace, "name", replacement.MachineRef.ObjectMeta.Name)
+
+		// The remaining replacements are deleted, one at a time, once each earlier deletion has been observed.
+		if !r.allowDeletion(mLogger, cpms, replacement) {
+			break
+		}
+
+		mLogger.V(1).Info(cancellingReplacement)
+
+		deleteCtx, span := util.StartSpan(ctx, "DeleteMachine",
+			attribute.Int("index", int(replacement.Index)), attribute.String("machine", replacement.MachineRef.ObjectMeta.Name))
+		err := machineProvider.DeleteMachine(deleteCtx, mLogger, replacement.MachineRef)
+		util.EndSpan(span, err)
+
+		if err != nil {
+			werr := fmt.Errorf("error deleting Machine %s/%s: %w", r.Namespace, replacement.MachineRef.ObjectMeta.Name, err)
+			mLogger.Error(werr, errorDeletingMachine)
+
+			return false, werr
+		}
+
+		r.deletions.record(replacement)
+
+		if r.Recorder != nil {
+			r.Recorder.Eventf(cpms, corev1.EventTypeNormal, reasonReplacementCancelled,
+				"Deleted replacement machine %s in index %d, the roll was cancelled before it became ready",
+				replacement.MachineRef.ObjectMeta.Name, replacement.Index)
+		}
+
+		cancelled = append(cancelled, replacement.MachineRef.ObjectMeta.Name)
+	}
+
+	logger.V(2).Info(rollCancelled)
+
+	message := fmt.Sprintf("No machines are created or deleted while the roll is cancelled, remove the %s annotation to resume", cancelRollAnnotation)
+	if len(cancelled) > 0 {
+		message = fmt.Sprintf("Deleted replacement machine(s) that were not yet ready: %s. %s", strings.Join(cancelled, ", "), message)
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionRollCancelled,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonCancelRequested,
+		Message:            message,
+		ObservedGeneration: cpms.Generation,
+	})
+
+	return true, nil
+}
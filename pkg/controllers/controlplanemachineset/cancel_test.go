@@ -0,0 +1,218 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeMachineDeleter is a machine provider that records the Machines it was asked to delete.
+type fakeMachineDeleter struct {
+	machineproviders.MachineProvider
+
+	deleted []string
+}
+
+// DeleteMachine records the name of the Machine that should be deleted.
+func (f *fakeMachineDeleter) DeleteMachine(_ context.Context, _ logr.Logger, machineRef *machineproviders.ObjectRef) error {
+	f.deleted = append(f.deleted, machineRef.ObjectMeta.Name)
+
+	return nil
+}
+
+var _ = Describe("reconcileRollCancellation", func() {
+	machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithReady(true).WithNeedsUpdate(false)
+
+	// surgingMachineInfos returns a machine for each index, where index 1 is outdated and has the given replacement.
+	surgingMachineInfos := func(replacement machineproviders.MachineInfo) map[int32][]machineproviders.MachineInfo {
+		machineInfos := map[int32][]machineproviders.MachineInfo{}
+
+		for i := int32(0); i < 3; i++ {
+			machineInfos[i] = []machineproviders.MachineInfo{machineInfoBuilder.WithIndex(i).WithMachineName(fmt.Sprintf("machine-%d", i)).Build()}
+		}
+
+		machineInfos[1] = []machineproviders.MachineInfo{
+			machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").WithNeedsUpdate(true).Build(),
+			replacement,
+		}
+
+		return machineInfos
+	}
+
+	pendingReplacement := machineInfoBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithReady(false).Build()
+
+	type reconcileRollCancellationTableInput struct {
+		cancelled         bool
+		machineInfos      map[int32][]machineproviders.MachineInfo
+		expectedCancelled bool
+		expectedDeleted   []string
+		expectedMessage   string
+	}
+
+	DescribeTable("cancels the roll", func(in reconcileRollCancellationTableInput) {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2).WithReplicas(3).Build()
+		cpms.Status.Conditions = []metav1.Condition{{
+			Type:   conditionRollCancelled,
+			Status: metav1.ConditionTrue,
+			Reason: reasonCancelRequested,
+		}}
+
+		if in.cancelled {
+			cpms.SetAnnotations(map[string]string{cancelRollAnnotation: "true"})
+		}
+
+		machineProvider := &fakeMachineDeleter{}
+		reconciler := &ControlPlaneMachineSetReconciler{Namespace: "openshift-machine-api"}
+
+		cancelled, err := reconciler.reconcileRollCancellation(ctx, testutils.NewTestLogger().Logger(), cpms, machineProvider, in.machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cancelled).To(Equal(in.expectedCancelled))
+		Expect(machineProvider.deleted).To(Equal(in.expectedDeleted))
+
+		condition := meta.FindStatusCondition(cpms.Status.Conditions, conditionRollCancelled)
+		if !in.cancelled {
+			Expect(condition).To(BeNil())
+
+			return
+		}
+
+		Expect(condition).To(SatisfyAll(
+			HaveField("Status", Equal(metav1.ConditionTrue)),
+			HaveField("Reason", Equal(reasonCancelRequested)),
+			HaveField("Message", Equal(in.expectedMessage)),
+			HaveField("ObservedGeneration", Equal(int64(2))),
+		))
+	},
+		Entry("when the roll is not cancelled", reconcileRollCancellationTableInput{
+			machineInfos: surgingMachineInfos(pendingReplacement),
+		}),
+		Entry("mid-surge, before the replacement is ready", reconcileRollCancellationTableInput{
+			cancelled:         true,
+			machineInfos:      surgingMachineInfos(pendingReplacement),
+			expectedCancelled: true,
+			expectedDeleted:   []string{"machine-replacement-1"},
+			expectedMessage: "Deleted replacement machine(s) that were not yet ready: machine-replacement-1. " +
+				"No machines are created or deleted while the roll is cancelled, remove the " + cancelRollAnnotation + " annotation to resume",
+		}),
+		Entry("mid-surge, once the replacement is ready", reconcileRollCancellationTableInput{
+			cancelled:         true,
+			machineInfos:      surgingMachineInfos(machineInfoBuilder.WithIndex(1).WithMachineName("machine-replacement-1").Build()),
+			expectedCancelled: true,
+			expectedMessage:   "No machines are created or deleted while the roll is cancelled, remove the " + cancelRollAnnotation + " annotation to resume",
+		}),
+		Entry("when the old machine is already being deleted", reconcileRollCancellationTableInput{
+			cancelled: true,
+			machineInfos: func() map[int32][]machineproviders.MachineInfo {
+				machineInfos := surgingMachineInfos(pendingReplacement)
+				machineInfos[1][0] = machineInfoBuilder.WithIndex(1).WithMachineName("machine-1").WithNeedsUpdate(true).
+					WithMachineDeletionTimestamp(metav1.Now()).Build()
+
+				return machineInfos
+			}(),
+			expectedCancelled: true,
+			expectedMessage:   "No machines are created or deleted while the roll is cancelled, remove the " + cancelRollAnnotation + " annotation to resume",
+		}),
+		Entry("with replacements in several indexes", reconcileRollCancellationTableInput{
+			cancelled: true,
+			machineInfos: func() map[int32][]machineproviders.MachineInfo {
+				machineInfos := surgingMachineInfos(pendingReplacement)
+				machineInfos[2] = []machineproviders.MachineInfo{
+					machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").WithNeedsUpdate(true).Build(),
+					machineInfoBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithReady(false).Build(),
+				}
+
+				return machineInfos
+			}(),
+			expectedCancelled: true,
+			// Only one replacement is deleted until its deletion has been observed.
+			expectedDeleted: []string{"machine-replacement-1"},
+			expectedMessage: "Deleted replacement machine(s) that were not yet ready: machine-replacement-1. " +
+				"No machines are created or deleted while the roll is cancelled, remove the " + cancelRollAnnotation + " annotation to resume",
+		}),
+		Entry("when the index has no other machine", reconcileRollCancellationTableInput{
+			cancelled: true,
+			machineInfos: func() map[int32][]machineproviders.MachineInfo {
+				machineInfos := surgingMachineInfos(pendingReplacement)
+				machineInfos[1] = []machineproviders.MachineInfo{pendingReplacement}
+
+				return machineInfos
+			}(),
+			expectedCancelled: true,
+			expectedMessage:   "No machines are created or deleted while the roll is cancelled, remove the " + cancelRollAnnotation + " annotation to resume",
+		}),
+	)
+
+	Context("with replacements in several indexes over several reconciles", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+		var machineProvider *fakeMachineDeleter
+		var reconciler *ControlPlaneMachineSetReconciler
+
+		pendingReplacement2 := machineInfoBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithReady(false).Build()
+
+		machineInfos := func(replacement1 machineproviders.MachineInfo) map[int32][]machineproviders.MachineInfo {
+			machineInfos := surgingMachineInfos(replacement1)
+			machineInfos[2] = []machineproviders.MachineInfo{
+				machineInfoBuilder.WithIndex(2).WithMachineName("machine-2").WithNeedsUpdate(true).Build(),
+				pendingReplacement2,
+			}
+
+			return machineInfos
+		}
+
+		reconcileCancellation := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+			_, err := reconciler.reconcileRollCancellation(ctx, testutils.NewTestLogger().Logger(), cpms, machineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+			cpms.SetAnnotations(map[string]string{cancelRollAnnotation: "true"})
+
+			machineProvider = &fakeMachineDeleter{}
+			reconciler = &ControlPlaneMachineSetReconciler{Namespace: "openshift-machine-api"}
+
+			reconcileCancellation(machineInfos(pendingReplacement))
+		})
+
+		It("does not delete the next replacement until the first deletion has been observed", func() {
+			reconcileCancellation(machineInfos(pendingReplacement))
+
+			Expect(machineProvider.deleted).To(Equal([]string{"machine-replacement-1"}))
+			Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDeletionHeld)).To(BeTrue())
+		})
+
+		It("deletes the next replacement once the first deletion has been observed", func() {
+			reconcileCancellation(machineInfos(machineInfoBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithReady(false).
+				WithMachineDeletionTimestamp(metav1.Now()).Build()))
+
+			Expect(machineProvider.deleted).To(Equal([]string{"machine-replacement-1", "machine-replacement-2"}))
+		})
+	})
+})
@@ -25,6 +25,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	"github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -71,24 +72,42 @@ func (r *ControlPlaneMachineSetReconciler) updateClusterOperatorStatus(ctx conte
 		}
 	}
 
-	// Define upgradable condition
-	if v1helpers.IsStatusConditionPresentAndEqual(conds, configv1.OperatorAvailable, configv1.ConditionTrue) {
+	// Define upgradable condition.
+	// An upgrade should not start while control plane machines are being replaced.
+	switch {
+	case !v1helpers.IsStatusConditionPresentAndEqual(conds, configv1.OperatorAvailable, configv1.ConditionTrue):
 		conds = append(conds, newClusterOperatorStatusCondition(
 			configv1.OperatorUpgradeable,
-			configv1.ConditionTrue,
+			configv1.ConditionFalse,
 			reasonAsExpected,
-			"cluster operator is upgradable"))
-	} else {
+			"cluster operator is not upgradable"))
+	case isReplacingMachines(cpms):
 		conds = append(conds, newClusterOperatorStatusCondition(
 			configv1.OperatorUpgradeable,
 			configv1.ConditionFalse,
+			reasonRollInProgress,
+			"control plane machines are being updated"))
+	default:
+		conds = append(conds, newClusterOperatorStatusCondition(
+			configv1.OperatorUpgradeable,
+			configv1.ConditionTrue,
 			reasonAsExpected,
-			"cluster operator is not upgradable"))
+			"cluster operator is upgradable"))
 	}
 
 	return r.patchClusterOperatorStatus(ctx, logger, co, conds)
 }
 
+// isReplacingMachines returns true when an Active ControlPlaneMachineSet, with the RollingUpdate strategy, is replacing
+// a control plane Machine, that is, an index has a replacement Machine alongside the Machine it replaces.
+// Progressing alone is not enough, as outdated Machines may wait indefinitely for an Inactive ControlPlaneMachineSet
+// to be activated, or for an OnDelete Machine to be deleted manually, and upgrades must not be blocked meanwhile.
+func isReplacingMachines(cpms *machinev1.ControlPlaneMachineSet) bool {
+	return isActive(cpms) &&
+		cpms.Spec.Strategy.Type == machinev1.RollingUpdate &&
+		meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionUpdatingIndex)
+}
+
 // getClusterOperator returns an instance of Cluster Operator resource for control-plane-machine-set cluster operator.
 func (r *ControlPlaneMachineSetReconciler) getClusterOperator(ctx context.Context, logger logr.Logger) (*configv1.ClusterOperator, error) {
 	co := &configv1.ClusterOperator{}
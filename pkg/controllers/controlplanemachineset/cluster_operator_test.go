@@ -46,6 +46,8 @@ var (
 
 	statusConditionDegraded    = metav1resourcebuilder.Condition().WithType(conditionDegraded).WithStatus(metav1.ConditionTrue).WithReason(reasonUnmanagedNodes).WithMessage("Found 3 unmanaged node(s)").Build()
 	statusConditionNotDegraded = metav1resourcebuilder.Condition().WithType(conditionDegraded).WithStatus(metav1.ConditionFalse).WithReason(reasonAsExpected).Build()
+
+	statusConditionUpdatingIndex = metav1resourcebuilder.Condition().WithType(conditionUpdatingIndex).WithStatus(metav1.ConditionTrue).WithReason(reasonReplacingMachine).WithMessage("Updating index 1 (replacing machine machine-1 with machine machine-replacement-1)").Build()
 )
 
 var _ = Describe("Cluster Operator Status with a running controller", func() {
@@ -363,6 +365,197 @@ var _ = Describe("Cluster Operator Status", func() {
 					},
 				},
 			}),
+			Entry("with an available control plane machine set that is rolling out an update", updateClusterOperatorStatusTableInput{
+				cpmsBuilder: cpmsBuilder.WithState(machinev1.ControlPlaneMachineSetStateActive).WithStrategyType(machinev1.RollingUpdate).
+					WithConditions([]metav1.Condition{statusConditionAvailable, statusConditionProgressing, statusConditionNotDegraded, statusConditionUpdatingIndex}),
+				expectedConditions: []configv1.ClusterOperatorStatusCondition{
+					{
+						Type:    configv1.OperatorAvailable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAllReplicasAvailable,
+						Message: "",
+					},
+					{
+						Type:    configv1.OperatorProgressing,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonNeedsUpdateReplicas,
+						Message: "Observed 1 replica(s) in need of update",
+					},
+					{
+						Type:   configv1.OperatorDegraded,
+						Status: configv1.ConditionFalse,
+						Reason: reasonAsExpected,
+					},
+					{
+						Type:    configv1.OperatorUpgradeable,
+						Status:  configv1.ConditionFalse,
+						Reason:  reasonRollInProgress,
+						Message: "control plane machines are being updated",
+					},
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level:   4,
+						Message: "Syncing cluster operator status",
+						KeysAndValues: []interface{}{
+							"available", string(metav1.ConditionTrue),
+							"progressing", string(metav1.ConditionTrue),
+							"degraded", string(metav1.ConditionFalse),
+							"upgradable", string(metav1.ConditionFalse),
+						},
+					},
+				},
+			}),
+			Entry("with an available control plane machine set that needs an update but has no replacement in flight", updateClusterOperatorStatusTableInput{
+				cpmsBuilder: cpmsBuilder.WithState(machinev1.ControlPlaneMachineSetStateActive).WithStrategyType(machinev1.RollingUpdate).
+					WithConditions([]metav1.Condition{statusConditionAvailable, statusConditionProgressing, statusConditionNotDegraded}),
+				expectedConditions: []configv1.ClusterOperatorStatusCondition{
+					{
+						Type:    configv1.OperatorAvailable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAllReplicasAvailable,
+						Message: "",
+					},
+					{
+						Type:    configv1.OperatorProgressing,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonNeedsUpdateReplicas,
+						Message: "Observed 1 replica(s) in need of update",
+					},
+					{
+						Type:   configv1.OperatorDegraded,
+						Status: configv1.ConditionFalse,
+						Reason: reasonAsExpected,
+					},
+					{
+						Type:    configv1.OperatorUpgradeable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAsExpected,
+						Message: "cluster operator is upgradable",
+					},
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level:   4,
+						Message: "Syncing cluster operator status",
+						KeysAndValues: []interface{}{
+							"available", string(metav1.ConditionTrue),
+							"progressing", string(metav1.ConditionTrue),
+							"degraded", string(metav1.ConditionFalse),
+							"upgradable", string(metav1.ConditionTrue),
+						},
+					},
+				},
+			}),
+			Entry("with an inactive control plane machine set whose machines need an update", updateClusterOperatorStatusTableInput{
+				cpmsBuilder: cpmsBuilder.WithState(machinev1.ControlPlaneMachineSetStateInactive).WithStrategyType(machinev1.RollingUpdate).
+					WithConditions([]metav1.Condition{statusConditionAvailable, statusConditionProgressing, statusConditionNotDegraded, statusConditionUpdatingIndex}),
+				expectedConditions: []configv1.ClusterOperatorStatusCondition{
+					{
+						Type:    configv1.OperatorAvailable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAllReplicasAvailable,
+						Message: "",
+					},
+					{
+						Type:    configv1.OperatorProgressing,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonNeedsUpdateReplicas,
+						Message: "Observed 1 replica(s) in need of update",
+					},
+					{
+						Type:   configv1.OperatorDegraded,
+						Status: configv1.ConditionFalse,
+						Reason: reasonAsExpected,
+					},
+					{
+						Type:    configv1.OperatorUpgradeable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAsExpected,
+						Message: "cluster operator is upgradable",
+					},
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level:   4,
+						Message: "Syncing cluster operator status",
+						KeysAndValues: []interface{}{
+							"available", string(metav1.ConditionTrue),
+							"progressing", string(metav1.ConditionTrue),
+							"degraded", string(metav1.ConditionFalse),
+							"upgradable", string(metav1.ConditionTrue),
+						},
+					},
+				},
+			}),
+			Entry("with an on delete control plane machine set whose outdated machines wait to be deleted", updateClusterOperatorStatusTableInput{
+				cpmsBuilder: cpmsBuilder.WithState(machinev1.ControlPlaneMachineSetStateActive).WithStrategyType(machinev1.OnDelete).
+					WithConditions([]metav1.Condition{statusConditionAvailable, statusConditionProgressing, statusConditionNotDegraded, statusConditionUpdatingIndex}),
+				expectedConditions: []configv1.ClusterOperatorStatusCondition{
+					{
+						Type:    configv1.OperatorAvailable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAllReplicasAvailable,
+						Message: "",
+					},
+					{
+						Type:    configv1.OperatorProgressing,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonNeedsUpdateReplicas,
+						Message: "Observed 1 replica(s) in need of update",
+					},
+					{
+						Type:   configv1.OperatorDegraded,
+						Status: configv1.ConditionFalse,
+						Reason: reasonAsExpected,
+					},
+					{
+						Type:    configv1.OperatorUpgradeable,
+						Status:  configv1.ConditionTrue,
+						Reason:  reasonAsExpected,
+						Message: "cluster operator is upgradable",
+					},
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level:   4,
+						Message: "Syncing cluster operator status",
+						KeysAndValues: []interface{}{
+							"available", string(metav1.ConditionTrue),
+							"progressing", string(metav1.ConditionTrue),
+							"degraded", string(metav1.ConditionFalse),
+							"upgradable", string(metav1.ConditionTrue),
+						},
+					},
+				},
+			}),
 		)
+
+		Context("when a rollout completes", func() {
+			BeforeEach(func() {
+				rollingUpdateBuilder := cpmsBuilder.WithState(machinev1.ControlPlaneMachineSetStateActive).WithStrategyType(machinev1.RollingUpdate)
+
+				cpms := rollingUpdateBuilder.WithConditions([]metav1.Condition{statusConditionAvailable, statusConditionProgressing, statusConditionNotDegraded, statusConditionUpdatingIndex}).Build()
+				Expect(reconciler.updateClusterOperatorStatus(ctx, logger.Logger(), cpms)).To(Succeed())
+
+				Eventually(komega.Object(co)).Should(HaveField("Status.Conditions", ContainElement(SatisfyAll(
+					HaveField("Type", Equal(configv1.OperatorUpgradeable)),
+					HaveField("Status", Equal(configv1.ConditionFalse)),
+					HaveField("Reason", Equal(reasonRollInProgress)),
+				))))
+
+				cpms = rollingUpdateBuilder.WithConditions([]metav1.Condition{statusConditionAvailable, statusConditionNotProgressing, statusConditionNotDegraded}).Build()
+				Expect(reconciler.updateClusterOperatorStatus(ctx, logger.Logger(), cpms)).To(Succeed())
+			})
+
+			It("should mark the cluster operator as upgradeable again", func() {
+				Eventually(komega.Object(co)).Should(HaveField("Status.Conditions", ContainElement(SatisfyAll(
+					HaveField("Type", Equal(configv1.OperatorUpgradeable)),
+					HaveField("Status", Equal(configv1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonAsExpected)),
+					HaveField("Message", Equal("cluster operator is upgradable")),
+				))))
+			})
+		})
 	})
 })
@@ -21,6 +21,108 @@ const (
 	masterNodeRoleLabel = "node-role.kubernetes.io/master"
 	// controlPlaneNodeRoleLabel denotes the control-plane node label for a node.
 	controlPlaneNodeRoleLabel = "node-role.kubernetes.io/control-plane"
+
+	// infrastructureName is the name of the cluster Infrastructure singleton.
+	// Changes to the platform status may change the failure domains available to the Control Plane Machines.
+	infrastructureName = "cluster"
+)
+
+// Annotations used to configure the operator on the ControlPlaneMachineSet.
+// The ControlPlaneMachineSet API is owned by openshift/api, so options that are not represented in the API are set
+// here. State recorded by the operator, which must survive an operator restart, is kept in the single persisted
+// state annotation instead.
+const (
+	// bootstrapAnnotation is set to "true" by users to confirm that Control Plane Machines should be created from the
+	// template when no Control Plane Machines exist. Without it, no Machines are created, as the Machines may have
+	// been lost to a disaster or hidden by a misconfigured selector.
+	// It is removed once Control Plane Machines exist again, so that each bootstrap must be confirmed.
+	bootstrapAnnotation = "controlplanemachineset.machine.openshift.io/bootstrap"
+
+	// forceRollAnnotation is set by users to request that all Control Plane Machines are replaced,
+	// even when their configuration is up to date. The value is an arbitrary token and a new roll
+	// is requested each time the token changes.
+	forceRollAnnotation = "controlplanemachineset.machine.openshift.io/force-roll"
+
+	// rolloutWindowAnnotation is set by users to throttle a RollingUpdate. The value is a duration, such as 24h,
+	// that must elapse after an index has been replaced before the replacement of the next index is started.
+	rolloutWindowAnnotation = "controlplanemachineset.machine.openshift.io/rollout-window"
+
+	// activeIndexesAnnotation is set by users to limit the Machine indexes that an Active ControlPlaneMachineSet
+	// manages. The value is a comma separated list of indexes, such as 0,2. Machines in other indexes are still
+	// observed and reported in the status, but are never created or deleted by the ControlPlaneMachineSet.
+	activeIndexesAnnotation = "controlplanemachineset.machine.openshift.io/active-indexes"
+
+	// deletionGraceAnnotation is set by users to delay the deletion of an outdated Machine once its replacement is
+	// ready during a RollingUpdate. The value is a duration, such as 10m. When unset, the Machine is deleted immediately.
+	deletionGraceAnnotation = "controlplanemachineset.machine.openshift.io/deletion-grace"
+
+	// minNodeReadyDurationAnnotation is set by users to require the Node of a replacement Machine to have been
+	// continuously Ready for a minimum duration, such as 5m, before the outdated Machine it replaces is deleted
+	// during a RollingUpdate. Unlike the deletion grace period, the duration restarts whenever the Node flaps NotReady.
+	minNodeReadyDurationAnnotation = "controlplanemachineset.machine.openshift.io/min-node-ready-duration"
+
+	// nodeReadinessGatesAnnotation is set by users to require additional conditions on the Node of a replacement
+	// Machine to be True, beyond the Node Ready condition, before the outdated Machine it replaces is deleted during
+	// a RollingUpdate. The value is a comma separated list of Node condition types.
+	nodeReadinessGatesAnnotation = "controlplanemachineset.machine.openshift.io/node-readiness-gates"
+
+	// apiVIPHolderKeyAnnotation is set by users on platforms where an API VIP is hosted on the control plane Nodes.
+	// The value is the key of a Node label or annotation, set by keepalived or the infrastructure, that marks the
+	// Node currently holding the API VIP. The deletion of an outdated Machine whose Node holds the VIP is delayed
+	// until the VIP has moved to another Node. When unset, the Node is not checked.
+	apiVIPHolderKeyAnnotation = "controlplanemachineset.machine.openshift.io/api-vip-holder-key"
+
+	// canaryAnnotation is set to true by users to replace a single index during a RollingUpdate, and then wait for
+	// approval before replacing the remaining indexes.
+	canaryAnnotation = "controlplanemachineset.machine.openshift.io/canary"
+
+	// canaryApprovalAnnotation is changed by users to approve the canary index and continue the RollingUpdate.
+	// Any change to the value, such as incrementing a counter, approves the current canary.
+	canaryApprovalAnnotation = "controlplanemachineset.machine.openshift.io/canary-approval"
+
+	// updatePlanAnnotation records, as a JSON list, the Machines that the ControlPlaneMachineSet intends to create
+	// and delete to bring each index up to date, in the order in which it intends to take the actions. It is only
+	// replaced when the plan changes, and is removed once no action remains, so that external controllers can
+	// follow a rollout from a stable plan. Unlike the persisted state, the plan is read by external controllers, so
+	// it is kept in an annotation of its own.
+	updatePlanAnnotation = "controlplanemachineset.machine.openshift.io/update-plan"
+
+	// logVerbosityAnnotation is set by users to change the log verbosity of the operator at runtime, for example to
+	// debug a rollout. Removing the annotation restores the verbosity configured at startup.
+	logVerbosityAnnotation = "controlplanemachineset.machine.openshift.io/log-verbosity"
+
+	// stepAnnotation is set by users to pause the ControlPlaneMachineSet, so that Machines are only created or
+	// deleted one at a time. Each change to the value, such as incrementing a counter, allows a single action.
+	stepAnnotation = "controlplanemachineset.machine.openshift.io/step"
+
+	// maintenanceWindowAnnotation is set by users to only allow Machines to be created or deleted within a
+	// maintenance window, for example "Mon-Fri 09:00-17:00 Europe/London". The days and the time zone are optional.
+	maintenanceWindowAnnotation = "controlplanemachineset.machine.openshift.io/maintenance-window"
+
+	// machineConfigPoolGuardAnnotation is set to "true" by users to defer creating or deleting Machines while the
+	// master MachineConfigPool is updating, so that the two do not compound the disruption to the control plane.
+	machineConfigPoolGuardAnnotation = "controlplanemachineset.machine.openshift.io/machine-config-pool-guard"
+
+	// etcdMemberMappingAnnotation is set to "true" by users to report which etcd member runs on the Machine of each
+	// index in the EtcdMembers condition, for example, when debugging the quorum of the etcd cluster.
+	etcdMemberMappingAnnotation = "controlplanemachineset.machine.openshift.io/etcd-member-mapping"
+
+	// cancelRollAnnotation is set to "true" by users to cancel an in-flight roll. Replacement Machines that are not
+	// yet ready are deleted, and no further Machine is created or deleted until the annotation is removed.
+	cancelRollAnnotation = "controlplanemachineset.machine.openshift.io/cancel-roll"
+
+	// protectedIndexAnnotation is set by users to the index, for example "0", that a RollingUpdate replaces last,
+	// and only once every other index has a single ready and up to date Machine.
+	protectedIndexAnnotation = "controlplanemachineset.machine.openshift.io/protected-index"
+
+	// failureDomainMappingAnnotation is set to "true" by users to export the failure domain mapped to each index
+	// into the failure domain mapping ConfigMap, for example, for use by external tooling.
+	failureDomainMappingAnnotation = "controlplanemachineset.machine.openshift.io/failure-domain-mapping"
+
+	// maintenanceFreezeAnnotation is set to "true" on the cluster ClusterVersion, rather than on the
+	// ControlPlaneMachineSet, to freeze maintenance across the cluster, for example, during a change freeze.
+	// While the freeze is in effect, no Machine is created, deleted or updated.
+	maintenanceFreezeAnnotation = "controlplanemachineset.machine.openshift.io/maintenance-freeze"
 )
 
 // Condition types for use in the ControlPlaneMachineSet status.
@@ -55,6 +157,118 @@ const (
 	// This condition may be false with a reason, such as when an update is needed
 	// but the rollout strategy is configured to OnDelete.
 	conditionProgressing = "Progressing"
+
+	// conditionUnmatchedFailureDomains is used to denote when the ControlPlaneMachineSet
+	// has observed Machines in a failure domain that is not listed in its failure domains.
+	// Such Machines will be moved to a configured failure domain when they are next replaced.
+	// The condition is removed once every Machine is within a configured failure domain.
+	conditionUnmatchedFailureDomains = "UnmatchedFailureDomains"
+
+	// conditionInconsistentProviderIDs is used to denote when the ControlPlaneMachineSet
+	// has observed Machines whose providerID does not match their Node, or whose Node no longer exists.
+	// The condition is removed once every such Machine has been replaced.
+	conditionInconsistentProviderIDs = "InconsistentProviderIDs"
+
+	// conditionReducedRedundancy is used to denote when the ControlPlaneMachineSet
+	// has observed a replaced Machine being removed, and so the control plane
+	// may briefly run with one fewer healthy member.
+	// The condition is removed once the replaced Machine has been removed.
+	conditionReducedRedundancy = "ReducedRedundancy"
+
+	// conditionSharedFailureDomains is an informational condition used to denote that the
+	// ControlPlaneMachineSet has more replicas than failure domains, and so some failure domains
+	// host more than one Control Plane Machine.
+	// The condition is removed once there are at least as many failure domains as replicas.
+	conditionSharedFailureDomains = "SharedFailureDomains"
+
+	// conditionIndexGaps is an informational condition used to denote that the Control Plane Machine
+	// indexes are not contiguous, for example, because a Machine with a lower index was removed during
+	// a scale down. Gapped indexes are valid and are not renumbered, as this would replace healthy Machines.
+	// The condition is removed once the indexes are contiguous from zero.
+	conditionIndexGaps = "IndexGaps"
+
+	// conditionAPIVIPHold is used to denote when the ControlPlaneMachineSet is delaying
+	// the deletion of an outdated Machine because its Node still holds the API VIP.
+	// The condition is removed once the VIP has moved and the deletion has proceeded.
+	conditionAPIVIPHold = "APIVIPHold"
+
+	// conditionDeletionHeld is used to denote when the ControlPlaneMachineSet is refusing to delete a Machine
+	// because the deletion of another Machine has not yet been observed.
+	// The condition is removed once the earlier deletion has been observed.
+	conditionDeletionHeld = "DeletionHeld"
+
+	// conditionCanaryComplete is used to denote the progress of the canary index when
+	// the ControlPlaneMachineSet is rolling out an update in canary mode.
+	// The condition is removed once the rollout has completed.
+	conditionCanaryComplete = "CanaryComplete"
+
+	// conditionMaintenanceWindow is used to denote whether the maintenance window configured on the
+	// ControlPlaneMachineSet is open, and when it next opens or closes. Outside of the maintenance window,
+	// the status is kept up to date, but no Machine is created or deleted.
+	// The condition is removed once no maintenance window is configured.
+	conditionMaintenanceWindow = "MaintenanceWindow"
+
+	// conditionMachineConfigPoolHold is used to denote when the ControlPlaneMachineSet is deferring
+	// the creation and deletion of Machines because the master MachineConfigPool is updating.
+	// The condition is removed once the MachineConfigPool has finished updating.
+	conditionMachineConfigPoolHold = "MachineConfigPoolHold"
+
+	// conditionMaintenanceFreeze is used to denote when the ControlPlaneMachineSet is deferring all changes
+	// to Machines because a maintenance freeze has been set on the cluster ClusterVersion.
+	// The condition is removed once the maintenance freeze is lifted.
+	conditionMaintenanceFreeze = "MaintenanceFreeze"
+
+	// conditionEtcdMembers is an informational condition used to denote which etcd member runs on the
+	// Machine of each index, when the etcd member mapping has been requested.
+	// The condition is true when every etcd member runs on an indexed Machine, and is removed once the
+	// mapping is no longer requested.
+	conditionEtcdMembers = "EtcdMembers"
+
+	// conditionRollCancelled is used to denote when the roll of the ControlPlaneMachineSet has been cancelled,
+	// and so no Machine is created or deleted other than to remove replacements that are not yet ready.
+	// The condition is removed once the cancel annotation is removed.
+	conditionRollCancelled = "RollCancelled"
+
+	// conditionUpdatingIndex is used to denote which Control Plane Machine indexes are
+	// currently having their outdated Machine replaced, naming the old and new Machines.
+	// The condition is removed once no replacement is in progress.
+	conditionUpdatingIndex = "UpdatingIndex"
+
+	// conditionMachineGenerations is an informational condition used to denote that some Machines were created for an
+	// earlier generation of the ControlPlaneMachineSet, and so predate one or more changes to it, even when their spec
+	// still matches the template.
+	// The condition is removed once every Machine was created for the current generation.
+	conditionMachineGenerations = "MachineGenerations"
+
+	// conditionIdle summarises whether the ControlPlaneMachineSet is currently acting on its Machines.
+	// When true, the reason describes why no action is being taken, for example, because every replica
+	// is up to date, because the ControlPlaneMachineSet is Inactive, or because it is waiting for a Machine
+	// to become ready. When false, Machines are being replaced.
+	conditionIdle = "Idle"
+
+	// conditionLastReconcileError is used to denote whether the most recent reconcile of the
+	// ControlPlaneMachineSet failed. When true, the reason is the category of the error, so that
+	// the kind of failure is visible without reading the operator logs.
+	conditionLastReconcileError = "LastReconcileError"
+
+	// conditionReplicasPolicy is used to denote that the desired number of Control Plane Machines
+	// is read from the replicas policy ConfigMap in place of spec.replicas. When true, the message
+	// holds the desired replicas in use. The condition is removed when no replicas policy is referenced.
+	conditionReplicasPolicy = "ReplicasPolicy"
+
+	// conditionReady is a kstatus compatible summary condition used to denote that the ControlPlaneMachineSet
+	// has been fully reconciled, with every replica available and up to date, and nothing stalled.
+	conditionReady = "Ready"
+
+	// conditionReconciling is a kstatus compatible summary condition used to denote that the ControlPlaneMachineSet
+	// is still making changes, either because Machines are being updated or because the latest generation has not
+	// yet been observed. Typically this condition is expected to be false.
+	conditionReconciling = "Reconciling"
+
+	// conditionStalled is a kstatus compatible summary condition used to denote that the ControlPlaneMachineSet
+	// is unable to make progress without intervention, because it is degraded or is continuously failing.
+	// Typically this condition is expected to be false.
+	conditionStalled = "Stalled"
 )
 
 // Condition reasons for use in the ControlPlaneMachineSet status.
@@ -102,6 +316,11 @@ const (
 	// Control Plane Machines.
 	reasonNoReadyMachines = "NoReadyMachines"
 
+	// reasonNoControlPlaneMachines denotes that the ControlPlaneMachineSet has not found any
+	// Control Plane Machines matching its selector. No Machines are created from the template
+	// until a user confirms, with the bootstrap annotation, that new Machines should be created.
+	reasonNoControlPlaneMachines = "NoControlPlaneMachines"
+
 	// reasonUnmanagedNodes denotes that the ControlPlaneMachineSet has identified some
 	// Control Plane Node that is not currently managed by a Control Plane Machine.
 	// In this scenario, to prevent potential for degrading the cluster into an unsupported
@@ -115,6 +334,65 @@ const (
 	// configuration, the ControlPlaneMachineSet will cease all operations.
 	reasonExcessIndexes = "ExcessIndexes"
 
+	// reasonRolloutStuck denotes that the ControlPlaneMachineSet has been rolling out
+	// an update but has not observed any change in the number of updated replicas within
+	// the configured timeout.
+	// This will typically occur when a replacement Machine never becomes ready.
+	reasonRolloutStuck = "RolloutStuck"
+
+	// reasonInsufficientControlPlaneMachines denotes that the ControlPlaneMachineSet has
+	// observed fewer Machines than the desired number of replicas, and that it is not
+	// able to create the missing Machines, for example, because the creation is continuously failing.
+	reasonInsufficientControlPlaneMachines = "InsufficientControlPlaneMachines"
+
+	// reasonInsufficientQuota denotes that the ControlPlaneMachineSet has skipped
+	// the creation of a Machine, because the machine provider reported that the cloud
+	// provider does not have enough quota or capacity available for it.
+	reasonInsufficientQuota = "InsufficientQuota"
+
+	// reasonProviderAuthenticationFailed denotes that the ControlPlaneMachineSet has observed
+	// a replacement Machine that the cloud provider rejected, because the cloud credentials
+	// used to create it were not accepted, or do not grant the permissions required.
+	// Unlike other failed replacements, retrying will not succeed until the credentials are fixed.
+	reasonProviderAuthenticationFailed = "ProviderAuthenticationFailed"
+
+	// reasonMissingReferencedSecret denotes that the ControlPlaneMachineSet has skipped
+	// the creation of a Machine, because a secret referenced by the Machine template,
+	// such as the user data or credentials secret, does not exist.
+	reasonMissingReferencedSecret = "MissingReferencedSecret"
+
+	// reasonMismatchedMachineIndex denotes that the ControlPlaneMachineSet has identified
+	// a Machine that it created for one index, but which is labelled with a different index.
+	// The ControlPlaneMachineSet will cease all operations until this Machine has been removed,
+	// as it would otherwise be treated as part of the wrong index.
+	reasonMismatchedMachineIndex = "MismatchedMachineIndex"
+
+	// reasonUnsupportedMachineType denotes that the ControlPlaneMachineSet template has
+	// a machine type that the operator does not know how to manage, for example, because
+	// it was introduced in a newer version of the API.
+	reasonUnsupportedMachineType = "UnsupportedMachineType"
+
+	// reasonInvalidPersistedState denotes that the persisted state annotation on the
+	// ControlPlaneMachineSet could not be parsed. The ControlPlaneMachineSet will cease
+	// all operations until the annotation has been corrected or removed, as the holds
+	// recorded within it would otherwise be lost.
+	reasonInvalidPersistedState = "InvalidPersistedState"
+
+	// reasonReplicasPolicyNotFound denotes that the ControlPlaneMachineSet references a
+	// replicas policy ConfigMap that does not exist. No operations are performed until it is
+	// created, as the desired number of Control Plane Machines is not known.
+	reasonReplicasPolicyNotFound = "ReplicasPolicyNotFound"
+
+	// reasonInvalidReplicasPolicy denotes that the replicas policy ConfigMap referenced by the
+	// ControlPlaneMachineSet does not hold a valid number of replicas, or requests a number of
+	// replicas that would resize the control plane. No operations are performed until it is fixed.
+	reasonInvalidReplicasPolicy = "InvalidReplicasPolicy"
+
+	// reasonFailureDomainsPlatformMismatch denotes that the platform of the failure domains within the
+	// ControlPlaneMachineSet template does not match the platform of the template provider spec, for example,
+	// because the failure domains were copied from a cluster on a different platform.
+	reasonFailureDomainsPlatformMismatch = "FailureDomainsPlatformMismatch"
+
 	// END: Degraded reasons.
 
 	// BEGIN: Error reasons.
@@ -150,5 +428,237 @@ const (
 	// replicas under its management that are currently in need of an update.
 	reasonNeedsUpdateReplicas = "NeedsUpdateReplicas"
 
+	// reasonDrainingOldNode denotes that the ControlPlaneMachineSet is waiting for the Node of
+	// an old Machine to be drained before the Machine can be removed.
+	reasonDrainingOldNode = "DrainingOldNode"
+
 	// END: Progressing reasons.
+
+	// BEGIN: Upgradeable reasons.
+
+	// reasonRollInProgress denotes that the ControlPlaneMachineSet is replacing control plane
+	// machines and so the cluster should not be upgraded until the rollout has completed.
+	reasonRollInProgress = "RollInProgress"
+
+	// END: Upgradeable reasons.
+
+	// BEGIN: UnmatchedFailureDomains reasons.
+
+	// reasonMachinesOutsideFailureDomains denotes that the ControlPlaneMachineSet has observed
+	// at least one Machine in a failure domain that is not part of its configuration.
+	// This will typically occur when a failure domain has been removed from the ControlPlaneMachineSet
+	// or when a Machine was created outside of the ControlPlaneMachineSet.
+	reasonMachinesOutsideFailureDomains = "MachinesOutsideFailureDomains"
+
+	// END: UnmatchedFailureDomains reasons.
+
+	// BEGIN: InconsistentProviderIDs reasons.
+
+	// reasonProviderIDMismatch denotes that the ControlPlaneMachineSet has observed
+	// at least one Machine whose providerID is inconsistent with its Node.
+	reasonProviderIDMismatch = "ProviderIDMismatch"
+
+	// END: InconsistentProviderIDs reasons.
+
+	// BEGIN: ReducedRedundancy reasons.
+
+	// reasonRemovingReplacedMachine denotes that a Machine which has an updated replacement
+	// is being deleted, and the ControlPlaneMachineSet is waiting for it to be removed.
+	reasonRemovingReplacedMachine = "RemovingReplacedMachine"
+
+	// END: ReducedRedundancy reasons.
+
+	// BEGIN: SharedFailureDomains reasons.
+
+	// reasonReplicasExceedFailureDomains denotes that the ControlPlaneMachineSet has more
+	// replicas than the number of failure domains configured in the Machine template.
+	reasonReplicasExceedFailureDomains = "ReplicasExceedFailureDomains"
+
+	// END: SharedFailureDomains reasons.
+
+	// BEGIN: IndexGaps reasons.
+
+	// reasonNonContiguousIndexes denotes that some indexes below the highest Control Plane
+	// Machine index have no Machine.
+	reasonNonContiguousIndexes = "NonContiguousIndexes"
+
+	// END: IndexGaps reasons.
+
+	// BEGIN: APIVIPHold reasons.
+
+	// reasonWaitingForAPIVIPToMove denotes that the Node of an outdated Machine which has
+	// an updated replacement is still marked as holding the API VIP.
+	reasonWaitingForAPIVIPToMove = "WaitingForAPIVIPToMove"
+
+	// END: APIVIPHold reasons.
+
+	// BEGIN: DeletionHeld reasons.
+
+	// reasonAwaitingObservedDeletion denotes that a Machine is not being deleted until the deletion of
+	// another Machine has been observed.
+	reasonAwaitingObservedDeletion = "AwaitingObservedDeletion"
+
+	// END: DeletionHeld reasons.
+
+	// BEGIN: CanaryComplete reasons.
+
+	// reasonCanaryInProgress denotes that the canary index is still being replaced.
+	reasonCanaryInProgress = "CanaryInProgress"
+
+	// reasonAwaitingApproval denotes that the canary index has been replaced, and that
+	// the remaining indexes will not be replaced until the canary has been approved.
+	reasonAwaitingApproval = "AwaitingApproval"
+
+	// reasonCanaryApproved denotes that the canary index has been approved, and that
+	// the remaining indexes are being replaced.
+	reasonCanaryApproved = "CanaryApproved"
+
+	// END: CanaryComplete reasons.
+
+	// BEGIN: MaintenanceWindow reasons.
+
+	// reasonWithinMaintenanceWindow denotes that the maintenance window is open, and so Machines
+	// may be created and deleted.
+	reasonWithinMaintenanceWindow = "WithinMaintenanceWindow"
+
+	// reasonOutsideMaintenanceWindow denotes that the maintenance window is closed, and so no Machine
+	// is created or deleted until it next opens. This is also used as a reason for the Idle condition.
+	reasonOutsideMaintenanceWindow = "OutsideMaintenanceWindow"
+
+	// reasonInvalidMaintenanceWindow denotes that the maintenance window annotation cannot be parsed,
+	// and so no Machine is created or deleted until it has been corrected.
+	reasonInvalidMaintenanceWindow = "InvalidMaintenanceWindow"
+
+	// END: MaintenanceWindow reasons.
+
+	// BEGIN: MachineConfigPoolHold reasons.
+
+	// reasonMachineConfigPoolUpdating denotes that the master MachineConfigPool reports that it is
+	// updating, and so no Machine is created or deleted until it has finished.
+	// This is also used as a reason for the Idle condition.
+	reasonMachineConfigPoolUpdating = "MachineConfigPoolUpdating"
+
+	// END: MachineConfigPoolHold reasons.
+
+	// BEGIN: MaintenanceFreeze reasons.
+
+	// reasonMaintenanceFreezeRequested denotes that the cluster ClusterVersion requests a maintenance freeze,
+	// and so no Machine is created, deleted or updated until it is lifted.
+	// This is also used as a reason for the Idle condition.
+	reasonMaintenanceFreezeRequested = "MaintenanceFreezeRequested"
+
+	// END: MaintenanceFreeze reasons.
+
+	// BEGIN: EtcdMembers reasons.
+
+	// reasonEtcdMembersMapped denotes that every etcd member runs on the Machine of an index.
+	reasonEtcdMembersMapped = "EtcdMembersMapped"
+
+	// reasonUnmappedEtcdMembers denotes that at least one etcd member does not run on the Machine of
+	// any index, for example, because the member was not removed when its Machine was.
+	reasonUnmappedEtcdMembers = "UnmappedEtcdMembers"
+
+	// reasonEtcdMembersUnavailable denotes that the members of the etcd cluster cannot be listed.
+	reasonEtcdMembersUnavailable = "EtcdMembersUnavailable"
+
+	// END: EtcdMembers reasons.
+
+	// BEGIN: RollCancelled reasons.
+
+	// reasonCancelRequested denotes that the cancel annotation is set on the ControlPlaneMachineSet, and so
+	// the roll does not continue until it is removed.
+	// This is also used as a reason for the Idle condition.
+	reasonCancelRequested = "CancelRequested"
+
+	// END: RollCancelled reasons.
+
+	// BEGIN: UpdatingIndex reasons.
+
+	// reasonReplacingMachine denotes that at least one index has both an outdated Machine
+	// and a replacement for it, and that the replacement has not yet completed.
+	reasonReplacingMachine = "ReplacingMachine"
+
+	// END: UpdatingIndex reasons.
+
+	// BEGIN: MachineGenerations reasons.
+
+	// reasonMachinesPredateGeneration denotes that at least one Machine was created for an earlier generation of
+	// the ControlPlaneMachineSet than the current generation.
+	reasonMachinesPredateGeneration = "MachinesPredateGeneration"
+
+	// END: MachineGenerations reasons.
+
+	// BEGIN: Idle reasons.
+
+	// reasonInactive denotes that the ControlPlaneMachineSet is Inactive, and so observes
+	// the Control Plane Machines without creating or deleting any of them.
+	reasonInactive = "Inactive"
+
+	// reasonFeatureGateDisabled denotes that the ControlPlaneMachineSet feature gate is disabled
+	// in the cluster, and so no operations are performed on the Control Plane Machines.
+	reasonFeatureGateDisabled = "FeatureGateDisabled"
+
+	// reasonWaitingForReadyReplicas denotes that the ControlPlaneMachineSet is not starting
+	// any further replacements until a new Machine has become ready.
+	reasonWaitingForReadyReplicas = "WaitingForReadyReplicas"
+
+	// reasonAwaitingMachineDeletion denotes that, with the OnDelete strategy, Machines need
+	// an update, but will not be replaced until they are deleted by the user.
+	reasonAwaitingMachineDeletion = "AwaitingMachineDeletion"
+
+	// reasonAwaitingStep denotes that the ControlPlaneMachineSet has been paused by the step annotation,
+	// and will not create or delete any Machine until the annotation is changed.
+	reasonAwaitingStep = "AwaitingStep"
+
+	// reasonReplacingMachines denotes that the ControlPlaneMachineSet is creating or deleting
+	// Machines to bring the Control Plane up to date with the desired configuration.
+	reasonReplacingMachines = "ReplacingMachines"
+
+	// END: Idle reasons.
+
+	// BEGIN: LastReconcileError reasons.
+
+	// reasonAPIConflict denotes that the reconcile failed because an object was modified
+	// concurrently, and the update was rejected with a conflict.
+	reasonAPIConflict = "APIConflict"
+
+	// reasonValidationFailed denotes that the reconcile failed because an object was rejected
+	// as invalid, or because the ControlPlaneMachineSet is missing required configuration.
+	reasonValidationFailed = "ValidationFailed"
+
+	// reasonMachineProviderError denotes that the reconcile failed because the machine provider
+	// could not be constructed, or failed to gather, create or delete Machines.
+	reasonMachineProviderError = "MachineProviderError"
+
+	// reasonUnknownError denotes that the reconcile failed with an error that does not fall into
+	// any other category.
+	reasonUnknownError = "UnknownError"
+
+	// END: LastReconcileError reasons.
+
+	// BEGIN: ReplicasPolicy reasons.
+
+	// reasonReplicasPolicyApplied denotes that the desired number of Control Plane Machines
+	// has been read from the replicas policy ConfigMap.
+	reasonReplicasPolicyApplied = "ReplicasPolicyApplied"
+
+	// END: ReplicasPolicy reasons.
+
+	// BEGIN: Ready reasons.
+
+	// reasonStalled denotes that the ControlPlaneMachineSet is not ready because it is stalled.
+	reasonStalled = "Stalled"
+
+	// reasonReconciling denotes that the ControlPlaneMachineSet is not ready because it is still reconciling.
+	reasonReconciling = "Reconciling"
+
+	// END: Ready reasons.
+
+	// BEGIN: Reconciling reasons.
+
+	// reasonGenerationNotObserved denotes that the latest generation of the ControlPlaneMachineSet
+	// has not yet been observed by a successful reconcile.
+	reasonGenerationNotObserved = "GenerationNotObserved"
+
+	// END: Reconciling reasons.
 )
@@ -20,7 +20,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
@@ -29,6 +33,8 @@ import (
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -37,12 +43,17 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	errorutils "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -59,8 +70,33 @@ const (
 	// degradedClusterState is used to denote that the control plane machine set has detected a degraded cluster.
 	// In this case, the controller will not perform any further actions.
 	degradedClusterState = "Cluster state is degraded. The control plane machine set will not take any action until issues have been resolved."
+
+	// retryingAfterConflict is a log message used to inform the user that an update of the control plane machine set
+	// conflicted with a newer version of the object, and is being retried against the latest version.
+	retryingAfterConflict = "Update of control plane machine set conflicted, retrying with the latest version"
+
+	// providerAuthenticationRetryInterval is how long to wait before checking again a replacement Machine that the
+	// cloud provider rejected because of the cloud credentials. The error on the Machine is not cleared until the
+	// credentials are fixed and the Machine is replaced, so checking sooner only repeats the failure.
+	providerAuthenticationRetryInterval = 5 * time.Minute
 )
 
+// providerAuthenticationFailureIndicators are the phrases, reported by cloud providers within the error message of a
+// Machine with an invalid configuration, that denote that the cloud credentials were rejected, or do not grant the
+// permissions required.
+var providerAuthenticationFailureIndicators = []string{
+	"unauthorized",
+	"not authorized",
+	"authorizationfailed",
+	"authenticationfailed",
+	"authentication failed",
+	"invalid credentials",
+	"access denied",
+	"accessdenied",
+	"permission denied",
+	"forbidden",
+}
+
 var (
 	// errFoundUnmanagedControlPlaneNodes is used to inform users that one or more nodes do not have machines associated with them.
 	errFoundUnmanagedControlPlaneNodes = errors.New("found unmanaged control plane nodes, the following node(s) do not have associated machines")
@@ -68,6 +104,9 @@ var (
 	// errNoReadyControlPlaneMachines is used to inform users that no control plane machines in the cluster are ready.
 	errNoReadyControlPlaneMachines = errors.New("no ready control plane machines")
 
+	// errNoControlPlaneMachines is used to inform users that no control plane machines exist in the cluster.
+	errNoControlPlaneMachines = errors.New("no control plane machines found")
+
 	// errFoundErroredReplacementControlPlaneMachine is used to inform users that one or more replacement control plane machines, have been found.
 	errFoundErroredReplacementControlPlaneMachine = errors.New("found replacement control plane machines in an error state, the following machines(s) are currently reporting an error")
 
@@ -76,6 +115,15 @@ var (
 
 	// errFoundExcessiveUpdatedReplicas is used to inform users that an excessive number of updated machines has been found for a single index.
 	errFoundExcessiveUpdatedReplicas = errors.New("found an excessive number of updated machines for a single index")
+
+	// errRolloutStuck is used to inform users that a rollout has not made any progress within the configured timeout.
+	errRolloutStuck = errors.New("rollout has not made any progress")
+
+	// errMismatchedMachineIndex is used to inform users that a Machine created for an index is labelled with a different index.
+	errMismatchedMachineIndex = errors.New("created machine is not labelled with the requested index")
+
+	// errInsufficientControlPlaneMachines is used to inform users that control plane machines are missing and cannot be created.
+	errInsufficientControlPlaneMachines = errors.New("fewer control plane machines than desired replicas")
 )
 
 // ControlPlaneMachineSetReconciler reconciles a ControlPlaneMachineSet object.
@@ -96,8 +144,68 @@ type ControlPlaneMachineSetReconciler struct {
 	// ReleaseVersion is the version of current cluster operator release.
 	ReleaseVersion string
 
+	// RolloutStuckTimeout is the duration after which a RollingUpdate rollout that has not
+	// changed the number of updated replicas is considered stuck, and the ControlPlaneMachineSet
+	// is marked as degraded.
+	// A zero value disables the timeout.
+	RolloutStuckTimeout time.Duration
+
+	// ReplacementProvisioningDeadline is the duration, from its creation, within which a replacement Machine
+	// must reach the Provisioned phase. A replacement that is still provisioning once the deadline has passed
+	// is deleted, so that it is created again once it has been removed.
+	// A zero value disables the deadline.
+	ReplacementProvisioningDeadline time.Duration
+
+	// Recorder is used to emit events on the ControlPlaneMachineSet.
+	// When not set, no events are emitted.
+	Recorder record.EventRecorder
+
+	// AuditEvents enables the emission of an audit event, through the Recorder, for each Machine created or
+	// deleted by the ControlPlaneMachineSet, recording when and why the Machine was created or deleted.
+	AuditEvents bool
+
+	// EtcdMembers is used to list the members of the etcd cluster, so that they can be mapped to the Machines
+	// when requested by the etcd member mapping annotation.
+	// When not set, the etcd members are not mapped.
+	EtcdMembers providers.EtcdMemberLister
+
+	// TracerProvider is used to record a span for each reconcile, with child spans around its major phases, so that
+	// the time spent in each phase can be inspected.
+	// When not set, no spans are recorded.
+	TracerProvider trace.TracerProvider
+
+	// MaxConcurrentReconciles is the maximum number of reconciles that may run at the same time.
+	// Requests for the same ControlPlaneMachineSet are never reconciled concurrently.
+	// When zero, a single reconcile runs at a time.
+	MaxConcurrentReconciles int
+
+	// lastErrorLock guards lastError, which is shared between concurrent reconciles.
+	lastErrorLock sync.Mutex
+
 	// lastError allows us to track the last error that occurred during reconciliation.
 	lastError *lastErrorTracker
+
+	// rollState records the roll state computed by the last reconcile, to be served on the metrics server.
+	rollState rollStateRecorder
+
+	// logVerbosity applies the log verbosity requested by the ControlPlaneMachineSet.
+	logVerbosity logVerbosityApplier
+
+	// readinessWaits counts the consecutive reconciles that have waited for a Machine to become ready, so that
+	// long waits are requeued and logged less often.
+	readinessWaits readinessWaitTracker
+
+	// deletions holds the Machine deleted by an earlier reconcile until its deletion has been observed, so that
+	// at most one Machine is being removed at a time.
+	deletions deletionGuard
+
+	// replicasPolicy tracks the replicas policy ConfigMap referenced by the ControlPlaneMachineSet, so that
+	// changes to it trigger a reconcile.
+	replicasPolicy replicasPolicyTracker
+
+	// clock is used to determine the current time.
+	// When not set, the real clock is used.
+	clock clock.PassiveClock
 }
 
 // lastErrorTracker tracks the last error that occurred during reconciliation.
@@ -132,6 +240,39 @@ func (r *ControlPlaneMachineSetReconciler) SetupWithManager(mgr ctrl.Manager) er
 			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
 			builder.WithPredicates(util.FilterClusterOperator(r.OperatorName)),
 		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
+			builder.WithPredicates(util.FilterConfigMap(machineproviders.InstanceTypeEquivalenceConfigMapName, r.Namespace)),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.replicasPolicy.isReferenced)),
+		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
+			builder.WithPredicates(util.FilterConfigMap(failureDomainMappingConfigMapName, r.Namespace)),
+		).
+		Watches(
+			&configv1.FeatureGate{},
+			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
+			builder.WithPredicates(util.FilterFeatureGate(featureGateName)),
+		).
+		Watches(
+			&configv1.Infrastructure{},
+			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
+			builder.WithPredicates(util.FilterInfrastructure(infrastructureName)),
+		).
+		Watches(
+			&configv1.ClusterVersion{},
+			handler.EnqueueRequestsFromMapFunc(util.ObjToControlPlaneMachineSet(clusterControlPlaneMachineSetName, r.Namespace)),
+			builder.WithPredicates(util.FilterClusterVersion(clusterVersionName)),
+		).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
 		// Override the default log constructor as it makes the logs very chatty.
 		WithLogConstructor(func(req *reconcile.Request) logr.Logger {
 			return mgr.GetLogger().WithValues(
@@ -142,6 +283,10 @@ func (r *ControlPlaneMachineSetReconciler) SetupWithManager(mgr ctrl.Manager) er
 		return fmt.Errorf("could not set up controller for control plane machine set: %w", err)
 	}
 
+	if err := mgr.AddMetricsExtraHandler(RollStatePath, &r.rollState); err != nil {
+		return fmt.Errorf("could not add roll state handler: %w", err)
+	}
+
 	// Set up API helpers from the manager.
 	r.Scheme = mgr.GetScheme()
 	r.RESTMapper = mgr.GetRESTMapper()
@@ -156,6 +301,10 @@ func (r *ControlPlaneMachineSetReconciler) Reconcile(ctx context.Context, req ct
 	logger.V(1).Info("Reconciling control plane machine set")
 	defer logger.V(1).Info("Finished reconciling control plane machine set")
 
+	ctx, span := r.getTracerProvider().Tracer(util.TracerName).Start(ctx, "Reconcile",
+		trace.WithAttributes(attribute.String("namespace", req.Namespace), attribute.String("name", req.Name)))
+	defer span.End()
+
 	cpms := &machinev1.ControlPlaneMachineSet{}
 	cpmsKey := client.ObjectKey{Namespace: req.Namespace, Name: req.Name}
 
@@ -163,6 +312,9 @@ func (r *ControlPlaneMachineSetReconciler) Reconcile(ctx context.Context, req ct
 	if err := r.Get(ctx, cpmsKey, cpms); apierrors.IsNotFound(err) {
 		logger.V(1).Info("No control plane machine set found, setting operator status available")
 
+		r.rollState.record(nil)
+		r.logVerbosity.apply(logger, nil)
+
 		if err := r.setClusterOperatorAvailable(ctx, logger); err != nil {
 			return ctrl.Result{}, fmt.Errorf("unable to reconcile cluster operator status: %w", err)
 		}
@@ -172,13 +324,23 @@ func (r *ControlPlaneMachineSetReconciler) Reconcile(ctx context.Context, req ct
 		return ctrl.Result{}, fmt.Errorf("unable to fetch control plane machine set: %w", err)
 	}
 
+	r.logVerbosity.apply(logger, cpms)
+
 	// Take a copy of the original object to be able to create a patch for the status at the end.
-	patchBase := client.MergeFrom(cpms.DeepCopy())
+	originalCPMS := cpms.DeepCopy()
+	patchBase := client.MergeFrom(originalCPMS)
 
 	// Collect errors as an aggregate to return together after all patches have been performed.
 	var errs []error
 
-	result, err := r.reconcile(ctx, logger, cpms)
+	// Resolve the desired replicas once, so that the reconcile and the status update work from the same count.
+	replicas, err := r.resolveReplicas(ctx, logger, cpms)
+
+	var result ctrl.Result
+	if err == nil {
+		result, err = r.reconcile(ctx, logger, cpms, replicas)
+	}
+
 	if err != nil {
 		// Don't return an error here so that we have an opportunity to update the status and cluster operator status.
 		errs = append(errs, fmt.Errorf("error reconciling control plane machine set: %w", err))
@@ -187,11 +349,24 @@ func (r *ControlPlaneMachineSetReconciler) Reconcile(ctx context.Context, req ct
 	// Track the reconcile error as our last error.
 	r.setLastError(logger, cpms, err)
 
-	if err := r.updateControlPlaneMachineSetStatus(ctx, logger, cpms, patchBase); err != nil {
+	// Persist any annotations set during the reconcile before the status update,
+	// as the status update will not persist changes to the metadata.
+	if err := r.updateControlPlaneMachineSetAnnotations(ctx, logger, cpms, originalCPMS); err != nil {
+		// Don't return an error here so that we have an opportunity to update the status and cluster operator status.
+		errs = append(errs, fmt.Errorf("error updating control plane machine set annotations: %w", err))
+	}
+
+	statusCtx, statusSpan := util.StartSpan(ctx, "UpdateStatus")
+	err = r.updateControlPlaneMachineSetStatus(statusCtx, logger, cpms, replicas, patchBase)
+	util.EndSpan(statusSpan, err)
+
+	if err != nil {
 		// Don't return an error here so that we have an opportunity to update the cluster operator status.
 		errs = append(errs, fmt.Errorf("error updating control plane machine set status: %w", err))
 	}
 
+	r.rollState.record(cpms)
+
 	if isActive(cpms) {
 		if err := r.updateClusterOperatorStatus(ctx, logger, cpms); err != nil {
 			// Don't return an error here so we can aggregate the errors with previous updates.
@@ -206,7 +381,10 @@ func (r *ControlPlaneMachineSetReconciler) Reconcile(ctx context.Context, req ct
 	}
 
 	if len(errs) > 0 {
-		return ctrl.Result{}, errorutils.NewAggregate(errs)
+		aggregate := errorutils.NewAggregate(errs)
+		util.EndSpan(span, aggregate)
+
+		return ctrl.Result{}, aggregate
 	}
 
 	return result, nil
@@ -215,12 +393,25 @@ func (r *ControlPlaneMachineSetReconciler) Reconcile(ctx context.Context, req ct
 // reconcile performs the main business logic of the ControlPlaneMachineSet operator.
 // Notably it actions the various parts of the business logic without performing any status updates on the
 // ControlPlaneMachineSet object itself, these updates are handled at the parent scope.
-func (r *ControlPlaneMachineSetReconciler) reconcile(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (ctrl.Result, error) {
+// The replicas are the desired number of Control Plane Machines, or nil when they could not be resolved.
+func (r *ControlPlaneMachineSetReconciler) reconcile(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas *int32) (ctrl.Result, error) {
 	// If the control plane machine set is being deleted, we need to handle that rather than the regular reconcile flow.
 	if cpms.GetDeletionTimestamp() != nil {
 		return r.reconcileDelete(ctx, logger, cpms)
 	}
 
+	// When the feature gate is disabled, the Machines are left alone entirely.
+	if enabled, err := r.isFeatureGateEnabled(ctx); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error checking feature gate: %w", err)
+	} else if !enabled {
+		logger.V(1).Info("Control plane machine set feature gate is disabled, no operations will be performed", "featureGate", controlPlaneMachineSetFeatureGate)
+
+		setIdle(cpms, metav1.ConditionTrue, reasonFeatureGateDisabled,
+			fmt.Sprintf("The %s feature gate is disabled, so machines are not managed", controlPlaneMachineSetFeatureGate))
+
+		return ctrl.Result{}, nil
+	}
+
 	// Add the finalizer before any updates to the status. This will ensure no status changes on the same reconcile
 	// as we add the finalizer. The finalizer must be present on the object before we take any actions.
 	if updatedFinalizer, err := r.ensureFinalizer(ctx, logger, cpms); err != nil {
@@ -229,22 +420,54 @@ func (r *ControlPlaneMachineSetReconciler) reconcile(ctx context.Context, logger
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	machineProvider, err := providers.NewMachineProvider(ctx, logger, r.Client, cpms)
+	// Without the recorded state, holds such as the canary would be lost, so report it rather than acting without it.
+	if ok := r.checkPersistedState(logger, cpms); !ok {
+		return ctrl.Result{}, nil
+	}
+
+	if isActive(cpms) {
+		// Record any new force roll request before the machine provider is constructed,
+		// so that the machine provider can report the affected Machines as needing an update.
+		if err := r.reconcileForceRoll(logger, cpms); err != nil {
+			return ctrl.Result{}, fmt.Errorf("error reconciling force roll: %w", err)
+		}
+	}
+
+	// Without the desired replicas, the machine provider cannot map each index to a failure domain.
+	// Why they could not be resolved has already been reported in the status.
+	if replicas == nil {
+		return ctrl.Result{}, nil
+	}
+
+	// A machine type from a newer API version cannot be managed, so report it rather than failing on every reconcile.
+	if ok := r.checkSupportedMachineType(logger, cpms); !ok {
+		return ctrl.Result{}, nil
+	}
+
+	// The template provider spec would be decoded for the wrong platform, so report it rather than failing on every reconcile.
+	if ok := r.checkFailureDomainsPlatform(logger, cpms); !ok {
+		return ctrl.Result{}, nil
+	}
+
+	machineProvider, err := providers.NewMachineProvider(ctx, logger, r.Client, cpms, *replicas)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("error constructing machine provider: %w", err)
+		return ctrl.Result{}, fmt.Errorf("error constructing machine provider: %w", machineProviderError{err: err})
 	}
 
-	machineInfos, err := machineProvider.GetMachineInfos(ctx, logger)
+	machineInfosCtx, span := util.StartSpan(ctx, "GetMachineInfos")
+	machineInfos, err := machineProvider.GetMachineInfos(machineInfosCtx, logger)
+	util.EndSpan(span, err)
+
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("error fetching machine info: %w", err)
+		return ctrl.Result{}, fmt.Errorf("error fetching machine info: %w", machineProviderError{err: err})
 	}
 
-	indexedMachineInfos, err := machineInfosByIndex(cpms, machineInfos)
+	indexedMachineInfos, err := machineInfosByIndex(*replicas, machineInfos)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("could not sort machine info by index: %w", err)
 	}
 
-	result, err := r.reconcileMachines(ctx, logger, cpms, machineProvider, indexedMachineInfos)
+	result, err := r.reconcileMachines(ctx, logger, cpms, *replicas, machineProvider, indexedMachineInfos)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("error reconciling machines: %w", err)
 	}
@@ -255,37 +478,164 @@ func (r *ControlPlaneMachineSetReconciler) reconcile(ctx context.Context, logger
 // reconcileMachines uses the gathered machine info to set the status of the ControlPlaneMachineSet and then,
 // after validating that the cluster state is as expected, uses the machine provider to take appropriate actions
 // to perform any requied roll outs.
-func (r *ControlPlaneMachineSetReconciler) reconcileMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
-	if err := reconcileStatusWithMachineInfo(logger, cpms, machineInfos); err != nil {
+func (r *ControlPlaneMachineSetReconciler) reconcileMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
+	// Keep track of the previously observed number of updated replicas so that we can tell whether the rollout
+	// has made any progress since the last reconcile.
+	previousUpdatedReplicas := cpms.Status.UpdatedReplicas
+	previousReplicas := cpms.Status.Replicas
+
+	if err := reconcileStatusWithMachineInfo(logger, cpms, replicas, machineInfos); err != nil {
 		return ctrl.Result{}, fmt.Errorf("error reconciling machine info with status: %w", err)
 	}
 
-	if err := r.validateClusterState(ctx, logger, cpms, machineInfos); err != nil {
+	if err := r.reconcileLastReplacementCompleted(logger, cpms, previousReplicas, previousUpdatedReplicas); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Record the completion of an index before any early return, so that the completion is not missed.
+	if err := r.reconcileRolloutWindow(logger, cpms, previousReplicas, previousUpdatedReplicas, machineInfos); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	reconcileUnmatchedFailureDomains(logger, cpms, machineInfos)
+	reconcileInconsistentProviderIDs(logger, cpms, machineInfos)
+	reconcileSharedFailureDomains(cpms, replicas)
+	reconcileIndexGaps(cpms, machineInfos)
+	reconcileReducedRedundancy(cpms, machineInfos)
+	reconcileUpdatingIndexes(cpms, machineInfos)
+	reconcileMachineGenerations(cpms, machineInfos)
+	r.reconcileEtcdMembers(ctx, logger, cpms, machineInfos)
+
+	if err := r.reconcileFailureDomainMapping(ctx, logger, cpms, machineProvider); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error exporting failure domain mapping: %w", err)
+	}
+
+	if err := r.validateClusterState(ctx, logger, cpms, replicas, machineProvider, machineInfos); err != nil {
 		return ctrl.Result{}, fmt.Errorf("error validating cluster state: %w", err)
 	}
 
 	if isControlPlaneMachineSetDegraded(cpms) {
 		logger.V(1).Info(degradedClusterState)
-		return ctrl.Result{}, nil
+
+		// No rollout takes place while degraded, so start tracking progress afresh once operations resume.
+		if err := deferMachineUpdates(logger, cpms, replicas, machineInfos); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{RequeueAfter: degradedRecheckInterval(cpms)}, nil
 	}
 
 	if !isActive(cpms) {
 		// When inactive, we don't want to modify the machines at all so stop processing here.
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, deferMachineUpdates(logger, cpms, replicas, machineInfos)
+	}
+
+	// While the cluster maintenance freeze is in effect, the status is kept up to date, but no Machine is changed.
+	if frozen, err := r.reconcileMaintenanceFreeze(ctx, logger, cpms); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error checking maintenance freeze: %w", err)
+	} else if frozen {
+		return ctrl.Result{}, deferMachineUpdates(logger, cpms, replicas, machineInfos)
 	}
 
 	if err := r.ensureOwnerReferences(ctx, logger, cpms, machineInfos); err != nil {
 		return ctrl.Result{}, fmt.Errorf("error ensuring owner references: %w", err)
 	}
 
-	result, err := r.reconcileMachineUpdates(ctx, logger, cpms, machineProvider, machineInfos)
+	// Make sure the index of each Machine is recorded before any Machine is replaced.
+	// This allows pre-existing Machines to be adopted into a stable index.
+	if err := r.ensureIndexLabels(ctx, logger, machineInfos); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error ensuring index labels: %w", err)
+	}
+
+	// Outside of the maintenance window, the status is kept up to date, but no Machine is created or deleted.
+	withinMaintenanceWindow, maintenanceWindowBoundary := r.reconcileMaintenanceWindow(logger, cpms)
+	if !withinMaintenanceWindow {
+		return ctrl.Result{RequeueAfter: maintenanceWindowBoundary}, deferMachineUpdates(logger, cpms, replicas, machineInfos)
+	}
+
+	if held, err := r.reconcileMachineConfigPoolHold(ctx, logger, cpms); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error checking machine config pool: %w", err)
+	} else if held {
+		return ctrl.Result{RequeueAfter: machineConfigPoolRecheckInterval}, deferMachineUpdates(logger, cpms, replicas, machineInfos)
+	}
+
+	// Once the roll is cancelled, only replacements that are not yet ready are deleted.
+	if cancelled, err := r.reconcileRollCancellation(ctx, logger, cpms, machineProvider, machineInfos); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error cancelling roll: %w", err)
+	} else if cancelled {
+		return ctrl.Result{}, deferMachineUpdates(logger, cpms, replicas, machineInfos)
+	}
+
+	// When paused by the step annotation, at most one Machine is created or deleted for each step.
+	if err := reconcileStep(logger, cpms); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Publish the actions intended before any are taken, so that the plan can be followed as each is taken.
+	setUpdatePlan(logger, cpms, computeUpdatePlan(cpms, machineInfos))
+
+	// Record the Machines created and deleted, so that they can be audited, whether or not the updates complete
+	// without error.
+	planRecorder := newUpdatePlanRecorder(machineProvider, machineInfos)
+
+	result, err := r.reconcileMachineUpdates(ctx, logger, cpms, replicas, planRecorder, machineInfos)
+	r.recordAuditEvents(logger, cpms, planRecorder.actions, machineInfos)
+
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("error reconciling machine updates: %w", err)
 	}
 
+	if err := reconcileIdle(cpms, replicas, machineInfos); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error reconciling idle condition: %w", err)
+	}
+
+	// Make sure we check back in once the rollout would be considered stuck.
+	requeueAfter, err := r.reconcileRolloutProgress(logger, cpms, previousUpdatedReplicas, machineInfos)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("error reconciling rollout progress: %w", err)
+	}
+
+	if requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+
+	// Make sure we check back in once the maintenance window closes.
+	if maintenanceWindowBoundary > 0 && (result.RequeueAfter == 0 || maintenanceWindowBoundary < result.RequeueAfter) {
+		result.RequeueAfter = maintenanceWindowBoundary
+	}
+
 	return result, nil
 }
 
+// degradedRecheckInterval returns how long to wait before checking a degraded ControlPlaneMachineSet again.
+// Most degraded states are resolved by a change to a watched object, but a replacement rejected by the cloud provider
+// is not updated once the cloud credentials are fixed, so it is checked again after a longer interval.
+func degradedRecheckInterval(cpms *machinev1.ControlPlaneMachineSet) time.Duration {
+	degraded := meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)
+	if degraded != nil && degraded.Reason == reasonProviderAuthenticationFailed {
+		return providerAuthenticationRetryInterval
+	}
+
+	return 0
+}
+
+// deferMachineUpdates keeps the status of the ControlPlaneMachineSet up to date when no Machine may be created or
+// deleted within the current reconcile. No rollout takes place while updates are deferred, so progress is tracked
+// afresh once updates resume.
+func deferMachineUpdates(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, machineInfos map[int32][]machineproviders.MachineInfo) error {
+	if err := clearLastProgressTime(cpms); err != nil {
+		return err
+	}
+
+	setUpdatePlan(logger, cpms, nil)
+
+	if err := reconcileIdle(cpms, replicas, machineInfos); err != nil {
+		return fmt.Errorf("error reconciling idle condition: %w", err)
+	}
+
+	return nil
+}
+
 // reconcileDelete handles the removal logic for the ControlPlaneMachineSet resource.
 // During the deletion process, the controller is expected to remove any owner references from Machines
 // that are owned by the ControlPlaneMachineSet.
@@ -350,6 +700,9 @@ func (r *ControlPlaneMachineSetReconciler) reconcileDelete(ctx context.Context,
 // setLastError handles the reconcile error and tracks similar errors so that we can set a condition
 // when the reconciler is repeatedly failing with the same error.
 func (r *ControlPlaneMachineSetReconciler) setLastError(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, err error) {
+	r.lastErrorLock.Lock()
+	defer r.lastErrorLock.Unlock()
+
 	switch {
 	case err == nil:
 		// When no error occurred, stop tracking the last error.
@@ -377,6 +730,112 @@ func (r *ControlPlaneMachineSetReconciler) setLastError(logger logr.Logger, cpms
 
 	errorCondition := getErrorCondition(cpms, r.lastError)
 	meta.SetStatusCondition(&cpms.Status.Conditions, errorCondition)
+
+	setLastReconcileError(cpms, err)
+}
+
+// setLastReconcileError counts a failed reconcile by the category of its error, and reflects the category of the
+// most recent error in the LastReconcileError condition.
+func setLastReconcileError(cpms *machinev1.ControlPlaneMachineSet, err error) {
+	if err == nil {
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:               conditionLastReconcileError,
+			Status:             metav1.ConditionFalse,
+			Reason:             reasonAsExpected,
+			ObservedGeneration: cpms.Generation,
+		})
+
+		return
+	}
+
+	category := reconcileErrorCategory(err)
+	reconcileErrors.WithLabelValues(category).Inc()
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionLastReconcileError,
+		Status:             metav1.ConditionTrue,
+		Reason:             category,
+		Message:            err.Error(),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileErrorCategory returns the category of a reconcile error.
+// API errors take precedence, as the machine provider may itself fail because of a conflicting or invalid object.
+func reconcileErrorCategory(err error) string {
+	switch {
+	case apierrors.IsConflict(err):
+		return reasonAPIConflict
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err), errors.Is(err, errReplicasRequired):
+		return reasonValidationFailed
+	case errors.As(err, &machineProviderError{}):
+		return reasonMachineProviderError
+	default:
+		return reasonUnknownError
+	}
+}
+
+// machineProviderError wraps an error returned by the machine provider, so that a failed reconcile can be
+// categorised as a machine provider error, without changing the message of the error.
+type machineProviderError struct {
+	err error
+}
+
+// Error returns the message of the wrapped error.
+func (e machineProviderError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e machineProviderError) Unwrap() error {
+	return e.err
+}
+
+// updateControlPlaneMachineSetAnnotations patches the metadata of the ControlPlaneMachineSet when the annotations
+// have been changed during the reconcile.
+// The resource version returned by the patch is copied back to the ControlPlaneMachineSet so that the subsequent
+// status update does not conflict.
+func (r *ControlPlaneMachineSetReconciler) updateControlPlaneMachineSetAnnotations(ctx context.Context, logger logr.Logger, cpms, originalCPMS *machinev1.ControlPlaneMachineSet) error {
+	if reflect.DeepEqual(cpms.GetAnnotations(), originalCPMS.GetAnnotations()) {
+		return nil
+	}
+
+	cpmsMeta := &metav1.PartialObjectMetadata{}
+	cpmsMeta.SetGroupVersionKind(machinev1.GroupVersion.WithKind("ControlPlaneMachineSet"))
+	cpmsMeta.ObjectMeta = *originalCPMS.ObjectMeta.DeepCopy()
+
+	patchBase := client.MergeFrom(cpmsMeta.DeepCopy())
+
+	cpmsMeta.SetAnnotations(cpms.GetAnnotations())
+
+	if err := r.Patch(ctx, cpmsMeta, patchBase); err != nil {
+		return fmt.Errorf("failed to patch control plane machine set annotations: %w", err)
+	}
+
+	logger.V(3).Info("Updated control plane machine set annotations")
+
+	cpms.SetResourceVersion(cpmsMeta.GetResourceVersion())
+
+	return nil
+}
+
+// getTracerProvider returns the tracer provider used to record the reconcile spans.
+// When not set, a tracer provider that records nothing is used.
+func (r *ControlPlaneMachineSetReconciler) getTracerProvider() trace.TracerProvider {
+	if r.TracerProvider == nil {
+		return trace.NewNoopTracerProvider()
+	}
+
+	return r.TracerProvider
+}
+
+// getClock returns the clock used by the reconciler, defaulting to the real clock.
+func (r *ControlPlaneMachineSetReconciler) getClock() clock.PassiveClock {
+	if r.clock == nil {
+		return clock.RealClock{}
+	}
+
+	return r.clock
 }
 
 // hasOwnerRef returns true if target has an ownerRef to owner.
@@ -419,24 +878,70 @@ func removeOwnerRef(target, owner client.Object) bool {
 // If the finalizer already exists, this function should be a no-op.
 // If the finalizer is added, the function will return true so that the reconciler can requeue the object.
 // Adding the finalizer in a separate reconcile ensures that spec updates are separate from status updates.
+// When the ControlPlaneMachineSet is stale, the latest version is fetched and copied into the cpms, and the
+// finalizer is only added if the latest version does not already have it.
 func (r *ControlPlaneMachineSetReconciler) ensureFinalizer(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (bool, error) {
-	// Check if we need to add the finalizer.
-	for _, finalizer := range cpms.GetFinalizers() {
-		if finalizer == controlPlaneMachineSetFinalizer {
-			logger.V(4).Info("Finalizer already present on control plane machine set")
-			return false, nil
+	updatedFinalizer := false
+
+	if err := r.retryOnConflict(ctx, logger, cpms, func(latest *machinev1.ControlPlaneMachineSet) error {
+		if latest != cpms {
+			latest.DeepCopyInto(cpms)
+		}
+
+		// Check if we need to add the finalizer.
+		for _, finalizer := range cpms.GetFinalizers() {
+			if finalizer == controlPlaneMachineSetFinalizer {
+				logger.V(4).Info("Finalizer already present on control plane machine set")
+				return nil
+			}
 		}
-	}
 
-	cpms.SetFinalizers(append(cpms.GetFinalizers(), controlPlaneMachineSetFinalizer))
+		cpms.SetFinalizers(append(cpms.GetFinalizers(), controlPlaneMachineSetFinalizer))
 
-	if err := r.Client.Update(ctx, cpms); err != nil {
-		return false, fmt.Errorf("error updating control plane machine set: %w", err)
+		if err := r.Client.Update(ctx, cpms); err != nil {
+			return fmt.Errorf("error updating control plane machine set: %w", err)
+		}
+
+		updatedFinalizer = true
+
+		return nil
+	}); err != nil {
+		return false, err
 	}
 
-	logger.V(2).Info("Added finalizer to control plane machine set")
+	if updatedFinalizer {
+		logger.V(2).Info("Added finalizer to control plane machine set")
+	}
 
-	return true, nil
+	return updatedFinalizer, nil
+}
+
+// retryOnConflict calls the update function with the ControlPlaneMachineSet. When the update conflicts with a
+// newer version of the ControlPlaneMachineSet, the latest version is fetched from the API and the update is
+// retried with it, so that the caller can reapply its changes, up to the number of steps of retry.DefaultRetry.
+// The last error is returned once the retries are exhausted.
+func (r *ControlPlaneMachineSetReconciler) retryOnConflict(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, update func(latest *machinev1.ControlPlaneMachineSet) error) error {
+	latest := cpms
+	attempt := 0
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if attempt > 0 {
+			logger.V(3).Info(retryingAfterConflict, "attempt", attempt+1)
+
+			latest = &machinev1.ControlPlaneMachineSet{}
+			if err := r.UncachedClient.Get(ctx, client.ObjectKeyFromObject(cpms), latest); err != nil {
+				return fmt.Errorf("failed to fetch latest control plane machine set: %w", err)
+			}
+		}
+
+		attempt++
+
+		return update(latest)
+	}); err != nil {
+		return fmt.Errorf("after %d attempt(s): %w", attempt, err)
+	}
+
+	return nil
 }
 
 // ensureOwnerReferences determines if any of the Machines within the machineInfos require a new controller owner
@@ -451,54 +956,124 @@ func (r *ControlPlaneMachineSetReconciler) ensureOwnerReferences(ctx context.Con
 			mObjectMeta := mInfo.MachineRef.ObjectMeta
 			mLogger := logger.WithValues("machineNamespace", mObjectMeta.GetNamespace(), "machineName", mObjectMeta.GetName())
 
-			machineGVK, err := r.RESTMapper.KindFor(mInfo.MachineRef.GroupVersionResource)
-			if err != nil {
-				return fmt.Errorf("error getting GVK for machine: %w", err)
+			if isOwnedByCurrentCPMS(cpms, &metav1.PartialObjectMetadata{ObjectMeta: mObjectMeta}) {
+				mLogger.V(4).Info("Owner reference already present on machine")
+
+				continue
 			}
 
-			machine := &metav1.PartialObjectMetadata{}
-			machine.SetGroupVersionKind(machineGVK)
-			machine.ObjectMeta = mObjectMeta
+			err := r.patchMachineMetadata(ctx, mInfo.MachineRef, func(machine *metav1.PartialObjectMetadata) error {
+				if err := controllerutil.SetControllerReference(cpms, machine, r.Scheme); err != nil {
+					return fmt.Errorf("cannot set controller reference: %w", err)
+				}
 
-			if isOwnedByCurrentCPMS(cpms, machine) {
-				mLogger.V(4).Info("Owner reference already present on machine")
+				return nil
+			})
+
+			var alreadyOwnedErr *controllerutil.AlreadyOwnedError
+
+			switch {
+			case errors.As(err, &alreadyOwnedErr):
+				mLogger.Error(alreadyOwnedErr, "Cannot add owner reference to machine")
+
+				meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+					Type:               conditionDegraded,
+					Status:             metav1.ConditionTrue,
+					Reason:             reasonMachinesAlreadyOwned,
+					ObservedGeneration: cpms.Generation,
+					Message:            "Observed already owned machine(s) in target machines",
+				})
 
+				// don't return an error here and continue iterating through the machines
 				continue
+			case err != nil:
+				return fmt.Errorf("error setting owner reference: %w", err)
 			}
 
-			patchBase := client.MergeFrom(machine.DeepCopy())
+			mLogger.V(2).Info("Added owner reference to machine")
+		}
+	}
 
-			if err := controllerutil.SetControllerReference(cpms, machine, r.Scheme); err != nil {
-				mLogger.Error(err, "Cannot add owner reference to machine")
+	return nil
+}
 
-				var alreadyOwnedErr *controllerutil.AlreadyOwnedError
-				if errors.As(err, &alreadyOwnedErr) {
-					meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
-						Type:               conditionDegraded,
-						Status:             metav1.ConditionTrue,
-						Reason:             reasonMachinesAlreadyOwned,
-						ObservedGeneration: cpms.Generation,
-						Message:            "Observed already owned machine(s) in target machines",
-					})
+// ensureIndexLabels determines if any of the Machines within the machineInfos are missing the index label, or have an
+// index label that does not match the index assigned by the machine provider, and then uses PartialObjectMetadata to
+// ensure that the index label is set.
+// A Machine created by the ControlPlaneMachineSet whose index label has been removed is indexed by the machine provider
+// from the index suffix of its name, so the label is restored before any replacement decisions are made, rather than
+// the Machine being treated as missing from its index.
+func (r *ControlPlaneMachineSetReconciler) ensureIndexLabels(ctx context.Context, logger logr.Logger, machineInfos map[int32][]machineproviders.MachineInfo) error {
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		for _, mInfo := range indexToMachines.machineInfos {
+			if mInfo.MachineRef == nil {
+				continue
+			}
 
-					// don't return an error here and continue iterating through the machines
-					continue
+			mObjectMeta := mInfo.MachineRef.ObjectMeta
+			mLogger := logger.WithValues("machineNamespace", mObjectMeta.GetNamespace(), "machineName", mObjectMeta.GetName())
+			index := strconv.Itoa(int(mInfo.Index))
+
+			currentIndex, hasIndexLabel := mObjectMeta.GetLabels()[machineproviders.MachineIndexLabel]
+			if currentIndex == index {
+				continue
+			}
+
+			if err := r.patchMachineMetadata(ctx, mInfo.MachineRef, func(machine *metav1.PartialObjectMetadata) error {
+				labels := machine.GetLabels()
+				if labels == nil {
+					labels = map[string]string{}
 				}
 
-				return fmt.Errorf("error setting owner reference: %w", err)
+				labels[machineproviders.MachineIndexLabel] = index
+				machine.SetLabels(labels)
+
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error setting index label: %w", err)
 			}
 
-			if err := r.Client.Patch(ctx, machine, patchBase); err != nil {
-				return fmt.Errorf("error patching machine: %w", err)
+			if _, owned := mObjectMeta.GetLabels()[machineproviders.MachineOwnerLabel]; owned && !hasIndexLabel {
+				// The Machine was created by the ControlPlaneMachineSet, so the index label has been removed since.
+				mLogger.V(1).Info("Restored missing index label on machine created by the control plane machine set", "index", mInfo.Index)
+
+				continue
 			}
 
-			mLogger.V(2).Info("Added owner reference to machine")
+			mLogger.V(2).Info("Added index label to machine", "index", mInfo.Index)
 		}
 	}
 
 	return nil
 }
 
+// patchMachineMetadata applies the changes made by mutate to the metadata of the referenced Machine.
+// The Machine is patched as PartialObjectMetadata, from a copy of the metadata observed by the machine provider, so the
+// merge patch only contains the metadata fields that mutate changed. The spec and status of the Machine, which are set
+// by other controllers, are never part of the patch, so adopting a Machine cannot revert their changes.
+func (r *ControlPlaneMachineSetReconciler) patchMachineMetadata(ctx context.Context, machineRef *machineproviders.ObjectRef, mutate func(*metav1.PartialObjectMetadata) error) error {
+	machineGVK, err := r.RESTMapper.KindFor(machineRef.GroupVersionResource)
+	if err != nil {
+		return fmt.Errorf("error getting GVK for machine: %w", err)
+	}
+
+	machine := &metav1.PartialObjectMetadata{}
+	machine.SetGroupVersionKind(machineGVK)
+	machineRef.ObjectMeta.DeepCopyInto(&machine.ObjectMeta)
+
+	patchBase := client.MergeFrom(machine.DeepCopy())
+
+	if err := mutate(machine); err != nil {
+		return err
+	}
+
+	if err := r.Client.Patch(ctx, machine, patchBase); err != nil {
+		return fmt.Errorf("error patching machine: %w", err)
+	}
+
+	return nil
+}
+
 // validateClusterState uses the machineInfos to validate that:
 //   - All Nodes in the cluster claiming to be control plane nodes have a valid machine.
 //   - At least 1 of the control plane machines is in the ready state (if there are no ready Machines then the cluster
@@ -508,15 +1083,29 @@ func (r *ControlPlaneMachineSetReconciler) ensureOwnerReferences(ctx context.Con
 //     -- Too few indexes, valid. We will later scale up without user intervention when we perform reconcileMachineUpdates.
 //     -- Too many indexes, invalid. We set the operator to degraded and ask the user for manual intervention.
 //   - No replacement machines (one that doesn't need update but has an equivalent in the index that needs update) have an error.
-func (r *ControlPlaneMachineSetReconciler) validateClusterState(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) error {
+func (r *ControlPlaneMachineSetReconciler) validateClusterState(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) error {
 	sortedIndexedMs := sortMachineInfosByIndex(machineInfos)
 
+	// Check that control plane machines exist, unless the user has confirmed that they should be created
+	// from the template (if there are no Machines then the cluster has likely lost its control plane or the
+	// selector is misconfigured).
+	if ok := r.checkControlPlaneMachinesExist(logger, cpms, sortedIndexedMs); !ok {
+		return nil
+	}
+
 	// Check that at least one of the control plane machines is in the ready state
 	// (if there are no ready Machines then the cluster is likely misconfigured).
 	if ok := r.checkReadyControlPlaneMachineExists(logger, cpms, sortedIndexedMs); !ok {
 		return nil
 	}
 
+	// Check that no Machine created with a mismatched index label remains in the cluster.
+	if ok, err := r.checkNoMismatchedIndexMachine(logger, cpms, sortedIndexedMs); err != nil {
+		return fmt.Errorf("failed to check for machines with a mismatched index: %w", err)
+	} else if !ok {
+		return nil
+	}
+
 	// Check that all Nodes in the cluster claiming to be control plane nodes have a valid machine.
 	ok, err := r.checkControlPlaneNodesToMachinesMappings(ctx, logger, cpms, sortedIndexedMs)
 	if err != nil {
@@ -525,8 +1114,13 @@ func (r *ControlPlaneMachineSetReconciler) validateClusterState(ctx context.Cont
 		return nil
 	}
 
+	// Check that the replicas policy, if any, does not resize the control plane.
+	if ok := r.checkReplicasPolicyMatchesIndexes(logger, cpms, replicas, sortedIndexedMs); !ok {
+		return nil
+	}
+
 	// Check that the number of the cpms indexes in the cluster is valid.
-	if ok := r.checkCorrectNumberOfIndexes(logger, cpms, sortedIndexedMs); !ok {
+	if ok := r.checkCorrectNumberOfIndexes(logger, cpms, replicas, machineProvider, sortedIndexedMs); !ok {
 		return nil
 	}
 
@@ -563,10 +1157,10 @@ func (r *ControlPlaneMachineSetReconciler) validateClusterState(ctx context.Cont
 // This allows the update strategies to process each index in turn.
 // It is expected to add an entry for each expected index (0-(replicas-1)) so that later logic of updates can process
 // indexes that do not have any associated Machines.
-func machineInfosByIndex(cpms *machinev1.ControlPlaneMachineSet, machineInfos []machineproviders.MachineInfo) (map[int32][]machineproviders.MachineInfo, error) {
+func machineInfosByIndex(replicas int32, machineInfos []machineproviders.MachineInfo) (map[int32][]machineproviders.MachineInfo, error) {
 	out := make(map[int32][]machineproviders.MachineInfo)
 
-	if cpms.Spec.Replicas == nil {
+	if replicas < 1 {
 		return nil, errReplicasRequired
 	}
 
@@ -577,7 +1171,7 @@ func machineInfosByIndex(cpms *machinev1.ControlPlaneMachineSet, machineInfos []
 	// If for any reason there aren't enough indexes to meet the replica count,
 	// populate empty indexes starting from index 0 until we have the correct
 	// number of indexes.
-	for i := int32(0); int32(len(out)) < *cpms.Spec.Replicas; i++ {
+	for i := int32(0); int32(len(out)) < replicas; i++ {
 		if _, ok := out[i]; !ok {
 			out[i] = []machineproviders.MachineInfo{}
 		}
@@ -615,6 +1209,137 @@ func isOwnedByCurrentCPMS(cpms *machinev1.ControlPlaneMachineSet, machineObjectM
 	return false
 }
 
+// checkNoMismatchedIndexMachine checks whether the Machine recorded as having been created with a mismatched index
+// label still exists. While it exists, the ControlPlaneMachineSet is marked as degraded, as that Machine would be
+// treated as part of the wrong index. Once the Machine has been removed, the record is cleared.
+func (r *ControlPlaneMachineSetReconciler) checkNoMismatchedIndexMachine(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) (bool, error) {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return false, fmt.Errorf("error reading mismatched index machine: %w", err)
+	}
+
+	machineName := state.MismatchedIndexMachine
+	if machineName == "" {
+		return true, nil
+	}
+
+	for _, indexToMachines := range sortedIndexedMs {
+		for _, machineInfo := range indexToMachines.machineInfos {
+			if machineInfo.MachineRef == nil || machineInfo.MachineRef.ObjectMeta.Name != machineName {
+				continue
+			}
+
+			logger.Error(
+				fmt.Errorf("%w: %s", errMismatchedMachineIndex, machineName),
+				"Machine created with a mismatched index label must be removed before operations can continue",
+				"index", machineInfo.Index,
+			)
+
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionProgressing,
+				Status: metav1.ConditionFalse,
+				Reason: reasonOperatorDegraded,
+			})
+
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionDegraded,
+				Status: metav1.ConditionTrue,
+				Reason: reasonMismatchedMachineIndex,
+				Message: fmt.Sprintf("Machine %s is labelled with index %q, which is not the index it was created for, remove it to continue",
+					machineName, machineInfo.MachineRef.ObjectMeta.Labels[machineproviders.MachineIndexLabel]),
+			})
+
+			return false, nil
+		}
+	}
+
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.MismatchedIndexMachine = ""
+	}); err != nil {
+		return false, fmt.Errorf("error clearing mismatched index machine: %w", err)
+	}
+
+	return true, nil
+}
+
+// checkPersistedState checks that the persisted state annotation of the ControlPlaneMachineSet can be parsed.
+// When it cannot, the ControlPlaneMachineSet is marked as degraded, and the annotation is left for the user to correct.
+func (r *ControlPlaneMachineSetReconciler) checkPersistedState(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) bool {
+	if _, err := machineproviders.GetPersistedState(cpms); err != nil {
+		logger.Error(err, "Control plane machine set persisted state is invalid, no operations can be performed")
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:   conditionProgressing,
+			Status: metav1.ConditionFalse,
+			Reason: reasonOperatorDegraded,
+		})
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:   conditionDegraded,
+			Status: metav1.ConditionTrue,
+			Reason: reasonInvalidPersistedState,
+			Message: fmt.Sprintf("The %s annotation could not be parsed, correct or remove it to continue: %v",
+				machineproviders.PersistedStateAnnotation, err),
+		})
+
+		return false
+	}
+
+	return true
+}
+
+// checkSupportedMachineType checks that the machine type of the ControlPlaneMachineSet template is one that a
+// machine provider exists for. When it is not, the ControlPlaneMachineSet is marked as degraded.
+func (r *ControlPlaneMachineSetReconciler) checkSupportedMachineType(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) bool {
+	if _, err := providers.GetMachineTypeMeta(cpms.Spec.Template.MachineType); err != nil {
+		logger.Error(err, "Control plane machine set template has an unsupported machine type, no operations can be performed")
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:   conditionProgressing,
+			Status: metav1.ConditionFalse,
+			Reason: reasonOperatorDegraded,
+		})
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:   conditionDegraded,
+			Status: metav1.ConditionTrue,
+			Reason: reasonUnsupportedMachineType,
+			Message: fmt.Sprintf("Machine type %q is not supported, supported machine types are: %s",
+				cpms.Spec.Template.MachineType, machinev1.OpenShiftMachineV1Beta1MachineType),
+		})
+
+		return false
+	}
+
+	return true
+}
+
+// checkFailureDomainsPlatform checks that the platform of the failure domains within the ControlPlaneMachineSet
+// template matches the platform of the template provider spec. When it does not, the ControlPlaneMachineSet is
+// marked as degraded.
+func (r *ControlPlaneMachineSetReconciler) checkFailureDomainsPlatform(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) bool {
+	if err := providers.CheckFailureDomainsPlatform(cpms); err != nil {
+		logger.Error(err, "Control plane machine set template failure domains do not match the provider spec platform, no operations can be performed")
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:   conditionProgressing,
+			Status: metav1.ConditionFalse,
+			Reason: reasonOperatorDegraded,
+		})
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:    conditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonFailureDomainsPlatformMismatch,
+			Message: fmt.Sprintf("The failure domains and the provider spec of the machine template must be for the same platform: %v", err),
+		})
+
+		return false
+	}
+
+	return true
+}
+
 // checkControlPlaneNodesToMachinesMappings checks that all nodes in the cluster claiming to be control plane nodes are referenced by a control plane machine.
 func (r *ControlPlaneMachineSetReconciler) checkControlPlaneNodesToMachinesMappings(ctx context.Context, logger logr.Logger,
 	cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) (bool, error) {
@@ -671,6 +1396,58 @@ func (r *ControlPlaneMachineSetReconciler) checkControlPlaneNodesToMachinesMappi
 	return true, nil
 }
 
+// checkControlPlaneMachinesExist checks that at least one control plane machine exists.
+// When none exist, no machines are created unless the bootstrap annotation confirms that they should be.
+// The bootstrap annotation is removed once control plane machines exist.
+func (r *ControlPlaneMachineSetReconciler) checkControlPlaneMachinesExist(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
+	annotations := cpms.GetAnnotations()
+	bootstrap := annotations[bootstrapAnnotation] == "true"
+
+	for _, indexToMachines := range sortedIndexedMs {
+		if len(indexToMachines.machineInfos) == 0 {
+			continue
+		}
+
+		if _, ok := annotations[bootstrapAnnotation]; ok {
+			delete(annotations, bootstrapAnnotation)
+			cpms.SetAnnotations(annotations)
+		}
+
+		return true
+	}
+
+	if bootstrap {
+		logger.Info("No control plane machines found, creating control plane machines from the template as confirmed by the bootstrap annotation")
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:    conditionDegraded,
+			Status:  metav1.ConditionFalse,
+			Reason:  reasonAsExpected,
+			Message: fmt.Sprintf("Creating control plane machines from the template, as confirmed by the %s annotation", bootstrapAnnotation),
+		})
+
+		return true
+	}
+
+	logger.Error(errNoControlPlaneMachines, "No control plane machines found, refusing to create control plane machines without confirmation")
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:   conditionProgressing,
+		Status: metav1.ConditionFalse,
+		Reason: reasonOperatorDegraded,
+	})
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:   conditionDegraded,
+		Status: metav1.ConditionTrue,
+		Reason: reasonNoControlPlaneMachines,
+		Message: fmt.Sprintf("No control plane machines matching the selector were found. "+
+			"Set the %s annotation to \"true\" to confirm that control plane machines should be created from the template", bootstrapAnnotation),
+	})
+
+	return false
+}
+
 // checkReadyControlPlaneMachineExists checks that at least one ready control plane machine exists in the cluster.
 func (r *ControlPlaneMachineSetReconciler) checkReadyControlPlaneMachineExists(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
 	var nonReadyMachineNames []string
@@ -709,23 +1486,28 @@ func (r *ControlPlaneMachineSetReconciler) checkReadyControlPlaneMachineExists(l
 }
 
 // checkCorrectNumberOfIndexes checks that the number of control plane machine set indexes found in the cluster is valid.
-func (r *ControlPlaneMachineSetReconciler) checkCorrectNumberOfIndexes(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
+// When there are too many indexes, the indexes that should be removed to scale down are reported, as chosen by the
+// scale preference of the ControlPlaneMachineSet.
+func (r *ControlPlaneMachineSetReconciler) checkCorrectNumberOfIndexes(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, machineProvider machineproviders.MachineProvider, sortedIndexedMs []indexToMachineInfos) bool {
 	currentIndexesCount := int32(len(sortedIndexedMs))
 
 	switch {
-	case currentIndexesCount == *cpms.Spec.Replicas:
+	case currentIndexesCount == replicas:
 		// Right number of indexes. The cluster state is valid.
-	case currentIndexesCount < *cpms.Spec.Replicas:
+	case currentIndexesCount < replicas:
 		// Too few indexes. The cluster state is valid.
 		// We will later scale up without user intervention when we perform reconcileMachineUpdates.
-	case currentIndexesCount > *cpms.Spec.Replicas:
+	case currentIndexesCount > replicas:
 		// Too many indexes. The cluster state is invalid.
 		// We set the operator to degraded and ask the user for manual intervention.
-		excessiveIndexes := currentIndexesCount - *cpms.Spec.Replicas
+		excessiveIndexes := currentIndexesCount - replicas
+		scaleDownIndexes := formatIndexes(selectScaleDownIndexes(logger, machineProvider, sortedIndexedMs, int(excessiveIndexes)))
+
 		logger.Error(
 			fmt.Errorf("%w: %d index(es) are in excess", errFoundExcessiveIndexes, excessiveIndexes),
 			"Observed an excessive number of control plane machine indexes",
 			"excessIndexes", excessiveIndexes,
+			"scaleDownIndexes", scaleDownIndexes,
 		)
 
 		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
@@ -738,7 +1520,7 @@ func (r *ControlPlaneMachineSetReconciler) checkCorrectNumberOfIndexes(logger lo
 			Type:    conditionDegraded,
 			Status:  metav1.ConditionTrue,
 			Reason:  reasonExcessIndexes,
-			Message: fmt.Sprintf("Observed %d index(es) in excess", excessiveIndexes),
+			Message: fmt.Sprintf("Observed %d index(es) in excess, remove index(es) %s to scale down", excessiveIndexes, scaleDownIndexes),
 		})
 
 		return false
@@ -747,6 +1529,38 @@ func (r *ControlPlaneMachineSetReconciler) checkCorrectNumberOfIndexes(logger lo
 	return true
 }
 
+// selectScaleDownIndexes chooses count indexes to remove from the indexes provided.
+// The machine provider chooses the indexes when it knows the failure domain of each index.
+// Otherwise, the highest indexes are chosen.
+func selectScaleDownIndexes(logger logr.Logger, machineProvider machineproviders.MachineProvider, sortedIndexedMs []indexToMachineInfos, count int) []int32 {
+	indexes := []int32{}
+
+	for _, indexToMachines := range sortedIndexedMs {
+		indexes = append(indexes, indexToMachines.index)
+	}
+
+	if selector, ok := machineProvider.(machineproviders.ScaleDownSelector); ok {
+		return selector.SelectScaleDownIndexes(logger, indexes, count)
+	}
+
+	if count > len(indexes) {
+		count = len(indexes)
+	}
+
+	return indexes[len(indexes)-count:]
+}
+
+// formatIndexes formats the indexes as a comma separated list.
+func formatIndexes(indexes []int32) string {
+	out := []string{}
+
+	for _, idx := range indexes {
+		out = append(out, strconv.Itoa(int(idx)))
+	}
+
+	return strings.Join(out, ",")
+}
+
 // checkValidNumerOfUpdatedMachinesPerIndex checks that the number of updated machines in an index is valid.
 func (r *ControlPlaneMachineSetReconciler) checkValidNumerOfUpdatedMachinesPerIndex(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
 	for _, indexToMachines := range sortedIndexedMs {
@@ -801,8 +1615,10 @@ func (r *ControlPlaneMachineSetReconciler) checkValidNumerOfUpdatedMachinesPerIn
 }
 
 // checkNoErrorForReplacements checks that there is no errored replacement machine.
+// A replacement that the provider could not create with its credentials is reported with its own reason, as it will
+// not succeed until the credentials are fixed.
 func (r *ControlPlaneMachineSetReconciler) checkNoErrorForReplacements(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) bool {
-	var erroredReplacementMachineNames []string
+	var erroredReplacementMachineNames, erroredReplacementMachineErrors, authenticationFailedMachineErrors []string
 
 	for _, indexToMachines := range sortedIndexedMs {
 		machines := indexToMachines.machineInfos
@@ -813,6 +1629,11 @@ func (r *ControlPlaneMachineSetReconciler) checkNoErrorForReplacements(logger lo
 			for _, m := range machinesPending {
 				if m.ErrorMessage != "" {
 					erroredReplacementMachineNames = append(erroredReplacementMachineNames, m.MachineRef.ObjectMeta.Name)
+					erroredReplacementMachineErrors = append(erroredReplacementMachineErrors, describeMachineError(m))
+
+					if isProviderAuthenticationFailure(m) {
+						authenticationFailedMachineErrors = append(authenticationFailedMachineErrors, describeMachineError(m))
+					}
 				}
 			}
 		}
@@ -831,11 +1652,23 @@ func (r *ControlPlaneMachineSetReconciler) checkNoErrorForReplacements(logger lo
 			Reason: reasonOperatorDegraded,
 		})
 
+		if len(authenticationFailedMachineErrors) > 0 {
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionDegraded,
+				Status: metav1.ConditionTrue,
+				Reason: reasonProviderAuthenticationFailed,
+				Message: fmt.Sprintf("Observed %d replacement machine(s) rejected by the cloud provider, check the cloud credentials: %s",
+					len(authenticationFailedMachineErrors), strings.Join(authenticationFailedMachineErrors, "; ")),
+			})
+
+			return false
+		}
+
 		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
 			Type:    conditionDegraded,
 			Status:  metav1.ConditionTrue,
 			Reason:  reasonFailedReplacement,
-			Message: fmt.Sprintf("Observed %d replacement machine(s) in error state", len(erroredReplacementMachineNames)),
+			Message: fmt.Sprintf("Observed %d replacement machine(s) in error state: %s", len(erroredReplacementMachineNames), strings.Join(erroredReplacementMachineErrors, "; ")),
 		})
 
 		return false
@@ -844,6 +1677,36 @@ func (r *ControlPlaneMachineSetReconciler) checkNoErrorForReplacements(logger lo
 	return true
 }
 
+// isProviderAuthenticationFailure returns true when the cloud provider rejected the Machine because the credentials
+// used to create it were not accepted, or do not grant the permissions required. The Machine API reports these as an
+// invalid configuration, and so the error message is used to tell them apart from other invalid configurations.
+func isProviderAuthenticationFailure(m machineproviders.MachineInfo) bool {
+	if m.ErrorReason != string(machinev1beta1.InvalidConfigurationMachineError) {
+		return false
+	}
+
+	message := strings.ToLower(m.ErrorMessage)
+
+	for _, indicator := range providerAuthenticationFailureIndicators {
+		if strings.Contains(message, indicator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describeMachineError summarises the error reported by the provider for a Machine,
+// naming the index and the Machine so that the user can identify which replacement has failed.
+func describeMachineError(m machineproviders.MachineInfo) string {
+	providerError := m.ErrorMessage
+	if m.ErrorReason != "" {
+		providerError = fmt.Sprintf("%s: %s", m.ErrorReason, m.ErrorMessage)
+	}
+
+	return fmt.Sprintf("index %d machine %s: %s", m.Index, m.MachineRef.ObjectMeta.Name, providerError)
+}
+
 // fetchControlPlaneNodes fetches a sorted list of unique nodes that have the "control-plane" (and/or legacy "master") labels.
 func (r *ControlPlaneMachineSetReconciler) fetchControlPlaneNodes(ctx context.Context) ([]corev1.Node, error) {
 	cpmsNodesLookup := make(map[string]struct{})
@@ -20,8 +20,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -35,14 +38,20 @@ import (
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
 	metav1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/meta/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
 	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/test/e2e/framework"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/test/e2e/helpers"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/test/integration"
+	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -161,6 +170,11 @@ var _ = Describe("With a running controller", func() {
 		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
 		namespaceName = ns.GetName()
 
+		By("Creating the secrets referenced by the machine template")
+		for _, secretName := range []string{"aws-user-data-12345678", "aws-cloud-credentials"} {
+			Expect(k8sClient.Create(ctx, corev1resourcebuilder.Secret().WithName(secretName).WithNamespace(namespaceName).Build())).To(Succeed())
+		}
+
 		By("Setting up a manager and controller")
 		var err error
 		mgr, err = ctrl.NewManager(cfg, ctrl.Options{
@@ -205,7 +219,9 @@ var _ = Describe("With a running controller", func() {
 
 		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
 			&corev1.Node{},
+			&corev1.Secret{},
 			&configv1.ClusterOperator{},
+			&configv1.FeatureGate{},
 			&machinev1beta1.Machine{},
 			&machinev1.ControlPlaneMachineSet{},
 		)
@@ -437,6 +453,41 @@ var _ = Describe("With a running controller", func() {
 			It("should create a replacement for the machine", func() {
 				Eventually(komega.ObjectList(&machinev1beta1.MachineList{})).Should(HaveField("Items", HaveLen(4)))
 			})
+
+			Context("and the feature gate is disabled", func() {
+				BeforeEach(func() {
+					By("Disabling the control plane machine set feature gate")
+					featureGate := &configv1.FeatureGate{
+						ObjectMeta: metav1.ObjectMeta{Name: featureGateName},
+						Spec: configv1.FeatureGateSpec{
+							FeatureGateSelection: configv1.FeatureGateSelection{
+								FeatureSet: configv1.CustomNoUpgrade,
+								CustomNoUpgrade: &configv1.CustomFeatureGates{
+									Disabled: []configv1.FeatureGateName{controlPlaneMachineSetFeatureGate},
+								},
+							},
+						},
+					}
+					Expect(k8sClient.Create(ctx, featureGate)).To(Succeed())
+				})
+
+				It("should report that the feature gate is disabled", func() {
+					Eventually(komega.Object(cpms)).Should(HaveField("Status.Conditions", ContainElement(testutils.MatchCondition(metav1.Condition{
+						Type:    conditionIdle,
+						Status:  metav1.ConditionTrue,
+						Reason:  reasonFeatureGateDisabled,
+						Message: "The ControlPlaneMachineSet feature gate is disabled, so machines are not managed",
+					}))))
+				})
+
+				It("should not create a replacement for the machine", func() {
+					Consistently(komega.ObjectList(&machinev1beta1.MachineList{})).Should(HaveField("Items", HaveLen(3)))
+				})
+
+				It("should not add an owner reference to any machine", func() {
+					Consistently(komega.ObjectList(&machinev1beta1.MachineList{})).Should(HaveField("Items", HaveEach(HaveField("ObjectMeta.OwnerReferences", BeEmpty()))))
+				})
+			})
 		})
 
 		Context("with no running machines", func() {
@@ -1575,22 +1626,81 @@ var _ = Describe("ensureFinalizer", func() {
 			updatedFinalizer, err = reconciler.ensureFinalizer(ctx, logger.Logger(), originalCPMS)
 		})
 
-		It("should return a conflict error", func() {
-			Expect(apierrors.ReasonForError(err)).To(Equal(metav1.StatusReasonConflict))
+		It("resolves the conflict by retrying with the latest version", func() {
+			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("returns that it did not update the finalizer", func() {
 			Expect(updatedFinalizer).To(BeFalse())
 		})
 
-		It("does not log", func() {
-			Expect(logger.Entries()).To(BeEmpty())
+		It("sets an appropriate log line", func() {
+			Expect(logger.Entries()).To(ConsistOf(
+				testutils.LogEntry{
+					Level:         3,
+					KeysAndValues: []interface{}{"attempt", 2},
+					Message:       retryingAfterConflict,
+				},
+				testutils.LogEntry{
+					Level:   4,
+					Message: "Finalizer already present on control plane machine set",
+				},
+			))
 		})
 
 		It("does not remove any existing finalizers", func() {
 			Eventually(komega.Object(cpms)).Should(HaveField("ObjectMeta.Finalizers", ConsistOf(controlPlaneMachineSetFinalizer, existingFinalizer)))
 		})
 	})
+
+	Context("when the finalizer does not exist, and the input is stale", func() {
+		var staleCPMS *machinev1.ControlPlaneMachineSet
+		var updatedFinalizer bool
+		var err error
+
+		BeforeEach(func() {
+			By("Updating the existing object")
+			staleCPMS = cpms.DeepCopy()
+			Eventually(komega.Update(cpms, func() {
+				cpms.SetLabels(map[string]string{"updated": "true"})
+			})).Should(Succeed())
+
+			updatedFinalizer, err = reconciler.ensureFinalizer(ctx, logger.Logger(), staleCPMS)
+		})
+
+		It("resolves the conflict by retrying with the latest version", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns that it updated the finalizer", func() {
+			Expect(updatedFinalizer).To(BeTrue())
+		})
+
+		It("sets an appropriate log line", func() {
+			Expect(logger.Entries()).To(ConsistOf(
+				testutils.LogEntry{
+					Level:         3,
+					KeysAndValues: []interface{}{"attempt", 2},
+					Message:       retryingAfterConflict,
+				},
+				testutils.LogEntry{
+					Level:   2,
+					Message: "Added finalizer to control plane machine set",
+				},
+			))
+		})
+
+		It("ensures the finalizer is set on the API, without losing the concurrent update", func() {
+			Eventually(komega.Object(cpms)).Should(SatisfyAll(
+				HaveField("ObjectMeta.Finalizers", ConsistOf(controlPlaneMachineSetFinalizer, existingFinalizer)),
+				HaveField("ObjectMeta.Labels", HaveKeyWithValue("updated", "true")),
+			))
+		})
+
+		It("updates the input with the latest version", func() {
+			Expect(staleCPMS.GetLabels()).To(HaveKeyWithValue("updated", "true"))
+		})
+	})
 })
 
 var _ = Describe("ensureOwnerRefrences", func() {
@@ -1934,6 +2044,138 @@ var _ = Describe("ensureOwnerRefrences", func() {
 	})
 })
 
+var _ = Describe("ensureIndexLabels", func() {
+	var namespaceName string
+	var reconciler *ControlPlaneMachineSetReconciler
+	var logger testutils.TestLogger
+
+	var machines []*machinev1beta1.Machine
+	var machineInfos map[int32][]machineproviders.MachineInfo
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-ensure-index-labels-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:         k8sClient,
+			UncachedClient: k8sClient,
+			Scheme:         testScheme,
+			RESTMapper:     testRESTMapper,
+			Namespace:      namespaceName,
+		}
+
+		logger = testutils.NewTestLogger()
+
+		By("Creating unlabeled machines to adopt")
+		machines = []*machinev1beta1.Machine{}
+		machineInfos = map[int32][]machineproviders.MachineInfo{}
+		machineBuilder := machinev1beta1resourcebuilder.Machine().WithNamespace(namespaceName).WithGenerateName("ensure-index-labels-test-")
+
+		for i := 0; i < 3; i++ {
+			machine := machineBuilder.Build()
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+
+			machines = append(machines, machine)
+
+			machineInfo := machineprovidersresourcebuilder.MachineInfo().WithIndex(int32(i)).WithMachineGVR(machineGVR).WithMachineName(machine.GetName()).WithMachineNamespace(namespaceName).Build()
+			machineInfos[int32(i)] = append(machineInfos[int32(i)], machineInfo)
+		}
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&machinev1beta1.Machine{},
+		)
+	})
+
+	Context("when the machines do not have an index label", func() {
+		BeforeEach(func() {
+			Expect(reconciler.ensureIndexLabels(ctx, logger.Logger(), machineInfos)).To(Succeed())
+		})
+
+		It("should adopt the machines into indexes 0, 1 and 2", func() {
+			for i, machine := range machines {
+				Eventually(komega.Object(machine)).Should(HaveField("ObjectMeta.Labels", HaveKeyWithValue(machineproviders.MachineIndexLabel, fmt.Sprintf("%d", i))))
+			}
+		})
+
+		It("should log that it has added the index labels", func() {
+			expectedEntries := []testutils.LogEntry{}
+
+			for i, machine := range machines {
+				expectedEntries = append(expectedEntries, testutils.LogEntry{
+					KeysAndValues: []interface{}{"machineNamespace", machine.GetNamespace(), "machineName", machine.GetName(), "index", int32(i)},
+					Level:         2,
+					Message:       "Added index label to machine",
+				})
+			}
+
+			Expect(logger.Entries()).To(ConsistOf(expectedEntries))
+		})
+	})
+
+	Context("when a machine created by the control plane machine set has lost its index label", func() {
+		BeforeEach(func() {
+			for i := range machines {
+				labels := map[string]string{machineproviders.MachineIndexLabel: fmt.Sprintf("%d", i)}
+				if i == 1 {
+					labels = map[string]string{machineproviders.MachineOwnerLabel: "cluster"}
+				}
+
+				patchBase := client.MergeFrom(machines[i].DeepCopy())
+				machines[i].SetLabels(labels)
+				Expect(k8sClient.Patch(ctx, machines[i], patchBase)).To(Succeed())
+
+				machineInfos[int32(i)][0].MachineRef.ObjectMeta.SetLabels(machines[i].GetLabels())
+			}
+
+			Expect(reconciler.ensureIndexLabels(ctx, logger.Logger(), machineInfos)).To(Succeed())
+		})
+
+		It("should restore the index label", func() {
+			Eventually(komega.Object(machines[1])).Should(HaveField("ObjectMeta.Labels", SatisfyAll(
+				HaveKeyWithValue(machineproviders.MachineIndexLabel, "1"),
+				HaveKeyWithValue(machineproviders.MachineOwnerLabel, "cluster"),
+			)))
+		})
+
+		It("should log that it has restored the index label", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				KeysAndValues: []interface{}{"machineNamespace", machines[1].GetNamespace(), "machineName", machines[1].GetName(), "index", int32(1)},
+				Level:         1,
+				Message:       "Restored missing index label on machine created by the control plane machine set",
+			}))
+		})
+	})
+
+	Context("when the machines already have the correct index label", func() {
+		BeforeEach(func() {
+			for i := range machines {
+				patchBase := client.MergeFrom(machines[i].DeepCopy())
+				machines[i].SetLabels(map[string]string{machineproviders.MachineIndexLabel: fmt.Sprintf("%d", i)})
+				Expect(k8sClient.Patch(ctx, machines[i], patchBase)).To(Succeed())
+
+				machineInfos[int32(i)][0].MachineRef.ObjectMeta.SetLabels(machines[i].GetLabels())
+			}
+
+			Expect(reconciler.ensureIndexLabels(ctx, logger.Logger(), machineInfos)).To(Succeed())
+		})
+
+		It("should not update the machines", func() {
+			for i, machine := range machines {
+				Consistently(komega.Object(machine)).Should(HaveField("ObjectMeta.Labels", HaveKeyWithValue(machineproviders.MachineIndexLabel, fmt.Sprintf("%d", i))))
+			}
+		})
+
+		It("should not log any updates", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+	})
+})
+
 var _ = Describe("machineInfosByIndex", func() {
 	i0m0 := machineprovidersresourcebuilder.MachineInfo().WithIndex(0).WithMachineName("machine-0-0").Build()
 	i0m1 := machineprovidersresourcebuilder.MachineInfo().WithIndex(0).WithMachineName("machine-1-0").Build()
@@ -1953,7 +2195,7 @@ var _ = Describe("machineInfosByIndex", func() {
 
 	DescribeTable("should sort Machine Infos by index", func(in tableInput) {
 		cpms := in.cpmsBuilder.Build()
-		out, err := machineInfosByIndex(cpms, in.machineInfos)
+		out, err := machineInfosByIndex(pointer.Int32Deref(cpms.Spec.Replicas, 0), in.machineInfos)
 		if in.expectedError != nil {
 			Expect(err).To(MatchError(in.expectedError))
 			return
@@ -2104,6 +2346,7 @@ var _ = Describe("validateClusterState", func() {
 
 	type validateClusterTableInput struct {
 		cpmsBuilder        machinev1resourcebuilder.ControlPlaneMachineSetInterface
+		machineProvider    machineproviders.MachineProvider
 		machineInfos       map[int32][]machineproviders.MachineInfo
 		nodes              []*corev1.Node
 		expectedError      error
@@ -2126,7 +2369,7 @@ var _ = Describe("validateClusterState", func() {
 
 		cpms := in.cpmsBuilder.Build()
 
-		err := reconciler.validateClusterState(ctx, logger.Logger(), cpms, in.machineInfos)
+		err := reconciler.validateClusterState(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, in.machineProvider, in.machineInfos)
 
 		if in.expectedError != nil {
 			Expect(err).To(MatchError(in.expectedError))
@@ -2335,7 +2578,115 @@ var _ = Describe("validateClusterState", func() {
 			},
 			expectedError: nil,
 			expectedConditions: []metav1.Condition{
-				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonFailedReplacement).WithMessage("Observed 1 replacement machine(s) in error state").Build(),
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonFailedReplacement).WithMessage("Observed 1 replacement machine(s) in error state: index 0 machine machine-replacement-0: Could not create new instance").Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error: fmt.Errorf("%w: %s", errFoundErroredReplacementControlPlaneMachine, "machine-replacement-0"),
+					KeysAndValues: []interface{}{
+						"failedReplacements", "machine-replacement-0",
+					},
+					Message: "Observed failed replacement control plane machines",
+				},
+			},
+		}),
+		Entry("with a failed replacement machine carrying a provider error reason", validateClusterTableInput{
+			cpmsBuilder: cpmsBuilder.WithConditions([]metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+			}),
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").WithNeedsUpdate(true).Build(),
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithErrorReason("InsufficientResources").WithErrorMessage("Quota exceeded for instance type m6i.xlarge").WithReady(false).Build(),
+				},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").WithNeedsUpdate(true).Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").WithNeedsUpdate(true).Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonFailedReplacement).WithMessage("Observed 1 replacement machine(s) in error state: index 0 machine machine-replacement-0: InsufficientResources: Quota exceeded for instance type m6i.xlarge").Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error: fmt.Errorf("%w: %s", errFoundErroredReplacementControlPlaneMachine, "machine-replacement-0"),
+					KeysAndValues: []interface{}{
+						"failedReplacements", "machine-replacement-0",
+					},
+					Message: "Observed failed replacement control plane machines",
+				},
+			},
+		}),
+		Entry("with a replacement machine rejected by the cloud provider credentials", validateClusterTableInput{
+			cpmsBuilder: cpmsBuilder.WithConditions([]metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+			}),
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").WithNeedsUpdate(true).Build(),
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithErrorReason("InvalidConfiguration").WithErrorMessage("error launching instance: UnauthorizedOperation: You are not authorized to perform this operation.").WithReady(false).Build(),
+				},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").WithNeedsUpdate(true).Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").WithNeedsUpdate(true).Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonProviderAuthenticationFailed).WithMessage("Observed 1 replacement machine(s) rejected by the cloud provider, check the cloud credentials: index 0 machine machine-replacement-0: InvalidConfiguration: error launching instance: UnauthorizedOperation: You are not authorized to perform this operation.").Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error: fmt.Errorf("%w: %s", errFoundErroredReplacementControlPlaneMachine, "machine-replacement-0"),
+					KeysAndValues: []interface{}{
+						"failedReplacements", "machine-replacement-0",
+					},
+					Message: "Observed failed replacement control plane machines",
+				},
+			},
+		}),
+		Entry("with a replacement machine that has an invalid configuration unrelated to the cloud credentials", validateClusterTableInput{
+			cpmsBuilder: cpmsBuilder.WithConditions([]metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+			}),
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").WithNeedsUpdate(true).Build(),
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithErrorReason("InvalidConfiguration").WithErrorMessage("error launching instance: InvalidAMIID.NotFound: The image id does not exist").WithReady(false).Build(),
+				},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").WithNeedsUpdate(true).Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").WithNeedsUpdate(true).Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonFailedReplacement).WithMessage("Observed 1 replacement machine(s) in error state: index 0 machine machine-replacement-0: InvalidConfiguration: error launching instance: InvalidAMIID.NotFound: The image id does not exist").Build(),
 				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
 			},
 			expectedLogs: []testutils.LogEntry{
@@ -2374,7 +2725,7 @@ var _ = Describe("validateClusterState", func() {
 			},
 			expectedError: nil,
 			expectedConditions: []metav1.Condition{
-				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonFailedReplacement).WithMessage("Observed 2 replacement machine(s) in error state").Build(),
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonFailedReplacement).WithMessage("Observed 2 replacement machine(s) in error state: index 0 machine machine-replacement-0: Could not create new instance; index 1 machine machine-replacement-1: Could not create new instance").Build(),
 				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
 			},
 			expectedLogs: []testutils.LogEntry{
@@ -2524,7 +2875,7 @@ var _ = Describe("validateClusterState", func() {
 			},
 			expectedError: nil,
 			expectedConditions: []metav1.Condition{
-				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonExcessIndexes).WithMessage("Observed 1 index(es) in excess").Build(),
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonExcessIndexes).WithMessage("Observed 1 index(es) in excess, remove index(es) 3 to scale down").Build(),
 				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
 			},
 			expectedLogs: []testutils.LogEntry{
@@ -2532,19 +2883,332 @@ var _ = Describe("validateClusterState", func() {
 					Error: fmt.Errorf("%w: %s", errFoundExcessiveIndexes, "1 index(es) are in excess"),
 					KeysAndValues: []interface{}{
 						"excessIndexes", int32(1),
+						"scaleDownIndexes", "3",
 					},
 					Message: "Observed an excessive number of control plane machine indexes",
 				},
 			},
 		}),
-	)
-})
-
-var _ = Describe("isControlPlaneMachineSetDegraded", func() {
-	cpmsBuilder := machinev1resourcebuilder.ControlPlaneMachineSet()
-	degradedConditionBuilder := metav1resourcebuilder.Condition().WithType(conditionDegraded)
-
-	DescribeTable("should determine if the ControlPlaneMachineSet is degraded", func(cpms *machinev1.ControlPlaneMachineSet, expectDegraded bool) {
+		Entry("with an excess in number of control plane indexes and a machine provider that selects the indexes to remove", validateClusterTableInput{
+			cpmsBuilder: cpmsBuilder.WithConditions([]metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).Build(),
+			}).WithReplicas(3),
+			machineProvider: scaleDownSelectingMachineProvider{scaleDownIndexes: []int32{1}},
+			machineInfos: map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("master-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("master-1").Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("master-2").Build()},
+				3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("master-3").Build()},
+			},
+			nodes: []*corev1.Node{
+				masterNodeBuilder.WithName("master-0").Build(),
+				masterNodeBuilder.WithName("master-1").Build(),
+				masterNodeBuilder.WithName("master-2").Build(),
+				masterNodeBuilder.WithName("master-3").Build(),
+				workerNodeBuilder.WithName("worker-0").Build(),
+				workerNodeBuilder.WithName("worker-1").Build(),
+				workerNodeBuilder.WithName("worker-2").Build(),
+			},
+			expectedError: nil,
+			expectedConditions: []metav1.Condition{
+				degradedConditionBuilder.WithStatus(metav1.ConditionTrue).WithReason(reasonExcessIndexes).WithMessage("Observed 1 index(es) in excess, remove index(es) 1 to scale down").Build(),
+				progressingConditionBuilder.WithStatus(metav1.ConditionFalse).WithReason(reasonOperatorDegraded).Build(),
+			},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error: fmt.Errorf("%w: %s", errFoundExcessiveIndexes, "1 index(es) are in excess"),
+					KeysAndValues: []interface{}{
+						"excessIndexes", int32(1),
+						"scaleDownIndexes", "1",
+					},
+					Message: "Observed an excessive number of control plane machine indexes",
+				},
+			},
+		}),
+	)
+})
+
+// scaleDownSelectingMachineProvider stubs the optional scale down selection of the machine provider.
+type scaleDownSelectingMachineProvider struct {
+	machineproviders.MachineProvider
+
+	scaleDownIndexes []int32
+}
+
+// SelectScaleDownIndexes returns the configured scale down indexes.
+func (s scaleDownSelectingMachineProvider) SelectScaleDownIndexes(logr.Logger, []int32, int) []int32 {
+	return s.scaleDownIndexes
+}
+
+var _ = Describe("checkNoMismatchedIndexMachine", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+		Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+			state.MismatchedIndexMachine = "machine-replacement-1"
+		})).To(Succeed())
+	})
+
+	Context("when the mislabelled machine still exists", func() {
+		var ok bool
+
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build(),
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-1").
+						WithMachineLabels(map[string]string{machineproviders.MachineIndexLabel: "2"}).Build(),
+				},
+			}
+
+			var err error
+			ok, err = reconciler.checkNoMismatchedIndexMachine(logger.Logger(), cpms, sortMachineInfosByIndex(machineInfos))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not allow operations to continue", func() {
+			Expect(ok).To(BeFalse())
+		})
+
+		It("marks the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMismatchedMachineIndex)),
+				HaveField("Message", Equal("Machine machine-replacement-1 is labelled with index \"2\", which is not the index it was created for, remove it to continue")),
+			))
+		})
+
+		It("keeps the record of the mislabelled machine", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("MismatchedIndexMachine", Equal("machine-replacement-1")))
+		})
+	})
+
+	Context("when the mislabelled machine has been removed", func() {
+		var ok bool
+
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			var err error
+			ok, err = reconciler.checkNoMismatchedIndexMachine(logger.Logger(), cpms, sortMachineInfosByIndex(machineInfos))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("allows operations to continue", func() {
+			Expect(ok).To(BeTrue())
+		})
+
+		It("clears the record of the mislabelled machine", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("MismatchedIndexMachine", BeEmpty()))
+		})
+
+		It("does not mark the control plane machine set as degraded", func() {
+			Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("checkSupportedMachineType", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+	})
+
+	Context("with an unknown machine type", func() {
+		var ok bool
+
+		BeforeEach(func() {
+			cpms.Spec.Template.MachineType = "FutureMachineType"
+
+			ok = reconciler.checkSupportedMachineType(logger.Logger(), cpms)
+		})
+
+		It("does not allow operations to continue", func() {
+			Expect(ok).To(BeFalse())
+		})
+
+		It("marks the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonUnsupportedMachineType)),
+				HaveField("Message", Equal("Machine type \"FutureMachineType\" is not supported, supported machine types are: machines_v1beta1_machine_openshift_io")),
+			))
+		})
+
+		It("marks the control plane machine set as not progressing", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionProgressing)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionFalse)),
+				HaveField("Reason", Equal(reasonOperatorDegraded)),
+			))
+		})
+	})
+
+	Context("with the OpenShift Machine v1beta1 machine type", func() {
+		It("allows operations to continue", func() {
+			Expect(reconciler.checkSupportedMachineType(logger.Logger(), cpms)).To(BeTrue())
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("checkFailureDomainsPlatform", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpmsBuilder machinev1resourcebuilder.ControlPlaneMachineSetBuilder
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{}
+
+		cpmsBuilder = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3)
+	})
+
+	Context("with an AWS template and Azure failure domains", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+		var ok bool
+
+		BeforeEach(func() {
+			cpms = cpmsBuilder.WithMachineTemplateBuilder(
+				machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+					WithFailureDomainsBuilder(machinev1resourcebuilder.AzureFailureDomains()).
+					WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec()),
+			).Build()
+
+			ok = reconciler.checkFailureDomainsPlatform(logger.Logger(), cpms)
+		})
+
+		It("does not allow operations to continue", func() {
+			Expect(ok).To(BeFalse())
+		})
+
+		It("marks the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonFailureDomainsPlatformMismatch)),
+				HaveField("Message", ContainSubstring("failure domains are for Azure, provider spec is for AWS")),
+			))
+		})
+
+		It("marks the control plane machine set as not progressing", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionProgressing)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionFalse)),
+				HaveField("Reason", Equal(reasonOperatorDegraded)),
+			))
+		})
+	})
+
+	Context("with an AWS template and AWS failure domains", func() {
+		It("allows operations to continue", func() {
+			cpms := cpmsBuilder.WithMachineTemplateBuilder(
+				machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+					WithFailureDomainsBuilder(machinev1resourcebuilder.AWSFailureDomains()).
+					WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec()),
+			).Build()
+
+			Expect(reconciler.checkFailureDomainsPlatform(logger.Logger(), cpms)).To(BeTrue())
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("with concurrent reconciles", func() {
+	const concurrentReconciles = 20
+
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+
+	// runConcurrently calls fn from concurrentReconciles goroutines at once and waits for them all to return.
+	runConcurrently := func(fn func()) {
+		var wg sync.WaitGroup
+
+		start := make(chan struct{})
+
+		for i := 0; i < concurrentReconciles; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				<-start
+				fn()
+			}()
+		}
+
+		close(start)
+		wg.Wait()
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			MaxConcurrentReconciles: concurrentReconciles,
+		}
+	})
+
+	It("counts every repeated error", func() {
+		reconcileErr := errors.New("error reconciling machines")
+
+		runConcurrently(func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+			reconciler.setLastError(logger.Logger(), cpms, reconcileErr)
+		})
+
+		Expect(reconciler.lastError).ToNot(BeNil())
+		Expect(reconciler.lastError.count).To(Equal(concurrentReconciles))
+
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+		reconciler.setLastError(logger.Logger(), cpms, reconcileErr)
+
+		Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionError)).To(BeTrue())
+	})
+
+	It("counts every wait for a machine to become ready", func() {
+		runConcurrently(func() {
+			reconciler.readinessWaits.requeueAfter()
+		})
+
+		Expect(reconciler.readinessWaits.count).To(Equal(concurrentReconciles))
+		Expect(reconciler.readinessWaits.requeueAfter()).To(Equal(readinessWaitMaxInterval))
+	})
+
+	It("serves a consistent roll state", func() {
+		runConcurrently(func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+			cpms.Status.Replicas = 3
+			cpms.Status.UpdatedReplicas = 1
+
+			reconciler.rollState.record(cpms)
+		})
+
+		Expect(reconciler.rollState.rollState).To(HaveField("Outdated", Equal(int32(2))))
+	})
+})
+
+var _ = Describe("isControlPlaneMachineSetDegraded", func() {
+	cpmsBuilder := machinev1resourcebuilder.ControlPlaneMachineSet()
+	degradedConditionBuilder := metav1resourcebuilder.Condition().WithType(conditionDegraded)
+
+	DescribeTable("should determine if the ControlPlaneMachineSet is degraded", func(cpms *machinev1.ControlPlaneMachineSet, expectDegraded bool) {
 		Expect(isControlPlaneMachineSetDegraded(cpms)).To(Equal(expectDegraded), "Degraded state of ControlPlaneMachineSet was not as expected")
 	},
 		Entry("with a CPMS without a degraded condition",
@@ -2561,3 +3225,314 @@ var _ = Describe("isControlPlaneMachineSetDegraded", func() {
 		),
 	)
 })
+
+var _ = Describe("reconcileMachines with no control plane machines", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	noMachineInfos := map[int32][]machineproviders.MachineInfo{0: {}, 1: {}, 2: {}}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{Namespace: "test"}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+	})
+
+	Context("when the bootstrap has not been confirmed", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachines(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, noMachineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sets the degraded condition, requiring confirmation", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonNoControlPlaneMachines)),
+				HaveField("Message", ContainSubstring(bootstrapAnnotation)),
+			))
+		})
+
+		It("logs that the machines will not be created", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Error:   errNoControlPlaneMachines,
+				Message: "No control plane machines found, refusing to create control plane machines without confirmation",
+			}))
+		})
+	})
+
+	Context("when the bootstrap has been confirmed", func() {
+		var ok bool
+
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{bootstrapAnnotation: "true"})
+
+			ok = reconciler.checkControlPlaneMachinesExist(logger.Logger(), cpms, sortMachineInfosByIndex(noMachineInfos))
+		})
+
+		It("allows the machines to be created", func() {
+			Expect(ok).To(BeTrue())
+		})
+
+		It("does not set the degraded condition", func() {
+			Expect(meta.IsStatusConditionFalse(cpms.Status.Conditions, conditionDegraded)).To(BeTrue())
+		})
+
+		It("keeps the bootstrap annotation until the machines exist", func() {
+			Expect(cpms.GetAnnotations()).To(HaveKeyWithValue(bootstrapAnnotation, "true"))
+		})
+	})
+
+	Context("when control plane machines exist and the bootstrap annotation is set", func() {
+		var ok bool
+
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{bootstrapAnnotation: "true"})
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {machineprovidersresourcebuilder.MachineInfo().WithIndex(0).WithMachineName("machine-0").WithReady(false).Build()},
+				1: {},
+				2: {},
+			}
+
+			ok = reconciler.checkControlPlaneMachinesExist(logger.Logger(), cpms, sortMachineInfosByIndex(machineInfos))
+		})
+
+		It("allows the reconcile to continue", func() {
+			Expect(ok).To(BeTrue())
+		})
+
+		It("removes the bootstrap annotation", func() {
+			Expect(cpms.GetAnnotations()).ToNot(HaveKey(bootstrapAnnotation))
+		})
+	})
+})
+
+var _ = Describe("setLastReconcileError", func() {
+	machineGroupResource := schema.GroupResource{Group: machinev1beta1.GroupVersion.Group, Resource: "machines"}
+
+	reconcileErrorsCounter := func(category string) float64 {
+		metric := &dto.Metric{}
+		Expect(reconcileErrors.WithLabelValues(category).Write(metric)).To(Succeed())
+
+		return metric.GetCounter().GetValue()
+	}
+
+	DescribeTable("categorises the reconcile error", func(err error, expectedCategory string) {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2).Build()
+		before := reconcileErrorsCounter(expectedCategory)
+
+		setLastReconcileError(cpms, err)
+
+		Expect(reconcileErrorsCounter(expectedCategory)).To(Equal(before + 1))
+		Expect(cpms.Status.Conditions).To(testutils.MatchConditions([]metav1.Condition{
+			{
+				Type:               conditionLastReconcileError,
+				Status:             metav1.ConditionTrue,
+				Reason:             expectedCategory,
+				Message:            err.Error(),
+				ObservedGeneration: 2,
+			},
+		}))
+	},
+		Entry("with an API conflict",
+			fmt.Errorf("error updating control plane machine set status: %w", apierrors.NewConflict(machineGroupResource, "machine-0", errors.New("the object has been modified"))),
+			reasonAPIConflict,
+		),
+		Entry("with an invalid object",
+			fmt.Errorf("error reconciling machines: %w", apierrors.NewInvalid(schema.GroupKind{Group: machinev1beta1.GroupVersion.Group, Kind: "Machine"}, "machine-0", field.ErrorList{field.Required(field.NewPath("spec"), "spec is required")})),
+			reasonValidationFailed,
+		),
+		Entry("with missing replicas",
+			fmt.Errorf("error reconciling control plane machine set: %w", errReplicasRequired),
+			reasonValidationFailed,
+		),
+		Entry("with a machine provider error",
+			fmt.Errorf("error fetching machine info: %w", machineProviderError{err: errors.New("could not list machines")}),
+			reasonMachineProviderError,
+		),
+		Entry("with an uncategorised error",
+			errors.New("error reconciling machines"),
+			reasonUnknownError,
+		),
+	)
+
+	It("clears the condition when the reconcile succeeds", func() {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2).Build()
+
+		setLastReconcileError(cpms, errors.New("error reconciling machines"))
+		setLastReconcileError(cpms, nil)
+
+		Expect(cpms.Status.Conditions).To(testutils.MatchConditions([]metav1.Condition{
+			{
+				Type:               conditionLastReconcileError,
+				Status:             metav1.ConditionFalse,
+				Reason:             reasonAsExpected,
+				ObservedGeneration: 2,
+			},
+		}))
+	})
+})
+
+// recordingPatchClient is a client that records the data of each patch it is asked to apply, without applying it.
+type recordingPatchClient struct {
+	client.Client
+
+	patches []string
+}
+
+// Patch records the data of the patch.
+func (c *recordingPatchClient) Patch(_ context.Context, obj client.Object, patch client.Patch, _ ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return fmt.Errorf("could not calculate patch data: %w", err)
+	}
+
+	c.patches = append(c.patches, string(data))
+
+	return nil
+}
+
+var _ = Describe("adoption patches", func() {
+	var logger testutils.TestLogger
+	var patchClient *recordingPatchClient
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var machineInfos map[int32][]machineproviders.MachineInfo
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		patchClient = &recordingPatchClient{}
+
+		restMapper := meta.NewDefaultRESTMapper(nil)
+		restMapper.Add(machinev1beta1.GroupVersion.WithKind("Machine"), meta.RESTScopeNamespace)
+
+		adoptionScheme := runtime.NewScheme()
+		Expect(machinev1.Install(adoptionScheme)).To(Succeed())
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:     patchClient,
+			RESTMapper: restMapper,
+			Scheme:     adoptionScheme,
+			Namespace:  "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace("test").Build()
+		cpms.SetUID("cpms-uid")
+
+		// The Machine was created by another controller, which has set its own labels, annotations and finalizers.
+		machineInfos = map[int32][]machineproviders.MachineInfo{
+			0: {{
+				MachineRef: &machineproviders.ObjectRef{
+					GroupVersionResource: machinev1beta1.GroupVersion.WithResource("machines"),
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "pre-existing-machine",
+						Namespace:       "test",
+						ResourceVersion: "1",
+						Labels:          map[string]string{"machine.openshift.io/cluster-api-machine-role": "master"},
+						Annotations:     map[string]string{"machine.openshift.io/instance-state": "running"},
+						Finalizers:      []string{"machine.machine.openshift.io"},
+					},
+				},
+				Index: 0,
+			}},
+		}
+	})
+
+	It("only adds the index label to the machine", func() {
+		Expect(reconciler.ensureIndexLabels(ctx, logger.Logger(), machineInfos)).To(Succeed())
+
+		Expect(patchClient.patches).To(ConsistOf(
+			MatchJSON(fmt.Sprintf(`{"metadata":{"labels":{%q:"0"}}}`, machineproviders.MachineIndexLabel)),
+		))
+	})
+
+	It("only adds the owner reference to the machine", func() {
+		Expect(reconciler.ensureOwnerReferences(ctx, logger.Logger(), cpms, machineInfos)).To(Succeed())
+
+		Expect(patchClient.patches).To(ConsistOf(
+			MatchJSON(`{"metadata":{"ownerReferences":[{"apiVersion":"machine.openshift.io/v1","kind":"ControlPlaneMachineSet","name":"cluster","uid":"cpms-uid","controller":true,"blockOwnerDeletion":true}]}}`),
+		))
+	})
+
+	It("does not modify the machine observed by the machine provider", func() {
+		Expect(reconciler.ensureIndexLabels(ctx, logger.Logger(), machineInfos)).To(Succeed())
+		Expect(reconciler.ensureOwnerReferences(ctx, logger.Logger(), cpms, machineInfos)).To(Succeed())
+
+		Expect(machineInfos[0][0].MachineRef.ObjectMeta.Labels).ToNot(HaveKey(machineproviders.MachineIndexLabel))
+		Expect(machineInfos[0][0].MachineRef.ObjectMeta.OwnerReferences).To(BeEmpty())
+	})
+})
+
+var _ = Describe("isProviderAuthenticationFailure", func() {
+	type isProviderAuthenticationFailureTableInput struct {
+		errorReason  string
+		errorMessage string
+		expected     bool
+	}
+
+	DescribeTable("classifies the error reported on the Machine", func(in isProviderAuthenticationFailureTableInput) {
+		machineInfo := machineprovidersresourcebuilder.MachineInfo().WithErrorReason(in.errorReason).WithErrorMessage(in.errorMessage).Build()
+
+		Expect(isProviderAuthenticationFailure(machineInfo)).To(Equal(in.expected))
+	},
+		Entry("with an AWS unauthorized operation", isProviderAuthenticationFailureTableInput{
+			errorReason:  "InvalidConfiguration",
+			errorMessage: "error launching instance: UnauthorizedOperation: You are not authorized to perform this operation.",
+			expected:     true,
+		}),
+		Entry("with an Azure authorization failure", isProviderAuthenticationFailureTableInput{
+			errorReason:  "InvalidConfiguration",
+			errorMessage: "failed to create vm: AuthorizationFailed: The client does not have authorization to perform action",
+			expected:     true,
+		}),
+		Entry("with a GCP permission denied error", isProviderAuthenticationFailureTableInput{
+			errorReason:  "InvalidConfiguration",
+			errorMessage: "googleapi: Error 403: Required 'compute.instances.create' permission, forbidden",
+			expected:     true,
+		}),
+		Entry("with an invalid configuration unrelated to the credentials", isProviderAuthenticationFailureTableInput{
+			errorReason:  "InvalidConfiguration",
+			errorMessage: "error launching instance: InvalidAMIID.NotFound: The image id does not exist",
+			expected:     false,
+		}),
+		Entry("with an authentication message and another error reason", isProviderAuthenticationFailureTableInput{
+			errorReason:  "InsufficientResources",
+			errorMessage: "access denied while checking quota",
+			expected:     false,
+		}),
+		Entry("with an authentication message and no error reason", isProviderAuthenticationFailureTableInput{
+			errorMessage: "access denied",
+			expected:     false,
+		}),
+	)
+})
+
+var _ = Describe("degradedRecheckInterval", func() {
+	degradedConditionBuilder := metav1resourcebuilder.Condition().WithType(conditionDegraded).WithStatus(metav1.ConditionTrue)
+
+	It("checks back in after the provider authentication retry interval when the cloud credentials were rejected", func() {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithConditions([]metav1.Condition{
+			degradedConditionBuilder.WithReason(reasonProviderAuthenticationFailed).Build(),
+		}).Build()
+
+		Expect(degradedRecheckInterval(cpms)).To(Equal(providerAuthenticationRetryInterval))
+	})
+
+	It("does not check back in for another failed replacement", func() {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithConditions([]metav1.Condition{
+			degradedConditionBuilder.WithReason(reasonFailedReplacement).Build(),
+		}).Build()
+
+		Expect(degradedRecheckInterval(cpms)).To(BeZero())
+	})
+})
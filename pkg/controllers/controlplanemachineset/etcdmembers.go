@@ -0,0 +1,134 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// etcdMembersUnavailable is a log message used to inform the user that the etcd members could not be mapped to
+	// the Machines, and so the EtcdMembers condition does not describe the mapping.
+	etcdMembersUnavailable = "Unable to map etcd members to machines"
+)
+
+// isEtcdMemberMappingEnabled returns true when the ControlPlaneMachineSet requests that the etcd members are mapped
+// to the Machines of each index.
+func isEtcdMemberMappingEnabled(cpms *machinev1.ControlPlaneMachineSet) bool {
+	return cpms.GetAnnotations()[etcdMemberMappingAnnotation] == "true"
+}
+
+// reconcileEtcdMembers sets the EtcdMembers condition to describe which etcd member runs on the Machine of each index.
+// The mapping is informational, so failing to list the etcd members is reported in the condition rather than failing
+// the reconcile, which would prevent the ControlPlaneMachineSet from replacing the Machine of an unhealthy member.
+func (r *ControlPlaneMachineSetReconciler) reconcileEtcdMembers(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) {
+	if r.EtcdMembers == nil || !isEtcdMemberMappingEnabled(cpms) {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionEtcdMembers)
+
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionEtcdMembers,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cpms.Generation,
+	}
+
+	mapping, err := r.mapEtcdMembers(ctx, cpms, machineInfos)
+
+	switch {
+	case err != nil:
+		logger.Error(err, etcdMembersUnavailable)
+
+		condition.Reason = reasonEtcdMembersUnavailable
+		condition.Message = fmt.Sprintf("Unable to map etcd members to machines: %v", err)
+	case len(mapping) == 0:
+		condition.Reason = reasonEtcdMembersUnavailable
+		condition.Message = "No etcd members are published by the etcd operator"
+	default:
+		message, allMapped := describeEtcdMembers(mapping)
+
+		condition.Reason = reasonUnmappedEtcdMembers
+		condition.Message = message
+
+		if allMapped {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = reasonEtcdMembersMapped
+		}
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, condition)
+}
+
+// mapEtcdMembers lists the etcd members and the Machines matched by the ControlPlaneMachineSet selector, and maps each
+// etcd member to the Machine with its address, and so to the index of that Machine.
+func (r *ControlPlaneMachineSetReconciler) mapEtcdMembers(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) ([]providers.EtcdMemberMapping, error) {
+	members, err := r.EtcdMembers.ListEtcdMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing etcd members: %w", err)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&cpms.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing selector: %w", err)
+	}
+
+	machineList := &machinev1beta1.MachineList{}
+	if err := r.List(ctx, machineList, client.InNamespace(cpms.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("error listing machines: %w", err)
+	}
+
+	indexedMachineInfos := []machineproviders.MachineInfo{}
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		indexedMachineInfos = append(indexedMachineInfos, indexToMachines.machineInfos...)
+	}
+
+	return providers.MapEtcdMembers(members, machineList.Items, indexedMachineInfos), nil
+}
+
+// describeEtcdMembers describes the Machine, and index, on which each etcd member runs, and returns whether every
+// etcd member runs on the Machine of an index.
+func describeEtcdMembers(mapping []providers.EtcdMemberMapping) (string, bool) {
+	descriptions := []string{}
+	allMapped := true
+
+	for _, member := range mapping {
+		switch {
+		case member.Index != nil:
+			descriptions = append(descriptions, fmt.Sprintf("index %d: member %s (%s) on machine %s", *member.Index, member.ID, member.Address, member.MachineName))
+		case member.MachineName != "":
+			allMapped = false
+			descriptions = append(descriptions, fmt.Sprintf("member %s (%s) on machine %s without an index", member.ID, member.Address, member.MachineName))
+		default:
+			allMapped = false
+			descriptions = append(descriptions, fmt.Sprintf("member %s (%s) not on any control plane machine", member.ID, member.Address))
+		}
+	}
+
+	return strings.Join(descriptions, "; "), allMapped
+}
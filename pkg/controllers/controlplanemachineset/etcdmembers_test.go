@@ -0,0 +1,176 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// stubEtcdMemberLister returns a fixed list of etcd members, or a fixed error.
+type stubEtcdMemberLister struct {
+	members []providers.EtcdMember
+	err     error
+}
+
+// ListEtcdMembers returns the stubbed etcd members.
+func (l stubEtcdMemberLister) ListEtcdMembers(_ context.Context) ([]providers.EtcdMember, error) {
+	return l.members, l.err
+}
+
+// machineListClient is a client that lists a fixed set of Machines.
+type machineListClient struct {
+	client.Client
+
+	machines []machinev1beta1.Machine
+}
+
+// List fills the MachineList with the fixed set of Machines.
+func (c machineListClient) List(_ context.Context, list client.ObjectList, _ ...client.ListOption) error {
+	machineList, ok := list.(*machinev1beta1.MachineList)
+	if !ok {
+		return errors.New("unexpected list type")
+	}
+
+	machineList.Items = c.machines
+
+	return nil
+}
+
+var _ = Describe("reconcileEtcdMembers", func() {
+	type reconcileEtcdMembersTableInput struct {
+		lister            providers.EtcdMemberLister
+		disabled          bool
+		expectedCondition *metav1.Condition
+	}
+
+	// machineWithAddress builds a control plane Machine with the given internal IP address.
+	machineWithAddress := func(name, address string) machinev1beta1.Machine {
+		machine := machinev1beta1resourcebuilder.Machine().AsMaster().WithName(name).Build()
+		machine.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: address}}
+
+		return *machine
+	}
+
+	machines := []machinev1beta1.Machine{
+		machineWithAddress("master-0", "10.0.0.10"),
+		machineWithAddress("master-1", "10.0.0.11"),
+		machineWithAddress("master-2", "10.0.0.12"),
+	}
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {{MachineRef: &machineproviders.ObjectRef{ObjectMeta: machines[0].ObjectMeta}, Index: 0}},
+		1: {{MachineRef: &machineproviders.ObjectRef{ObjectMeta: machines[1].ObjectMeta}, Index: 1}},
+		2: {{MachineRef: &machineproviders.ObjectRef{ObjectMeta: machines[2].ObjectMeta}, Index: 2}},
+	}
+
+	DescribeTable("sets the EtcdMembers condition", func(in reconcileEtcdMembersTableInput) {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2).WithReplicas(3).Build()
+		cpms.Status.Conditions = []metav1.Condition{{
+			Type:   conditionEtcdMembers,
+			Status: metav1.ConditionTrue,
+			Reason: reasonEtcdMembersMapped,
+		}}
+
+		if !in.disabled {
+			cpms.SetAnnotations(map[string]string{etcdMemberMappingAnnotation: "true"})
+		}
+
+		reconciler := &ControlPlaneMachineSetReconciler{
+			Client:      machineListClient{machines: machines},
+			EtcdMembers: in.lister,
+		}
+
+		reconciler.reconcileEtcdMembers(ctx, testutils.NewTestLogger().Logger(), cpms, machineInfos)
+
+		condition := meta.FindStatusCondition(cpms.Status.Conditions, conditionEtcdMembers)
+		if in.expectedCondition == nil {
+			Expect(condition).To(BeNil())
+
+			return
+		}
+
+		Expect(condition).To(SatisfyAll(
+			HaveField("Status", Equal(in.expectedCondition.Status)),
+			HaveField("Reason", Equal(in.expectedCondition.Reason)),
+			HaveField("Message", Equal(in.expectedCondition.Message)),
+			HaveField("ObservedGeneration", Equal(int64(2))),
+		))
+	},
+		Entry("with a member on the machine of each index", reconcileEtcdMembersTableInput{
+			lister: stubEtcdMemberLister{members: []providers.EtcdMember{
+				{ID: "1a2b", Address: "10.0.0.12"},
+				{ID: "3c4d", Address: "10.0.0.10"},
+				{ID: "5e6f", Address: "10.0.0.11"},
+			}},
+			expectedCondition: &metav1.Condition{
+				Status: metav1.ConditionTrue,
+				Reason: reasonEtcdMembersMapped,
+				Message: "index 0: member 3c4d (10.0.0.10) on machine master-0; " +
+					"index 1: member 5e6f (10.0.0.11) on machine master-1; " +
+					"index 2: member 1a2b (10.0.0.12) on machine master-2",
+			},
+		}),
+		Entry("with a member not on any control plane machine", reconcileEtcdMembersTableInput{
+			lister: stubEtcdMemberLister{members: []providers.EtcdMember{
+				{ID: "1a2b", Address: "10.0.0.99"},
+				{ID: "3c4d", Address: "10.0.0.10"},
+			}},
+			expectedCondition: &metav1.Condition{
+				Status: metav1.ConditionFalse,
+				Reason: reasonUnmappedEtcdMembers,
+				Message: "index 0: member 3c4d (10.0.0.10) on machine master-0; " +
+					"member 1a2b (10.0.0.99) not on any control plane machine",
+			},
+		}),
+		Entry("with no published members", reconcileEtcdMembersTableInput{
+			lister: stubEtcdMemberLister{members: []providers.EtcdMember{}},
+			expectedCondition: &metav1.Condition{
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonEtcdMembersUnavailable,
+				Message: "No etcd members are published by the etcd operator",
+			},
+		}),
+		Entry("when the etcd members cannot be listed", reconcileEtcdMembersTableInput{
+			lister: stubEtcdMemberLister{err: errors.New("configmaps \"etcd-endpoints\" not found")},
+			expectedCondition: &metav1.Condition{
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonEtcdMembersUnavailable,
+				Message: "Unable to map etcd members to machines: error listing etcd members: configmaps \"etcd-endpoints\" not found",
+			},
+		}),
+		Entry("when the mapping is not requested", reconcileEtcdMembersTableInput{
+			lister:   stubEtcdMemberLister{members: []providers.EtcdMember{{ID: "3c4d", Address: "10.0.0.10"}}},
+			disabled: true,
+		}),
+		Entry("without an etcd member lister", reconcileEtcdMembersTableInput{}),
+	)
+})
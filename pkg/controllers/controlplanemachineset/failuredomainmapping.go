@@ -0,0 +1,120 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// failureDomainMappingConfigMapName is the name of the ConfigMap, within the ControlPlaneMachineSet namespace,
+	// into which the failure domain mapped to each index is exported. Each key is an index, and each value describes
+	// the failure domain of that index.
+	failureDomainMappingConfigMapName = "control-plane-machine-set-failure-domain-mapping"
+
+	// exportingFailureDomainMapping is a log message used to inform the user that the failure domain mapping
+	// ConfigMap is being created or updated to match the failure domain mapping.
+	exportingFailureDomainMapping = "Exporting failure domain mapping"
+)
+
+// isFailureDomainMappingExportEnabled returns true when the ControlPlaneMachineSet requests that the failure domain
+// mapping is exported.
+func isFailureDomainMappingExportEnabled(cpms *machinev1.ControlPlaneMachineSet) bool {
+	return cpms.GetAnnotations()[failureDomainMappingAnnotation] == "true"
+}
+
+// failureDomainMappingData describes the failure domain mapped to each index, as the data of the failure domain
+// mapping ConfigMap. When the machine provider does not map indexes to failure domains, the data is empty.
+func failureDomainMappingData(machineProvider machineproviders.MachineProvider) map[string]string {
+	data := map[string]string{}
+
+	mapper, ok := machineProvider.(machineproviders.FailureDomainMapper)
+	if !ok {
+		return data
+	}
+
+	for idx, failureDomain := range mapper.FailureDomainMapping() {
+		data[strconv.Itoa(int(idx))] = failureDomain
+	}
+
+	return data
+}
+
+// reconcileFailureDomainMapping keeps the failure domain mapping ConfigMap in sync with the failure domain mapped to
+// each index by the machine provider. The ConfigMap is controlled by the ControlPlaneMachineSet, so it is garbage
+// collected along with the ControlPlaneMachineSet. Once the mapping is no longer exported, the ConfigMap is left as is.
+func (r *ControlPlaneMachineSetReconciler) reconcileFailureDomainMapping(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider) error {
+	if !isFailureDomainMappingExportEnabled(cpms) {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMapKey := client.ObjectKey{Namespace: cpms.Namespace, Name: failureDomainMappingConfigMapName}
+	cmLogger := logger.WithValues("configMap", configMapKey.String())
+
+	err := r.Get(ctx, configMapKey, configMap)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get config map %s: %w", configMapKey, err)
+	}
+
+	exists := err == nil
+
+	data := failureDomainMappingData(machineProvider)
+
+	// The API server drops empty data, so a nil map must be treated as equal to an empty mapping.
+	if exists && metav1.IsControlledBy(configMap, cpms) && equality.Semantic.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+
+	if !exists {
+		configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: configMapKey.Namespace, Name: configMapKey.Name}}
+	}
+
+	configMap.Data = data
+
+	if err := controllerutil.SetControllerReference(cpms, configMap, r.Scheme); err != nil {
+		return fmt.Errorf("cannot set controller reference on config map %s: %w", configMapKey, err)
+	}
+
+	cmLogger.V(2).Info(exportingFailureDomainMapping, "failureDomainMapping", data)
+
+	if !exists {
+		if err := r.Create(ctx, configMap); err != nil {
+			return fmt.Errorf("failed to create config map %s: %w", configMapKey, err)
+		}
+
+		return nil
+	}
+
+	if err := r.Update(ctx, configMap); err != nil {
+		return fmt.Errorf("failed to update config map %s: %w", configMapKey, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeFailureDomainMapper is a machine provider that maps each index to a fixed failure domain.
+type fakeFailureDomainMapper struct {
+	machineproviders.MachineProvider
+
+	mapping map[int32]string
+}
+
+// FailureDomainMapping returns the fixed failure domain mapping.
+func (f fakeFailureDomainMapper) FailureDomainMapping() map[int32]string {
+	return f.mapping
+}
+
+// configMapClient is a client that stores ConfigMaps in memory.
+type configMapClient struct {
+	client.Client
+
+	configMaps map[types.NamespacedName]*corev1.ConfigMap
+}
+
+// Get returns a copy of the stored ConfigMap.
+func (c configMapClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return errors.New("unexpected object type")
+	}
+
+	stored, ok := c.configMaps[key]
+	if !ok {
+		return apierrors.NewNotFound(corev1.Resource("configmaps"), key.Name)
+	}
+
+	stored.DeepCopyInto(configMap)
+
+	return nil
+}
+
+// Create stores a copy of the ConfigMap, failing if it already exists.
+func (c configMapClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	key := client.ObjectKeyFromObject(obj)
+	if _, ok := c.configMaps[key]; ok {
+		return apierrors.NewAlreadyExists(corev1.Resource("configmaps"), key.Name)
+	}
+
+	return c.store(obj)
+}
+
+// Update stores a copy of the ConfigMap, failing if it does not exist.
+func (c configMapClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	key := client.ObjectKeyFromObject(obj)
+	if _, ok := c.configMaps[key]; !ok {
+		return apierrors.NewNotFound(corev1.Resource("configmaps"), key.Name)
+	}
+
+	return c.store(obj)
+}
+
+func (c configMapClient) store(obj client.Object) error {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		return errors.New("unexpected object type")
+	}
+
+	c.configMaps[client.ObjectKeyFromObject(obj)] = configMap.DeepCopy()
+
+	return nil
+}
+
+var _ = Describe("reconcileFailureDomainMapping", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var configMaps map[types.NamespacedName]*corev1.ConfigMap
+
+	configMapKey := types.NamespacedName{Namespace: "openshift-machine-api", Name: failureDomainMappingConfigMapName}
+
+	reconcileMapping := func(mapping map[int32]string) {
+		Expect(reconciler.reconcileFailureDomainMapping(ctx, logger.Logger(), cpms, fakeFailureDomainMapper{mapping: mapping})).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		configMaps = map[types.NamespacedName]*corev1.ConfigMap{}
+
+		scheme := runtime.NewScheme()
+		Expect(machinev1.Install(scheme)).To(Succeed())
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    configMapClient{configMaps: configMaps},
+			Scheme:    scheme,
+			Namespace: "openshift-machine-api",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace("openshift-machine-api").WithReplicas(3).Build()
+		cpms.SetUID("cpms-uid")
+		cpms.SetAnnotations(map[string]string{failureDomainMappingAnnotation: "true"})
+	})
+
+	Context("when the mapping is exported", func() {
+		BeforeEach(func() {
+			reconcileMapping(map[int32]string{0: "AWSFailureDomain{AvailabilityZone:us-east-1a}", 1: "AWSFailureDomain{AvailabilityZone:us-east-1b}", 2: "AWSFailureDomain{AvailabilityZone:us-east-1c}"})
+		})
+
+		It("creates the ConfigMap with the failure domain of each index", func() {
+			Expect(configMaps).To(HaveKey(configMapKey))
+			Expect(configMaps[configMapKey].Data).To(Equal(map[string]string{
+				"0": "AWSFailureDomain{AvailabilityZone:us-east-1a}",
+				"1": "AWSFailureDomain{AvailabilityZone:us-east-1b}",
+				"2": "AWSFailureDomain{AvailabilityZone:us-east-1c}",
+			}))
+		})
+
+		It("sets the ControlPlaneMachineSet as the controller of the ConfigMap", func() {
+			Expect(metav1.IsControlledBy(configMaps[configMapKey], cpms)).To(BeTrue())
+		})
+
+		It("logs that the mapping was exported", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", exportingFailureDomainMapping)))
+		})
+
+		Context("and the failure domains change", func() {
+			BeforeEach(func() {
+				reconcileMapping(map[int32]string{0: "AWSFailureDomain{AvailabilityZone:us-east-1a}", 1: "AWSFailureDomain{AvailabilityZone:us-east-1b}", 2: "AWSFailureDomain{AvailabilityZone:us-east-1d}"})
+			})
+
+			It("updates the ConfigMap with the new failure domain mapping", func() {
+				Expect(configMaps[configMapKey].Data).To(HaveKeyWithValue("2", "AWSFailureDomain{AvailabilityZone:us-east-1d}"))
+			})
+		})
+
+		Context("and the failure domains do not change", func() {
+			BeforeEach(func() {
+				logger = testutils.NewTestLogger()
+
+				reconcileMapping(map[int32]string{0: "AWSFailureDomain{AvailabilityZone:us-east-1a}", 1: "AWSFailureDomain{AvailabilityZone:us-east-1b}", 2: "AWSFailureDomain{AvailabilityZone:us-east-1c}"})
+			})
+
+			It("does not update the ConfigMap", func() {
+				Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", exportingFailureDomainMapping)))
+			})
+		})
+	})
+
+	Context("when the ConfigMap has been modified", func() {
+		BeforeEach(func() {
+			configMaps[configMapKey] = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: configMapKey.Namespace, Name: configMapKey.Name},
+				Data:       map[string]string{"0": "modified"},
+			}
+
+			reconcileMapping(map[int32]string{0: "AWSFailureDomain{AvailabilityZone:us-east-1a}"})
+		})
+
+		It("restores the failure domain mapping", func() {
+			Expect(configMaps[configMapKey].Data).To(Equal(map[string]string{"0": "AWSFailureDomain{AvailabilityZone:us-east-1a}"}))
+			Expect(metav1.IsControlledBy(configMaps[configMapKey], cpms)).To(BeTrue())
+		})
+	})
+
+	Context("when the mapping is not exported", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(nil)
+
+			reconcileMapping(map[int32]string{0: "AWSFailureDomain{AvailabilityZone:us-east-1a}"})
+		})
+
+		It("does not create the ConfigMap", func() {
+			Expect(configMaps).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// featureGateName is the name of the cluster FeatureGate singleton.
+	featureGateName = "cluster"
+
+	// controlPlaneMachineSetFeatureGate is the feature gate that allows the ControlPlaneMachineSet
+	// to manage the Control Plane Machines. The gate is enabled unless it is explicitly disabled.
+	controlPlaneMachineSetFeatureGate configv1.FeatureGateName = "ControlPlaneMachineSet"
+)
+
+// isFeatureGateEnabled determines whether the ControlPlaneMachineSet feature gate is enabled in the cluster.
+// The enabled and disabled gates reported in the FeatureGate status for the release version of the operator
+// take precedence. Until the status reports the release version, the gate is only disabled when it is listed
+// in the disabled gates of the CustomNoUpgrade feature set.
+// When no FeatureGate exists, the gate is enabled.
+func (r *ControlPlaneMachineSetReconciler) isFeatureGateEnabled(ctx context.Context) (bool, error) {
+	featureGate := &configv1.FeatureGate{}
+	if err := r.Get(ctx, client.ObjectKey{Name: featureGateName}, featureGate); apierrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get FeatureGate %q: %w", featureGateName, err)
+	}
+
+	for _, details := range featureGate.Status.FeatureGates {
+		if details.Version != r.ReleaseVersion {
+			continue
+		}
+
+		for _, disabled := range details.Disabled {
+			if disabled.Name == controlPlaneMachineSetFeatureGate {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	}
+
+	if featureGate.Spec.FeatureSet == configv1.CustomNoUpgrade && featureGate.Spec.CustomNoUpgrade != nil {
+		for _, disabled := range featureGate.Spec.CustomNoUpgrade.Disabled {
+			if disabled == controlPlaneMachineSetFeatureGate {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
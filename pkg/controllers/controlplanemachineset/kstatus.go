@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileKStatusConditions sets the Ready, Reconciling and Stalled conditions, which summarise the operator specific
+// conditions in the form expected by kstatus, so that generic tooling can determine whether the ControlPlaneMachineSet
+// is current, in progress or failed.
+// The summary is derived from the Available, Degraded, Error and Progressing conditions, and so must be computed
+// after they have been set.
+func reconcileKStatusConditions(cpms *machinev1.ControlPlaneMachineSet) {
+	stalled := getStalledCondition(cpms)
+	meta.SetStatusCondition(&cpms.Status.Conditions, stalled)
+
+	reconciling := getReconcilingCondition(cpms)
+	meta.SetStatusCondition(&cpms.Status.Conditions, reconciling)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, getReadyCondition(cpms, stalled, reconciling))
+}
+
+// getStalledCondition computes the Stalled condition. The ControlPlaneMachineSet is stalled when it is degraded, or
+// when it is continuously failing to reconcile, in which case the reason and message are those of the cause.
+func getStalledCondition(cpms *machinev1.ControlPlaneMachineSet) metav1.Condition {
+	for _, conditionType := range []string{conditionDegraded, conditionError} {
+		if cause := meta.FindStatusCondition(cpms.Status.Conditions, conditionType); cause != nil && cause.Status == metav1.ConditionTrue {
+			return metav1.Condition{
+				Type:               conditionStalled,
+				Status:             metav1.ConditionTrue,
+				Reason:             cause.Reason,
+				Message:            cause.Message,
+				ObservedGeneration: cpms.Generation,
+			}
+		}
+	}
+
+	return metav1.Condition{
+		Type:               conditionStalled,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonAsExpected,
+		ObservedGeneration: cpms.Generation,
+	}
+}
+
+// getReconcilingCondition computes the Reconciling condition. The ControlPlaneMachineSet is reconciling while the
+// latest generation has not been observed, or while it is progressing, in which case the reason and message are
+// those of the Progressing condition.
+func getReconcilingCondition(cpms *machinev1.ControlPlaneMachineSet) metav1.Condition {
+	if cpms.Status.ObservedGeneration < cpms.Generation {
+		return metav1.Condition{
+			Type:               conditionReconciling,
+			Status:             metav1.ConditionTrue,
+			Reason:             reasonGenerationNotObserved,
+			Message:            fmt.Sprintf("Waiting for generation %d to be observed, last observed generation %d", cpms.Generation, cpms.Status.ObservedGeneration),
+			ObservedGeneration: cpms.Generation,
+		}
+	}
+
+	if progressing := meta.FindStatusCondition(cpms.Status.Conditions, conditionProgressing); progressing != nil && progressing.Status == metav1.ConditionTrue {
+		return metav1.Condition{
+			Type:               conditionReconciling,
+			Status:             metav1.ConditionTrue,
+			Reason:             progressing.Reason,
+			Message:            progressing.Message,
+			ObservedGeneration: cpms.Generation,
+		}
+	}
+
+	return metav1.Condition{
+		Type:               conditionReconciling,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonAsExpected,
+		ObservedGeneration: cpms.Generation,
+	}
+}
+
+// getReadyCondition computes the Ready condition. The ControlPlaneMachineSet is ready once it is neither stalled nor
+// reconciling, and every index has an available Machine.
+func getReadyCondition(cpms *machinev1.ControlPlaneMachineSet, stalled, reconciling metav1.Condition) metav1.Condition {
+	ready := metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cpms.Generation,
+	}
+
+	available := meta.FindStatusCondition(cpms.Status.Conditions, conditionAvailable)
+
+	switch {
+	case stalled.Status == metav1.ConditionTrue:
+		ready.Reason = reasonStalled
+		ready.Message = stalled.Message
+	case reconciling.Status == metav1.ConditionTrue:
+		ready.Reason = reasonReconciling
+		ready.Message = reconciling.Message
+	case available == nil || available.Status != metav1.ConditionTrue:
+		ready.Reason = reasonUnavailableReplicas
+		ready.Message = "Waiting for every index to have an available replica"
+
+		if available != nil {
+			ready.Reason = available.Reason
+			ready.Message = available.Message
+		}
+	default:
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = reasonAsExpected
+	}
+
+	return ready
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("reconcileKStatusConditions", func() {
+	type kstatusTableInput struct {
+		observedGeneration  int64
+		conditions          []metav1.Condition
+		expectedReady       metav1.Condition
+		expectedReconciling metav1.Condition
+		expectedStalled     metav1.Condition
+	}
+
+	available := metav1.Condition{Type: conditionAvailable, Status: metav1.ConditionTrue, Reason: reasonAllReplicasAvailable}
+	notDegraded := metav1.Condition{Type: conditionDegraded, Status: metav1.ConditionFalse, Reason: reasonAsExpected}
+	noError := metav1.Condition{Type: conditionError, Status: metav1.ConditionFalse, Reason: reasonAsExpected}
+	notProgressing := metav1.Condition{Type: conditionProgressing, Status: metav1.ConditionFalse, Reason: reasonAllReplicasUpdated}
+
+	notStalled := metav1.Condition{Type: conditionStalled, Status: metav1.ConditionFalse, Reason: reasonAsExpected}
+	notReconciling := metav1.Condition{Type: conditionReconciling, Status: metav1.ConditionFalse, Reason: reasonAsExpected}
+
+	DescribeTable("maps the operator conditions to the kstatus conditions", func(in kstatusTableInput) {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(2).WithReplicas(3).Build()
+		cpms.Status.ObservedGeneration = in.observedGeneration
+		cpms.Status.Conditions = in.conditions
+
+		reconcileKStatusConditions(cpms)
+
+		for _, expected := range []metav1.Condition{in.expectedReady, in.expectedReconciling, in.expectedStalled} {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, expected.Type)).To(SatisfyAll(
+				HaveField("Status", Equal(expected.Status)),
+				HaveField("Reason", Equal(expected.Reason)),
+				HaveField("Message", Equal(expected.Message)),
+				HaveField("ObservedGeneration", Equal(int64(2))),
+			), "condition %s", expected.Type)
+		}
+	},
+		Entry("with every replica available and up to date", kstatusTableInput{
+			observedGeneration:  2,
+			conditions:          []metav1.Condition{available, notDegraded, noError, notProgressing},
+			expectedReady:       metav1.Condition{Type: conditionReady, Status: metav1.ConditionTrue, Reason: reasonAsExpected},
+			expectedReconciling: notReconciling,
+			expectedStalled:     notStalled,
+		}),
+		Entry("with a rollout in progress", kstatusTableInput{
+			observedGeneration: 2,
+			conditions: []metav1.Condition{available, notDegraded, noError, {
+				Type:    conditionProgressing,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonNeedsUpdateReplicas,
+				Message: "Observed 1 replica(s) in need of update",
+			}},
+			expectedReady: metav1.Condition{
+				Type:    conditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonReconciling,
+				Message: "Observed 1 replica(s) in need of update",
+			},
+			expectedReconciling: metav1.Condition{
+				Type:    conditionReconciling,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonNeedsUpdateReplicas,
+				Message: "Observed 1 replica(s) in need of update",
+			},
+			expectedStalled: notStalled,
+		}),
+		Entry("with the latest generation not yet observed", kstatusTableInput{
+			observedGeneration: 1,
+			conditions:         []metav1.Condition{available, notDegraded, noError, notProgressing},
+			expectedReady: metav1.Condition{
+				Type:    conditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonReconciling,
+				Message: "Waiting for generation 2 to be observed, last observed generation 1",
+			},
+			expectedReconciling: metav1.Condition{
+				Type:    conditionReconciling,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonGenerationNotObserved,
+				Message: "Waiting for generation 2 to be observed, last observed generation 1",
+			},
+			expectedStalled: notStalled,
+		}),
+		Entry("with a stuck rollout", kstatusTableInput{
+			observedGeneration: 2,
+			conditions: []metav1.Condition{available, noError, {
+				Type:    conditionDegraded,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonRolloutStuck,
+				Message: "Rollout has made no progress since 2023-06-01T12:00:00Z, waiting on index 1",
+			}, {
+				Type:    conditionProgressing,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonNeedsUpdateReplicas,
+				Message: "Observed 2 replica(s) in need of update",
+			}},
+			expectedReady: metav1.Condition{
+				Type:    conditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonStalled,
+				Message: "Rollout has made no progress since 2023-06-01T12:00:00Z, waiting on index 1",
+			},
+			expectedReconciling: metav1.Condition{
+				Type:    conditionReconciling,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonNeedsUpdateReplicas,
+				Message: "Observed 2 replica(s) in need of update",
+			},
+			expectedStalled: metav1.Condition{
+				Type:    conditionStalled,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonRolloutStuck,
+				Message: "Rollout has made no progress since 2023-06-01T12:00:00Z, waiting on index 1",
+			},
+		}),
+		Entry("with continuous reconcile errors", kstatusTableInput{
+			observedGeneration: 2,
+			conditions: []metav1.Condition{available, notDegraded, notProgressing, {
+				Type:    conditionError,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonContinuousErrors,
+				Message: "The control plane machine set has experienced the following error more than 15 consecutive times",
+			}},
+			expectedReady: metav1.Condition{
+				Type:    conditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonStalled,
+				Message: "The control plane machine set has experienced the following error more than 15 consecutive times",
+			},
+			expectedReconciling: notReconciling,
+			expectedStalled: metav1.Condition{
+				Type:    conditionStalled,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonContinuousErrors,
+				Message: "The control plane machine set has experienced the following error more than 15 consecutive times",
+			},
+		}),
+		Entry("with an unavailable replica", kstatusTableInput{
+			observedGeneration: 2,
+			conditions: []metav1.Condition{notDegraded, noError, notProgressing, {
+				Type:    conditionAvailable,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonUnavailableReplicas,
+				Message: "Missing 1 available replica(s)",
+			}},
+			expectedReady: metav1.Condition{
+				Type:    conditionReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonUnavailableReplicas,
+				Message: "Missing 1 available replica(s)",
+			},
+			expectedReconciling: notReconciling,
+			expectedStalled:     notStalled,
+		}),
+	)
+})
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// masterMachineConfigPoolName is the name of the MachineConfigPool that configures the Control Plane Nodes.
+	masterMachineConfigPoolName = "master"
+
+	// machineConfigPoolConditionUpdating is the type of the MachineConfigPool condition that is true while
+	// the Nodes within the pool are being updated to a new configuration.
+	machineConfigPoolConditionUpdating = "Updating"
+
+	// machineConfigPoolRecheckInterval is how often the master MachineConfigPool is checked while the creation
+	// and deletion of Machines is deferred. MachineConfigPools are not watched, as the API may not be installed.
+	machineConfigPoolRecheckInterval = 30 * time.Second
+
+	// waitingForMachineConfigPool is a log message used to inform the user that no Machine is being created or
+	// deleted because the master MachineConfigPool is updating.
+	waitingForMachineConfigPool = "Deferring machine updates while the master machine config pool is updating"
+
+	// machineConfigPoolNotFound is a log message used to inform the user that the master MachineConfigPool
+	// could not be found, and so the creation and deletion of Machines is not deferred.
+	machineConfigPoolNotFound = "Master machine config pool not found, machine updates are not deferred"
+)
+
+// machineConfigPoolGVK returns the GroupVersionKind of a MachineConfigPool.
+// The MachineConfigPool types are not vendored, so the MachineConfigPool is read as an unstructured object.
+func machineConfigPoolGVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: "machineconfiguration.openshift.io", Version: "v1", Kind: "MachineConfigPool"}
+}
+
+// isMachineConfigPoolGuardEnabled returns true when the ControlPlaneMachineSet requests that Machines are not created
+// or deleted while the master MachineConfigPool is updating.
+func isMachineConfigPoolGuardEnabled(cpms *machinev1.ControlPlaneMachineSet) bool {
+	return cpms.GetAnnotations()[machineConfigPoolGuardAnnotation] == "true"
+}
+
+// reconcileMachineConfigPoolHold sets the MachineConfigPoolHold condition, and returns true when no Machine may be
+// created or deleted because the master MachineConfigPool is updating.
+// When the MachineConfigPool does not exist, for example because the Machine Config Operator is not installed,
+// nothing is deferred.
+func (r *ControlPlaneMachineSetReconciler) reconcileMachineConfigPoolHold(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (bool, error) {
+	if !isMachineConfigPoolGuardEnabled(cpms) {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMachineConfigPoolHold)
+
+		return false, nil
+	}
+
+	pool := &unstructured.Unstructured{}
+	pool.SetGroupVersionKind(machineConfigPoolGVK())
+
+	if err := r.Get(ctx, client.ObjectKey{Name: masterMachineConfigPoolName}, pool); apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		logger.V(2).Info(machineConfigPoolNotFound)
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMachineConfigPoolHold)
+
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get MachineConfigPool %q: %w", masterMachineConfigPoolName, err)
+	}
+
+	if !isMachineConfigPoolUpdating(pool) {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMachineConfigPoolHold)
+
+		return false, nil
+	}
+
+	logger.V(2).Info(waitingForMachineConfigPool)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionMachineConfigPoolHold,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonMachineConfigPoolUpdating,
+		Message:            fmt.Sprintf("No machines are created or deleted while the %s machine config pool is updating", masterMachineConfigPoolName),
+		ObservedGeneration: cpms.Generation,
+	})
+
+	return true, nil
+}
+
+// isMachineConfigPoolUpdating returns true when the Updating condition of the MachineConfigPool is True.
+func isMachineConfigPoolUpdating(pool *unstructured.Unstructured) bool {
+	conditions, _, err := unstructured.NestedSlice(pool.Object, "status", "conditions")
+	if err != nil {
+		return false
+	}
+
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if condition["type"] == machineConfigPoolConditionUpdating {
+			return condition["status"] == string(metav1.ConditionTrue)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
+)
+
+// createMachineConfigPool creates a MachineConfigPool in the test environment, with the Updating condition set to
+// the given status.
+func createMachineConfigPool(name string, updating metav1.ConditionStatus) {
+	pool := &unstructured.Unstructured{}
+	pool.SetGroupVersionKind(machineConfigPoolGVK())
+	pool.SetName(name)
+
+	Expect(k8sClient.Create(ctx, pool)).To(Succeed())
+
+	Expect(unstructured.SetNestedSlice(pool.Object, []interface{}{
+		map[string]interface{}{"type": "Updated", "status": string(metav1.ConditionFalse)},
+		map[string]interface{}{"type": machineConfigPoolConditionUpdating, "status": string(updating)},
+	}, "status", "conditions")).To(Succeed())
+
+	Expect(k8sClient.Status().Update(ctx, pool)).To(Succeed())
+}
+
+// cleanupMachineConfigPools removes all MachineConfigPools from the test environment.
+func cleanupMachineConfigPools() {
+	pool := &unstructured.Unstructured{}
+	pool.SetGroupVersionKind(machineConfigPoolGVK())
+
+	Expect(k8sClient.DeleteAllOf(ctx, pool)).To(Succeed())
+
+	pools := &unstructured.UnstructuredList{}
+	pools.SetGroupVersionKind(machineConfigPoolGVK().GroupVersion().WithKind("MachineConfigPoolList"))
+
+	Eventually(komega.ObjectList(pools)).Should(HaveField("Items", BeEmpty()))
+}
+
+var _ = Describe("reconcileMachineConfigPoolHold", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var reconcileCtx context.Context
+
+	var held bool
+	var err error
+
+	// cancelReconcile makes any request to the API server fail, as the reconcile context has been cancelled.
+	cancelReconcile := func() {
+		var cancel context.CancelFunc
+		reconcileCtx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconcileCtx = ctx
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    k8sClient,
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		cpms.SetAnnotations(map[string]string{machineConfigPoolGuardAnnotation: "true"})
+	})
+
+	AfterEach(func() {
+		cleanupMachineConfigPools()
+	})
+
+	JustBeforeEach(func() {
+		held, err = reconciler.reconcileMachineConfigPoolHold(reconcileCtx, logger.Logger(), cpms)
+	})
+
+	Context("when the master machine config pool is updating", func() {
+		BeforeEach(func() {
+			createMachineConfigPool(masterMachineConfigPoolName, metav1.ConditionTrue)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("defers the roll", func() {
+			Expect(held).To(BeTrue())
+		})
+
+		It("sets a condition explaining the deferral", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMachineConfigPoolHold)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMachineConfigPoolUpdating)),
+				HaveField("Message", Equal("No machines are created or deleted while the master machine config pool is updating")),
+				HaveField("ObservedGeneration", Equal(int64(1))),
+			))
+		})
+
+		It("logs that machine updates are deferred", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level:   2,
+				Message: waitingForMachineConfigPool,
+			}))
+		})
+
+		It("reports that the control plane machine set is idle", func() {
+			Expect(reconcileIdle(cpms, *cpms.Spec.Replicas, nil)).To(Succeed())
+
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMachineConfigPoolUpdating)),
+			))
+		})
+
+		Context("and the guard is not enabled", func() {
+			BeforeEach(func() {
+				cpms.SetAnnotations(nil)
+				cancelReconcile()
+			})
+
+			It("does not read the machine config pool", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("does not defer the roll", func() {
+				Expect(held).To(BeFalse())
+			})
+		})
+	})
+
+	Context("when the master machine config pool has finished updating", func() {
+		BeforeEach(func() {
+			createMachineConfigPool(masterMachineConfigPoolName, metav1.ConditionFalse)
+
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionMachineConfigPoolHold,
+				Status: metav1.ConditionTrue,
+				Reason: reasonMachineConfigPoolUpdating,
+			})
+		})
+
+		It("does not defer the roll", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(held).To(BeFalse())
+		})
+
+		It("removes the condition", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMachineConfigPoolHold)).To(BeNil())
+		})
+	})
+
+	Context("when only another machine config pool is updating", func() {
+		BeforeEach(func() {
+			createMachineConfigPool("worker", metav1.ConditionTrue)
+		})
+
+		It("does not defer the roll", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(held).To(BeFalse())
+		})
+
+		It("logs that the master machine config pool was not found", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level:   2,
+				Message: machineConfigPoolNotFound,
+			}))
+		})
+	})
+
+	Context("when the machine config pool API is not installed", func() {
+		BeforeEach(func() {
+			// A REST mapper that knows no kinds reports the MachineConfigPool kind as not installed.
+			noKindsClient, clientErr := client.New(cfg, client.Options{Scheme: testScheme, Mapper: meta.NewDefaultRESTMapper(nil)})
+			Expect(clientErr).ToNot(HaveOccurred())
+
+			reconciler.Client = noKindsClient
+		})
+
+		It("does not defer the roll", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(held).To(BeFalse())
+		})
+	})
+
+	Context("when reading the machine config pool fails", func() {
+		BeforeEach(func() {
+			createMachineConfigPool(masterMachineConfigPoolName, metav1.ConditionTrue)
+			cancelReconcile()
+		})
+
+		It("returns the error", func() {
+			Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			Expect(held).To(BeFalse())
+		})
+	})
+})
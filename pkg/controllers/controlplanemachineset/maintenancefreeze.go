@@ -0,0 +1,74 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// clusterVersionName is the name of the cluster ClusterVersion singleton.
+	clusterVersionName = "version"
+
+	// deferringForMaintenanceFreeze is a log message used to inform the user that no Machine is being created,
+	// deleted or updated because a maintenance freeze is in effect across the cluster.
+	deferringForMaintenanceFreeze = "Deferring machine updates while the cluster maintenance freeze is in effect"
+)
+
+// reconcileMaintenanceFreeze sets the MaintenanceFreeze condition, and returns true when no Machine may be created,
+// deleted or updated because the cluster ClusterVersion requests a maintenance freeze.
+// Unlike the other holds, the freeze is set cluster wide, so that the ControlPlaneMachineSet can honour a freeze
+// without it being configured on the ControlPlaneMachineSet itself. When no ClusterVersion exists, nothing is deferred.
+func (r *ControlPlaneMachineSetReconciler) reconcileMaintenanceFreeze(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (bool, error) {
+	clusterVersion := &configv1.ClusterVersion{}
+
+	if err := r.Get(ctx, client.ObjectKey{Name: clusterVersionName}, clusterVersion); apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMaintenanceFreeze)
+
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get ClusterVersion %q: %w", clusterVersionName, err)
+	}
+
+	if clusterVersion.GetAnnotations()[maintenanceFreezeAnnotation] != "true" {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMaintenanceFreeze)
+
+		return false, nil
+	}
+
+	logger.V(2).Info(deferringForMaintenanceFreeze)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:   conditionMaintenanceFreeze,
+		Status: metav1.ConditionTrue,
+		Reason: reasonMaintenanceFreezeRequested,
+		Message: fmt.Sprintf("No machines are created, deleted or updated while the cluster maintenance freeze is in effect, "+
+			"remove the %s annotation from the %s cluster version to resume", maintenanceFreezeAnnotation, clusterVersionName),
+		ObservedGeneration: cpms.Generation,
+	})
+
+	return true, nil
+}
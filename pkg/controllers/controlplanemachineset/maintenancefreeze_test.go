@@ -0,0 +1,395 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
+)
+
+// clusterVersionWithFreeze builds the cluster ClusterVersion with the maintenance freeze annotation set to the given
+// value, or without the annotation when the value is empty.
+func clusterVersionWithFreeze(value string) *configv1.ClusterVersion {
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterVersionName},
+		Spec: configv1.ClusterVersionSpec{
+			ClusterID: "00000000-0000-0000-0000-000000000000",
+		},
+	}
+
+	if value != "" {
+		clusterVersion.SetAnnotations(map[string]string{maintenanceFreezeAnnotation: value})
+	}
+
+	return clusterVersion
+}
+
+var _ = Describe("reconcileMaintenanceFreeze", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var reconcileCtx context.Context
+
+	var frozen bool
+	var err error
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconcileCtx = ctx
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    k8sClient,
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, "",
+			&configv1.ClusterVersion{},
+		)
+	})
+
+	JustBeforeEach(func() {
+		frozen, err = reconciler.reconcileMaintenanceFreeze(reconcileCtx, logger.Logger(), cpms)
+	})
+
+	Context("when the cluster version requests a maintenance freeze", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, clusterVersionWithFreeze("true"))).To(Succeed())
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("defers machine updates", func() {
+			Expect(frozen).To(BeTrue())
+		})
+
+		It("sets a condition explaining the deferral", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMaintenanceFreeze)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMaintenanceFreezeRequested)),
+				HaveField("Message", Equal("No machines are created, deleted or updated while the cluster maintenance freeze is in effect, "+
+					"remove the "+maintenanceFreezeAnnotation+" annotation from the version cluster version to resume")),
+				HaveField("ObservedGeneration", Equal(int64(1))),
+			))
+		})
+
+		It("logs that machine updates are deferred", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level:   2,
+				Message: deferringForMaintenanceFreeze,
+			}))
+		})
+
+		It("reports that the control plane machine set is idle", func() {
+			Expect(reconcileIdle(cpms, *cpms.Spec.Replicas, nil)).To(Succeed())
+
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMaintenanceFreezeRequested)),
+			))
+		})
+	})
+
+	Context("when the maintenance freeze has been lifted", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, clusterVersionWithFreeze(""))).To(Succeed())
+
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionMaintenanceFreeze,
+				Status: metav1.ConditionTrue,
+				Reason: reasonMaintenanceFreezeRequested,
+			})
+		})
+
+		It("does not defer machine updates", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frozen).To(BeFalse())
+		})
+
+		It("removes the condition", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMaintenanceFreeze)).To(BeNil())
+		})
+	})
+
+	Context("when the maintenance freeze is not set to true", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, clusterVersionWithFreeze("false"))).To(Succeed())
+		})
+
+		It("does not defer machine updates", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frozen).To(BeFalse())
+		})
+	})
+
+	Context("when the cluster version does not exist", func() {
+		It("does not defer machine updates", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frozen).To(BeFalse())
+		})
+	})
+
+	Context("when reading the cluster version fails", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, clusterVersionWithFreeze("true"))).To(Succeed())
+
+			var cancel context.CancelFunc
+			reconcileCtx, cancel = context.WithCancel(ctx)
+			cancel()
+		})
+
+		It("returns the error", func() {
+			Expect(err).To(MatchError(ContainSubstring("context canceled")))
+			Expect(frozen).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("reconcileMachines with a maintenance freeze", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	outdatedMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		Expect(k8sClient.Create(ctx, clusterVersionWithFreeze("true"))).To(Succeed())
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    k8sClient,
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).
+			WithState(machinev1.ControlPlaneMachineSetStateActive).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, "",
+			&configv1.ClusterVersion{},
+		)
+	})
+
+	JustBeforeEach(func() {
+		// The machines only exist as machine infos, so any attempt to update one of them fails the reconcile.
+		_, err := reconciler.reconcileMachines(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, outdatedMachineInfos)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("keeps the status up to date", func() {
+		Expect(cpms.Status).To(SatisfyAll(
+			HaveField("Replicas", Equal(int32(3))),
+			HaveField("ReadyReplicas", Equal(int32(3))),
+			HaveField("UpdatedReplicas", Equal(int32(0))),
+		))
+	})
+
+	It("sets the maintenance freeze condition", func() {
+		Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionMaintenanceFreeze)).To(BeTrue())
+	})
+
+	It("reports that the control plane machine set is idle", func() {
+		Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+			HaveField("Status", Equal(metav1.ConditionTrue)),
+			HaveField("Reason", Equal(reasonMaintenanceFreezeRequested)),
+		))
+	})
+})
+
+var _ = Describe("reconcileMachines gate precedence", func() {
+	type gatesTableInput struct {
+		frozen                   bool
+		outsideMaintenanceWindow bool
+		machineConfigPoolHeld    bool
+		rollCancelled            bool
+		awaitingStep             bool
+		withinRolloutWindow      bool
+		expectedIdleReason       string
+		expectedRequeueAfter     time.Duration
+	}
+
+	// June 1st 2023 is a Thursday, so the 09:00-17:00 maintenance window next opens 15 hours later.
+	now := time.Date(2023, time.June, 1, 18, 0, 0, 0, time.UTC)
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, "",
+			&configv1.ClusterVersion{},
+		)
+
+		cleanupMachineConfigPools()
+	})
+
+	DescribeTable("names the first gate that holds back the roll, and creates or deletes no machine", func(in gatesTableInput) {
+		logger := testutils.NewTestLogger()
+
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).
+			WithState(machinev1.ControlPlaneMachineSetStateActive).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetGroupVersionKind(machinev1.GroupVersion.WithKind("ControlPlaneMachineSet"))
+
+		annotations := map[string]string{}
+
+		if in.outsideMaintenanceWindow {
+			annotations[maintenanceWindowAnnotation] = "09:00-17:00"
+		}
+
+		if in.machineConfigPoolHeld {
+			annotations[machineConfigPoolGuardAnnotation] = "true"
+		}
+
+		if in.rollCancelled {
+			annotations[cancelRollAnnotation] = "true"
+		}
+
+		state := machineproviders.PersistedState{}
+
+		if in.awaitingStep {
+			annotations[stepAnnotation] = "1"
+			state.StepObserved = pointer.String("1")
+		}
+
+		if in.withinRolloutWindow {
+			annotations[rolloutWindowAnnotation] = "1h"
+			state.LastIndexCompletedTime = persistedTime(now.Add(-10 * time.Minute))
+		}
+
+		annotations[machineproviders.PersistedStateAnnotation] = persistedStateAnnotation(state)
+		cpms.SetAnnotations(annotations)
+
+		freeze := ""
+		if in.frozen {
+			freeze = "true"
+		}
+
+		Expect(k8sClient.Create(ctx, clusterVersionWithFreeze(freeze))).To(Succeed())
+		createMachineConfigPool(masterMachineConfigPoolName, metav1.ConditionTrue)
+
+		reconciler := &ControlPlaneMachineSetReconciler{
+			Client:    k8sClient,
+			Namespace: "test",
+			clock:     clocktesting.NewFakePassiveClock(now),
+		}
+
+		// The machines are already owned and indexed, so that no machine is patched once the freeze is lifted.
+		ownerReference := metav1.OwnerReference{
+			APIVersion: machinev1.GroupVersion.String(),
+			Kind:       "ControlPlaneMachineSet",
+			Name:       cpms.GetName(),
+			Controller: pointer.Bool(true),
+		}
+
+		machineInfos := map[int32][]machineproviders.MachineInfo{}
+
+		for i := int32(0); i < 3; i++ {
+			machineInfos[i] = []machineproviders.MachineInfo{
+				outdatedMachineBuilder.WithIndex(i).WithMachineName(fmt.Sprintf("machine-%d", i)).WithNodeName(fmt.Sprintf("node-%d", i)).
+					WithMachineOwnerReference(ownerReference).
+					WithMachineLabels(map[string]string{machineproviders.MachineIndexLabel: fmt.Sprintf("%d", i)}).Build(),
+			}
+		}
+
+		mockCtrl := gomock.NewController(GinkgoT())
+		mockMachineProvider := mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := reconciler.reconcileMachines(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(result.RequeueAfter).To(Equal(in.expectedRequeueAfter))
+		Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(
+			HaveField("Reason", Equal(in.expectedIdleReason)),
+		)
+	},
+		Entry("with every gate, the maintenance freeze takes precedence", gatesTableInput{
+			frozen:                   true,
+			outsideMaintenanceWindow: true,
+			machineConfigPoolHeld:    true,
+			rollCancelled:            true,
+			awaitingStep:             true,
+			withinRolloutWindow:      true,
+			expectedIdleReason:       reasonMaintenanceFreezeRequested,
+		}),
+		Entry("without a maintenance freeze, the maintenance window takes precedence", gatesTableInput{
+			outsideMaintenanceWindow: true,
+			machineConfigPoolHeld:    true,
+			rollCancelled:            true,
+			awaitingStep:             true,
+			withinRolloutWindow:      true,
+			expectedIdleReason:       reasonOutsideMaintenanceWindow,
+			expectedRequeueAfter:     15 * time.Hour,
+		}),
+		Entry("within the maintenance window, the machine config pool hold takes precedence", gatesTableInput{
+			machineConfigPoolHeld: true,
+			rollCancelled:         true,
+			awaitingStep:          true,
+			withinRolloutWindow:   true,
+			expectedIdleReason:    reasonMachineConfigPoolUpdating,
+			expectedRequeueAfter:  machineConfigPoolRecheckInterval,
+		}),
+		Entry("once the machine config pool is updated, the roll cancellation takes precedence", gatesTableInput{
+			rollCancelled:       true,
+			awaitingStep:        true,
+			withinRolloutWindow: true,
+			expectedIdleReason:  reasonCancelRequested,
+		}),
+		Entry("once the roll resumes, the step is awaited while the rollout window elapses", gatesTableInput{
+			awaitingStep:         true,
+			withinRolloutWindow:  true,
+			expectedIdleReason:   reasonAwaitingStep,
+			expectedRequeueAfter: 50 * time.Minute,
+		}),
+		Entry("with only the rollout window, no index starts its replacement until the window elapses", gatesTableInput{
+			withinRolloutWindow:  true,
+			expectedIdleReason:   reasonReplacingMachines,
+			expectedRequeueAfter: 50 * time.Minute,
+		}),
+	)
+})
@@ -0,0 +1,280 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// waitingForMaintenanceWindow is a log message used to inform the user that no Machine is being created or
+	// deleted because the maintenance window is closed.
+	waitingForMaintenanceWindow = "Deferring machine updates until the maintenance window opens"
+
+	// invalidMaintenanceWindow is a log message used to inform the user that no Machine is being created or
+	// deleted because the maintenance window annotation cannot be parsed.
+	invalidMaintenanceWindow = "Deferring machine updates until the maintenance window is corrected"
+
+	// minutesPerDay is the number of minutes in a day, used as the latest time at which a maintenance window may close.
+	minutesPerDay = 24 * 60
+)
+
+var (
+	// errInvalidMaintenanceWindow is used to denote that the maintenance window annotation cannot be parsed.
+	errInvalidMaintenanceWindow = errors.New("invalid maintenance window, expected [days] HH:MM-HH:MM [time zone]")
+)
+
+// maintenanceWindow is a recurring window of time within which Machines may be created or deleted.
+type maintenanceWindow struct {
+	// days are the days of the week on which the window opens, indexed by time.Weekday.
+	// A window that closes earlier in the day than it opens closes on the following day.
+	days [7]bool
+
+	// start and end are the times of day, in minutes after midnight, at which the window opens and closes.
+	start, end int
+
+	// location is the time zone in which the window is evaluated.
+	location *time.Location
+}
+
+// parseMaintenanceWindow parses the value of the maintenance window annotation.
+// The value is an optional list of days, such as "Mon-Fri" or "Sat,Sun", a time of day range, such as "22:00-04:00",
+// and an optional IANA time zone, such as "Europe/London". Without days, the window opens every day, and without a
+// time zone, the window is evaluated in UTC.
+func parseMaintenanceWindow(value string) (maintenanceWindow, error) {
+	window := maintenanceWindow{location: time.UTC}
+	fields := strings.Fields(value)
+
+	if len(fields) > 0 && !strings.Contains(fields[0], ":") {
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return maintenanceWindow{}, err
+		}
+
+		window.days = days
+		fields = fields[1:]
+	} else {
+		window.days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	if len(fields) == 0 || len(fields) > 2 {
+		return maintenanceWindow{}, fmt.Errorf("%w: %q", errInvalidMaintenanceWindow, value)
+	}
+
+	start, end, found := strings.Cut(fields[0], "-")
+	if !found {
+		return maintenanceWindow{}, fmt.Errorf("%w: %q is not a time of day range", errInvalidMaintenanceWindow, fields[0])
+	}
+
+	var err error
+
+	if window.start, err = parseTimeOfDay(start, minutesPerDay-1); err != nil {
+		return maintenanceWindow{}, err
+	}
+
+	if window.end, err = parseTimeOfDay(end, minutesPerDay); err != nil {
+		return maintenanceWindow{}, err
+	}
+
+	if len(fields) == 2 {
+		if window.location, err = time.LoadLocation(fields[1]); err != nil {
+			return maintenanceWindow{}, fmt.Errorf("%w: unknown time zone %q: %v", errInvalidMaintenanceWindow, fields[1], err)
+		}
+	}
+
+	return window, nil
+}
+
+// parseWeekdays parses a comma separated list of days, or ranges of days, such as "Mon-Fri,Sun".
+// A range that ends on an earlier day than it starts wraps around the end of the week.
+func parseWeekdays(value string) ([7]bool, error) {
+	days := [7]bool{}
+
+	for _, item := range strings.Split(value, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		if !isRange {
+			last = first
+		}
+
+		firstDay, ok := parseWeekday(first)
+		if !ok {
+			return days, fmt.Errorf("%w: unknown day %q", errInvalidMaintenanceWindow, first)
+		}
+
+		lastDay, ok := parseWeekday(last)
+		if !ok {
+			return days, fmt.Errorf("%w: unknown day %q", errInvalidMaintenanceWindow, last)
+		}
+
+		for day := firstDay; ; day = (day + 1) % 7 {
+			days[day] = true
+
+			if day == lastDay {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// parseWeekday parses the abbreviated name of a day of the week, such as "Mon", regardless of case.
+func parseWeekday(value string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(value, day.String()[:3]) {
+			return day, true
+		}
+	}
+
+	return 0, false
+}
+
+// parseTimeOfDay parses a time of day, such as "09:30", into the number of minutes after midnight.
+func parseTimeOfDay(value string, maximum int) (int, error) {
+	hours, minutes, found := strings.Cut(value, ":")
+	if !found {
+		return 0, fmt.Errorf("%w: %q is not a time of day", errInvalidMaintenanceWindow, value)
+	}
+
+	hour, hourErr := strconv.Atoi(hours)
+	minute, minuteErr := strconv.Atoi(minutes)
+
+	if hourErr != nil || minuteErr != nil || len(minutes) != 2 || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > maximum {
+		return 0, fmt.Errorf("%w: %q is not a time of day", errInvalidMaintenanceWindow, value)
+	}
+
+	return hour*60 + minute, nil
+}
+
+// occurrence returns the times at which the window opens and closes when it opens on the given day,
+// or false when the window does not open on that day.
+func (w maintenanceWindow) occurrence(year int, month time.Month, day int) (time.Time, time.Time, bool) {
+	opens := time.Date(year, month, day, 0, w.start, 0, 0, w.location)
+	if !w.days[opens.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+
+	closes := time.Date(year, month, day, 0, w.end, 0, 0, w.location)
+	if w.end <= w.start {
+		closes = time.Date(year, month, day+1, 0, w.end, 0, 0, w.location)
+	}
+
+	return opens, closes, true
+}
+
+// evaluate returns whether the window is open at the time given. When open, it also returns the time at which the
+// window closes, joining any occurrences that follow on without a gap. Otherwise, it returns the time at which the
+// window next opens.
+func (w maintenanceWindow) evaluate(now time.Time) (bool, time.Time) {
+	local := now.In(w.location)
+
+	// Start from the previous day, as a window that opened the day before may not yet have closed.
+	for offset := -1; offset <= 7; offset++ {
+		day := local.Day() + offset
+
+		opens, closes, ok := w.occurrence(local.Year(), local.Month(), day)
+		if !ok {
+			continue
+		}
+
+		if opens.After(now) {
+			return false, opens
+		}
+
+		if !now.Before(closes) {
+			continue
+		}
+
+		for next := day + 1; next <= day+7; next++ {
+			nextOpens, nextCloses, ok := w.occurrence(local.Year(), local.Month(), next)
+			if !ok {
+				continue
+			}
+
+			if nextOpens.After(closes) {
+				break
+			}
+
+			if nextCloses.After(closes) {
+				closes = nextCloses
+			}
+		}
+
+		return true, closes
+	}
+
+	// Only reached when the window does not open on any day, which parseMaintenanceWindow does not allow.
+	return false, time.Time{}
+}
+
+// reconcileMaintenanceWindow sets the MaintenanceWindow condition, and returns whether Machines may be created or
+// deleted, along with the time until the maintenance window next opens or closes, so that the reconcile can check
+// back in at that time.
+// When the maintenance window annotation cannot be parsed, no Machine is created or deleted until it is corrected,
+// as ignoring the annotation could replace Machines outside of the window that was intended.
+func (r *ControlPlaneMachineSetReconciler) reconcileMaintenanceWindow(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (bool, time.Duration) {
+	value, ok := cpms.GetAnnotations()[maintenanceWindowAnnotation]
+	if !ok {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMaintenanceWindow)
+
+		return true, 0
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionMaintenanceWindow,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cpms.Generation,
+	}
+
+	window, err := parseMaintenanceWindow(value)
+	if err != nil {
+		logger.Error(err, invalidMaintenanceWindow, "annotation", maintenanceWindowAnnotation)
+
+		condition.Reason = reasonInvalidMaintenanceWindow
+		condition.Message = fmt.Sprintf("No machines are created or deleted until the %s annotation is corrected: %v", maintenanceWindowAnnotation, err)
+		meta.SetStatusCondition(&cpms.Status.Conditions, condition)
+
+		return false, 0
+	}
+
+	now := r.getClock().Now()
+	open, boundary := window.evaluate(now)
+
+	if open {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = reasonWithinMaintenanceWindow
+		condition.Message = fmt.Sprintf("The maintenance window is open until %s", boundary.Format(time.RFC3339))
+	} else {
+		logger.V(2).WithValues("opens", boundary.Format(time.RFC3339)).Info(waitingForMaintenanceWindow)
+
+		condition.Reason = reasonOutsideMaintenanceWindow
+		condition.Message = fmt.Sprintf("No machines are created or deleted until the next maintenance window opens at %s", boundary.Format(time.RFC3339))
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, condition)
+
+	return open, boundary.Sub(now)
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var _ = Describe("maintenanceWindow", func() {
+	type evaluateTableInput struct {
+		value            string
+		now              time.Time
+		expectedOpen     bool
+		expectedBoundary time.Time
+	}
+
+	// June 1st 2023 is a Thursday.
+	thursdayAt := func(hour, minute int) time.Time {
+		return time.Date(2023, time.June, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	DescribeTable("evaluates whether the window is open", func(in evaluateTableInput) {
+		window, err := parseMaintenanceWindow(in.value)
+		Expect(err).ToNot(HaveOccurred())
+
+		open, boundary := window.evaluate(in.now)
+		Expect(open).To(Equal(in.expectedOpen))
+		Expect(boundary).To(BeTemporally("==", in.expectedBoundary))
+	},
+		Entry("within a daily window", evaluateTableInput{
+			value:            "09:00-17:00",
+			now:              thursdayAt(12, 0),
+			expectedOpen:     true,
+			expectedBoundary: thursdayAt(17, 0),
+		}),
+		Entry("before a daily window", evaluateTableInput{
+			value:            "09:00-17:00",
+			now:              thursdayAt(8, 30),
+			expectedBoundary: thursdayAt(9, 0),
+		}),
+		Entry("after a daily window", evaluateTableInput{
+			value:            "09:00-17:00",
+			now:              thursdayAt(17, 0),
+			expectedBoundary: thursdayAt(9, 0).AddDate(0, 0, 1),
+		}),
+		Entry("within an overnight window that opened the day before", evaluateTableInput{
+			value:            "22:00-04:00",
+			now:              thursdayAt(1, 0),
+			expectedOpen:     true,
+			expectedBoundary: thursdayAt(4, 0),
+		}),
+		Entry("on a day that the window does not open", evaluateTableInput{
+			value:            "Mon-Wed 09:00-17:00",
+			now:              thursdayAt(12, 0),
+			expectedBoundary: thursdayAt(9, 0).AddDate(0, 0, 4),
+		}),
+		Entry("within a window that wraps around the end of the week", evaluateTableInput{
+			value:            "Thu-Mon,wed 09:00-17:00",
+			now:              thursdayAt(12, 0),
+			expectedOpen:     true,
+			expectedBoundary: thursdayAt(17, 0),
+		}),
+		Entry("within windows that follow on from each other", evaluateTableInput{
+			value:            "Sat,Sun 00:00-24:00",
+			now:              thursdayAt(12, 0).AddDate(0, 0, 2),
+			expectedOpen:     true,
+			expectedBoundary: thursdayAt(0, 0).AddDate(0, 0, 4),
+		}),
+		Entry("within a window in another time zone", evaluateTableInput{
+			value:            "09:00-17:00 America/New_York",
+			now:              thursdayAt(14, 0),
+			expectedOpen:     true,
+			expectedBoundary: thursdayAt(21, 0),
+		}),
+		Entry("before a window in another time zone", evaluateTableInput{
+			value:            "09:00-17:00 America/New_York",
+			now:              thursdayAt(12, 0),
+			expectedBoundary: thursdayAt(13, 0),
+		}),
+	)
+
+	DescribeTable("rejects an invalid window", func(value string) {
+		_, err := parseMaintenanceWindow(value)
+		Expect(err).To(MatchError(errInvalidMaintenanceWindow))
+	},
+		Entry("with an empty value", ""),
+		Entry("without a time range", "Mon-Fri"),
+		Entry("with an unknown day", "Mon-Fry 09:00-17:00"),
+		Entry("with an invalid time", "09:00-25:00"),
+		Entry("with a time without minutes", "9-17"),
+		Entry("with an unknown time zone", "09:00-17:00 Mars/Olympus_Mons"),
+		Entry("with too many fields", "Mon 09:00-17:00 UTC extra"),
+	)
+})
+
+var _ = Describe("reconcileMaintenanceWindow", func() {
+	var logger testutils.TestLogger
+	var fakeClock *clocktesting.FakePassiveClock
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var open bool
+	var requeueAfter time.Duration
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		fakeClock = clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+			clock:     fakeClock,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		cpms.SetAnnotations(map[string]string{maintenanceWindowAnnotation: "Mon-Fri 09:00-17:00 Europe/London"})
+	})
+
+	JustBeforeEach(func() {
+		open, requeueAfter = reconciler.reconcileMaintenanceWindow(logger.Logger(), cpms)
+	})
+
+	Context("when inside the maintenance window", func() {
+		It("allows machines to be created and deleted", func() {
+			Expect(open).To(BeTrue())
+		})
+
+		It("checks back in when the window closes", func() {
+			// 17:00 in London is 16:00 UTC during the summer.
+			Expect(requeueAfter).To(Equal(4 * time.Hour))
+		})
+
+		It("reports when the window closes", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMaintenanceWindow)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonWithinMaintenanceWindow)),
+				HaveField("Message", Equal("The maintenance window is open until 2023-06-01T17:00:00+01:00")),
+				HaveField("ObservedGeneration", Equal(int64(1))),
+			))
+		})
+	})
+
+	Context("when outside the maintenance window", func() {
+		BeforeEach(func() {
+			// Friday at 18:00 UTC, so the window next opens on Monday.
+			fakeClock.SetTime(time.Date(2023, time.June, 2, 18, 0, 0, 0, time.UTC))
+		})
+
+		It("defers creating and deleting machines", func() {
+			Expect(open).To(BeFalse())
+		})
+
+		It("checks back in when the window opens", func() {
+			Expect(requeueAfter).To(Equal(2*24*time.Hour + 14*time.Hour))
+		})
+
+		It("reports when the window next opens", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMaintenanceWindow)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionFalse)),
+				HaveField("Reason", Equal(reasonOutsideMaintenanceWindow)),
+				HaveField("Message", Equal("No machines are created or deleted until the next maintenance window opens at 2023-06-05T09:00:00+01:00")),
+			))
+		})
+
+		It("logs that machine updates are deferred", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level:         2,
+				KeysAndValues: []interface{}{"opens", "2023-06-05T09:00:00+01:00"},
+				Message:       waitingForMaintenanceWindow,
+			}))
+		})
+
+		It("reports that the control plane machine set is idle", func() {
+			Expect(reconcileIdle(cpms, *cpms.Spec.Replicas, nil)).To(Succeed())
+
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonOutsideMaintenanceWindow)),
+			))
+		})
+	})
+
+	Context("when the maintenance window is invalid", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{maintenanceWindowAnnotation: "weekdays 09:00-17:00"})
+		})
+
+		It("defers creating and deleting machines", func() {
+			Expect(open).To(BeFalse())
+			Expect(requeueAfter).To(BeZero())
+		})
+
+		It("reports that the window is invalid", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMaintenanceWindow)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionFalse)),
+				HaveField("Reason", Equal(reasonInvalidMaintenanceWindow)),
+			))
+		})
+	})
+
+	Context("when the maintenance window is removed", func() {
+		BeforeEach(func() {
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionMaintenanceWindow,
+				Status: metav1.ConditionFalse,
+				Reason: reasonOutsideMaintenanceWindow,
+			})
+
+			cpms.SetAnnotations(nil)
+		})
+
+		It("allows machines to be created and deleted", func() {
+			Expect(open).To(BeTrue())
+			Expect(requeueAfter).To(BeZero())
+		})
+
+		It("removes the condition", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMaintenanceWindow)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// machinesUnmatchedFailureDomain is the number of control plane Machines observed in a failure domain
+	// that is not listed in the ControlPlaneMachineSet failure domains.
+	machinesUnmatchedFailureDomain = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "cpms_machines_unmatched_failure_domain",
+		Help: "Number of control plane machines in a failure domain not configured on the control plane machine set.",
+	})
+
+	// reconcileErrors is the number of failed reconciles of the ControlPlaneMachineSet, by the category of the error.
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cpms_reconcile_errors_total",
+		Help: "Number of failed reconciles of the control plane machine set, by the category of the error.",
+	}, []string{"category"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(machinesUnmatchedFailureDomain, reconcileErrors)
+}
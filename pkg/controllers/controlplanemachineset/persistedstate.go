@@ -0,0 +1,77 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updatePersistedState applies the changes made by mutate to the state recorded on the ControlPlaneMachineSet.
+// The annotation is only replaced when the state changes, and is removed once no state remains.
+// An invalid persisted state annotation is left in place, so that it can be corrected.
+func updatePersistedState(cpms *machinev1.ControlPlaneMachineSet, mutate func(state *machineproviders.PersistedState)) error {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return fmt.Errorf("error reading persisted state: %w", err)
+	}
+
+	mutate(&state)
+
+	annotations := cpms.GetAnnotations()
+
+	if reflect.DeepEqual(state, machineproviders.PersistedState{}) {
+		if _, ok := annotations[machineproviders.PersistedStateAnnotation]; ok {
+			delete(annotations, machineproviders.PersistedStateAnnotation)
+			cpms.SetAnnotations(annotations)
+		}
+
+		return nil
+	}
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error encoding %s annotation: %w", machineproviders.PersistedStateAnnotation, err)
+	}
+
+	if annotations[machineproviders.PersistedStateAnnotation] == string(value) {
+		return nil
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[machineproviders.PersistedStateAnnotation] = string(value)
+	cpms.SetAnnotations(annotations)
+
+	return nil
+}
+
+// persistedTime returns the time to be recorded in the persisted state.
+// Times are recorded in UTC, to the second.
+func persistedTime(t time.Time) *metav1.Time {
+	recorded := metav1.NewTime(t.UTC())
+
+	return &recorded
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// persistedStateAnnotation encodes the persisted state, so that it can be set alongside the annotations set by users.
+func persistedStateAnnotation(state machineproviders.PersistedState) string {
+	value, err := json.Marshal(state)
+	Expect(err).ToNot(HaveOccurred())
+
+	return string(value)
+}
+
+// persistedTestTime parses the RFC3339 time, to be recorded in the persisted state.
+func persistedTestTime(value string) *metav1.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	Expect(err).ToNot(HaveOccurred())
+
+	return persistedTime(t)
+}
+
+// bePersistedTime matches a time recorded in the persisted state against the RFC3339 time.
+func bePersistedTime(value string) types.GomegaMatcher {
+	return HaveValue(HaveField("Time", BeTemporally("==", persistedTestTime(value).Time)))
+}
+
+var _ = Describe("persistedState", func() {
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	BeforeEach(func() {
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+	})
+
+	Context("when no state has been recorded", func() {
+		It("returns an empty state", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(Equal(machineproviders.PersistedState{}))
+		})
+
+		It("does not set the annotation when no state is recorded", func() {
+			Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+				state.LastProgressTime = nil
+			})).To(Succeed())
+
+			Expect(cpms.GetAnnotations()).To(BeEmpty())
+		})
+	})
+
+	Context("when state is recorded", func() {
+		BeforeEach(func() {
+			Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+				state.LastProgressTime = persistedTime(time.Date(2023, time.June, 1, 12, 0, 0, 500, time.UTC))
+				state.StepObserved = pointer.String("")
+			})).To(Succeed())
+		})
+
+		It("records the state in a single annotation, with times to the second", func() {
+			Expect(cpms.GetAnnotations()).To(Equal(map[string]string{
+				machineproviders.PersistedStateAnnotation: `{"lastProgressTime":"2023-06-01T12:00:00Z","stepObserved":""}`,
+			}))
+		})
+
+		It("returns the recorded state", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(SatisfyAll(
+				HaveField("LastProgressTime", bePersistedTime("2023-06-01T12:00:00Z")),
+				HaveField("StepObserved", HaveValue(BeEmpty())),
+			))
+		})
+
+		It("removes the annotation once no state remains", func() {
+			Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+				state.LastProgressTime = nil
+				state.StepObserved = nil
+			})).To(Succeed())
+
+			Expect(cpms.GetAnnotations()).ToNot(HaveKey(machineproviders.PersistedStateAnnotation))
+		})
+	})
+
+	Context("when the annotation is invalid", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{machineproviders.PersistedStateAnnotation: "invalid"})
+		})
+
+		It("returns an error", func() {
+			_, err := machineproviders.GetPersistedState(cpms)
+			Expect(err).To(MatchError(ContainSubstring("error parsing %s annotation", machineproviders.PersistedStateAnnotation)))
+		})
+
+		It("does not replace the annotation", func() {
+			Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+				state.MismatchedIndexMachine = "machine-1"
+			})).ToNot(Succeed())
+
+			Expect(cpms.GetAnnotations()).To(HaveKeyWithValue(machineproviders.PersistedStateAnnotation, "invalid"))
+		})
+
+		It("does not record a force roll", func() {
+			cpms.Annotations[forceRollAnnotation] = "token-1"
+
+			reconciler := &ControlPlaneMachineSetReconciler{}
+			Expect(reconciler.reconcileForceRoll(testutils.NewTestLogger().Logger(), cpms)).ToNot(Succeed())
+
+			Expect(cpms.GetAnnotations()).To(HaveKeyWithValue(machineproviders.PersistedStateAnnotation, "invalid"))
+		})
+
+		Context("when checking the persisted state", func() {
+			var ok bool
+
+			BeforeEach(func() {
+				reconciler := &ControlPlaneMachineSetReconciler{}
+				ok = reconciler.checkPersistedState(testutils.NewTestLogger().Logger(), cpms)
+			})
+
+			It("does not allow operations to continue", func() {
+				Expect(ok).To(BeFalse())
+			})
+
+			It("marks the control plane machine set as degraded", func() {
+				Expect(cpms.Status.Conditions).To(ContainElement(SatisfyAll(
+					HaveField("Type", Equal(conditionDegraded)),
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonInvalidPersistedState)),
+				)))
+			})
+		})
+	})
+
+	Context("when checking a valid persisted state", func() {
+		It("allows operations to continue", func() {
+			Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+				state.MismatchedIndexMachine = "machine-1"
+			})).To(Succeed())
+
+			reconciler := &ControlPlaneMachineSetReconciler{}
+			Expect(reconciler.checkPersistedState(testutils.NewTestLogger().Logger(), cpms)).To(BeTrue())
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+})
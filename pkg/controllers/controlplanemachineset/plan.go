@@ -0,0 +1,185 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+)
+
+// UpdatePlanActionType is the type of an action that the ControlPlaneMachineSet takes on a Machine.
+type UpdatePlanActionType string
+
+const (
+	// UpdatePlanActionCreate denotes that a Machine is created for an index.
+	UpdatePlanActionCreate UpdatePlanActionType = "Create"
+
+	// UpdatePlanActionDelete denotes that a Machine is deleted from an index.
+	UpdatePlanActionDelete UpdatePlanActionType = "Delete"
+)
+
+// UpdatePlanAction describes a single action that the ControlPlaneMachineSet takes on a Machine.
+// The update plan annotation holds a JSON list of these actions, in the order in which they are to be taken.
+type UpdatePlanAction struct {
+	// Action is the type of the action.
+	Action UpdatePlanActionType `json:"action"`
+
+	// Index is the index of the Machine that is created or deleted.
+	Index int32 `json:"index"`
+
+	// Machine is the name of the deleted Machine.
+	// The name of a created Machine is chosen by the machine provider, so is not known.
+	Machine string `json:"machine,omitempty"`
+}
+
+// computeUpdatePlan computes, in order, the actions that the ControlPlaneMachineSet intends to take to bring each
+// index up to date, from the Machines observed at the start of the reconcile. Indexes are updated in order.
+// With the RollingUpdate strategy, each outdated Machine is replaced before it is deleted.
+// With the OnDelete strategy, a replacement is only created once the outdated Machine has been deleted, so outdated
+// Machines that have not been deleted are not planned.
+// The actions taken during a reconcile are the first actions of the plan, though some may be held back by the
+// safety checks of the update strategy, for example, while a replacement is not yet ready.
+func computeUpdatePlan(cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) []UpdatePlanAction {
+	plan := []UpdatePlanAction{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfos) {
+		idx := indexedMachineInfos.index
+		machines := indexedMachineInfos.machineInfos
+
+		outdated := nonDeletedMachines(needReplacementMachines(machines))
+		replaced := hasAny(nonDeletedMachines(upToDateMachines(machines)))
+
+		if cpms.Spec.Strategy.Type == machinev1.OnDelete {
+			if !replaced && isEmpty(outdated) {
+				plan = append(plan, UpdatePlanAction{Action: UpdatePlanActionCreate, Index: idx})
+			}
+
+			continue
+		}
+
+		if !replaced {
+			plan = append(plan, UpdatePlanAction{Action: UpdatePlanActionCreate, Index: idx})
+		}
+
+		for _, machine := range outdated {
+			plan = append(plan, UpdatePlanAction{
+				Action:  UpdatePlanActionDelete,
+				Index:   idx,
+				Machine: machine.MachineRef.ObjectMeta.Name,
+			})
+		}
+	}
+
+	return plan
+}
+
+// updatePlanRecorder wraps a machine provider and records the Machines created and deleted through it,
+// so that the actions audited are exactly those that were taken.
+type updatePlanRecorder struct {
+	machineproviders.MachineProvider
+
+	// machineIndexes maps the name of each known Machine to its index, as the machine provider
+	// only receives a reference to the Machine to delete.
+	machineIndexes map[string]int32
+
+	actions []UpdatePlanAction
+}
+
+// newUpdatePlanRecorder creates a new update plan recorder for the Machines within the machineInfos.
+func newUpdatePlanRecorder(machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) *updatePlanRecorder {
+	machineIndexes := make(map[string]int32)
+
+	for idx, machines := range machineInfos {
+		for _, machine := range machines {
+			if machine.MachineRef != nil {
+				machineIndexes[machine.MachineRef.ObjectMeta.Name] = idx
+			}
+		}
+	}
+
+	return &updatePlanRecorder{
+		MachineProvider: machineProvider,
+		machineIndexes:  machineIndexes,
+		actions:         []UpdatePlanAction{},
+	}
+}
+
+// CreateMachine creates a Machine for the index and records the action when the Machine was created.
+func (u *updatePlanRecorder) CreateMachine(ctx context.Context, logger logr.Logger, idx int32) (string, error) {
+	machineName, err := u.MachineProvider.CreateMachine(ctx, logger, idx)
+	if err != nil {
+		return "", machineProviderError{err: err}
+	}
+
+	u.actions = append(u.actions, UpdatePlanAction{Action: UpdatePlanActionCreate, Index: idx})
+
+	return machineName, nil
+}
+
+// DeleteMachine deletes the Machine and records the action when the Machine was deleted.
+func (u *updatePlanRecorder) DeleteMachine(ctx context.Context, logger logr.Logger, machineRef *machineproviders.ObjectRef) error {
+	if err := u.MachineProvider.DeleteMachine(ctx, logger, machineRef); err != nil {
+		return machineProviderError{err: err}
+	}
+
+	u.actions = append(u.actions, UpdatePlanAction{
+		Action:  UpdatePlanActionDelete,
+		Index:   u.machineIndexes[machineRef.ObjectMeta.Name],
+		Machine: machineRef.ObjectMeta.Name,
+	})
+
+	return nil
+}
+
+// setUpdatePlan publishes the actions that the ControlPlaneMachineSet intends to take in the update plan annotation.
+// The annotation is only replaced when the plan changes, and is removed when no action remains.
+func setUpdatePlan(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, actions []UpdatePlanAction) {
+	annotations := cpms.GetAnnotations()
+
+	if len(actions) == 0 {
+		if _, ok := annotations[updatePlanAnnotation]; ok {
+			delete(annotations, updatePlanAnnotation)
+			cpms.SetAnnotations(annotations)
+		}
+
+		return
+	}
+
+	plan, err := json.Marshal(actions)
+	if err != nil {
+		// The actions only contain strings and integers, so this should never happen.
+		logger.Error(fmt.Errorf("error marshalling update plan: %w", err), "Unable to publish update plan")
+
+		return
+	}
+
+	if annotations[updatePlanAnnotation] == string(plan) {
+		return
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[updatePlanAnnotation] = string(plan)
+	cpms.SetAnnotations(annotations)
+}
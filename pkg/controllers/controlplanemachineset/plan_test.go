@@ -0,0 +1,238 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("updatePlanRecorder", func() {
+	var logger testutils.TestLogger
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+	var recorder *updatePlanRecorder
+
+	replacedMachine1 := outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {
+			replacedMachine1,
+			updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+		},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		recorder = newUpdatePlanRecorder(mockMachineProvider, machineInfos)
+	})
+
+	Context("when reconciling a rolling update with a ready replacement", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine1.MachineRef).Return(nil).Times(1)
+
+			reconciler := &ControlPlaneMachineSetReconciler{Namespace: "test"}
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, recorder, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("records the deletion of the replaced machine", func() {
+			Expect(recorder.actions).To(Equal([]UpdatePlanAction{
+				{Action: UpdatePlanActionDelete, Index: 1, Machine: "machine-1"},
+			}))
+		})
+
+		It("takes the first actions of the update plan", func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+			plan := computeUpdatePlan(cpms, machineInfos)
+
+			Expect(recorder.actions).ToNot(BeEmpty())
+			Expect(plan[:len(recorder.actions)]).To(Equal(recorder.actions))
+		})
+	})
+
+	Context("when the machine provider creates and deletes machines", func() {
+		BeforeEach(func() {
+			gomock.InOrder(
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("machine-replacement-1", nil).Times(1),
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine1.MachineRef).Return(nil).Times(1),
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return("machine-replacement-2", nil).Times(1),
+			)
+
+			Expect(recorder.CreateMachine(ctx, logger.Logger(), 1)).To(Equal("machine-replacement-1"))
+			Expect(recorder.DeleteMachine(ctx, logger.Logger(), replacedMachine1.MachineRef)).To(Succeed())
+			Expect(recorder.CreateMachine(ctx, logger.Logger(), 2)).To(Equal("machine-replacement-2"))
+		})
+
+		It("records the actions in the order in which they were taken", func() {
+			Expect(recorder.actions).To(Equal([]UpdatePlanAction{
+				{Action: UpdatePlanActionCreate, Index: 1},
+				{Action: UpdatePlanActionDelete, Index: 1, Machine: "machine-1"},
+				{Action: UpdatePlanActionCreate, Index: 2},
+			}))
+		})
+	})
+
+	Context("when the machine provider fails to create a machine", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", errors.New("create failed")).Times(1)
+
+			_, err := recorder.CreateMachine(ctx, logger.Logger(), 1)
+			Expect(err).To(MatchError("create failed"))
+		})
+
+		It("does not record an action", func() {
+			Expect(recorder.actions).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("computeUpdatePlan", func() {
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {
+			outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build(),
+			updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithReady(false).Build(),
+		},
+		2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		3: {outdatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").WithMachineDeletionTimestamp(metav1.Now()).Build()},
+		4: {},
+	}
+
+	It("plans each replacement, by index, with the RollingUpdate strategy", func() {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(5).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		Expect(computeUpdatePlan(cpms, machineInfos)).To(Equal([]UpdatePlanAction{
+			{Action: UpdatePlanActionDelete, Index: 1, Machine: "machine-1"},
+			{Action: UpdatePlanActionCreate, Index: 2},
+			{Action: UpdatePlanActionDelete, Index: 2, Machine: "machine-2"},
+			{Action: UpdatePlanActionCreate, Index: 3},
+			{Action: UpdatePlanActionCreate, Index: 4},
+		}))
+	})
+
+	It("only plans replacements for deleted machines with the OnDelete strategy", func() {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(5).WithStrategyType(machinev1.OnDelete).Build()
+
+		Expect(computeUpdatePlan(cpms, machineInfos)).To(Equal([]UpdatePlanAction{
+			{Action: UpdatePlanActionCreate, Index: 3},
+			{Action: UpdatePlanActionCreate, Index: 4},
+		}))
+	})
+
+	It("plans no action once every index is up to date", func() {
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		Expect(computeUpdatePlan(cpms, map[int32][]machineproviders.MachineInfo{
+			0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+			1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("setUpdatePlan", func() {
+	var logger testutils.TestLogger
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+	})
+
+	Context("with actions", func() {
+		BeforeEach(func() {
+			setUpdatePlan(logger.Logger(), cpms, []UpdatePlanAction{
+				{Action: UpdatePlanActionCreate, Index: 0},
+				{Action: UpdatePlanActionDelete, Index: 1, Machine: "machine-1"},
+			})
+		})
+
+		It("publishes the actions in the update plan annotation", func() {
+			Expect(cpms.GetAnnotations()).To(HaveKeyWithValue(updatePlanAnnotation,
+				`[{"action":"Create","index":0},{"action":"Delete","index":1,"machine":"machine-1"}]`,
+			))
+		})
+
+		Context("and a later reconcile computes the same plan", func() {
+			var annotations map[string]string
+
+			BeforeEach(func() {
+				annotations = cpms.GetAnnotations()
+
+				setUpdatePlan(logger.Logger(), cpms, []UpdatePlanAction{
+					{Action: UpdatePlanActionCreate, Index: 0},
+					{Action: UpdatePlanActionDelete, Index: 1, Machine: "machine-1"},
+				})
+			})
+
+			It("does not replace the update plan annotation", func() {
+				Expect(cpms.GetAnnotations()).To(Equal(annotations))
+			})
+		})
+
+		Context("and a later reconcile computes a different plan", func() {
+			BeforeEach(func() {
+				setUpdatePlan(logger.Logger(), cpms, []UpdatePlanAction{
+					{Action: UpdatePlanActionDelete, Index: 1, Machine: "machine-1"},
+				})
+			})
+
+			It("replaces the update plan annotation", func() {
+				Expect(cpms.GetAnnotations()).To(HaveKeyWithValue(updatePlanAnnotation, `[{"action":"Delete","index":1,"machine":"machine-1"}]`))
+			})
+		})
+
+		Context("and no action remains", func() {
+			BeforeEach(func() {
+				setUpdatePlan(logger.Logger(), cpms, nil)
+			})
+
+			It("removes the update plan annotation", func() {
+				Expect(cpms.GetAnnotations()).ToNot(HaveKey(updatePlanAnnotation))
+			})
+		})
+	})
+
+	Context("without actions", func() {
+		BeforeEach(func() {
+			setUpdatePlan(logger.Logger(), cpms, nil)
+		})
+
+		It("does not set the update plan annotation", func() {
+			Expect(cpms.GetAnnotations()).ToNot(HaveKey(updatePlanAnnotation))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"strconv"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+)
+
+const (
+	// waitingForUnprotectedIndexes is a log message used to inform the user that no replacement is being created for
+	// the protected index, because another index has not yet been updated, or is not yet ready.
+	waitingForUnprotectedIndexes = "Waiting for every other index to be updated and ready before replacing the protected index"
+)
+
+// getProtectedIndex returns the index that a RollingUpdate replaces last, if one has been configured.
+func getProtectedIndex(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (int32, bool) {
+	value, ok := cpms.GetAnnotations()[protectedIndexAnnotation]
+	if !ok {
+		return 0, false
+	}
+
+	idx, err := strconv.ParseUint(value, 10, 31)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid protected index", "annotation", protectedIndexAnnotation, "value", value)
+
+		return 0, false
+	}
+
+	return int32(idx), true
+}
+
+// holdForProtectedIndex returns true when the index is the protected index, and so must not start its replacement
+// until every other index has a single Machine that is ready and up to date. This means the protected index is
+// always the last to be replaced, and is never replaced while another index has reduced redundancy.
+func holdForProtectedIndex(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, idx int32, sortedIndexedMs []indexToMachineInfos) bool {
+	protectedIdx, ok := getProtectedIndex(logger, cpms)
+	if !ok || protectedIdx != idx {
+		return false
+	}
+
+	for _, indexToMachines := range sortedIndexedMs {
+		if indexToMachines.index == idx {
+			continue
+		}
+
+		machines := indexToMachines.machineInfos
+
+		if len(machines) != 1 || hasAny(needReplacementMachines(machines)) || hasAny(nonReadyMachines(machines)) {
+			logger.V(2).WithValues("index", idx, "waitingOnIndex", indexToMachines.index).Info(waitingForUnprotectedIndexes)
+
+			return true
+		}
+	}
+
+	return false
+}
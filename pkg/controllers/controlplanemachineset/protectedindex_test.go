@@ -0,0 +1,166 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+)
+
+var _ = Describe("reconcileMachineUpdates with a protected index", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	pendingMachine := func(idx int32, name string) machineproviders.MachineInfo {
+		return updatedMachineBuilder.WithIndex(idx).WithMachineName(name).WithReady(false).Build()
+	}
+
+	// expectReplacement expects a replacement Machine to be created for the index, once no existing replacement
+	// is found by the uncached machine provider.
+	expectReplacement := func(idx int32, machineInfos map[int32][]machineproviders.MachineInfo) {
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), idx).Return("", nil).Times(1)
+	}
+
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	waitingOnIndexLog := func(waitingOnIndex int32) testutils.LogEntry {
+		return testutils.LogEntry{
+			Level: 2,
+			KeysAndValues: []interface{}{
+				"updateStrategy", machinev1.RollingUpdate,
+				"index", int32(0),
+				"waitingOnIndex", waitingOnIndex,
+			},
+			Message: waitingForUnprotectedIndexes,
+		}
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{protectedIndexAnnotation: "0"})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the rollout starts", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachine(0, "machine-0")},
+				1: {outdatedMachine(1, "machine-1")},
+				2: {outdatedMachine(2, "machine-2")},
+			}
+
+			expectReplacement(1, machineInfos)
+			reconcileUpdates(machineInfos)
+		})
+
+		It("logs that the protected index is waiting while the next index is replaced", func() {
+			Expect(logger.Entries()).To(ContainElement(waitingOnIndexLog(1)))
+		})
+	})
+
+	Context("when another index is still being replaced", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachine(0, "machine-0")},
+				1: {updatedMachine(1, "machine-replacement-1")},
+				2: {outdatedMachine(2, "machine-2"), pendingMachine(2, "machine-replacement-2")},
+			})
+		})
+
+		It("logs that the protected index is waiting for the index being replaced", func() {
+			Expect(logger.Entries()).To(ContainElement(waitingOnIndexLog(2)))
+		})
+	})
+
+	Context("when every other index has been replaced", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachine(0, "machine-0")},
+				1: {updatedMachine(1, "machine-replacement-1")},
+				2: {updatedMachine(2, "machine-replacement-2")},
+			}
+
+			expectReplacement(0, machineInfos)
+			reconcileUpdates(machineInfos)
+		})
+
+		It("replaces the protected index last", func() {
+			Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", waitingForUnprotectedIndexes)))
+		})
+	})
+
+	Context("when another index has a machine that is not ready", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachine(0, "machine-0")},
+				1: {updatedMachine(1, "machine-replacement-1")},
+				2: {pendingMachine(2, "machine-replacement-2")},
+			})
+		})
+
+		It("logs that the protected index is waiting for the machine to become ready", func() {
+			Expect(logger.Entries()).To(ContainElement(waitingOnIndexLog(2)))
+		})
+	})
+
+	Context("with an invalid protected index", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{protectedIndexAnnotation: "first"})
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachine(0, "machine-0")},
+				1: {outdatedMachine(1, "machine-1")},
+				2: {outdatedMachine(2, "machine-2")},
+			}
+
+			expectReplacement(0, machineInfos)
+			reconcileUpdates(machineInfos)
+		})
+
+		It("replaces the indexes in order", func() {
+			Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", waitingForUnprotectedIndexes)))
+		})
+	})
+})
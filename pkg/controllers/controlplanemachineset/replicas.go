@@ -0,0 +1,217 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// replicasPolicyReplicasKey is the key, within the replicas policy ConfigMap, that holds the desired
+	// number of Control Plane Machines.
+	replicasPolicyReplicasKey = "replicas"
+)
+
+var (
+	// errInvalidReplicasPolicy is used to denote that the replicas policy ConfigMap does not hold a valid number of replicas.
+	errInvalidReplicasPolicy = errors.New("invalid replicas policy")
+
+	// allowedPolicyReplicas are the numbers of Control Plane Machines that a replicas policy may request.
+	// These match the values allowed for spec.replicas, so that the policy cannot grow the control plane beyond
+	// what the API would accept.
+	allowedPolicyReplicas = []int64{3, 5}
+)
+
+// replicasPolicyTracker records the replicas policy ConfigMap last referenced by the
+// ControlPlaneMachineSet, so that changes to the ConfigMap trigger a reconcile.
+// It is shared by concurrent reconciles and the watch predicates, so access is guarded by a lock.
+type replicasPolicyTracker struct {
+	lock sync.Mutex
+
+	// key is the namespace and name of the referenced ConfigMap, empty when no ConfigMap is referenced.
+	key client.ObjectKey
+}
+
+// record records the ConfigMap referenced by the ControlPlaneMachineSet.
+func (t *replicasPolicyTracker) record(key client.ObjectKey) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.key = key
+}
+
+// isReferenced returns true when the object is the ConfigMap last referenced by the ControlPlaneMachineSet.
+func (t *replicasPolicyTracker) isReferenced(obj client.Object) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.key.Name != "" && client.ObjectKeyFromObject(obj) == t.key
+}
+
+// resolveReplicas returns the desired number of Control Plane Machines for the ControlPlaneMachineSet.
+// When the replicas policy annotation references a ConfigMap, the desired replicas are read from it in place of
+// spec.replicas, which is left unchanged, and are published in the ReplicasPolicy condition.
+// When the ConfigMap is missing, or does not hold a valid number of replicas, the ControlPlaneMachineSet is marked
+// as degraded and nil is returned, so that no action is taken on the Machines until the policy has been fixed.
+func (r *ControlPlaneMachineSetReconciler) resolveReplicas(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (*int32, error) {
+	name := cpms.GetAnnotations()[machineproviders.ReplicasPolicyAnnotation]
+	if name == "" {
+		r.replicasPolicy.record(client.ObjectKey{})
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionReplicasPolicy)
+
+		if cpms.Spec.Replicas == nil {
+			return nil, errReplicasRequired
+		}
+
+		return pointer.Int32(*cpms.Spec.Replicas), nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	configMapKey := client.ObjectKey{Namespace: cpms.Namespace, Name: name}
+	r.replicasPolicy.record(configMapKey)
+
+	if err := r.Get(ctx, configMapKey, configMap); apierrors.IsNotFound(err) {
+		setReplicasPolicyDegraded(logger, cpms, reasonReplicasPolicyNotFound,
+			fmt.Sprintf("Replicas policy config map %s does not exist", configMapKey))
+
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get replicas policy config map %s: %w", configMapKey, err)
+	}
+
+	replicas, err := parsePolicyReplicas(configMap.Data)
+	if err != nil {
+		setReplicasPolicyDegraded(logger, cpms, reasonInvalidReplicasPolicy,
+			fmt.Sprintf("Replicas policy config map %s is invalid: %v", configMapKey, err))
+
+		return nil, nil
+	}
+
+	if cpms.Spec.Replicas == nil || *cpms.Spec.Replicas != replicas {
+		logger.V(2).Info("Using desired replicas from replicas policy", "configMap", configMapKey.String(), "replicas", replicas)
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionReplicasPolicy,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonReplicasPolicyApplied,
+		Message:            fmt.Sprintf("Desired replicas are %d, as set by config map %s", replicas, configMapKey),
+		ObservedGeneration: cpms.Generation,
+	})
+
+	return pointer.Int32(replicas), nil
+}
+
+// checkReplicasPolicyMatchesIndexes checks that the desired replicas set by the replicas policy do not resize the
+// control plane. There is no scale down path, excess indexes must be removed manually, and growing the control plane
+// would add etcd members outside of any supported procedure, so the policy may only differ from spec.replicas when it
+// matches the number of indexes that already have Machines.
+func (r *ControlPlaneMachineSetReconciler) checkReplicasPolicyMatchesIndexes(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, sortedIndexedMs []indexToMachineInfos) bool {
+	if !meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionReplicasPolicy) {
+		// No replicas policy is referenced, the desired replicas are spec.replicas.
+		return true
+	}
+
+	if cpms.Spec.Replicas != nil && *cpms.Spec.Replicas == replicas {
+		return true
+	}
+
+	// Empty indexes are padded up to the desired replicas, so only count the indexes that have Machines.
+	populatedIndexes := int32(0)
+
+	for _, indexToMachines := range sortedIndexedMs {
+		if len(indexToMachines.machineInfos) > 0 {
+			populatedIndexes++
+		}
+	}
+
+	if populatedIndexes == replicas {
+		return true
+	}
+
+	configMapKey := client.ObjectKey{Namespace: cpms.Namespace, Name: cpms.GetAnnotations()[machineproviders.ReplicasPolicyAnnotation]}
+
+	setReplicasPolicyDegraded(logger, cpms, reasonInvalidReplicasPolicy,
+		fmt.Sprintf("Replicas policy config map %s requests %d replicas, but %d control plane indexes exist: the replicas policy cannot resize the control plane",
+			configMapKey, replicas, populatedIndexes))
+
+	return false
+}
+
+// setReplicasPolicyDegraded marks the ControlPlaneMachineSet as degraded because the desired replicas could not be
+// read from the replicas policy ConfigMap.
+func setReplicasPolicyDegraded(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, reason, message string) {
+	logger.Error(errInvalidReplicasPolicy, "Unable to resolve the desired replicas, no operations can be performed", "reason", reason, "message", message)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionReplicasPolicy,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cpms.Generation,
+	})
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:   conditionProgressing,
+		Status: metav1.ConditionFalse,
+		Reason: reasonOperatorDegraded,
+	})
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// parsePolicyReplicas parses the replicas from the data of a replicas policy ConfigMap.
+// The replicas must be one of the values allowed for spec.replicas, 3 or 5.
+func parsePolicyReplicas(data map[string]string) (int32, error) {
+	value, ok := data[replicasPolicyReplicasKey]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s key is not set", errInvalidReplicasPolicy, replicasPolicyReplicasKey)
+	}
+
+	replicas, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer: %v", errInvalidReplicasPolicy, replicasPolicyReplicasKey, err)
+	}
+
+	for _, allowed := range allowedPolicyReplicas {
+		if replicas == allowed {
+			return int32(replicas), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %s must be 3 or 5, got %d", errInvalidReplicasPolicy, replicasPolicyReplicasKey, replicas)
+}
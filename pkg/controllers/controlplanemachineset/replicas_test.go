@@ -0,0 +1,363 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("resolveReplicas", func() {
+	var namespaceName string
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var logger testutils.TestLogger
+
+	const policyName = "control-plane-size"
+
+	createPolicy := func(replicas string) {
+		configMap := corev1resourcebuilder.ConfigMap().
+			WithNamespace(namespaceName).
+			WithName(policyName).
+			WithData(map[string]string{replicasPolicyReplicasKey: replicas}).
+			Build()
+
+		Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-controller-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    k8sClient,
+			Scheme:    testScheme,
+			Namespace: namespaceName,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithReplicas(3).Build()
+		cpms.SetAnnotations(map[string]string{machineproviders.ReplicasPolicyAnnotation: policyName})
+
+		logger = testutils.NewTestLogger()
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&corev1.ConfigMap{},
+		)
+	})
+
+	Context("when no replicas policy is referenced", func() {
+		var replicas *int32
+		var err error
+
+		BeforeEach(func() {
+			cpms.SetAnnotations(nil)
+
+			replicas, err = reconciler.resolveReplicas(ctx, logger.Logger(), cpms)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("uses spec.replicas", func() {
+			Expect(replicas).To(Equal(pointer.Int32(3)))
+		})
+
+		It("does not set a replicas policy condition", func() {
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+
+		It("does not track a replicas policy", func() {
+			policy := corev1resourcebuilder.ConfigMap().WithNamespace(namespaceName).WithName(policyName).Build()
+			Expect(reconciler.replicasPolicy.isReferenced(policy)).To(BeFalse())
+		})
+	})
+
+	Context("when the replicas policy requests 5 replicas", func() {
+		var replicas *int32
+		var err error
+
+		BeforeEach(func() {
+			createPolicy("5")
+
+			replicas, err = reconciler.resolveReplicas(ctx, logger.Logger(), cpms)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("uses the replicas from the replicas policy", func() {
+			Expect(replicas).To(Equal(pointer.Int32(5)))
+		})
+
+		It("does not change spec.replicas", func() {
+			Expect(cpms.Spec.Replicas).To(Equal(pointer.Int32(3)))
+		})
+
+		It("publishes the desired replicas in the replicas policy condition", func() {
+			Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+				Type:    conditionReplicasPolicy,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonReplicasPolicyApplied,
+				Message: fmt.Sprintf("Desired replicas are 5, as set by config map %s/%s", namespaceName, policyName),
+			})))
+		})
+
+		It("tracks the replicas policy", func() {
+			policy := corev1resourcebuilder.ConfigMap().WithNamespace(namespaceName).WithName(policyName).Build()
+			Expect(reconciler.replicasPolicy.isReferenced(policy)).To(BeTrue())
+		})
+
+		It("sets an appropriate log line", func() {
+			Expect(logger.Entries()).To(ConsistOf(
+				testutils.LogEntry{
+					Level:         2,
+					KeysAndValues: []interface{}{"configMap", client.ObjectKey{Namespace: namespaceName, Name: policyName}.String(), "replicas", int32(5)},
+					Message:       "Using desired replicas from replicas policy",
+				},
+			))
+		})
+	})
+
+	Context("when the replicas policy requests an even number of replicas", func() {
+		var replicas *int32
+		var err error
+
+		BeforeEach(func() {
+			createPolicy("4")
+
+			replicas, err = reconciler.resolveReplicas(ctx, logger.Logger(), cpms)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not resolve the desired replicas", func() {
+			Expect(replicas).To(BeNil())
+		})
+
+		It("does not change spec.replicas", func() {
+			Expect(cpms.Spec.Replicas).To(Equal(pointer.Int32(3)))
+		})
+
+		It("marks the ControlPlaneMachineSet as degraded", func() {
+			Expect(cpms.Status.Conditions).To(ContainElement(testutils.MatchCondition(metav1.Condition{
+				Type:   conditionDegraded,
+				Status: metav1.ConditionTrue,
+				Reason: reasonInvalidReplicasPolicy,
+				Message: fmt.Sprintf("Replicas policy config map %s/%s is invalid: %s", namespaceName, policyName,
+					"invalid replicas policy: replicas must be 3 or 5, got 4"),
+			})))
+		})
+	})
+
+	Context("when the replicas policy does not exist", func() {
+		var replicas *int32
+		var err error
+
+		BeforeEach(func() {
+			replicas, err = reconciler.resolveReplicas(ctx, logger.Logger(), cpms)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not resolve the desired replicas", func() {
+			Expect(replicas).To(BeNil())
+		})
+
+		It("does not change spec.replicas", func() {
+			Expect(cpms.Spec.Replicas).To(Equal(pointer.Int32(3)))
+		})
+
+		It("marks the ControlPlaneMachineSet as degraded", func() {
+			message := fmt.Sprintf("Replicas policy config map %s/%s does not exist", namespaceName, policyName)
+
+			Expect(cpms.Status.Conditions).To(ConsistOf(
+				testutils.MatchCondition(metav1.Condition{
+					Type:    conditionReplicasPolicy,
+					Status:  metav1.ConditionFalse,
+					Reason:  reasonReplicasPolicyNotFound,
+					Message: message,
+				}),
+				testutils.MatchCondition(metav1.Condition{
+					Type:   conditionProgressing,
+					Status: metav1.ConditionFalse,
+					Reason: reasonOperatorDegraded,
+				}),
+				testutils.MatchCondition(metav1.Condition{
+					Type:    conditionDegraded,
+					Status:  metav1.ConditionTrue,
+					Reason:  reasonReplicasPolicyNotFound,
+					Message: message,
+				}),
+			))
+		})
+	})
+})
+
+var _ = Describe("checkReplicasPolicyMatchesIndexes", func() {
+	const policyName = "control-plane-size"
+
+	machineInfoBuilder := machineprovidersresourcebuilder.MachineInfo().WithReady(true)
+
+	indexesWithMachines := func(count int32) []indexToMachineInfos {
+		indexes := []indexToMachineInfos{}
+
+		for i := int32(0); i < count; i++ {
+			indexes = append(indexes, indexToMachineInfos{
+				index:        i,
+				machineInfos: []machineproviders.MachineInfo{machineInfoBuilder.WithIndex(i).WithMachineName(fmt.Sprintf("machine-%d", i)).Build()},
+			})
+		}
+
+		return indexes
+	}
+
+	type checkReplicasPolicyTableInput struct {
+		specReplicas    int32
+		policyReplicas  int32
+		withPolicy      bool
+		sortedIndexedMs []indexToMachineInfos
+		expectOK        bool
+		expectedMessage string
+	}
+
+	DescribeTable("checks the replicas policy against the indexes", func(in checkReplicasPolicyTableInput) {
+		logger := testutils.NewTestLogger()
+		reconciler := &ControlPlaneMachineSetReconciler{}
+
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace("test").WithReplicas(in.specReplicas).Build()
+
+		if in.withPolicy {
+			cpms.SetAnnotations(map[string]string{machineproviders.ReplicasPolicyAnnotation: policyName})
+			cpms.Status.Conditions = []metav1.Condition{{
+				Type:   conditionReplicasPolicy,
+				Status: metav1.ConditionTrue,
+				Reason: reasonReplicasPolicyApplied,
+			}}
+		}
+
+		Expect(reconciler.checkReplicasPolicyMatchesIndexes(logger.Logger(), cpms, in.policyReplicas, in.sortedIndexedMs)).To(Equal(in.expectOK))
+
+		if in.expectOK {
+			Expect(cpms.Status.Conditions).ToNot(ContainElement(testutils.MatchCondition(metav1.Condition{
+				Type:   conditionDegraded,
+				Status: metav1.ConditionTrue,
+			})))
+
+			return
+		}
+
+		Expect(cpms.Status.Conditions).To(ContainElements(
+			testutils.MatchCondition(metav1.Condition{
+				Type:    conditionReplicasPolicy,
+				Status:  metav1.ConditionFalse,
+				Reason:  reasonInvalidReplicasPolicy,
+				Message: in.expectedMessage,
+			}),
+			testutils.MatchCondition(metav1.Condition{
+				Type:    conditionDegraded,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonInvalidReplicasPolicy,
+				Message: in.expectedMessage,
+			}),
+		))
+	},
+		Entry("without a replicas policy", checkReplicasPolicyTableInput{
+			specReplicas:    3,
+			policyReplicas:  3,
+			sortedIndexedMs: indexesWithMachines(3),
+			expectOK:        true,
+		}),
+		Entry("with a replicas policy matching spec.replicas", checkReplicasPolicyTableInput{
+			specReplicas:    3,
+			policyReplicas:  3,
+			withPolicy:      true,
+			sortedIndexedMs: indexesWithMachines(3),
+			expectOK:        true,
+		}),
+		Entry("with a replicas policy of 5 and 5 indexes with machines", checkReplicasPolicyTableInput{
+			specReplicas:    3,
+			policyReplicas:  5,
+			withPolicy:      true,
+			sortedIndexedMs: indexesWithMachines(5),
+			expectOK:        true,
+		}),
+		Entry("with a replicas policy that would grow the control plane from 3 to 5", checkReplicasPolicyTableInput{
+			specReplicas:    3,
+			policyReplicas:  5,
+			withPolicy:      true,
+			sortedIndexedMs: append(indexesWithMachines(3), indexToMachineInfos{index: 3}, indexToMachineInfos{index: 4}),
+			expectOK:        false,
+			expectedMessage: "Replicas policy config map test/control-plane-size requests 5 replicas, but 3 control plane indexes exist: the replicas policy cannot resize the control plane",
+		}),
+		Entry("with a replicas policy that would shrink the control plane from 5 to 3", checkReplicasPolicyTableInput{
+			specReplicas:    5,
+			policyReplicas:  3,
+			withPolicy:      true,
+			sortedIndexedMs: indexesWithMachines(5),
+			expectOK:        false,
+			expectedMessage: "Replicas policy config map test/control-plane-size requests 3 replicas, but 5 control plane indexes exist: the replicas policy cannot resize the control plane",
+		}),
+	)
+})
+
+var _ = Describe("parsePolicyReplicas", func() {
+	DescribeTable("parses the replicas policy",
+		func(data map[string]string, expectedReplicas int32, expectedErr string) {
+			replicas, err := parsePolicyReplicas(data)
+
+			if expectedErr != "" {
+				Expect(err).To(MatchError(errInvalidReplicasPolicy))
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replicas).To(Equal(expectedReplicas))
+		},
+		Entry("with 3 replicas", map[string]string{replicasPolicyReplicasKey: "3"}, int32(3), ""),
+		Entry("with 5 replicas", map[string]string{replicasPolicyReplicasKey: "5"}, int32(5), ""),
+		Entry("with more replicas than spec.replicas allows", map[string]string{replicasPolicyReplicasKey: "7"}, int32(0), "replicas must be 3 or 5, got 7"),
+		Entry("with an even number of replicas", map[string]string{replicasPolicyReplicasKey: "4"}, int32(0), "replicas must be 3 or 5, got 4"),
+		Entry("with a single replica", map[string]string{replicasPolicyReplicasKey: "1"}, int32(0), "replicas must be 3 or 5, got 1"),
+		Entry("with a non integer number of replicas", map[string]string{replicasPolicyReplicasKey: "five"}, int32(0), "replicas must be an integer"),
+		Entry("without the replicas key", map[string]string{}, int32(0), "replicas key is not set"),
+	)
+})
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+const (
+	// RollStatePath is the path, on the metrics server, at which the roll state of the
+	// ControlPlaneMachineSet is served.
+	RollStatePath = "/rollstate"
+)
+
+// RollState summarises the progress of the ControlPlaneMachineSet rollout for external automation.
+type RollState struct {
+	// State is the state of the ControlPlaneMachineSet, either Active or Inactive.
+	State machinev1.ControlPlaneMachineSetState `json:"state"`
+
+	// Strategy is the update strategy of the ControlPlaneMachineSet.
+	Strategy machinev1.ControlPlaneMachineSetStrategyType `json:"strategy"`
+
+	// Total is the number of control plane Machines observed.
+	Total int32 `json:"total"`
+
+	// Ready is the number of control plane Machines that are ready.
+	Ready int32 `json:"ready"`
+
+	// Outdated is the number of control plane Machines that are not both ready and up to date.
+	Outdated int32 `json:"outdated"`
+
+	// RollInProgress is true while the ControlPlaneMachineSet reports that it is progressing.
+	RollInProgress bool `json:"rollInProgress"`
+}
+
+// rollStateRecorder keeps the roll state computed by the last reconcile so that it can be served
+// without making any API calls.
+type rollStateRecorder struct {
+	lock sync.RWMutex
+
+	// rollState is nil until a ControlPlaneMachineSet has been reconciled.
+	rollState *RollState
+}
+
+// record stores the roll state of the ControlPlaneMachineSet, or clears it when the ControlPlaneMachineSet is nil.
+func (r *rollStateRecorder) record(cpms *machinev1.ControlPlaneMachineSet) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if cpms == nil {
+		r.rollState = nil
+
+		return
+	}
+
+	outdated := cpms.Status.Replicas - cpms.Status.UpdatedReplicas
+	if outdated < 0 {
+		outdated = 0
+	}
+
+	r.rollState = &RollState{
+		State:          cpms.Spec.State,
+		Strategy:       cpms.Spec.Strategy.Type,
+		Total:          cpms.Status.Replicas,
+		Ready:          cpms.Status.ReadyReplicas,
+		Outdated:       outdated,
+		RollInProgress: meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionProgressing),
+	}
+}
+
+// ServeHTTP writes the last recorded roll state as JSON.
+// When no ControlPlaneMachineSet has been reconciled, it responds with a not found status.
+func (r *rollStateRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.rollState == nil {
+		http.Error(w, "no control plane machine set has been observed", http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(r.rollState); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("rollStateRecorder", func() {
+	var recorder *rollStateRecorder
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		recorder = &rollStateRecorder{}
+
+		mux := http.NewServeMux()
+		mux.Handle(RollStatePath, recorder)
+		server = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	getRollState := func() (*http.Response, map[string]interface{}) {
+		resp, err := http.Get(server.URL + RollStatePath) //nolint:noctx
+		Expect(err).ToNot(HaveOccurred())
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return resp, nil
+		}
+
+		body := map[string]interface{}{}
+		Expect(json.NewDecoder(resp.Body).Decode(&body)).To(Succeed())
+
+		return resp, body
+	}
+
+	Context("before a control plane machine set has been reconciled", func() {
+		It("responds with not found", func() {
+			resp, _ := getRollState()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("with a control plane machine set that is rolling out an update", func() {
+		BeforeEach(func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+			cpms.Spec.State = machinev1.ControlPlaneMachineSetStateActive
+			cpms.Status.Replicas = 4
+			cpms.Status.ReadyReplicas = 3
+			cpms.Status.UpdatedReplicas = 2
+			meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+				Type:   conditionProgressing,
+				Status: metav1.ConditionTrue,
+				Reason: reasonNeedsUpdateReplicas,
+			})
+
+			recorder.record(cpms)
+		})
+
+		It("serves the roll state as JSON", func() {
+			resp, body := getRollState()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			Expect(body).To(Equal(map[string]interface{}{
+				"state":          "Active",
+				"strategy":       "RollingUpdate",
+				"total":          float64(4),
+				"ready":          float64(3),
+				"outdated":       float64(2),
+				"rollInProgress": true,
+			}))
+		})
+
+		Context("and the control plane machine set is then removed", func() {
+			BeforeEach(func() {
+				recorder.record(nil)
+			})
+
+			It("responds with not found", func() {
+				resp, _ := getRollState()
+				Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("with an inactive control plane machine set that is up to date", func() {
+		BeforeEach(func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.OnDelete).Build()
+			cpms.Spec.State = machinev1.ControlPlaneMachineSetStateInactive
+			cpms.Status.Replicas = 3
+			cpms.Status.ReadyReplicas = 3
+			cpms.Status.UpdatedReplicas = 3
+
+			recorder.record(cpms)
+		})
+
+		It("reports no roll in progress", func() {
+			_, body := getRollState()
+
+			Expect(body).To(SatisfyAll(
+				HaveKeyWithValue("state", "Inactive"),
+				HaveKeyWithValue("strategy", "OnDelete"),
+				HaveKeyWithValue("outdated", float64(0)),
+				HaveKeyWithValue("rollInProgress", false),
+			))
+		})
+	})
+})
@@ -19,10 +19,15 @@ package controlplanemachineset
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	machinev1 "github.com/openshift/api/machine/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -46,7 +51,11 @@ const (
 
 // updateControlPlaneMachineSetStatus ensures that the status of the ControlPlaneMachineSet is up to date after
 // the resource has been reconciled.
-func (r *ControlPlaneMachineSetReconciler) updateControlPlaneMachineSetStatus(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, patchBase client.Patch) error {
+// The replicas are the desired number of Control Plane Machines, or nil when they could not be resolved.
+func (r *ControlPlaneMachineSetReconciler) updateControlPlaneMachineSetStatus(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas *int32, patchBase client.Patch) error {
+	reconcileInsufficientMachines(logger, cpms, replicas)
+	reconcileKStatusConditions(cpms)
+
 	data, err := patchBase.Data(cpms)
 	if err != nil {
 		return fmt.Errorf("cannot calculate patch data from control plane machine set object: %w", err)
@@ -59,8 +68,17 @@ func (r *ControlPlaneMachineSetReconciler) updateControlPlaneMachineSetStatus(ct
 		return nil
 	}
 
-	if err := r.Status().Update(ctx, cpms); err != nil {
-		return fmt.Errorf("failed to sync status for control plane machine set object: %w", err)
+	// The operator owns the status, so on a conflict the status is updated against the latest resource version.
+	if err := r.retryOnConflict(ctx, logger, cpms, func(latest *machinev1.ControlPlaneMachineSet) error {
+		cpms.SetResourceVersion(latest.GetResourceVersion())
+
+		if err := r.Status().Update(ctx, cpms); err != nil {
+			return fmt.Errorf("failed to sync status for control plane machine set object: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	logger.V(3).Info(updatingStatus, "data", string(data))
@@ -79,7 +97,7 @@ func (r *ControlPlaneMachineSetReconciler) updateControlPlaneMachineSetStatus(ct
 //   - UnavailableReplicas is the number of Machines required to satisfy the requirement of at least 1 Ready Replica per
 //     index. Eg. if one index has no ready replicas, this is 1, if an index has 2 ready replicas, this does not count as
 //     2 available replicas.
-func reconcileStatusWithMachineInfo(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) error {
+func reconcileStatusWithMachineInfo(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, desiredReplicas int32, machineInfosByIndex map[int32][]machineproviders.MachineInfo) error {
 	replicas := int32(0)
 	readyReplicas := int32(0)
 	updatedReplicas := int32(0)
@@ -124,22 +142,24 @@ func reconcileStatusWithMachineInfo(logger logr.Logger, cpms *machinev1.ControlP
 		"unavailableReplicas", cpms.Status.UnavailableReplicas,
 	)
 
-	if err := setConditions(cpms); err != nil {
+	if err := setConditions(cpms, desiredReplicas); err != nil {
 		return fmt.Errorf("could not set control plane machine set conditions: %w", err)
 	}
 
+	reconcileDrainingOldNodes(cpms, machineInfosByIndex)
+
 	return nil
 }
 
 // setConditions sets Available, Degraded and Progressing conditions on the ControlPlaneMachineSet.
-func setConditions(cpms *machinev1.ControlPlaneMachineSet) error {
+func setConditions(cpms *machinev1.ControlPlaneMachineSet, desiredReplicas int32) error {
 	availableCondition := getAvailableCondition(cpms)
 	meta.SetStatusCondition(&cpms.Status.Conditions, availableCondition)
 
 	degradedCondition := getDegradedCondition(cpms)
 	meta.SetStatusCondition(&cpms.Status.Conditions, degradedCondition)
 
-	progressingCondition, err := getProgressingCondition(cpms)
+	progressingCondition, err := getProgressingCondition(cpms, desiredReplicas)
 	if err != nil {
 		return fmt.Errorf("could not set progressing condition: %w", err)
 	}
@@ -149,6 +169,41 @@ func setConditions(cpms *machinev1.ControlPlaneMachineSet) error {
 	return nil
 }
 
+// reconcileDrainingOldNodes sets the Progressing condition to denote that the rollout is waiting for the Nodes of
+// old Machines to be drained. A Machine that is being deleted and is no longer held by a pre-drain hook has its
+// Node drained by the Machine API before the Machine is removed.
+func reconcileDrainingOldNodes(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	nodes := drainingNodes(machineInfosByIndex)
+	if len(nodes) == 0 {
+		return
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionProgressing,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonDrainingOldNode,
+		Message:            fmt.Sprintf("Waiting for node(s) %s to be drained before the old machine(s) are removed", strings.Join(nodes, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// drainingNodes returns the sorted names of the Nodes of Machines that are awaiting removal.
+func drainingNodes(machineInfosByIndex map[int32][]machineproviders.MachineInfo) []string {
+	nodes := []string{}
+
+	for _, machineInfos := range machineInfosByIndex {
+		for _, machineInfo := range awaitingRemovalMachines(machineInfos) {
+			if machineInfo.NodeRef != nil {
+				nodes = append(nodes, machineInfo.NodeRef.ObjectMeta.Name)
+			}
+		}
+	}
+
+	sort.Strings(nodes)
+
+	return nodes
+}
+
 // getProgressingCondition computes Available condition based on the current ControlPlaneMachineSet status.
 func getAvailableCondition(cpms *machinev1.ControlPlaneMachineSet) metav1.Condition {
 	if cpms.Status.UnavailableReplicas != 0 {
@@ -188,6 +243,454 @@ func getDegradedCondition(cpms *machinev1.ControlPlaneMachineSet) metav1.Conditi
 	}
 }
 
+// reconcileInsufficientMachines marks the ControlPlaneMachineSet as degraded when fewer Machines exist than the
+// desired number of replicas and the missing Machines cannot be created.
+// Replicas counts both ready Machines and those still in flight, so this only applies once nothing is left pending.
+// Creation is considered impossible when the reconciler is continuously failing, as reported by the Error condition.
+// Any other Degraded condition is left in place since it will describe the cause more precisely.
+func reconcileInsufficientMachines(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, desiredReplicas *int32) {
+	if desiredReplicas == nil || cpms.Status.Replicas >= *desiredReplicas {
+		return
+	}
+
+	if !meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionError) || isControlPlaneMachineSetDegraded(cpms) {
+		return
+	}
+
+	missingReplicas := *desiredReplicas - cpms.Status.Replicas
+
+	logger.Error(
+		fmt.Errorf("%w: %d of %d machine(s) present", errInsufficientControlPlaneMachines, cpms.Status.Replicas, *desiredReplicas),
+		"Observed fewer control plane machines than desired with no machine creation possible",
+		"missingReplicas", missingReplicas,
+	)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonInsufficientControlPlaneMachines,
+		Message:            fmt.Sprintf("Observed %d missing control plane machine(s) that could not be created", missingReplicas),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileUnmatchedFailureDomains reports the Machines whose failure domain does not match any of the failure domains
+// configured on the ControlPlaneMachineSet.
+// These Machines are listed in the UnmatchedFailureDomains condition and counted in the
+// cpms_machines_unmatched_failure_domain metric. The condition is removed when all Machines are in a known failure domain.
+func reconcileUnmatchedFailureDomains(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	unmatched := []string{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfosByIndex) {
+		for _, machineInfo := range indexedMachineInfos.machineInfos {
+			if machineInfo.UnmatchedFailureDomain == "" || machineInfo.MachineRef == nil {
+				continue
+			}
+
+			unmatched = append(unmatched, fmt.Sprintf("%s (%s)", machineInfo.MachineRef.ObjectMeta.Name, machineInfo.UnmatchedFailureDomain))
+		}
+	}
+
+	machinesUnmatchedFailureDomain.Set(float64(len(unmatched)))
+
+	if len(unmatched) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionUnmatchedFailureDomains)
+
+		return
+	}
+
+	logger.Info("Observed control plane machines outside of the configured failure domains", "machines", unmatched)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionUnmatchedFailureDomains,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonMachinesOutsideFailureDomains,
+		Message:            fmt.Sprintf("Observed machine(s) in failure domains not configured on the control plane machine set: %s", strings.Join(unmatched, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileInconsistentProviderIDs reports the Machines whose providerID is inconsistent with their Node.
+// These Machines are flagged as needing an update, so that they are replaced rather than treated as healthy.
+// The condition is removed once every Machine agrees with its Node.
+func reconcileInconsistentProviderIDs(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	inconsistent := []string{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfosByIndex) {
+		for _, machineInfo := range indexedMachineInfos.machineInfos {
+			if machineInfo.InconsistentProviderID == "" || machineInfo.MachineRef == nil {
+				continue
+			}
+
+			inconsistent = append(inconsistent, fmt.Sprintf("%s (%s)", machineInfo.MachineRef.ObjectMeta.Name, machineInfo.InconsistentProviderID))
+		}
+	}
+
+	if len(inconsistent) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionInconsistentProviderIDs)
+
+		return
+	}
+
+	logger.Info("Observed control plane machines with a providerID inconsistent with their node", "machines", inconsistent)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionInconsistentProviderIDs,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonProviderIDMismatch,
+		Message:            fmt.Sprintf("Observed machine(s) that will be replaced as their providerID is inconsistent with their node: %s", strings.Join(inconsistent, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileReducedRedundancy reports the replaced Machines that are being removed while their replacement is serving.
+// Until such a Machine has been removed, the control plane may briefly run with one fewer healthy member.
+func reconcileReducedRedundancy(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	removing := []string{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfosByIndex) {
+		machines := indexedMachineInfos.machineInfos
+
+		if isEmpty(updatedNonDeletedMachines(machines)) {
+			continue
+		}
+
+		for _, machineInfo := range deletingMachines(machines) {
+			removing = append(removing, machineInfo.MachineRef.ObjectMeta.Name)
+		}
+	}
+
+	if len(removing) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionReducedRedundancy)
+
+		return
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionReducedRedundancy,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonRemovingReplacedMachine,
+		Message:            fmt.Sprintf("Control plane redundancy is reduced until replaced machine(s) have been removed: %s", strings.Join(removing, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileSharedFailureDomains reports the failure domains that host more than one Control Plane Machine because
+// the ControlPlaneMachineSet has more replicas than failure domains. This is derived from the spec alone: the indexes
+// are spread across the failure domains, sorted by name, in a round-robin, as they are when no Machines exist yet.
+func reconcileSharedFailureDomains(cpms *machinev1.ControlPlaneMachineSet, desiredReplicas int32) {
+	shared, replicas, total := sharedFailureDomains(cpms, desiredReplicas)
+	if len(shared) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionSharedFailureDomains)
+
+		return
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:   conditionSharedFailureDomains,
+		Status: metav1.ConditionTrue,
+		Reason: reasonReplicasExceedFailureDomains,
+		Message: fmt.Sprintf("%d replicas are spread across %d failure domain(s), so the following failure domain(s) host more than one machine: %s",
+			replicas, total, strings.Join(shared, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileIndexGaps reports the indexes below the highest Control Plane Machine index that have no Machine.
+// Indexes are not required to be contiguous: a gap left by a scale down is not renumbered, as that would replace
+// healthy Machines, and the lowest free indexes are the first to be used when scaling up.
+func reconcileIndexGaps(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	gaps := indexGaps(machineInfosByIndex)
+	if len(gaps) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionIndexGaps)
+
+		return
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionIndexGaps,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonNonContiguousIndexes,
+		Message:            fmt.Sprintf("Control plane machine indexes are not contiguous, no machine exists for index(es) %s", formatIndexes(gaps)),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// indexGaps returns, in ascending order, the indexes below the highest index that are not present in the
+// machineInfosByIndex. Indexes that are present without any Machines are about to be created, so are not gaps.
+func indexGaps(machineInfosByIndex map[int32][]machineproviders.MachineInfo) []int32 {
+	highest := int32(-1)
+
+	for idx := range machineInfosByIndex {
+		if idx > highest {
+			highest = idx
+		}
+	}
+
+	gaps := []int32{}
+
+	for idx := int32(0); idx < highest; idx++ {
+		if _, ok := machineInfosByIndex[idx]; !ok {
+			gaps = append(gaps, idx)
+		}
+	}
+
+	return gaps
+}
+
+// sharedFailureDomains returns the names of the failure domains that are assigned more than one index,
+// along with the number of replicas and failure domains.
+// No failure domains are returned when the Machine template has no valid failure domains.
+func sharedFailureDomains(cpms *machinev1.ControlPlaneMachineSet, desiredReplicas int32) ([]string, int, int) {
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil {
+		return nil, 0, 0
+	}
+
+	failureDomains, err := failuredomain.NewFailureDomains(template.FailureDomains)
+	if err != nil || len(failureDomains) == 0 {
+		return nil, 0, 0
+	}
+
+	replicas := int(desiredReplicas)
+
+	names := []string{}
+	for _, failureDomain := range failureDomains {
+		names = append(names, failureDomain.String())
+	}
+
+	sort.Strings(names)
+
+	shared := []string{}
+
+	for i, name := range names {
+		// With a round-robin, the first replicas%len(names) failure domains receive one index more than the others.
+		count := replicas / len(names)
+		if i < replicas%len(names) {
+			count++
+		}
+
+		if count > 1 {
+			shared = append(shared, name)
+		}
+	}
+
+	return shared, replicas, len(names)
+}
+
+// reconcileUpdatingIndexes reports the indexes whose outdated Machine is currently being replaced, along with the
+// names of the old and new Machines, so that the progress of a rollout can be read from a single condition.
+// An index is being replaced while it has both an outdated Machine and a replacement that is not yet deleted.
+func reconcileUpdatingIndexes(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	updating := []string{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfosByIndex) {
+		machines := indexedMachineInfos.machineInfos
+
+		oldMachines := needReplacementMachines(machines)
+		newMachines := nonDeletedMachines(upToDateMachines(machines))
+
+		if isEmpty(oldMachines) || isEmpty(newMachines) {
+			continue
+		}
+
+		updating = append(updating, fmt.Sprintf("index %d (replacing machine %s with %s)",
+			indexedMachineInfos.index, oldMachines[0].MachineRef.ObjectMeta.Name, describeMachineOrigin(newMachines[0])))
+	}
+
+	if len(updating) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionUpdatingIndex)
+
+		return
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionUpdatingIndex,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonReplacingMachine,
+		Message:            fmt.Sprintf("Updating %s", strings.Join(updating, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// reconcileMachineGenerations reports the Machines that were created for an earlier generation of the
+// ControlPlaneMachineSet, and by how many generations they are behind, so that Machines predating recent changes can
+// be audited. Machines without a recorded generation are not reported, as their generation is not known.
+func reconcileMachineGenerations(cpms *machinev1.ControlPlaneMachineSet, machineInfosByIndex map[int32][]machineproviders.MachineInfo) {
+	behind := []string{}
+
+	for _, machineGeneration := range providers.MapMachineGenerations(cpms.Generation, machineInfosMaptoSlice(machineInfosByIndex)) {
+		if machineGeneration.GenerationsBehind == nil || *machineGeneration.GenerationsBehind <= 0 {
+			continue
+		}
+
+		behind = append(behind, fmt.Sprintf("machine %s (index %d, created for generation %d, %d generation(s) behind)",
+			machineGeneration.MachineName, machineGeneration.Index, *machineGeneration.CreatedForGeneration, *machineGeneration.GenerationsBehind))
+	}
+
+	if len(behind) == 0 {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionMachineGenerations)
+
+		return
+	}
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionMachineGenerations,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonMachinesPredateGeneration,
+		Message:            fmt.Sprintf("Machine(s) created for an earlier generation than the current generation %d: %s", cpms.Generation, strings.Join(behind, ", ")),
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// describeMachineOrigin returns the name of the Machine, along with the generation and failure domain that it was
+// created for, as recorded in its annotations by the machine provider when it created the Machine.
+// Machines created before the annotations were recorded, or by another means, are described by their name alone.
+func describeMachineOrigin(machine machineproviders.MachineInfo) string {
+	annotations := machine.MachineRef.ObjectMeta.Annotations
+	generation, hasGeneration := annotations[machineproviders.MachineGenerationAnnotation]
+	failureDomain, hasFailureDomain := annotations[machineproviders.MachineFailureDomainAnnotation]
+
+	switch {
+	case hasGeneration && hasFailureDomain:
+		return fmt.Sprintf("machine %s, created for generation %s in %s", machine.MachineRef.ObjectMeta.Name, generation, failureDomain)
+	case hasGeneration:
+		return fmt.Sprintf("machine %s, created for generation %s", machine.MachineRef.ObjectMeta.Name, generation)
+	case hasFailureDomain:
+		return fmt.Sprintf("machine %s, created in %s", machine.MachineRef.ObjectMeta.Name, failureDomain)
+	default:
+		return fmt.Sprintf("machine %s", machine.MachineRef.ObjectMeta.Name)
+	}
+}
+
+// reconcileIdle summarises why the ControlPlaneMachineSet is not acting on its Machines, once the decisions for the
+// current reconcile have been made. The causes are checked in the order that the reconcile observes them, so that
+// the reason names the first thing that is holding the ControlPlaneMachineSet back, if anything.
+func reconcileIdle(cpms *machinev1.ControlPlaneMachineSet, desiredReplicas int32, machineInfosByIndex map[int32][]machineproviders.MachineInfo) error {
+	if isControlPlaneMachineSetDegraded(cpms) {
+		setIdle(cpms, metav1.ConditionTrue, reasonOperatorDegraded, "No machines are being replaced while the control plane machine set is degraded")
+
+		return nil
+	}
+
+	if !isActive(cpms) {
+		setIdle(cpms, metav1.ConditionTrue, reasonInactive, "The control plane machine set is Inactive, so machines are observed but not managed")
+
+		return nil
+	}
+
+	if meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionMaintenanceFreeze) {
+		setIdle(cpms, metav1.ConditionTrue, reasonMaintenanceFreezeRequested, "No machines are created, deleted or updated while the cluster maintenance freeze is in effect")
+
+		return nil
+	}
+
+	if meta.IsStatusConditionFalse(cpms.Status.Conditions, conditionMaintenanceWindow) {
+		setIdle(cpms, metav1.ConditionTrue, reasonOutsideMaintenanceWindow, "No machines are created or deleted outside of the maintenance window")
+
+		return nil
+	}
+
+	if meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionMachineConfigPoolHold) {
+		setIdle(cpms, metav1.ConditionTrue, reasonMachineConfigPoolUpdating, "No machines are created or deleted while the master machine config pool is updating")
+
+		return nil
+	}
+
+	if meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionRollCancelled) {
+		setIdle(cpms, metav1.ConditionTrue, reasonCancelRequested,
+			fmt.Sprintf("The roll is cancelled, remove the %s annotation to resume", cancelRollAnnotation))
+
+		return nil
+	}
+
+	if waiting := waitingForReadyMachines(machineInfosByIndex); len(waiting) > 0 {
+		setIdle(cpms, metav1.ConditionTrue, reasonWaitingForReadyReplicas,
+			fmt.Sprintf("Waiting for machine(s) to become ready before continuing: %s", strings.Join(waiting, ", ")))
+
+		return nil
+	}
+
+	if cpms.Status.Replicas == desiredReplicas &&
+		cpms.Status.UpdatedReplicas == desiredReplicas && cpms.Status.ReadyReplicas == desiredReplicas {
+		setIdle(cpms, metav1.ConditionTrue, reasonAllReplicasUpdated, "All replicas are ready and up to date")
+
+		return nil
+	}
+
+	awaitingStep, err := isAwaitingStep(cpms)
+	if err != nil {
+		return err
+	}
+
+	if awaitingStep {
+		setIdle(cpms, metav1.ConditionTrue, reasonAwaitingStep,
+			fmt.Sprintf("Paused after step %q, change the %s annotation to allow the next action", cpms.GetAnnotations()[stepAnnotation], stepAnnotation))
+
+		return nil
+	}
+
+	if cpms.Spec.Strategy.Type == machinev1.OnDelete {
+		if outdated := outdatedNonDeletedMachines(machineInfosByIndex); len(outdated) > 0 {
+			setIdle(cpms, metav1.ConditionTrue, reasonAwaitingMachineDeletion,
+				fmt.Sprintf("Machine(s) require an update, delete them to trigger a replacement: %s", strings.Join(outdated, ", ")))
+
+			return nil
+		}
+	}
+
+	setIdle(cpms, metav1.ConditionFalse, reasonReplacingMachines, "Machines are being replaced")
+
+	return nil
+}
+
+// setIdle sets the Idle condition on the ControlPlaneMachineSet.
+func setIdle(cpms *machinev1.ControlPlaneMachineSet, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionIdle,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cpms.Generation,
+	})
+}
+
+// waitingForReadyMachines returns the names of the new Machines that the update strategy is waiting on to become
+// ready, either because their index has no other ready Machine, or because they replace an outdated Machine.
+func waitingForReadyMachines(machineInfosByIndex map[int32][]machineproviders.MachineInfo) []string {
+	waiting := []string{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfosByIndex) {
+		machines := indexedMachineInfos.machineInfos
+		machinesPending := pendingMachines(machines)
+
+		if isEmpty(machinesPending) {
+			continue
+		}
+
+		if hasAny(needReplacementMachines(machines)) || (isEmpty(readyMachines(machines)) && isEmpty(deletingMachines(machines))) {
+			waiting = append(waiting, machinesPending[0].MachineRef.ObjectMeta.Name)
+		}
+	}
+
+	return waiting
+}
+
+// outdatedNonDeletedMachines returns the names of the Machines that need an update and have not yet been deleted.
+func outdatedNonDeletedMachines(machineInfosByIndex map[int32][]machineproviders.MachineInfo) []string {
+	outdated := []string{}
+
+	for _, indexedMachineInfos := range sortMachineInfosByIndex(machineInfosByIndex) {
+		for _, machineInfo := range nonDeletedMachines(indexedMachineInfos.machineInfos) {
+			if machineInfo.NeedsUpdate {
+				outdated = append(outdated, machineInfo.MachineRef.ObjectMeta.Name)
+			}
+		}
+	}
+
+	return outdated
+}
+
 // getErrorCondition returns an error condition based on the given error and the status of the tracked last errors.
 func getErrorCondition(cpms *machinev1.ControlPlaneMachineSet, lastError *lastErrorTracker) metav1.Condition {
 	if lastError == nil || lastError.count < maxContinuousErrors {
@@ -209,13 +712,11 @@ func getErrorCondition(cpms *machinev1.ControlPlaneMachineSet, lastError *lastEr
 }
 
 // getProgressingCondition computes Progressing condition based on the current ControlPlaneMachineSet status.
-func getProgressingCondition(cpms *machinev1.ControlPlaneMachineSet) (metav1.Condition, error) {
-	if cpms.Spec.Replicas == nil {
+func getProgressingCondition(cpms *machinev1.ControlPlaneMachineSet, desiredReplicas int32) (metav1.Condition, error) {
+	if desiredReplicas < 1 {
 		return metav1.Condition{}, errReplicasRequired
 	}
 
-	desiredReplicas := *cpms.Spec.Replicas
-
 	if desiredReplicas > cpms.Status.UpdatedReplicas {
 		return metav1.Condition{
 			Type:               conditionProgressing,
@@ -243,3 +744,124 @@ func getProgressingCondition(cpms *machinev1.ControlPlaneMachineSet) (metav1.Con
 		ObservedGeneration: cpms.Generation,
 	}, nil
 }
+
+// reconcileRolloutProgress tracks when the number of updated replicas last changed during a RollingUpdate rollout.
+// If the rollout has not made any progress within the RolloutStuckTimeout, the ControlPlaneMachineSet is marked as
+// degraded, so that the stuck rollout is surfaced through the ClusterOperator.
+// The first index that has not yet been updated is reported as the stuck index.
+// It returns the duration after which the rollout progress should be checked again, zero if no check is required.
+func (r *ControlPlaneMachineSetReconciler) reconcileRolloutProgress(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, previousUpdatedReplicas int32, machineInfos map[int32][]machineproviders.MachineInfo) (time.Duration, error) {
+	if r.RolloutStuckTimeout <= 0 || cpms.Spec.Strategy.Type != machinev1.RollingUpdate || !meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionProgressing) {
+		return 0, clearLastProgressTime(cpms)
+	}
+
+	now := r.getClock().Now()
+
+	lastProgressTime, ok, err := getLastProgressTime(cpms)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok || cpms.Status.UpdatedReplicas != previousUpdatedReplicas {
+		return r.RolloutStuckTimeout, setLastProgressTime(cpms, now)
+	}
+
+	if elapsed := now.Sub(lastProgressTime); elapsed < r.RolloutStuckTimeout {
+		return r.RolloutStuckTimeout - elapsed, nil
+	}
+
+	stuckIndex := firstNonUpdatedIndex(machineInfos)
+
+	logger.Error(
+		fmt.Errorf("%w: index %d has not been updated since %s", errRolloutStuck, stuckIndex, lastProgressTime.Format(time.RFC3339)),
+		"Observed a stuck rollout",
+		"index", stuckIndex,
+		"lastProgressTime", lastProgressTime.Format(time.RFC3339),
+	)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionDegraded,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonRolloutStuck,
+		Message:            fmt.Sprintf("Rollout has made no progress since %s, waiting on index %d", lastProgressTime.Format(time.RFC3339), stuckIndex),
+		ObservedGeneration: cpms.Generation,
+	})
+
+	return 0, nil
+}
+
+// isReplacementCompleted returns true when a Machine replacement has completed since the last reconcile.
+// A replacement is complete once the replacement Machine is Ready and its predecessor has been removed.
+// This is observed as a drop in the number of replicas since the last reconcile, without losing any updated replicas,
+// while every index still has an available Machine.
+func isReplacementCompleted(cpms *machinev1.ControlPlaneMachineSet, previousReplicas, previousUpdatedReplicas int32) bool {
+	return cpms.Status.Replicas < previousReplicas && cpms.Status.UpdatedReplicas >= previousUpdatedReplicas && cpms.Status.UnavailableReplicas == 0
+}
+
+// reconcileLastReplacementCompleted records the time at which a Machine replacement was last completed.
+func (r *ControlPlaneMachineSetReconciler) reconcileLastReplacementCompleted(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, previousReplicas, previousUpdatedReplicas int32) error {
+	if !isReplacementCompleted(cpms, previousReplicas, previousUpdatedReplicas) {
+		return nil
+	}
+
+	completedTime := persistedTime(r.getClock().Now())
+
+	logger.V(2).Info("Observed a completed control plane machine replacement", "completedTime", completedTime.Format(time.RFC3339))
+
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.LastReplacementCompletedTime = completedTime
+	}); err != nil {
+		return fmt.Errorf("error recording last replacement completed time: %w", err)
+	}
+
+	return nil
+}
+
+// firstNonUpdatedIndex returns the lowest index which either has no updated machine, or still has a machine that
+// needs to be replaced.
+func firstNonUpdatedIndex(machineInfos map[int32][]machineproviders.MachineInfo) int32 {
+	for _, indexToMachines := range sortMachineInfosByIndex(machineInfos) {
+		if isEmpty(updatedMachines(indexToMachines.machineInfos)) || hasAny(needReplacementMachines(indexToMachines.machineInfos)) {
+			return indexToMachines.index
+		}
+	}
+
+	return 0
+}
+
+// getLastProgressTime returns the last progress time from the ControlPlaneMachineSet persisted state.
+// It returns false when no last progress time has been recorded.
+func getLastProgressTime(cpms *machinev1.ControlPlaneMachineSet) (time.Time, bool, error) {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("error reading last progress time: %w", err)
+	}
+
+	if state.LastProgressTime == nil {
+		return time.Time{}, false, nil
+	}
+
+	return state.LastProgressTime.Time, true, nil
+}
+
+// setLastProgressTime records the given time as the last progress time in the ControlPlaneMachineSet persisted state.
+func setLastProgressTime(cpms *machinev1.ControlPlaneMachineSet, t time.Time) error {
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.LastProgressTime = persistedTime(t)
+	}); err != nil {
+		return fmt.Errorf("error recording last progress time: %w", err)
+	}
+
+	return nil
+}
+
+// clearLastProgressTime removes the last progress time from the ControlPlaneMachineSet persisted state.
+func clearLastProgressTime(cpms *machinev1.ControlPlaneMachineSet) error {
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.LastProgressTime = nil
+	}); err != nil {
+		return fmt.Errorf("error clearing last progress time: %w", err)
+	}
+
+	return nil
+}
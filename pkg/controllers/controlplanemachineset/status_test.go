@@ -17,6 +17,9 @@ limitations under the License.
 package controlplanemachineset
 
 import (
+	"fmt"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -27,8 +30,12 @@ import (
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
+	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/pointer"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
@@ -88,7 +95,7 @@ var _ = Describe("Status", func() {
 				cpms.Status.ReadyReplicas = 4
 
 				// Use a DeepCopy of the CPMS to avoid any reflection from the update affecting the test cases.
-				Expect(reconciler.updateControlPlaneMachineSetStatus(ctx, logger.Logger(), cpms.DeepCopy(), patchBase)).To(Succeed())
+				Expect(reconciler.updateControlPlaneMachineSetStatus(ctx, logger.Logger(), cpms.DeepCopy(), cpms.Spec.Replicas, patchBase)).To(Succeed())
 			})
 
 			It("updates the status on the API", func() {
@@ -113,6 +120,42 @@ var _ = Describe("Status", func() {
 			})
 		})
 
+		Context("when the status has changed, but the input is stale", func() {
+			BeforeEach(func() {
+				staleCPMS := cpms.DeepCopy()
+
+				By("Updating the existing object")
+				Eventually(komega.Update(cpms, func() {
+					cpms.SetLabels(map[string]string{"updated": "true"})
+				})).Should(Succeed())
+
+				staleCPMS.Status.ObservedGeneration = 2
+				staleCPMS.Status.Replicas = 3
+				staleCPMS.Status.ReadyReplicas = 4
+
+				Expect(reconciler.updateControlPlaneMachineSetStatus(ctx, logger.Logger(), staleCPMS, staleCPMS.Spec.Replicas, patchBase)).To(Succeed())
+			})
+
+			It("resolves the conflict by retrying with the latest version, and updates the status on the API", func() {
+				Eventually(komega.Object(cpms)).Should(SatisfyAll(
+					HaveField("ObjectMeta.Labels", HaveKeyWithValue("updated", "true")),
+					HaveField("Status", SatisfyAll(
+						HaveField("ObservedGeneration", int64(2)),
+						HaveField("Replicas", int32(3)),
+						HaveField("ReadyReplicas", int32(4)),
+					)),
+				))
+			})
+
+			It("should log the retry", func() {
+				Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+					Level:         3,
+					KeysAndValues: []interface{}{"attempt", 2},
+					Message:       retryingAfterConflict,
+				}))
+			})
+		})
+
 		Context("when the status has not changed", func() {
 			BeforeEach(func() {
 				// Use different values to what is set on the API, but a different patch base to prove
@@ -126,7 +169,7 @@ var _ = Describe("Status", func() {
 				patchBase = client.MergeFrom(cpms.DeepCopy())
 
 				// Use a DeepCopy of the CPMS to avoid any reflection from the update affecting the test cases.
-				Expect(reconciler.updateControlPlaneMachineSetStatus(ctx, logger.Logger(), cpms.DeepCopy(), patchBase)).To(Succeed())
+				Expect(reconciler.updateControlPlaneMachineSetStatus(ctx, logger.Logger(), cpms.DeepCopy(), cpms.Spec.Replicas, patchBase)).To(Succeed())
 			})
 
 			It("does not update the status on the API", func() {
@@ -179,7 +222,7 @@ var _ = Describe("Status", func() {
 			logger := testutils.NewTestLogger()
 			cpms := in.cpmsBuilder.Build()
 
-			err := reconcileStatusWithMachineInfo(logger.Logger(), cpms, in.machineInfos)
+			err := reconcileStatusWithMachineInfo(logger.Logger(), cpms, *cpms.Spec.Replicas, in.machineInfos)
 			if in.expectedError != nil {
 				Expect(err).To(MatchError(ContainSubstring(in.expectedError.Error())))
 				return
@@ -405,6 +448,59 @@ var _ = Describe("Status", func() {
 					},
 				},
 			}),
+			Entry("with the node of an old machine being drained", &reconcileStatusTableInput{
+				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(4),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithReady(false).WithNeedsUpdate(true).WithAwaitingRemoval(true).Build(),
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				expectedError: nil,
+				expectedStatus: machinev1.ControlPlaneMachineSetStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               conditionAvailable,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonAllReplicasAvailable,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionDegraded,
+							Status:             metav1.ConditionFalse,
+							Reason:             reasonAsExpected,
+							ObservedGeneration: 4,
+						},
+						{
+							Type:               conditionProgressing,
+							Status:             metav1.ConditionTrue,
+							Reason:             reasonDrainingOldNode,
+							ObservedGeneration: 4,
+							Message:            "Waiting for node(s) node-1 to be drained before the old machine(s) are removed",
+						},
+					},
+					ObservedGeneration:  4,
+					Replicas:            4,
+					ReadyReplicas:       3,
+					UpdatedReplicas:     3,
+					UnavailableReplicas: 0,
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"observedGeneration", "4",
+							"replicas", "4",
+							"readyReplicas", "3",
+							"updatedReplicas", "3",
+							"unavailableReplicas", "0",
+						},
+						Message: "Observed Machine Configuration",
+					},
+				},
+			}),
 			Entry("with no MachineInfos", &reconcileStatusTableInput{
 				cpmsBuilder: machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(5),
 				machineInfos: map[int32][]machineproviders.MachineInfo{
@@ -617,4 +713,1134 @@ var _ = Describe("Status", func() {
 			}),
 		)
 	})
+
+	Context("reconcileInsufficientMachines", func() {
+		var logger testutils.TestLogger
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		continuousErrorCondition := metav1.Condition{
+			Type:   conditionError,
+			Status: metav1.ConditionTrue,
+			Reason: reasonContinuousErrors,
+		}
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+
+			By("Observing two machines with no pending creation for the third index")
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {},
+			}
+			Expect(reconcileStatusWithMachineInfo(logger.Logger(), cpms, *cpms.Spec.Replicas, machineInfos)).To(Succeed())
+		})
+
+		Context("when the machine creation is continuously failing", func() {
+			BeforeEach(func() {
+				meta.SetStatusCondition(&cpms.Status.Conditions, continuousErrorCondition)
+
+				logger = testutils.NewTestLogger()
+				reconcileInsufficientMachines(logger.Logger(), cpms, cpms.Spec.Replicas)
+			})
+
+			It("marks the control plane machine set as degraded", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonInsufficientControlPlaneMachines)),
+					HaveField("Message", Equal("Observed 1 missing control plane machine(s) that could not be created")),
+				))
+			})
+
+			It("logs the missing machines", func() {
+				Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+					Error: fmt.Errorf("%w: 2 of 3 machine(s) present", errInsufficientControlPlaneMachines),
+					KeysAndValues: []interface{}{
+						"missingReplicas", int32(1),
+					},
+					Message: "Observed fewer control plane machines than desired with no machine creation possible",
+				}))
+			})
+		})
+
+		Context("when the machine creation may still succeed", func() {
+			BeforeEach(func() {
+				reconcileInsufficientMachines(logger.Logger(), cpms, cpms.Spec.Replicas)
+			})
+
+			It("does not mark the control plane machine set as degraded", func() {
+				Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+			})
+		})
+
+		Context("when the control plane machine set is already degraded", func() {
+			BeforeEach(func() {
+				meta.SetStatusCondition(&cpms.Status.Conditions, continuousErrorCondition)
+				meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+					Type:   conditionDegraded,
+					Status: metav1.ConditionTrue,
+					Reason: reasonUnmanagedNodes,
+				})
+
+				reconcileInsufficientMachines(logger.Logger(), cpms, cpms.Spec.Replicas)
+			})
+
+			It("keeps the existing degraded reason", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(HaveField("Reason", Equal(reasonUnmanagedNodes)))
+			})
+		})
+
+		Context("when all of the machines are present", func() {
+			BeforeEach(func() {
+				cpms.Status.Replicas = 3
+				meta.SetStatusCondition(&cpms.Status.Conditions, continuousErrorCondition)
+
+				reconcileInsufficientMachines(logger.Logger(), cpms, cpms.Spec.Replicas)
+			})
+
+			It("does not mark the control plane machine set as degraded", func() {
+				Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+			})
+		})
+	})
+
+	Context("reconcileUpdatingIndexes", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		steadyStateMachineInfos := map[int32][]machineproviders.MachineInfo{
+			0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+			1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		})
+
+		Context("when a replacement is in flight", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build()},
+					1: {
+						readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build(),
+						readyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithReady(false).Build(),
+					},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build()},
+				}
+
+				reconcileUpdatingIndexes(cpms, machineInfos)
+			})
+
+			It("sets the updating index condition naming the index and its machines", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUpdatingIndex)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonReplacingMachine)),
+					HaveField("Message", Equal("Updating index 1 (replacing machine machine-1 with machine machine-replacement-1)")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+
+			Context("and the rollout then completes", func() {
+				BeforeEach(func() {
+					reconcileUpdatingIndexes(cpms, steadyStateMachineInfos)
+				})
+
+				It("removes the updating index condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUpdatingIndex)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when the old machine has been deleted and its replacement is ready", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {
+						readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithMachineDeletionTimestamp(metav1.Now()).Build(),
+						readyMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build(),
+					},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileUpdatingIndexes(cpms, machineInfos)
+			})
+
+			It("reports the index as updating until the old machine is removed", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUpdatingIndex)).To(
+					HaveField("Message", Equal("Updating index 0 (replacing machine machine-0 with machine machine-replacement-0)")),
+				)
+			})
+		})
+
+		Context("when the replacement records the generation and failure domain it was created for", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build(),
+						readyMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithReady(false).WithMachineAnnotations(map[string]string{
+							machineproviders.MachineGenerationAnnotation:    "2",
+							machineproviders.MachineFailureDomainAnnotation: "AzureFailureDomain{Zone:2}",
+						}).Build(),
+					},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileUpdatingIndexes(cpms, machineInfos)
+			})
+
+			It("reports the generation and failure domain of the replacement", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUpdatingIndex)).To(
+					HaveField("Message", Equal("Updating index 1 (replacing machine machine-1 with machine machine-replacement-1, created for generation 2 in AzureFailureDomain{Zone:2})")),
+				)
+			})
+		})
+
+		Context("when machines need an update but no replacement has been created", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithNeedsUpdate(true).Build()},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build()},
+				}
+
+				reconcileUpdatingIndexes(cpms, machineInfos)
+			})
+
+			It("does not set the updating index condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUpdatingIndex)).To(BeNil())
+			})
+		})
+
+		Context("at steady state", func() {
+			BeforeEach(func() {
+				reconcileUpdatingIndexes(cpms, steadyStateMachineInfos)
+			})
+
+			It("does not set the updating index condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUpdatingIndex)).To(BeNil())
+			})
+		})
+	})
+
+	Context("reconcileInconsistentProviderIDs", func() {
+		var logger testutils.TestLogger
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		})
+
+		Context("when a machine has a providerID that does not match its node", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithNeedsUpdate(true).
+						WithInconsistentProviderID("machine providerID aws:///us-east-1a/i-0 does not match node node-0 providerID aws:///us-east-1a/i-1").Build()},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileInconsistentProviderIDs(logger.Logger(), cpms, machineInfos)
+			})
+
+			It("sets the inconsistent providerIDs condition naming the machine", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionInconsistentProviderIDs)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonProviderIDMismatch)),
+					HaveField("Message", Equal("Observed machine(s) that will be replaced as their providerID is inconsistent with their node: "+
+						"machine-0 (machine providerID aws:///us-east-1a/i-0 does not match node node-0 providerID aws:///us-east-1a/i-1)")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+
+			Context("and the machine is later replaced", func() {
+				BeforeEach(func() {
+					machineInfos := map[int32][]machineproviders.MachineInfo{
+						0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build()},
+						1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+						2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+					}
+
+					reconcileInconsistentProviderIDs(logger.Logger(), cpms, machineInfos)
+				})
+
+				It("removes the inconsistent providerIDs condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionInconsistentProviderIDs)).To(BeNil())
+				})
+			})
+		})
+	})
+
+	Context("reconcileReducedRedundancy", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		})
+
+		Context("when a replaced machine is being removed", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {
+						readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+							WithNeedsUpdate(true).WithMachineDeletionTimestamp(metav1.Now()).Build(),
+						readyMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build(),
+					},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileReducedRedundancy(cpms, machineInfos)
+			})
+
+			It("sets the reduced redundancy condition naming the machine", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionReducedRedundancy)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonRemovingReplacedMachine)),
+					HaveField("Message", Equal("Control plane redundancy is reduced until replaced machine(s) have been removed: machine-0")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+
+			Context("and the machine is then removed", func() {
+				BeforeEach(func() {
+					machineInfos := map[int32][]machineproviders.MachineInfo{
+						0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build()},
+						1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+						2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+					}
+
+					reconcileReducedRedundancy(cpms, machineInfos)
+				})
+
+				It("removes the reduced redundancy condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionReducedRedundancy)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when a machine without a replacement is being removed", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+						WithMachineDeletionTimestamp(metav1.Now()).Build()},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileReducedRedundancy(cpms, machineInfos)
+			})
+
+			It("does not set the reduced redundancy condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionReducedRedundancy)).To(BeNil())
+			})
+		})
+	})
+
+	Context("reconcileUnmatchedFailureDomains", func() {
+		var logger testutils.TestLogger
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		unmatchedFailureDomainsGauge := func() float64 {
+			metric := &dto.Metric{}
+			Expect(machinesUnmatchedFailureDomain.Write(metric)).To(Succeed())
+
+			return metric.GetGauge().GetValue()
+		}
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		})
+
+		Context("when a machine is in a failure domain that is not configured", func() {
+			BeforeEach(func() {
+				By("Observing a machine in zone d while the failure domains list zones a, b and c")
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+						WithNeedsUpdate(true).WithUnmatchedFailureDomain("AWSFailureDomain{AvailabilityZone:us-east-1d}").Build()},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileUnmatchedFailureDomains(logger.Logger(), cpms, machineInfos)
+			})
+
+			It("sets the unmatched failure domains condition naming the machine and its failure domain", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUnmatchedFailureDomains)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonMachinesOutsideFailureDomains)),
+					HaveField("Message", Equal("Observed machine(s) in failure domains not configured on the control plane machine set: "+
+						"machine-0 (AWSFailureDomain{AvailabilityZone:us-east-1d})")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+
+			It("reports the machine in the metric", func() {
+				Expect(unmatchedFailureDomainsGauge()).To(Equal(float64(1)))
+			})
+
+			It("logs the unmatched machines", func() {
+				Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+					KeysAndValues: []interface{}{
+						"machines", []string{"machine-0 (AWSFailureDomain{AvailabilityZone:us-east-1d})"},
+					},
+					Message: "Observed control plane machines outside of the configured failure domains",
+				}))
+			})
+
+			Context("and the machine is later replaced within a configured failure domain", func() {
+				BeforeEach(func() {
+					machineInfos := map[int32][]machineproviders.MachineInfo{
+						0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build()},
+						1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+						2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+					}
+
+					reconcileUnmatchedFailureDomains(logger.Logger(), cpms, machineInfos)
+				})
+
+				It("removes the unmatched failure domains condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUnmatchedFailureDomains)).To(BeNil())
+				})
+
+				It("resets the metric", func() {
+					Expect(unmatchedFailureDomainsGauge()).To(Equal(float64(0)))
+				})
+			})
+		})
+
+		Context("when all machines are in a configured failure domain", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileUnmatchedFailureDomains(logger.Logger(), cpms, machineInfos)
+			})
+
+			It("does not set the unmatched failure domains condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionUnmatchedFailureDomains)).To(BeNil())
+			})
+
+			It("reports no machines in the metric", func() {
+				Expect(unmatchedFailureDomainsGauge()).To(Equal(float64(0)))
+			})
+
+			It("does not log", func() {
+				Expect(logger.Entries()).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("reconcileSharedFailureDomains", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		cpmsWithZones := func(replicas int32, zones ...string) *machinev1.ControlPlaneMachineSet {
+			failureDomainBuilders := []machinev1resourcebuilder.AWSFailureDomainBuilder{}
+			for _, zone := range zones {
+				failureDomainBuilders = append(failureDomainBuilders, machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone(zone))
+			}
+
+			return machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(replicas).WithMachineTemplateBuilder(
+				machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().WithFailureDomainsBuilder(
+					machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(failureDomainBuilders...),
+				),
+			).Build()
+		}
+
+		Context("with 3 replicas over 2 zones", func() {
+			BeforeEach(func() {
+				cpms = cpmsWithZones(3, "us-east-1b", "us-east-1a")
+
+				reconcileSharedFailureDomains(cpms, *cpms.Spec.Replicas)
+			})
+
+			It("sets the shared failure domains condition naming the doubled up zone", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionSharedFailureDomains)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonReplicasExceedFailureDomains)),
+					HaveField("Message", Equal("3 replicas are spread across 2 failure domain(s), so the following failure domain(s) "+
+						"host more than one machine: AWSFailureDomain{AvailabilityZone:us-east-1a}")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+
+			Context("and a third zone is later added", func() {
+				BeforeEach(func() {
+					cpms.Spec.Template = cpmsWithZones(3, "us-east-1a", "us-east-1b", "us-east-1c").Spec.Template
+
+					reconcileSharedFailureDomains(cpms, *cpms.Spec.Replicas)
+				})
+
+				It("removes the shared failure domains condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionSharedFailureDomains)).To(BeNil())
+				})
+			})
+		})
+
+		Context("with 5 replicas over 2 zones", func() {
+			BeforeEach(func() {
+				cpms = cpmsWithZones(5, "us-east-1a", "us-east-1b")
+
+				reconcileSharedFailureDomains(cpms, *cpms.Spec.Replicas)
+			})
+
+			It("names both zones in the shared failure domains condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionSharedFailureDomains)).To(
+					HaveField("Message", Equal("5 replicas are spread across 2 failure domain(s), so the following failure domain(s) "+
+						"host more than one machine: AWSFailureDomain{AvailabilityZone:us-east-1a}, AWSFailureDomain{AvailabilityZone:us-east-1b}")),
+				)
+			})
+		})
+
+		Context("without failure domains", func() {
+			BeforeEach(func() {
+				cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).Build()
+
+				reconcileSharedFailureDomains(cpms, *cpms.Spec.Replicas)
+			})
+
+			It("does not set the shared failure domains condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionSharedFailureDomains)).To(BeNil())
+			})
+		})
+	})
+
+	Context("reconcileIndexGaps", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).Build()
+		})
+
+		Context("when indexes 0, 1 and 4 remain after a scale down", func() {
+			var machineInfos map[int32][]machineproviders.MachineInfo
+
+			BeforeEach(func() {
+				var err error
+				machineInfos, err = machineInfosByIndex(*cpms.Spec.Replicas, []machineproviders.MachineInfo{
+					readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build(),
+					readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build(),
+					readyMachineBuilder.WithIndex(4).WithMachineName("machine-4").WithNodeName("node-4").Build(),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				reconcileIndexGaps(cpms, machineInfos)
+			})
+
+			It("keeps the gapped indexes without adding an index to fill the gap", func() {
+				Expect(machineInfos).To(HaveLen(3))
+				Expect(machineInfos).To(HaveKey(int32(4)))
+			})
+
+			It("sets the index gaps condition naming the missing indexes", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIndexGaps)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonNonContiguousIndexes)),
+					HaveField("Message", Equal("Control plane machine indexes are not contiguous, no machine exists for index(es) 2,3")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+
+			Context("and the machine in index 4 is later removed", func() {
+				BeforeEach(func() {
+					var err error
+					machineInfos, err = machineInfosByIndex(*cpms.Spec.Replicas, []machineproviders.MachineInfo{
+						readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build(),
+						readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build(),
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					reconcileIndexGaps(cpms, machineInfos)
+				})
+
+				It("fills the lowest free index", func() {
+					Expect(machineInfos).To(HaveKeyWithValue(int32(2), BeEmpty()))
+				})
+
+				It("removes the index gaps condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIndexGaps)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when only indexes 0 and 4 remain", func() {
+			BeforeEach(func() {
+				machineInfos, err := machineInfosByIndex(*cpms.Spec.Replicas, []machineproviders.MachineInfo{
+					readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build(),
+					readyMachineBuilder.WithIndex(4).WithMachineName("machine-4").WithNodeName("node-4").Build(),
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				reconcileIndexGaps(cpms, machineInfos)
+			})
+
+			It("does not report the index that is about to be created as a gap", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIndexGaps)).To(
+					HaveField("Message", Equal("Control plane machine indexes are not contiguous, no machine exists for index(es) 2,3")),
+				)
+			})
+		})
+
+		Context("when the indexes are contiguous", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {readyMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				reconcileIndexGaps(cpms, machineInfos)
+			})
+
+			It("does not set the index gaps condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIndexGaps)).To(BeNil())
+			})
+		})
+	})
+
+	Context("reconcileMachineGenerations", func() {
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+		nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+
+		readyMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+			WithMachineGVR(machineGVR).
+			WithNodeGVR(nodeGVR).
+			WithReady(true).
+			WithNeedsUpdate(false)
+
+		// machineCreatedFor builds an up to date Machine stamped with the generation it was created for.
+		machineCreatedFor := func(idx int32, name, generation string) machineproviders.MachineInfo {
+			return readyMachineBuilder.WithIndex(idx).WithMachineName(name).WithNodeName("node-" + name).WithMachineAnnotations(map[string]string{
+				machineproviders.MachineGenerationAnnotation: generation,
+			}).Build()
+		}
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(5).WithReplicas(3).Build()
+		})
+
+		Context("when machines were created for earlier generations", func() {
+			BeforeEach(func() {
+				reconcileMachineGenerations(cpms, map[int32][]machineproviders.MachineInfo{
+					0: {machineCreatedFor(0, "machine-0", "2")},
+					1: {machineCreatedFor(1, "machine-1", "5")},
+					2: {machineCreatedFor(2, "machine-2", "4")},
+				})
+			})
+
+			It("sets the machine generations condition naming the machines that predate the current generation", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMachineGenerations)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonMachinesPredateGeneration)),
+					HaveField("Message", Equal("Machine(s) created for an earlier generation than the current generation 5: "+
+						"machine machine-0 (index 0, created for generation 2, 3 generation(s) behind), "+
+						"machine machine-2 (index 2, created for generation 4, 1 generation(s) behind)")),
+					HaveField("ObservedGeneration", Equal(int64(5))),
+				))
+			})
+
+			Context("and the machines are replaced for the current generation", func() {
+				BeforeEach(func() {
+					reconcileMachineGenerations(cpms, map[int32][]machineproviders.MachineInfo{
+						0: {machineCreatedFor(0, "machine-replacement-0", "5")},
+						1: {machineCreatedFor(1, "machine-1", "5")},
+						2: {machineCreatedFor(2, "machine-replacement-2", "5")},
+					})
+				})
+
+				It("removes the machine generations condition", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMachineGenerations)).To(BeNil())
+				})
+			})
+		})
+
+		Context("when machines do not record the generation they were created for", func() {
+			BeforeEach(func() {
+				reconcileMachineGenerations(cpms, map[int32][]machineproviders.MachineInfo{
+					0: {readyMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {machineCreatedFor(1, "machine-1", "5")},
+					2: {readyMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				})
+			})
+
+			It("does not set the machine generations condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionMachineGenerations)).To(BeNil())
+			})
+		})
+	})
+
+	Context("reconcileLastReplacementCompleted", func() {
+		var logger testutils.TestLogger
+		var fakeClock *clocktesting.FakePassiveClock
+		var reconciler *ControlPlaneMachineSetReconciler
+		var cpms *machinev1.ControlPlaneMachineSet
+		var machineInfos map[int32][]machineproviders.MachineInfo
+
+		// reconcileReplacement mimics the reconcile by observing the status of the machines
+		// and then checking whether a replacement has completed.
+		reconcileReplacement := func() {
+			previousReplicas := cpms.Status.Replicas
+			previousUpdatedReplicas := cpms.Status.UpdatedReplicas
+			Expect(reconcileStatusWithMachineInfo(logger.Logger(), cpms, *cpms.Spec.Replicas, machineInfos)).To(Succeed())
+
+			Expect(reconciler.reconcileLastReplacementCompleted(logger.Logger(), cpms, previousReplicas, previousUpdatedReplicas)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			fakeClock = clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+			reconciler = &ControlPlaneMachineSetReconciler{
+				clock: fakeClock,
+			}
+
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).Build()
+
+			By("Setting up a rollout where the replacement for index 2 is ready")
+			machineInfos = map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build(),
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithNodeName("node-replacement-2").Build(),
+				},
+			}
+
+			reconcileReplacement()
+		})
+
+		It("does not record a completed replacement while the predecessor remains", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastReplacementCompletedTime", BeNil()))
+		})
+
+		Context("when the predecessor has been removed", func() {
+			BeforeEach(func() {
+				fakeClock.SetTime(fakeClock.Now().Add(10 * time.Minute))
+
+				machineInfos[2] = []machineproviders.MachineInfo{
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithNodeName("node-replacement-2").Build(),
+				}
+
+				reconcileReplacement()
+			})
+
+			It("records the completed replacement time", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastReplacementCompletedTime", bePersistedTime("2023-06-01T12:10:00Z")))
+			})
+
+			Context("and a further reconcile makes no changes", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(10 * time.Minute))
+
+					reconcileReplacement()
+				})
+
+				It("does not update the completed replacement time", func() {
+					Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastReplacementCompletedTime", bePersistedTime("2023-06-01T12:10:00Z")))
+				})
+			})
+		})
+
+		Context("when the predecessor is removed before the replacement is ready", func() {
+			BeforeEach(func() {
+				machineInfos[2] = []machineproviders.MachineInfo{
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithReady(false).Build(),
+				}
+
+				reconcileReplacement()
+			})
+
+			It("does not record a completed replacement", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastReplacementCompletedTime", BeNil()))
+			})
+		})
+	})
+
+	Context("reconcileRolloutProgress", func() {
+		const rolloutStuckTimeout = 30 * time.Minute
+
+		var logger testutils.TestLogger
+		var fakeClock *clocktesting.FakePassiveClock
+		var reconciler *ControlPlaneMachineSetReconciler
+		var cpms *machinev1.ControlPlaneMachineSet
+		var machineInfos map[int32][]machineproviders.MachineInfo
+
+		// reconcileProgress mimics the reconcile by first observing the status of the machines
+		// and then checking the rollout progress.
+		reconcileProgress := func() time.Duration {
+			previousUpdatedReplicas := cpms.Status.UpdatedReplicas
+			Expect(reconcileStatusWithMachineInfo(logger.Logger(), cpms, *cpms.Spec.Replicas, machineInfos)).To(Succeed())
+
+			requeueAfter, err := reconciler.reconcileRolloutProgress(logger.Logger(), cpms, previousUpdatedReplicas, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+
+			return requeueAfter
+		}
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			fakeClock = clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+			reconciler = &ControlPlaneMachineSetReconciler{
+				RolloutStuckTimeout: rolloutStuckTimeout,
+				clock:               fakeClock,
+			}
+
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).Build()
+
+			By("Setting up a rollout where the replacement for index 2 never becomes ready")
+			machineInfos = map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build(),
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithReady(false).Build(),
+				},
+			}
+		})
+
+		Context("when the rollout is first observed", func() {
+			var requeueAfter time.Duration
+
+			BeforeEach(func() {
+				requeueAfter = reconcileProgress()
+			})
+
+			It("records the last progress time", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastProgressTime", bePersistedTime("2023-06-01T12:00:00Z")))
+			})
+
+			It("requeues after the timeout", func() {
+				Expect(requeueAfter).To(Equal(rolloutStuckTimeout))
+			})
+
+			It("does not mark the control plane machine set as degraded", func() {
+				Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+			})
+
+			Context("and the timeout has not yet elapsed", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(10 * time.Minute))
+					requeueAfter = reconcileProgress()
+				})
+
+				It("does not update the last progress time", func() {
+					Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastProgressTime", bePersistedTime("2023-06-01T12:00:00Z")))
+				})
+
+				It("requeues for the remainder of the timeout", func() {
+					Expect(requeueAfter).To(Equal(20 * time.Minute))
+				})
+
+				It("does not mark the control plane machine set as degraded", func() {
+					Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+				})
+			})
+
+			Context("and the replacement never becomes ready", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(rolloutStuckTimeout))
+					requeueAfter = reconcileProgress()
+				})
+
+				It("marks the control plane machine set as degraded, pointing at the stuck index", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+						HaveField("Status", Equal(metav1.ConditionTrue)),
+						HaveField("Reason", Equal(reasonRolloutStuck)),
+						HaveField("Message", Equal("Rollout has made no progress since 2023-06-01T12:00:00Z, waiting on index 2")),
+					))
+				})
+
+				It("does not requeue", func() {
+					Expect(requeueAfter).To(BeZero())
+				})
+
+				It("logs the stuck rollout", func() {
+					Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+						Error: fmt.Errorf("%w: index 2 has not been updated since 2023-06-01T12:00:00Z", errRolloutStuck),
+						KeysAndValues: []interface{}{
+							"index", int32(2),
+							"lastProgressTime", "2023-06-01T12:00:00Z",
+						},
+						Message: "Observed a stuck rollout",
+					}))
+				})
+			})
+
+			Context("and the replacement becomes ready", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(rolloutStuckTimeout))
+
+					machineInfos[2] = []machineproviders.MachineInfo{
+						updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").WithNeedsUpdate(true).Build(),
+						updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithNodeName("node-replacement-2").Build(),
+					}
+
+					requeueAfter = reconcileProgress()
+				})
+
+				It("updates the last progress time", func() {
+					Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastProgressTime", bePersistedTime("2023-06-01T12:30:00Z")))
+				})
+
+				It("does not mark the control plane machine set as degraded", func() {
+					Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when the rollout stuck timeout is disabled", func() {
+			BeforeEach(func() {
+				reconciler.RolloutStuckTimeout = 0
+				Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+					state.LastProgressTime = persistedTestTime("2023-06-01T10:00:00Z")
+				})).To(Succeed())
+
+				Expect(reconcileProgress()).To(BeZero())
+			})
+
+			It("removes the last progress time", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastProgressTime", BeNil()))
+			})
+
+			It("does not mark the control plane machine set as degraded", func() {
+				Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDegraded)).To(BeFalse())
+			})
+		})
+
+		Context("when no rollout is in progress", func() {
+			BeforeEach(func() {
+				machineInfos[2] = []machineproviders.MachineInfo{
+					updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-2").WithNodeName("node-replacement-2").Build(),
+				}
+				Expect(updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+					state.LastProgressTime = persistedTestTime("2023-06-01T10:00:00Z")
+				})).To(Succeed())
+
+				Expect(reconcileProgress()).To(BeZero())
+			})
+
+			It("removes the last progress time", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastProgressTime", BeNil()))
+			})
+		})
+	})
+
+	Context("reconcileIdle", func() {
+		var logger testutils.TestLogger
+		var cpms *machinev1.ControlPlaneMachineSet
+
+		updatedMachineInfos := map[int32][]machineproviders.MachineInfo{
+			0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+			1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+
+		outdatedMachineInfos := map[int32][]machineproviders.MachineInfo{
+			0: {outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+			1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+
+		reconcileIdleWith := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+			Expect(reconcileStatusWithMachineInfo(logger.Logger(), cpms, *cpms.Spec.Replicas, machineInfos)).To(Succeed())
+
+			Expect(reconcileIdle(cpms, *cpms.Spec.Replicas, machineInfos)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			logger = testutils.NewTestLogger()
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithGeneration(1).WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		})
+
+		Context("when all replicas are up to date", func() {
+			BeforeEach(func() {
+				reconcileIdleWith(updatedMachineInfos)
+			})
+
+			It("reports that there is nothing to do", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonAllReplicasUpdated)),
+					HaveField("Message", Equal("All replicas are ready and up to date")),
+					HaveField("ObservedGeneration", Equal(int64(1))),
+				))
+			})
+		})
+
+		Context("when the control plane machine set is Inactive", func() {
+			BeforeEach(func() {
+				cpms.Spec.State = machinev1.ControlPlaneMachineSetStateInactive
+
+				reconcileIdleWith(outdatedMachineInfos)
+			})
+
+			It("reports that the machines are not managed", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonInactive)),
+					HaveField("Message", Equal("The control plane machine set is Inactive, so machines are observed but not managed")),
+				))
+			})
+		})
+
+		Context("when the control plane machine set is degraded", func() {
+			BeforeEach(func() {
+				meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+					Type:   conditionDegraded,
+					Status: metav1.ConditionTrue,
+					Reason: reasonUnmanagedNodes,
+				})
+
+				Expect(reconcileIdle(cpms, *cpms.Spec.Replicas, outdatedMachineInfos)).To(Succeed())
+			})
+
+			It("reports that no machines are being replaced", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonOperatorDegraded)),
+				))
+			})
+		})
+
+		Context("when a replacement machine is not yet ready", func() {
+			BeforeEach(func() {
+				reconcileIdleWith(map[int32][]machineproviders.MachineInfo{
+					0: {
+						outdatedMachineInfos[0][0],
+						updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithReady(false).Build(),
+					},
+					1: outdatedMachineInfos[1],
+					2: outdatedMachineInfos[2],
+				})
+			})
+
+			It("reports that it is waiting for the machine to become ready", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonWaitingForReadyReplicas)),
+					HaveField("Message", Equal("Waiting for machine(s) to become ready before continuing: machine-replacement-0")),
+				))
+			})
+
+			Context("and the replacement then becomes ready", func() {
+				BeforeEach(func() {
+					reconcileIdleWith(map[int32][]machineproviders.MachineInfo{
+						0: {
+							outdatedMachineInfos[0][0],
+							updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-3").Build(),
+						},
+						1: outdatedMachineInfos[1],
+						2: outdatedMachineInfos[2],
+					})
+				})
+
+				It("reports that machines are being replaced", func() {
+					Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+						HaveField("Status", Equal(metav1.ConditionFalse)),
+						HaveField("Reason", Equal(reasonReplacingMachines)),
+					))
+				})
+			})
+		})
+
+		Context("when machines need an update with the OnDelete strategy", func() {
+			BeforeEach(func() {
+				cpms.Spec.Strategy.Type = machinev1.OnDelete
+
+				reconcileIdleWith(outdatedMachineInfos)
+			})
+
+			It("reports that the machines must be deleted", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonAwaitingMachineDeletion)),
+					HaveField("Message", Equal("Machine(s) require an update, delete them to trigger a replacement: machine-0, machine-1, machine-2")),
+				))
+			})
+		})
+
+		Context("when machines need an update while paused by the step annotation", func() {
+			BeforeEach(func() {
+				cpms.SetAnnotations(map[string]string{
+					stepAnnotation: "2",
+					machineproviders.PersistedStateAnnotation: persistedStateAnnotation(machineproviders.PersistedState{
+						StepObserved: pointer.String("2"),
+					}),
+				})
+
+				reconcileIdleWith(outdatedMachineInfos)
+			})
+
+			It("reports that it is awaiting the next step", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionIdle)).To(SatisfyAll(
+					HaveField("Status", Equal(metav1.ConditionTrue)),
+					HaveField("Reason", Equal(reasonAwaitingStep)),
+					HaveField("Message", Equal(fmt.Sprintf("Paused after step \"2\", change the %s annotation to allow the next action", stepAnnotation))),
+				))
+			})
+		})
+	})
 })
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+)
+
+const (
+	// pausedForStep is a log message used to inform the user that the ControlPlaneMachineSet has been paused,
+	// and that Machines will only be created or deleted when the step annotation is changed.
+	pausedForStep = "Pausing automatic updates, change the step annotation to allow the next action"
+
+	// waitingForStep is a log message used to inform the user that a Machine is not being created or deleted
+	// because the ControlPlaneMachineSet is paused, and no step has been allowed since the last action.
+	waitingForStep = "Waiting for the step annotation to change before taking the next action"
+
+	// consumedStep is a log message used to inform the user that the action allowed by the step annotation has
+	// been taken, and that the ControlPlaneMachineSet is paused again.
+	consumedStep = "Took the action allowed by the step annotation, pausing until the next step"
+)
+
+// isStepEnabled returns true when the ControlPlaneMachineSet has been paused by the step annotation, so that each
+// Machine is only created or deleted once a step has been allowed.
+func isStepEnabled(cpms *machinev1.ControlPlaneMachineSet) bool {
+	_, ok := cpms.GetAnnotations()[stepAnnotation]
+
+	return ok
+}
+
+// reconcileStep records the value of the step annotation when the ControlPlaneMachineSet is first paused, so that
+// setting the annotation pauses updates without taking any action. Once the annotation is removed, the record of the
+// last step is removed along with it, and updates resume automatically.
+func reconcileStep(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) error {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return fmt.Errorf("error reading observed step: %w", err)
+	}
+
+	observed := state.StepObserved != nil
+
+	switch {
+	case !isStepEnabled(cpms) && observed:
+		return setStepObserved(cpms, nil)
+	case isStepEnabled(cpms) && !observed:
+		step := cpms.GetAnnotations()[stepAnnotation]
+		if err := setStepObserved(cpms, &step); err != nil {
+			return err
+		}
+
+		logger.V(2).Info(pausedForStep)
+	}
+
+	return nil
+}
+
+// isAwaitingStep returns true when the ControlPlaneMachineSet has been paused, and the step annotation has not been
+// changed since the last action was taken.
+func isAwaitingStep(cpms *machinev1.ControlPlaneMachineSet) (bool, error) {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return false, fmt.Errorf("error reading observed step: %w", err)
+	}
+
+	return isStepEnabled(cpms) && state.StepObserved != nil && cpms.GetAnnotations()[stepAnnotation] == *state.StepObserved, nil
+}
+
+// stepAllowsAction returns true when a Machine may be created or deleted. This is always the case unless the
+// ControlPlaneMachineSet has been paused, in which case an action is only allowed when the step annotation has been
+// changed since the last action was taken.
+func stepAllowsAction(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (bool, error) {
+	awaiting, err := isAwaitingStep(cpms)
+	if err != nil {
+		return false, err
+	}
+
+	if !awaiting {
+		return true, nil
+	}
+
+	logger.V(2).Info(waitingForStep)
+
+	return false, nil
+}
+
+// consumeStep records that the action allowed by the step annotation has been taken, so that no further Machine is
+// created or deleted until the step annotation is changed again.
+func consumeStep(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) error {
+	if !isStepEnabled(cpms) {
+		return nil
+	}
+
+	step := cpms.GetAnnotations()[stepAnnotation]
+	if err := setStepObserved(cpms, &step); err != nil {
+		return err
+	}
+
+	logger.V(2).Info(consumedStep)
+
+	return nil
+}
+
+// setStepObserved records the value of the step annotation that was last acted upon in the persisted state, or
+// removes the record when nil.
+func setStepObserved(cpms *machinev1.ControlPlaneMachineSet, step *string) error {
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.StepObserved = step
+	}); err != nil {
+		return fmt.Errorf("error recording observed step: %w", err)
+	}
+
+	return nil
+}
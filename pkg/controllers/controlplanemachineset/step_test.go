@@ -0,0 +1,263 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("reconcileStep", func() {
+	var logger testutils.TestLogger
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+	})
+
+	Context("when the step annotation is first set", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{stepAnnotation: "1"})
+
+			Expect(reconcileStep(logger.Logger(), cpms)).To(Succeed())
+		})
+
+		It("records the step as observed, so that no action is allowed", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("StepObserved", HaveValue(Equal("1"))))
+			Expect(stepAllowsAction(logger.Logger(), cpms)).To(BeFalse())
+		})
+
+		It("logs that automatic updates are paused", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level:   2,
+				Message: pausedForStep,
+			}))
+		})
+	})
+
+	Context("when the step annotation is removed", func() {
+		BeforeEach(func() {
+			Expect(setStepObserved(cpms, pointer.String("1"))).To(Succeed())
+
+			Expect(reconcileStep(logger.Logger(), cpms)).To(Succeed())
+		})
+
+		It("removes the record of the last step", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("StepObserved", BeNil()))
+		})
+
+		It("allows actions again", func() {
+			Expect(stepAllowsAction(logger.Logger(), cpms)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates when paused by the step annotation", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	// reconcileUpdates mimics the reconcile by first observing the step annotation and then performing the updates.
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+
+		Expect(reconcileStep(logger.Logger(), cpms)).To(Succeed())
+
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	// step changes the step annotation to allow the next action.
+	step := func(token string) {
+		annotations := cpms.GetAnnotations()
+		annotations[stepAnnotation] = token
+		cpms.SetAnnotations(annotations)
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{stepAnnotation: "0"})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+	})
+
+	Context("when two indexes need an update with the RollingUpdate strategy", func() {
+		machine0 := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+		machine1 := outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()
+		machine2 := updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()
+		replacement0 := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build()
+		replacement1 := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build()
+
+		outdatedMachineInfos := map[int32][]machineproviders.MachineInfo{
+			0: {machine0},
+			1: {machine1},
+			2: {machine2},
+		}
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(outdatedMachineInfos)
+		})
+
+		It("does not take any action until a step is allowed", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(waitingForStep))))
+		})
+
+		Context("and the first step is allowed", func() {
+			BeforeEach(func() {
+				step("1")
+
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
+
+				reconcileUpdates(outdatedMachineInfos)
+			})
+
+			It("creates the replacement for the first index", func() {
+				Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(createdReplacement))))
+			})
+
+			It("records the step as observed", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("StepObserved", HaveValue(Equal("1"))))
+			})
+
+			Context("and the replacement becomes ready", func() {
+				replacedMachineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {machine0, replacement0},
+					1: {machine1},
+					2: {machine2},
+				}
+
+				BeforeEach(func() {
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					reconcileUpdates(replacedMachineInfos)
+				})
+
+				It("does not delete the replaced machine until the next step is allowed", func() {
+					Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(waitingForStep))))
+				})
+
+				Context("and the second step is allowed", func() {
+					BeforeEach(func() {
+						step("2")
+
+						mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machine0.MachineRef).Return(nil).Times(1)
+
+						reconcileUpdates(replacedMachineInfos)
+					})
+
+					It("deletes the replaced machine", func() {
+						Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(removingOldMachine))))
+					})
+
+					Context("and the replaced machine has been removed", func() {
+						secondIndexMachineInfos := map[int32][]machineproviders.MachineInfo{
+							0: {replacement0},
+							1: {machine1},
+							2: {machine2},
+						}
+
+						BeforeEach(func() {
+							reconcileUpdates(secondIndexMachineInfos)
+						})
+
+						It("does not start the second index until the next step is allowed", func() {
+							Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(waitingForStep))))
+						})
+
+						Context("and the third step is allowed", func() {
+							BeforeEach(func() {
+								step("3")
+
+								mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+								reconcileUpdates(secondIndexMachineInfos)
+							})
+
+							It("creates the replacement for the second index", func() {
+								Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("StepObserved", HaveValue(Equal("3"))))
+							})
+
+							Context("and the fourth step is allowed once the replacement is ready", func() {
+								BeforeEach(func() {
+									step("4")
+
+									mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machine1.MachineRef).Return(nil).Times(1)
+
+									reconcileUpdates(map[int32][]machineproviders.MachineInfo{
+										0: {replacement0},
+										1: {machine1, replacement1},
+										2: {machine2},
+									})
+								})
+
+								It("completes the roll by deleting the second replaced machine", func() {
+									Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("StepObserved", HaveValue(Equal("4"))))
+								})
+							})
+						})
+					})
+				})
+			})
+		})
+	})
+
+	Context("when two deleted machines need replacements with the OnDelete strategy", func() {
+		machineInfos := map[int32][]machineproviders.MachineInfo{
+			0: {outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithMachineDeletionTimestamp(metav1.Now()).Build()},
+			1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithMachineDeletionTimestamp(metav1.Now()).Build()},
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").Build()},
+		}
+
+		BeforeEach(func() {
+			cpms.Spec.Strategy.Type = machinev1.OnDelete
+
+			Expect(reconcileStep(logger.Logger(), cpms)).To(Succeed())
+			step("1")
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Times(0)
+
+			reconcileUpdates(machineInfos)
+		})
+
+		It("only creates a single replacement for the step", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(waitingForStep))))
+		})
+	})
+})
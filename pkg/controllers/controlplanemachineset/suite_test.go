@@ -66,6 +66,7 @@ var _ = BeforeSuite(func() {
 			filepath.Join("..", "..", "..", "vendor", "github.com", "openshift", "api", "machine", "v1"),
 			filepath.Join("..", "..", "..", "vendor", "github.com", "openshift", "api", "machine", "v1beta1"),
 			filepath.Join("..", "..", "..", "vendor", "github.com", "openshift", "api", "config", "v1"),
+			"testdata",
 		},
 		ErrorIfCRDPathMissing: true,
 	}
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var _ = Describe("reconcile tracing", func() {
+	var logger testutils.TestLogger
+	var spanRecorder *tracetest.SpanRecorder
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	outdatedMachine := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").
+		WithNeedsUpdate(true).WithDiff([]string{"InstanceType: m6i.xlarge != different"}).Build()
+
+	outdatedMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachine},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	replacedMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {
+			outdatedMachine,
+			updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+		},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	// reconcileUpdates reconciles the machine updates within a reconcile span, as the span started by Reconcile
+	// would be the parent of the spans around each phase.
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) error {
+		reconcileCtx, span := reconciler.getTracerProvider().Tracer("test").Start(ctx, "Reconcile")
+		defer span.End()
+
+		_, err := reconciler.reconcileMachineUpdates(reconcileCtx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+
+		return err
+	}
+
+	// endedSpan returns the ended span with the given name, which must have been recorded as a child of the
+	// reconcile span.
+	endedSpan := func(name string) sdktrace.ReadOnlySpan {
+		var reconcileSpan, span sdktrace.ReadOnlySpan
+
+		for _, s := range spanRecorder.Ended() {
+			switch s.Name() {
+			case "Reconcile":
+				reconcileSpan = s
+			case name:
+				span = s
+			}
+		}
+
+		Expect(reconcileSpan).ToNot(BeNil(), "the reconcile span should have ended")
+		Expect(span).ToNot(BeNil(), "the %s span should have ended", name)
+		Expect(span.Parent().SpanID()).To(Equal(reconcileSpan.SpanContext().SpanID()), "the %s span should be a child of the reconcile span", name)
+
+		return span
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		spanRecorder = tracetest.NewSpanRecorder()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace:      "test",
+			TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)),
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(outdatedMachineInfos), nil).AnyTimes()
+	})
+
+	Context("when a replacement machine is created", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			Expect(reconcileUpdates(outdatedMachineInfos)).To(Succeed())
+		})
+
+		It("records a CreateMachine span for the index", func() {
+			span := endedSpan("CreateMachine")
+			Expect(span.Attributes()).To(ContainElement(attribute.Int("index", 1)))
+			Expect(span.Status().Code).To(Equal(codes.Unset))
+		})
+	})
+
+	Context("when creating the replacement machine fails", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", errors.New("quota exceeded")).Times(1)
+
+			Expect(reconcileUpdates(outdatedMachineInfos)).ToNot(Succeed())
+		})
+
+		It("records the error on the CreateMachine span", func() {
+			span := endedSpan("CreateMachine")
+			Expect(span.Status().Code).To(Equal(codes.Error))
+			Expect(span.Status().Description).To(Equal("quota exceeded"))
+		})
+	})
+
+	Context("when an outdated machine is deleted", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), outdatedMachine.MachineRef).Return(nil).Times(1)
+
+			Expect(reconcileUpdates(replacedMachineInfos)).To(Succeed())
+		})
+
+		It("records a DeleteMachine span for the machine", func() {
+			span := endedSpan("DeleteMachine")
+			Expect(span.Attributes()).To(ContainElements(attribute.Int("index", 1), attribute.String("machine", "machine-1")))
+		})
+	})
+
+	Context("without a tracer provider", func() {
+		BeforeEach(func() {
+			reconciler.TracerProvider = nil
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+			Expect(reconcileUpdates(outdatedMachineInfos)).To(Succeed())
+		})
+
+		It("does not record any span", func() {
+			Expect(spanRecorder.Ended()).To(BeEmpty())
+		})
+	})
+})
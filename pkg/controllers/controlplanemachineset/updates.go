@@ -21,17 +21,34 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	machinev1 "github.com/openshift/api/machine/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/util"
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
+	// completingRollingUpdateReplacement is a log message used to inform the user that an outdated Machine is being
+	// deleted to complete a replacement that was started before the update strategy was changed to OnDelete.
+	completingRollingUpdateReplacement = "Completing replacement started by the RollingUpdate strategy"
+
+	// createdMachineWithMismatchedIndex is a log message used to inform the user that a Machine created for an index
+	// is not labelled with that index, and that no further operations will take place until it is removed.
+	createdMachineWithMismatchedIndex = "Created machine is labelled with a different index, halting operations"
+
 	// createdReplacement is a log message used to inform the user that a new Machine was created to
 	// replace an existing Machine.
 	createdReplacement = "Created replacement machine"
@@ -48,6 +65,10 @@ const (
 	// replace an existing Machine, because a replacement has already been created.
 	alreadyPresentReplacement = "Replacement machine already present"
 
+	// onDeleteMachineNotDeleted is a log message used to inform the user that a new Machine was not created for an
+	// index, because the OnDelete strategy never surges and a Machine in the index has not been deleted.
+	onDeleteMachineNotDeleted = "Not creating a machine while the index has a machine that is not being deleted, the OnDelete strategy never surges"
+
 	// invalidStrategyMessage is used to inform the user that they have provided an invalid value
 	// for the update strategy.
 	invalidStrategyMessage = "invalid value for spec.strategy.type"
@@ -74,6 +95,14 @@ const (
 	// deleted as a part of the rollout operation.
 	removingOldMachine = "Removing old machine"
 
+	// deletingUnprovisionedMachine is a log message used to inform the user that a pending Machine is being
+	// deleted because it has not reached the Provisioned phase within the replacement provisioning deadline.
+	deletingUnprovisionedMachine = "Machine has not been provisioned within the deadline, deleting it so that it is created again"
+
+	// reasonProvisioningDeadlineExceeded is the reason of the event emitted when a pending Machine is deleted
+	// because it has not reached the Provisioned phase within the replacement provisioning deadline.
+	reasonProvisioningDeadlineExceeded = "ProvisioningDeadlineExceeded"
+
 	// waitingForReady is a log message used to inform the user that no operations are taking
 	// place because the rollout is waiting for a Machine to be ready.
 	// This is used exclusively when adding a new Machine to a missing index.
@@ -83,11 +112,75 @@ const (
 	// place because the rollout is waiting for a Machine to be removed.
 	waitingForRemoved = "Waiting for machine to be removed"
 
+	// waitingForRemovalBeforeReplacement is a log message used to inform the user that no replacement is being
+	// created for an index because its deleted Machine is no longer held by a pre-drain hook, and so will be removed
+	// without a replacement. The replacement is created once the Machine has gone, so as not to exceed the surge.
+	waitingForRemovalBeforeReplacement = "Waiting for deleted machine to be removed before creating its replacement"
+
 	// waitingForReplacement is a log message used to inform the user that no operations are taking
 	// place because the rollout is waiting for a replacement Machine to become ready.
 	// This is used when replacing a Machine within an index.
 	waitingForReplacement = "Waiting for replacement machine to become ready"
 
+	// waitingForDeletionGrace is a log message used to inform the user that an old Machine is not yet being
+	// deleted because the deletion grace period since its replacement became ready has not yet elapsed.
+	waitingForDeletionGrace = "Waiting for deletion grace period to elapse before removing old machine"
+
+	// waitingForStableReplacement is a log message used to inform the user that an old Machine is not yet being
+	// deleted because the Node of its replacement has not yet been Ready for the minimum node ready duration.
+	waitingForStableReplacement = "Waiting for replacement machine node to be stable before removing old machine"
+
+	// waitingForReadinessGates is a log message used to inform the user that an old Machine is not yet being
+	// deleted because the Node of its replacement does not yet have all of the configured readiness gates True.
+	waitingForReadinessGates = "Waiting for replacement machine node readiness gates before removing old machine"
+
+	// waitingForAPIVIPToMove is a log message used to inform the user that an old Machine is not yet being
+	// deleted because its Node still holds the API VIP.
+	waitingForAPIVIPToMove = "Waiting for API VIP to move before removing old machine"
+
+	// waitingForRolloutWindow is a log message used to inform the user that no replacement is being created
+	// for an index because the rollout window since the last index was replaced has not yet elapsed.
+	waitingForRolloutWindow = "Waiting for rollout window to elapse before replacing the next machine"
+
+	// startingCanary is a log message used to inform the user that an index has been chosen as the canary for
+	// the rollout, and that the remaining indexes will wait for approval once it has been replaced.
+	startingCanary = "Starting canary replacement"
+
+	// waitingForCanaryApproval is a log message used to inform the user that no replacement is being created for
+	// an index because the canary index has not yet been approved.
+	waitingForCanaryApproval = "Waiting for canary approval before replacing the next machine"
+
+	// refusingConcurrentDeletion is a log message used to inform the user that a Machine was not deleted because
+	// the deletion of another Machine has not yet been observed.
+	refusingConcurrentDeletion = "Refusing to delete machine until the previous deletion has been observed"
+
+	// skippingInactiveIndex is a log message used to inform the user that no operations are taking place for
+	// an index, because it is not listed in the active indexes annotation.
+	skippingInactiveIndex = "Skipping index not listed as active"
+
+	// insufficientQuotaForMachine is a log message used to inform the user that a new Machine was not created
+	// because the cloud provider does not have enough quota or capacity available for it.
+	insufficientQuotaForMachine = "Insufficient quota to create machine, skipping machine creation"
+
+	// missingReferencedSecretForMachine is a log message used to inform the user that a new Machine was not created
+	// because a secret referenced by the Machine template does not exist.
+	missingReferencedSecretForMachine = "Referenced secret is missing, skipping machine creation"
+
+	// apiVIPRecheckInterval is how often the Node of an old Machine is checked while its deletion is held
+	// because the Node holds the API VIP. Node label and annotation changes do not trigger a reconcile.
+	apiVIPRecheckInterval = 30 * time.Second
+
+	// readinessGateRecheckInterval is how often the Node of a replacement Machine is checked while the deletion of
+	// the old Machine is held by its readiness gates. Node condition changes do not trigger a reconcile.
+	readinessGateRecheckInterval = 30 * time.Second
+
+	// readinessWaitBaseInterval is the requeue interval used the first time a reconcile waits for a Machine to
+	// become ready. Node readiness changes of a Machine that is already Running are not otherwise observed.
+	readinessWaitBaseInterval = 5 * time.Second
+
+	// readinessWaitMaxInterval caps the requeue interval while waiting for a Machine to become ready.
+	readinessWaitMaxInterval = time.Minute
+
 	// unknownMachineName is a value used for logging new machines when we do not know the name
 	// of the upcoming machine. This can occur when all machines have been removed from an index
 	// and a new one will be created.
@@ -103,6 +196,10 @@ var (
 	// must be set to continue operation.
 	errReplicasRequired = errors.New("spec.replicas is unset: replicas is required")
 
+	// errInvalidActiveIndexes is used to inform users that the active indexes annotation could not be parsed
+	// as a comma separated list of indexes.
+	errInvalidActiveIndexes = errors.New("invalid value for active indexes annotation")
+
 	// errUnknownStrategy is used to inform users that the update strategy they have provided is not recognised.
 	errUnknownStrategy = errors.New("unknown update strategy")
 )
@@ -110,10 +207,22 @@ var (
 // reconcileMachineUpdates determines if any Machines are in need of an update and then handles those updates as per the
 // update strategy within the ControlPlaneMachineSet.
 // When a Machine needs an update, this function should create a replacement where appropriate.
-func (r *ControlPlaneMachineSetReconciler) reconcileMachineUpdates(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
+func (r *ControlPlaneMachineSetReconciler) reconcileMachineUpdates(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, machineProvider machineproviders.MachineProvider, machineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
+	// Observe the earlier deletion across every index, before any is left out as inactive.
+	r.observeDeletions(cpms, machineInfos)
+
+	machineInfos = activeMachineInfos(logger, cpms, machineInfos)
+
+	if cpms.Spec.Strategy.Type != machinev1.RollingUpdate {
+		// The deletion grace period is only observed by the RollingUpdate strategy.
+		if err := clearDeletionGraceStartTime(cpms); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	switch cpms.Spec.Strategy.Type {
 	case machinev1.RollingUpdate:
-		return r.reconcileMachineRollingUpdate(ctx, logger, cpms, machineProvider, machineInfos)
+		return r.reconcileMachineRollingUpdate(ctx, logger, cpms, replicas, machineProvider, machineInfos)
 	case machinev1.OnDelete:
 		return r.reconcileMachineOnDeleteUpdate(ctx, logger, cpms, machineProvider, machineInfos)
 	case machinev1.Recreate:
@@ -158,7 +267,7 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineUpdates(ctx context.C
 // to create a new Machine to fulfil the requirement of that index.
 //
 //nolint:cyclop
-func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, indexedMachineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
+func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replicas int32, machineProvider machineproviders.MachineProvider, indexedMachineInfos map[int32][]machineproviders.MachineInfo) (ctrl.Result, error) {
 	logger = logger.WithValues("updateStrategy", cpms.Spec.Strategy.Type)
 
 	// To ensure an ordered and safe reconciliation,
@@ -176,18 +285,44 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx con
 	// Devise the existing surge and keep track of the current surge count.
 	// No check for early stoppage is done here,
 	// as deletions can continue even if the maxSurge has been already reached.
-	surgeCount := deviseExistingSurge(cpms, sortedIndexedMs)
+	surgeCount := deviseExistingSurge(replicas, sortedIndexedMs)
 
-	var updated, shouldRequeue bool
+	// When the rollout is throttled, no further index may start its replacement until the window has elapsed.
+	rolloutWindowRemaining, err := r.getRolloutWindowRemaining(logger, cpms, sortedIndexedMs)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var updated, shouldRequeue, throttled bool
+
+	// The time left before an old Machine may be deleted, if its deletion is delayed by the deletion grace period,
+	// or by the minimum node ready duration of its replacement.
+	var deletionGraceRemaining time.Duration
+
+	// Whether the deletion of an old Machine is held because its Node holds the API VIP.
+	apiVIPHeld := false
 
 	for _, indexToMachines := range sortedIndexedMs {
 		idx := indexToMachines.index
 		machines := indexToMachines.machineInfos
 
-		if done, result, err := r.deleteReplacedMachines(ctx, logger, machineProvider, machines); err != nil {
+		if done, result, err := r.deleteReplacedMachines(ctx, logger, cpms, machineProvider, machines, &apiVIPHeld); err != nil {
 			return result, err
 		} else if done {
 			updated = true
+
+			if result.RequeueAfter > 0 && (deletionGraceRemaining == 0 || result.RequeueAfter < deletionGraceRemaining) {
+				deletionGraceRemaining = result.RequeueAfter
+			}
+		}
+
+		if done, err := r.deleteUnprovisionedMachines(ctx, logger, cpms, machineProvider, machines); err != nil {
+			return ctrl.Result{}, err
+		} else if done {
+			// The replacement is created again once the deleted Machine has been removed.
+			updated = true
+
+			continue
 		}
 
 		if r.waitForReadyMachine(logger, machines) || r.waitForReplacementMachine(logger, machines) {
@@ -201,7 +336,31 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx con
 			updated = true
 		}
 
-		if done, result, err := r.createRollingUpdateReplacementMachines(ctx, logger, machineProvider, machines, idx, maxSurge, &surgeCount); err != nil {
+		if rolloutWindowRemaining > 0 && startsUpdate(machines) {
+			logger.V(2).WithValues("index", idx, "remaining", rolloutWindowRemaining.String()).Info(waitingForRolloutWindow)
+
+			updated, throttled = true, true
+
+			continue
+		}
+
+		if startsUpdate(machines) && holdForProtectedIndex(logger, cpms, idx, sortedIndexedMs) {
+			updated = true
+
+			continue
+		}
+
+		if startsUpdate(machines) {
+			if held, err := holdForCanary(logger, cpms, idx); err != nil {
+				return ctrl.Result{}, err
+			} else if held {
+				updated = true
+
+				continue
+			}
+		}
+
+		if done, result, err := r.createRollingUpdateReplacementMachines(ctx, logger, cpms, machineProvider, machines, idx, maxSurge, &surgeCount); err != nil {
 			return result, err
 		} else if done {
 			updated = true
@@ -212,8 +371,35 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx con
 		logger.V(4).Info(noUpdatesRequired)
 	}
 
+	if deletionGraceRemaining == 0 {
+		// No old Machine is waiting to be deleted, so the next replacement starts its grace period afresh.
+		if err := clearDeletionGraceStartTime(cpms); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !apiVIPHeld {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionAPIVIPHold)
+	}
+
+	if err := reconcileCanary(logger, cpms, sortedIndexedMs); err != nil {
+		return ctrl.Result{}, fmt.Errorf("error reconciling canary: %w", err)
+	}
+
 	if shouldRequeue {
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.readinessWaits.requeueAfter()}, nil
+	}
+
+	r.readinessWaits.reset()
+
+	if deletionGraceRemaining > 0 {
+		// Check back in once the grace period has elapsed so that the old Machine can be deleted.
+		return ctrl.Result{RequeueAfter: deletionGraceRemaining}, nil
+	}
+
+	if throttled {
+		// Check back in once the rollout window has elapsed so that the next index can be replaced.
+		return ctrl.Result{RequeueAfter: rolloutWindowRemaining}, nil
 	}
 
 	return ctrl.Result{}, nil
@@ -225,6 +411,12 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineRollingUpdate(ctx con
 //
 // For on-delete updates, a new Machine is required when a machine index has a Machine with a non-zero deletion
 // timestamp but does not yet have a replacement created.
+// The OnDelete strategy never surges: a new Machine is never created while its index has a Machine that is not being
+// deleted.
+//
+// When the strategy is changed from RollingUpdate while a replacement is in flight, that replacement is allowed to
+// finish. Once the replacement Machine is ready, the Machine it replaces is deleted, as it would have been by the
+// rolling update. No further replacements are started until the user deletes the remaining outdated Machines.
 //
 // In certain scenarios, there may be indexes with missing Machines. In these circumstances, the update should attempt
 // to create a new Machine to fulfil the requirement of that index.
@@ -253,6 +445,15 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineOnDeleteUpdate(ctx co
 			continue
 		}
 
+		if done, err := r.deleteUnprovisionedMachines(ctx, logger, cpms, machineProvider, machines); err != nil {
+			return ctrl.Result{}, err
+		} else if done {
+			// The replacement is created again once the deleted Machine has been removed.
+			updated = true
+
+			continue
+		}
+
 		if r.waitForReadyMachine(logger, machines) || r.waitForReplacementMachine(logger, machines) {
 			// Relying on machine events is not sufficient in this case, as the machine could already be in Running phase
 			// while the backing node might still be in NotReady condition. Therefore in order to catch
@@ -264,7 +465,13 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineOnDeleteUpdate(ctx co
 			updated = true
 		}
 
-		if done, result, err := r.createOnDeleteReplacementMachines(ctx, logger, machineProvider, machines, idx); err != nil {
+		if done, result, err := r.completeRollingUpdateReplacement(ctx, logger, cpms, machineProvider, machines); err != nil {
+			return result, err
+		} else if done {
+			updated = true
+		}
+
+		if done, result, err := r.createOnDeleteReplacementMachines(ctx, logger, cpms, machineProvider, machines, idx); err != nil {
 			return result, err
 		} else if done {
 			updated = true
@@ -276,9 +483,11 @@ func (r *ControlPlaneMachineSetReconciler) reconcileMachineOnDeleteUpdate(ctx co
 	}
 
 	if shouldRequeue {
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: r.readinessWaits.requeueAfter()}, nil
 	}
 
+	r.readinessWaits.reset()
+
 	return ctrl.Result{}, nil
 }
 
@@ -295,7 +504,7 @@ func (r *ControlPlaneMachineSetReconciler) waitForReadyMachine(logger logr.Logge
 		// Consider the first found pending machine for this index to be the replacement machine.
 		replacementMachine := machinesPending[0]
 		logger := logger.WithValues("index", replacementMachine.Index, "namespace", r.Namespace, "name", replacementMachine.MachineRef.ObjectMeta.Name)
-		logger.V(2).Info(waitingForReady)
+		logger.V(r.readinessWaits.logLevel()).Info(waitingForReady)
 
 		return true
 	}
@@ -303,6 +512,61 @@ func (r *ControlPlaneMachineSetReconciler) waitForReadyMachine(logger logr.Logge
 	return false
 }
 
+// deleteUnprovisionedMachines deletes the pending Machines in an index that have not reached the Provisioned phase
+// within the replacement provisioning deadline, measured from their creation, so that they are created again.
+// Like any other deletion, no Machine is deleted while the deletion of another Machine has not yet been observed.
+func (r *ControlPlaneMachineSetReconciler) deleteUnprovisionedMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo) (bool, error) {
+	if r.ReplacementProvisioningDeadline <= 0 {
+		return false, nil
+	}
+
+	now := r.getClock().Now()
+	deleted := false
+
+	for _, machine := range pendingMachines(machines) {
+		created := machine.MachineRef.ObjectMeta.CreationTimestamp
+
+		if !machine.Provisioning || created.IsZero() || now.Sub(created.Time) < r.ReplacementProvisioningDeadline {
+			continue
+		}
+
+		logger := logger.WithValues("index", machine.Index, "namespace", r.Namespace, "name", machine.MachineRef.ObjectMeta.Name)
+		logger.V(2).WithValues("deadline", r.ReplacementProvisioningDeadline.String()).Info(deletingUnprovisionedMachine)
+
+		if !r.allowDeletion(logger, cpms, machine) {
+			// The Machine is deleted once the earlier deletion has been observed.
+			return true, nil
+		}
+
+		if allowed, err := stepAllowsAction(logger, cpms); err != nil {
+			return false, err
+		} else if !allowed {
+			// The Machine is deleted once the next step has been allowed.
+			return true, nil
+		}
+
+		if _, err := deleteMachine(ctx, logger, machineProvider, machine, r.Namespace); err != nil {
+			return false, err
+		}
+
+		r.deletions.record(machine)
+
+		if err := consumeStep(logger, cpms); err != nil {
+			return false, err
+		}
+
+		if r.Recorder != nil {
+			r.Recorder.Eventf(cpms, corev1.EventTypeWarning, reasonProvisioningDeadlineExceeded,
+				"Deleted machine %s in index %d, it was not provisioned within %s of its creation",
+				machine.MachineRef.ObjectMeta.Name, machine.Index, r.ReplacementProvisioningDeadline)
+		}
+
+		deleted = true
+	}
+
+	return deleted, nil
+}
+
 // waitForReplacementMachine checks machines and finds out whether to wait or not for any replacement to become ready.
 func (r *ControlPlaneMachineSetReconciler) waitForReplacementMachine(logger logr.Logger, machines []machineproviders.MachineInfo) bool {
 	machinesPending := pendingMachines(machines)
@@ -317,7 +581,7 @@ func (r *ControlPlaneMachineSetReconciler) waitForReplacementMachine(logger logr
 		outdatedMachine := machinesNeedingReplacement[0]
 
 		logger := logger.WithValues("index", outdatedMachine.Index, "namespace", r.Namespace, "name", outdatedMachine.MachineRef.ObjectMeta.Name)
-		logger.V(2).WithValues("replacementName", replacementMachine.MachineRef.ObjectMeta.Name).Info(waitingForReplacement)
+		logger.V(r.readinessWaits.logLevel()).WithValues("replacementName", replacementMachine.MachineRef.ObjectMeta.Name).Info(waitingForReplacement)
 
 		return true
 	}
@@ -325,6 +589,57 @@ func (r *ControlPlaneMachineSetReconciler) waitForReplacementMachine(logger logr
 	return false
 }
 
+// readinessWaitTracker counts the consecutive reconciles that have requeued to wait for a Machine to become ready.
+// It is shared by concurrent reconciles, so access is guarded by a lock.
+type readinessWaitTracker struct {
+	lock sync.Mutex
+
+	// count is the number of consecutive reconciles that have waited.
+	count int
+}
+
+// logLevel returns the verbosity at which the current wait is logged. The first wait, and each wait at which the
+// number of consecutive waits has doubled, is logged at the usual level. Other waits are logged at a higher verbosity
+// so that long waits do not flood the logs.
+func (t *readinessWaitTracker) logLevel() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if attempt := t.count + 1; attempt&(attempt-1) == 0 {
+		return 2
+	}
+
+	return 4
+}
+
+// requeueAfter records a wait and returns the interval after which to check the Machine again.
+// The interval doubles with each consecutive wait, up to readinessWaitMaxInterval.
+func (t *readinessWaitTracker) requeueAfter() time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	interval := readinessWaitBaseInterval
+	for i := 0; i < t.count && interval < readinessWaitMaxInterval; i++ {
+		interval *= 2
+	}
+
+	t.count++
+
+	if interval > readinessWaitMaxInterval {
+		return readinessWaitMaxInterval
+	}
+
+	return interval
+}
+
+// reset clears the count once a reconcile no longer waits for a Machine to become ready.
+func (t *readinessWaitTracker) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.count = 0
+}
+
 // waitForRemoveMachine checks machines and finds out whether to wait or not for any of them to be removed.
 func (r *ControlPlaneMachineSetReconciler) waitForRemoveMachine(logger logr.Logger, machines []machineproviders.MachineInfo) bool {
 	machinesDeleting := deletingMachines(machines)
@@ -345,18 +660,32 @@ func (r *ControlPlaneMachineSetReconciler) waitForRemoveMachine(logger logr.Logg
 	return false
 }
 
-func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+// deleteReplacedMachines deletes the Machines in an index that are no longer required.
+// When a deletion grace period is configured, the deletion of an outdated Machine that has a ready replacement is
+// delayed until the grace period has elapsed. While waiting, the returned result requests a requeue once the grace
+// period has elapsed.
+// When a minimum node ready duration is configured, the deletion is first delayed until the Node of the replacement
+// has been continuously Ready for that duration.
+// When the API VIP holder key is configured, the deletion is also delayed while the Node of the outdated Machine
+// holds the API VIP.
+// No Machine is deleted while the deletion of another Machine has not yet been observed.
+func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo, apiVIPHeld *bool) (bool, ctrl.Result, error) {
 	machinesNeedingReplacement := needReplacementMachines(machines)
 	machinesUpdated := updatedMachines(machines)
 	machinesOutdatedNonReady := nonReadyMachines(machinesNeedingReplacement)
 
 	var toDeleteMachine machineproviders.MachineInfo
 
+	// Only a Machine that has been replaced may still be serving, so only its deletion observes the grace period
+	// and reduces the redundancy of the control plane.
+	var deletingServingMachine bool
+
 	if hasAny(machinesNeedingReplacement) && hasAny(machinesUpdated) {
 		// The Outdated Machine still exists for this index,
 		// but an Updated replacement exists for it.
 		// Thus it is safe to trigger its Deletion.
 		toDeleteMachine = machinesNeedingReplacement[0]
+		deletingServingMachine = true
 	}
 
 	if hasAny(machinesOutdatedNonReady) {
@@ -366,6 +695,7 @@ func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Co
 		// but the configuration is broken or the Machine simply never becomes Ready.
 		// This means the Machine should be deleted to make room for a "third generation" replacement machine.
 		toDeleteMachine = machinesOutdatedNonReady[0]
+		deletingServingMachine = false
 	}
 
 	if len(machinesUpdated) > 1 {
@@ -373,6 +703,7 @@ func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Co
 		// This means there is an excess in Updated Machines for this index and
 		// the oldest Machine in this state should be deleted.
 		toDeleteMachine = sortMachineInfoByCreationTimestamp(machinesUpdated)[0]
+		deletingServingMachine = false
 	}
 
 	// Check if any Machine was deemed for deletion.
@@ -380,11 +711,65 @@ func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Co
 		logger := logger.WithValues("index", toDeleteMachine.Index, "namespace", r.Namespace, "name", toDeleteMachine.MachineRef.ObjectMeta.Name)
 
 		if !isDeletedMachine(toDeleteMachine) {
+			if deletingServingMachine {
+				if remaining := r.getReplacementStabilityRemaining(logger, cpms, machinesUpdated[0]); remaining > 0 {
+					logger.V(2).WithValues("replacement", machinesUpdated[0].MachineRef.ObjectMeta.Name, "remaining", remaining.String()).Info(waitingForStableReplacement)
+
+					return true, ctrl.Result{RequeueAfter: remaining}, nil
+				}
+
+				if unmet, err := r.getUnmetReadinessGates(ctx, cpms, machinesUpdated[0]); err != nil {
+					return false, ctrl.Result{}, err
+				} else if len(unmet) > 0 {
+					logger.V(2).WithValues("replacement", machinesUpdated[0].MachineRef.ObjectMeta.Name, "conditions", unmet).Info(waitingForReadinessGates)
+
+					return true, ctrl.Result{RequeueAfter: readinessGateRecheckInterval}, nil
+				}
+
+				if remaining, err := r.getDeletionGraceRemaining(logger, cpms); err != nil {
+					return false, ctrl.Result{}, err
+				} else if remaining > 0 {
+					logger.V(2).WithValues("remaining", remaining.String()).Info(waitingForDeletionGrace)
+
+					return true, ctrl.Result{RequeueAfter: remaining}, nil
+				}
+
+				if holdsVIP, err := r.nodeHoldsAPIVIP(ctx, cpms, toDeleteMachine); err != nil {
+					return false, ctrl.Result{}, err
+				} else if holdsVIP {
+					logger.V(2).WithValues("node", toDeleteMachine.NodeRef.ObjectMeta.Name).Info(waitingForAPIVIPToMove)
+					setAPIVIPHold(cpms, toDeleteMachine)
+					*apiVIPHeld = true
+
+					return true, ctrl.Result{RequeueAfter: apiVIPRecheckInterval}, nil
+				}
+			}
+
+			if !r.allowDeletion(logger, cpms, toDeleteMachine) {
+				return true, ctrl.Result{}, nil
+			}
+
+			if allowed, err := stepAllowsAction(logger, cpms); err != nil {
+				return false, ctrl.Result{}, err
+			} else if !allowed {
+				return true, ctrl.Result{}, nil
+			}
+
 			result, err := deleteMachine(ctx, logger, machineProvider, toDeleteMachine, r.Namespace)
 			if err != nil {
 				return false, result, err
 			}
 
+			r.deletions.record(toDeleteMachine)
+
+			if err := consumeStep(logger, cpms); err != nil {
+				return false, result, err
+			}
+
+			if deletingServingMachine {
+				r.recordReducedRedundancy(cpms, toDeleteMachine)
+			}
+
 			return true, result, nil
 		}
 
@@ -395,17 +780,57 @@ func (r *ControlPlaneMachineSetReconciler) deleteReplacedMachines(ctx context.Co
 	return false, ctrl.Result{}, nil
 }
 
+// completeRollingUpdateReplacement finishes the replacement of an index that was started by the RollingUpdate strategy.
+// The OnDelete strategy only creates a replacement once the outdated Machine has been deleted, so an outdated Machine
+// that is not being deleted, alongside a Ready replacement, can only be left over from a RollingUpdate surge created
+// before the strategy was changed. The outdated Machine is deleted so that the index settles on the replacement.
+func (r *ControlPlaneMachineSetReconciler) completeRollingUpdateReplacement(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo) (bool, ctrl.Result, error) {
+	machinesOutdatedNonDeleted := nonDeletedMachines(needReplacementMachines(machines))
+
+	if isEmpty(machinesOutdatedNonDeleted) || isEmpty(updatedNonDeletedMachines(machines)) {
+		return false, ctrl.Result{}, nil
+	}
+
+	// Consider the first found outdated machine for this index to be the one that was being replaced.
+	outdatedMachine := machinesOutdatedNonDeleted[0]
+	logger = logger.WithValues("index", outdatedMachine.Index, "namespace", r.Namespace, "name", outdatedMachine.MachineRef.ObjectMeta.Name)
+	logger.V(2).Info(completingRollingUpdateReplacement)
+
+	if !r.allowDeletion(logger, cpms, outdatedMachine) {
+		return true, ctrl.Result{}, nil
+	}
+
+	if allowed, err := stepAllowsAction(logger, cpms); err != nil {
+		return false, ctrl.Result{}, err
+	} else if !allowed {
+		return true, ctrl.Result{}, nil
+	}
+
+	result, err := deleteMachine(ctx, logger, machineProvider, outdatedMachine, r.Namespace)
+	if err != nil {
+		return false, result, err
+	}
+
+	r.deletions.record(outdatedMachine)
+
+	if err := consumeStep(logger, cpms); err != nil {
+		return false, result, err
+	}
+
+	return true, result, nil
+}
+
 // create replacement machines for the OnDelete method.
 // this function will attempt to create new machines when none are available
 // in the machine info, or when there is a machine that needs an update for
 // which no replacement has been created.
-func (r *ControlPlaneMachineSetReconciler) createOnDeleteReplacementMachines(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo, idx int32) (bool, ctrl.Result, error) {
+func (r *ControlPlaneMachineSetReconciler) createOnDeleteReplacementMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo, idx int32) (bool, ctrl.Result, error) {
 	if isEmpty(machines) {
 		// No Machines exist for this index.
 		// Trigger a Machine creation.
 		logger := logger.WithValues("index", idx, "namespace", r.Namespace, "name", unknownMachineName)
 
-		_, result, err := r.createMachine(ctx, logger, machineProvider, idx)
+		_, result, err := r.createMachine(ctx, logger, cpms, machineProvider, idx)
 		if err != nil {
 			return false, result, err
 		}
@@ -418,9 +843,15 @@ func (r *ControlPlaneMachineSetReconciler) createOnDeleteReplacementMachines(ctx
 		// if there is only 1 machine and it needs an update
 		logger := logger.WithValues("index", machines[0].Index, "namespace", r.Namespace, "name", machines[0].MachineRef.ObjectMeta.Name)
 
+		if machines[0].AwaitingRemoval {
+			// if deleted and no longer held by a pre-drain hook, wait for it to be removed
+			logger.V(2).Info(waitingForRemovalBeforeReplacement)
+			return true, ctrl.Result{}, nil
+		}
+
 		if isDeletedMachine(machines[0]) {
 			// if deleted create the replacement
-			_, result, err := r.createMachine(ctx, logger, machineProvider, idx)
+			_, result, err := r.createMachine(ctx, logger, cpms, machineProvider, idx)
 			if err != nil {
 				return false, result, err
 			}
@@ -441,7 +872,7 @@ func (r *ControlPlaneMachineSetReconciler) createOnDeleteReplacementMachines(ctx
 // in the machine info, or when there is a machine that needs an update for
 // which no replacement has been created. in all cases it will observe the
 // surge parameters when creating new machines.
-func (r *ControlPlaneMachineSetReconciler) createRollingUpdateReplacementMachines(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo, idx int32, maxSurge int, surgeCount *int) (bool, ctrl.Result, error) {
+func (r *ControlPlaneMachineSetReconciler) createRollingUpdateReplacementMachines(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, machines []machineproviders.MachineInfo, idx int32, maxSurge int, surgeCount *int) (bool, ctrl.Result, error) {
 	machinesNeedingReplacement := needReplacementMachines(machines)
 	machinesPending := pendingMachines(machines)
 	machinesUpdatedNonDeleted := updatedNonDeletedMachines(machines)
@@ -451,7 +882,7 @@ func (r *ControlPlaneMachineSetReconciler) createRollingUpdateReplacementMachine
 		// Trigger a Machine creation.
 		logger := logger.WithValues("index", idx, "namespace", r.Namespace, "name", unknownMachineName)
 
-		result, err := r.createMachineWithSurge(ctx, logger, machineProvider, idx, maxSurge, surgeCount)
+		result, err := r.createMachineWithSurge(ctx, logger, cpms, machineProvider, idx, maxSurge, surgeCount)
 		if err != nil {
 			return false, result, err
 		}
@@ -467,13 +898,21 @@ func (r *ControlPlaneMachineSetReconciler) createRollingUpdateReplacementMachine
 		outdatedMachine := machinesNeedingReplacement[0]
 		logger := logger.WithValues("index", outdatedMachine.Index, "namespace", r.Namespace, "name", outdatedMachine.MachineRef.ObjectMeta.Name)
 
+		if len(awaitingRemovalMachines(machinesNeedingReplacement)) == len(machinesNeedingReplacement) {
+			// The Machines in need of replacement are being deleted without waiting for a replacement.
+			// Wait for them to be removed before creating the replacement, rather than surging while they remain.
+			logger.V(2).Info(waitingForRemovalBeforeReplacement)
+
+			return true, ctrl.Result{}, nil
+		}
+
 		// The diff will be nil if the machine has been deleted.
 		// Only log whether a machine requires an update if diff is not nil.
 		if outdatedMachine.Diff != nil {
 			logger.V(2).WithValues("diff", outdatedMachine.Diff).Info(machineRequiresUpdate)
 		}
 
-		result, err := r.createMachineWithSurge(ctx, logger, machineProvider, outdatedMachine.Index, maxSurge, surgeCount)
+		result, err := r.createMachineWithSurge(ctx, logger, cpms, machineProvider, outdatedMachine.Index, maxSurge, surgeCount)
 		if err != nil {
 			return false, result, err
 		}
@@ -484,9 +923,95 @@ func (r *ControlPlaneMachineSetReconciler) createRollingUpdateReplacementMachine
 	return false, ctrl.Result{}, nil
 }
 
+// deletionGuard ensures that at most one Machine is being removed at a time, whatever the surge.
+// The cache does not observe a deletion until a later reconcile, so without the guard a second deletion could
+// remove a further etcd member before the loss of the first has been accounted for, putting quorum at risk.
+// The guard holds an expectation for the deleted Machine across reconciles, until the cache shows that the Machine is
+// being deleted, or has been removed. It is shared by concurrent reconciles, so access is guarded by a lock.
+type deletionGuard struct {
+	lock sync.Mutex
+
+	// deleted is the name of the Machine whose deletion has not yet been observed, if any.
+	deleted string
+}
+
+// observe clears the expectation once the Machine deleted has been observed as deleted, or as removed, within the
+// Machines provided.
+func (g *deletionGuard) observe(machineInfos map[int32][]machineproviders.MachineInfo) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.deleted == "" {
+		return
+	}
+
+	for _, machine := range machineInfosMaptoSlice(machineInfos) {
+		if machine.MachineRef != nil && machine.MachineRef.ObjectMeta.Name == g.deleted && !isDeletedMachine(machine) {
+			return
+		}
+	}
+
+	g.deleted = ""
+}
+
+// pending returns the name of the Machine whose deletion has not yet been observed, if any.
+func (g *deletionGuard) pending() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.deleted
+}
+
+// record notes that the Machine has been deleted, so that no other Machine is deleted until this deletion has been
+// observed.
+func (g *deletionGuard) record(machine machineproviders.MachineInfo) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.deleted = machine.MachineRef.ObjectMeta.Name
+}
+
+// observeDeletions clears the expectation held by the deletion guard once its deletion has been observed, and removes
+// the DeletionHeld condition once no deletion is awaiting observation.
+func (r *ControlPlaneMachineSetReconciler) observeDeletions(cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) {
+	r.deletions.observe(machineInfos)
+
+	if r.deletions.pending() == "" {
+		meta.RemoveStatusCondition(&cpms.Status.Conditions, conditionDeletionHeld)
+	}
+}
+
+// allowDeletion returns true when no earlier deletion is awaiting observation.
+// Otherwise, the refused deletion is logged and explained by the DeletionHeld condition, and is retried once the
+// earlier deletion has been observed.
+func (r *ControlPlaneMachineSetReconciler) allowDeletion(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machine machineproviders.MachineInfo) bool {
+	deleted := r.deletions.pending()
+	if deleted == "" {
+		return true
+	}
+
+	logger.V(2).WithValues("deletedMachine", deleted).Info(refusingConcurrentDeletion)
+
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionDeletionHeld,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonAwaitingObservedDeletion,
+		ObservedGeneration: cpms.Generation,
+		Message: fmt.Sprintf("Not deleting machine %s in index %d until the deletion of machine %s has been observed",
+			machine.MachineRef.ObjectMeta.Name, machine.Index, deleted),
+	})
+
+	return false
+}
+
 // deleteMachine deletes the Machine provided.
 func deleteMachine(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, outdatedMachine machineproviders.MachineInfo, namespace string) (ctrl.Result, error) { //nolint:unparam
-	if err := machineProvider.DeleteMachine(ctx, logger, outdatedMachine.MachineRef); err != nil {
+	deleteCtx, span := util.StartSpan(ctx, "DeleteMachine",
+		attribute.Int("index", int(outdatedMachine.Index)), attribute.String("machine", outdatedMachine.MachineRef.ObjectMeta.Name))
+	err := machineProvider.DeleteMachine(deleteCtx, logger, outdatedMachine.MachineRef)
+	util.EndSpan(span, err)
+
+	if err != nil {
 		werr := fmt.Errorf("error deleting Machine %s/%s: %w", namespace, outdatedMachine.MachineRef.ObjectMeta.Name, err)
 		logger.Error(werr, errorDeletingMachine)
 
@@ -499,15 +1024,25 @@ func deleteMachine(ctx context.Context, logger logr.Logger, machineProvider mach
 }
 
 // createMachine checks if a machine already exists and otherwise creates the Machine provided.
-func (r *ControlPlaneMachineSetReconciler) createMachine(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, idx int32) (bool, ctrl.Result, error) { //nolint:unparam
+func (r *ControlPlaneMachineSetReconciler) createMachine(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, idx int32) (bool, ctrl.Result, error) { //nolint:unparam
 	// Check if a replacement machine already exists and
 	// was not previously detected due to potential stale cache.
-	exists, err := r.checkForExistingReplacement(ctx, logger, machineProvider, idx)
+	existing, err := r.checkForExistingReplacement(ctx, logger, cpms, machineProvider, idx)
 	if err != nil {
 		return false, ctrl.Result{}, fmt.Errorf("error checking for existing replacement: %w", err)
 	}
 
-	if exists {
+	if existing != nil && existing.NeedsUpdate {
+		// The OnDelete strategy only creates a Machine once the Machine it replaces has been deleted.
+		// This means the machine provider cache was stale, or did not yet include the Machine, when we
+		// previously checked.
+		logger.V(2).WithValues("machine", existing.MachineRef.ObjectMeta.Name).Info(onDeleteMachineNotDeleted)
+
+		// Do not error but signal the machine was not created (created=false).
+		return false, ctrl.Result{}, nil
+	}
+
+	if existing != nil {
 		// A machine within the index for which we are about to
 		// create a replacement already has a replacement machine.
 		// This means the machine provider cache was stale when we previously checked.
@@ -518,22 +1053,164 @@ func (r *ControlPlaneMachineSetReconciler) createMachine(ctx context.Context, lo
 		return false, ctrl.Result{}, nil
 	}
 
-	if err := machineProvider.CreateMachine(ctx, logger, idx); err != nil {
+	if found, err := checkReferencedSecrets(ctx, logger, cpms, machineProvider, idx); err != nil {
+		return false, ctrl.Result{}, err
+	} else if !found {
+		// A Machine created without its secrets would never start.
+		// Do not error but signal the machine was not created (created=false).
+		return false, ctrl.Result{}, nil
+	}
+
+	if sufficient, err := checkMachineCapacity(ctx, logger, cpms, machineProvider, idx); err != nil {
+		return false, ctrl.Result{}, err
+	} else if !sufficient {
+		// Creating the Machine now would only leave a failed Machine behind.
+		// Do not error but signal the machine was not created (created=false).
+		return false, ctrl.Result{}, nil
+	}
+
+	if allowed, err := stepAllowsAction(logger, cpms); err != nil {
+		return false, ctrl.Result{}, err
+	} else if !allowed {
+		// The Machine is created once the next step has been allowed.
+		// Do not error but signal the machine was not created (created=false).
+		return false, ctrl.Result{}, nil
+	}
+
+	createCtx, span := util.StartSpan(ctx, "CreateMachine", attribute.Int("index", int(idx)))
+	machineName, err := machineProvider.CreateMachine(createCtx, logger, idx)
+	util.EndSpan(span, err)
+
+	if err != nil {
 		werr := fmt.Errorf("error creating new Machine for index %d: %w", idx, err)
 		logger.Error(werr, errorCreatingMachine)
 
 		return false, ctrl.Result{}, werr
 	}
 
+	if err := consumeStep(logger, cpms); err != nil {
+		return false, ctrl.Result{}, err
+	}
+
+	if err := r.verifyCreatedMachineIndex(ctx, logger, cpms, machineProvider, idx, machineName); err != nil {
+		return false, ctrl.Result{}, err
+	}
+
 	logger.V(2).Info(createdReplacement)
 
 	return true, ctrl.Result{}, nil
 }
 
+// verifyCreatedMachineIndex checks that the Machine created for the index carries the index label for that index.
+// Only the Machine created by this call is checked, as Machines created concurrently by other actors are not
+// expected to carry the index label of this index. A mislabelled Machine would be treated as part of another index,
+// so it is recorded on the ControlPlaneMachineSet, which is marked as degraded until the Machine has been removed.
+func (r *ControlPlaneMachineSetReconciler) verifyCreatedMachineIndex(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, idx int32, machineName string) error {
+	mInfos, err := machineProvider.WithClient(r.UncachedClient).GetMachineInfos(ctx, logger)
+	if err != nil {
+		return fmt.Errorf("error getting Machines: %w", err)
+	}
+
+	expectedIndex := strconv.Itoa(int(idx))
+
+	for _, m := range mInfos {
+		if m.MachineRef == nil || m.MachineRef.ObjectMeta.Name != machineName {
+			continue
+		}
+
+		index, ok := m.MachineRef.ObjectMeta.Labels[machineproviders.MachineIndexLabel]
+		if ok && index == expectedIndex {
+			return nil
+		}
+
+		werr := fmt.Errorf("%w: machine %s created for index %d has index label %q", errMismatchedMachineIndex, machineName, idx, index)
+		logger.Error(werr, createdMachineWithMismatchedIndex)
+
+		if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+			state.MismatchedIndexMachine = machineName
+		}); err != nil {
+			return fmt.Errorf("error recording mismatched index machine %s: %w", machineName, err)
+		}
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:    conditionDegraded,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonMismatchedMachineIndex,
+			Message: fmt.Sprintf("Machine %s was created for index %d but is labelled with index %q, remove it to continue", machineName, idx, index),
+		})
+
+		return werr
+	}
+
+	return nil
+}
+
+// checkReferencedSecrets checks that the secrets referenced by the Machine template exist, when the machine provider
+// implements the check. When a secret is missing, the ControlPlaneMachineSet is marked as degraded and false is
+// returned so that the Machine creation is skipped.
+func checkReferencedSecrets(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, idx int32) (bool, error) {
+	secretChecker, ok := machineProvider.(machineproviders.SecretChecker)
+	if !ok {
+		return true, nil
+	}
+
+	err := secretChecker.CheckReferencedSecrets(ctx, logger)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, machineproviders.ErrMissingReferencedSecret):
+		logger.Error(err, missingReferencedSecretForMachine)
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:               conditionDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             reasonMissingReferencedSecret,
+			Message:            fmt.Sprintf("Unable to create a machine for index %d: %v", idx, err),
+			ObservedGeneration: cpms.Generation,
+		})
+
+		return false, nil
+	default:
+		return false, fmt.Errorf("error checking referenced secrets for new Machine for index %d: %w", idx, err)
+	}
+}
+
+// checkMachineCapacity runs the capacity preflight for the index, when the machine provider implements one.
+// When the cloud provider reports insufficient quota, the ControlPlaneMachineSet is marked as degraded and
+// false is returned so that the Machine creation is skipped.
+func checkMachineCapacity(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, idx int32) (bool, error) {
+	capacityChecker, ok := machineProvider.(machineproviders.CapacityChecker)
+	if !ok {
+		return true, nil
+	}
+
+	err := capacityChecker.CheckCapacity(ctx, logger, idx)
+
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, machineproviders.ErrInsufficientQuota):
+		logger.Error(err, insufficientQuotaForMachine)
+
+		meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+			Type:               conditionDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             reasonInsufficientQuota,
+			Message:            fmt.Sprintf("Unable to create a machine for index %d: %v", idx, err),
+			ObservedGeneration: cpms.Generation,
+		})
+
+		return false, nil
+	default:
+		return false, fmt.Errorf("error checking capacity for new Machine for index %d: %w", idx, err)
+	}
+}
+
 // createMachineWithSurge creates the Machine provided while observing the surge count.
 // This function will not create machines if the current surgeCount is greater
 // than the maxSurge. If it does create a machine, it will increase the surgeCount.
-func (r *ControlPlaneMachineSetReconciler) createMachineWithSurge(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, idx int32, maxSurge int, surgeCount *int) (ctrl.Result, error) {
+func (r *ControlPlaneMachineSetReconciler) createMachineWithSurge(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, idx int32, maxSurge int, surgeCount *int) (ctrl.Result, error) {
 	// Check if a surge in Machines is allowed.
 	if *surgeCount >= maxSurge {
 		// No more room to surge
@@ -544,7 +1221,7 @@ func (r *ControlPlaneMachineSetReconciler) createMachineWithSurge(ctx context.Co
 
 	// There is still room to surge,
 	// trigger a Replacement Machine creation.
-	created, result, err := r.createMachine(ctx, logger, machineProvider, idx)
+	created, result, err := r.createMachine(ctx, logger, cpms, machineProvider, idx)
 	if err != nil {
 		return result, err
 	}
@@ -557,25 +1234,37 @@ func (r *ControlPlaneMachineSetReconciler) createMachineWithSurge(ctx context.Co
 }
 
 // checkForExistingReplacement checks with an uncached API client if a specific index,
-// already has an existing, up to date, replacement machine.
-func (r *ControlPlaneMachineSetReconciler) checkForExistingReplacement(ctx context.Context, logger logr.Logger, machineProvider machineproviders.MachineProvider, idx int32) (bool, error) {
+// already has an existing, up to date, replacement machine, and returns it.
+// The OnDelete strategy never surges, so with the OnDelete strategy any Machine in the index that is not being
+// deleted is returned, whether or not it needs an update.
+func (r *ControlPlaneMachineSetReconciler) checkForExistingReplacement(ctx context.Context, logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineProvider machineproviders.MachineProvider, idx int32) (*machineproviders.MachineInfo, error) {
 	// Define an uncached machine provider.
 	uncachedMachineProvider := machineProvider.WithClient(r.UncachedClient)
 
 	mInfos, err := uncachedMachineProvider.GetMachineInfos(ctx, logger)
 	if err != nil {
-		return false, fmt.Errorf("error getting Machines: %w", err)
+		return nil, fmt.Errorf("error getting Machines: %w", err)
 	}
 
-	for _, m := range mInfos {
-		if m.Index == idx && !m.NeedsUpdate && m.MachineRef.ObjectMeta.DeletionTimestamp == nil {
+	var outdated *machineproviders.MachineInfo
+
+	for i, m := range mInfos {
+		if m.Index != idx || m.MachineRef.ObjectMeta.DeletionTimestamp != nil {
+			continue
+		}
+
+		if !m.NeedsUpdate {
 			// An up to date Machine has been found for the specified index,
 			// meaning an updated replacement already exists for this index.
-			return true, nil
+			return &mInfos[i], nil
+		}
+
+		if outdated == nil && cpms.Spec.Strategy.Type == machinev1.OnDelete {
+			outdated = &mInfos[i]
 		}
 	}
 
-	return false, nil
+	return outdated, nil
 }
 
 // isDeletedMachine checks if a machine is deleted.
@@ -597,6 +1286,20 @@ func needReplacementMachines(machinesInfo []machineproviders.MachineInfo) []mach
 	return needsReplacement
 }
 
+// upToDateMachines returns the list of MachineInfo which have a Machine that does not need an update,
+// whether or not it is Ready.
+func upToDateMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for _, m := range machinesInfo {
+		if !m.NeedsUpdate {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
 // deletingMachines returns the list of MachineInfo which have a Machine with a deletion timestamp.
 func deletingMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
 	result := []machineproviders.MachineInfo{}
@@ -610,6 +1313,20 @@ func deletingMachines(machinesInfo []machineproviders.MachineInfo) []machineprov
 	return result
 }
 
+// awaitingRemovalMachines returns the list of MachineInfo which have a Machine that is being deleted and is no
+// longer held by a pre-drain lifecycle hook.
+func awaitingRemovalMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for i := range machinesInfo {
+		if machinesInfo[i].AwaitingRemoval {
+			result = append(result, machinesInfo[i])
+		}
+	}
+
+	return result
+}
+
 // pendingMachines returns the list of MachineInfo which have a Pending Machine and are not pending deletion.
 // A Machine pending deletion should not be considered pending as it will never progress into a Ready Machine.
 func pendingMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
@@ -637,6 +1354,19 @@ func updatedMachines(machinesInfo []machineproviders.MachineInfo) []machineprovi
 	return result
 }
 
+// nonDeletedMachines returns the list of MachineInfo which have a Machine that is not pending deletion.
+func nonDeletedMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
+	result := []machineproviders.MachineInfo{}
+
+	for i := range machinesInfo {
+		if !isDeletedMachine(machinesInfo[i]) {
+			result = append(result, machinesInfo[i])
+		}
+	}
+
+	return result
+}
+
 // updatedNonDeletedMachines returns the list of MachineInfo which have an Updated (Spec up-to-date and Ready) Machine and
 // are not pending deletion.
 func updatedNonDeletedMachines(machinesInfo []machineproviders.MachineInfo) []machineproviders.MachineInfo {
@@ -725,8 +1455,8 @@ func machineInfosMaptoSlice(indexedMachineInfos map[int32][]machineproviders.Mac
 }
 
 // deviseExistingSurge computes the current amount of replicas surge for the ControlPlaneMachineSet.
-func deviseExistingSurge(cpms *machinev1.ControlPlaneMachineSet, mis []indexToMachineInfos) int {
-	desiredReplicas := int(*cpms.Spec.Replicas)
+func deviseExistingSurge(replicas int32, mis []indexToMachineInfos) int {
+	desiredReplicas := int(replicas)
 	currentReplicas := 0
 
 	for _, mi := range mis {
@@ -745,3 +1475,407 @@ func hasAny(machinesInfo []machineproviders.MachineInfo) bool {
 func isEmpty(machinesInfo []machineproviders.MachineInfo) bool {
 	return len(machinesInfo) == 0
 }
+
+// reconcileForceRoll checks whether a new force roll token has been set on the ControlPlaneMachineSet.
+// When the token has not yet been consumed, the time of the request is recorded so that the machine provider
+// reports every Machine created before the request as needing an update, and the token is marked as consumed.
+// From there, the configured update strategy replaces the Machines as it would for any other update.
+// Removing the force roll annotation cancels any forced roll that has not yet completed.
+func (r *ControlPlaneMachineSetReconciler) reconcileForceRoll(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) error {
+	token := cpms.GetAnnotations()[forceRollAnnotation]
+	if token == "" {
+		return updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+			state.ForceRollToken = ""
+			state.ForceRollTime = nil
+		})
+	}
+
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return fmt.Errorf("error reading force roll token: %w", err)
+	}
+
+	if state.ForceRollToken == token {
+		// This token has already been acted upon.
+		return nil
+	}
+
+	forceRollTime := persistedTime(r.getClock().Now())
+
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.ForceRollToken = token
+		state.ForceRollTime = forceRollTime
+	}); err != nil {
+		return fmt.Errorf("error recording force roll token: %w", err)
+	}
+
+	logger.V(1).Info("Observed a force roll request, machines created before the request will be replaced",
+		"token", token, "forceRollTime", forceRollTime.Format(time.RFC3339))
+
+	return nil
+}
+
+// getRolloutWindow returns the rollout window configured on the ControlPlaneMachineSet,
+// or zero when the RollingUpdate is not throttled.
+func getRolloutWindow(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) time.Duration {
+	value, ok := cpms.GetAnnotations()[rolloutWindowAnnotation]
+	if !ok {
+		return 0
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid rollout window", "annotation", rolloutWindowAnnotation)
+
+		return 0
+	}
+
+	return window
+}
+
+// getActiveIndexes returns the set of indexes listed in the active indexes annotation, and whether the
+// annotation is set. When the annotation cannot be parsed, no index is considered active so that the
+// ControlPlaneMachineSet does not start managing Machines that the user intended to handle manually.
+func getActiveIndexes(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (sets.Set[int32], bool) {
+	value, ok := cpms.GetAnnotations()[activeIndexesAnnotation]
+	if !ok {
+		return nil, false
+	}
+
+	activeIndexes := sets.New[int32]()
+
+	for _, field := range strings.Split(value, ",") {
+		idx, err := strconv.ParseInt(strings.TrimSpace(field), 10, 32)
+		if err != nil || idx < 0 {
+			logger.Error(fmt.Errorf("%w: %q", errInvalidActiveIndexes, value), "No indexes will be managed", "annotation", activeIndexesAnnotation)
+
+			return sets.New[int32](), true
+		}
+
+		activeIndexes.Insert(int32(idx))
+	}
+
+	return activeIndexes, true
+}
+
+// activeMachineInfos filters the indexed machine information down to the indexes listed in the active indexes
+// annotation, so that the update strategies never create or delete Machines in any other index.
+// The failure domain mapping is computed by the machine provider across all indexes, so indexes that are
+// not listed keep their failure domains and remain reported in the status.
+func activeMachineInfos(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, machineInfos map[int32][]machineproviders.MachineInfo) map[int32][]machineproviders.MachineInfo {
+	activeIndexes, ok := getActiveIndexes(logger, cpms)
+	if !ok {
+		return machineInfos
+	}
+
+	filtered := make(map[int32][]machineproviders.MachineInfo, len(machineInfos))
+
+	for idx, machines := range machineInfos {
+		if !activeIndexes.Has(idx) {
+			logger.V(4).WithValues("index", idx).Info(skippingInactiveIndex)
+
+			continue
+		}
+
+		filtered[idx] = machines
+	}
+
+	return filtered
+}
+
+// getRolloutWindowRemaining returns how long the RollingUpdate must wait before starting the replacement of the
+// next index. While an index is completing its replacement, the full window remains. Once complete, the window
+// is counted from the last index completion time recorded on the ControlPlaneMachineSet.
+func (r *ControlPlaneMachineSetReconciler) getRolloutWindowRemaining(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, sortedIndexedMs []indexToMachineInfos) (time.Duration, error) {
+	window := getRolloutWindow(logger, cpms)
+	if window <= 0 {
+		return 0, nil
+	}
+
+	for _, indexToMachines := range sortedIndexedMs {
+		if isCompletingUpdate(indexToMachines.machineInfos) {
+			return window, nil
+		}
+	}
+
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return 0, fmt.Errorf("error reading last index completed time: %w", err)
+	}
+
+	if state.LastIndexCompletedTime == nil {
+		return 0, nil
+	}
+
+	if remaining := state.LastIndexCompletedTime.Add(window).Sub(r.getClock().Now()); remaining > 0 {
+		return remaining, nil
+	}
+
+	return 0, nil
+}
+
+// reconcileRolloutWindow records the time at which an index completes its replacement, so that a throttled
+// RollingUpdate can wait for the rollout window to elapse before replacing the next index.
+// The time is recorded once, when the replaced Machine has been removed. While an index is completing its
+// replacement, the full window remains, so the time recorded for the previous index is cleared.
+func (r *ControlPlaneMachineSetReconciler) reconcileRolloutWindow(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, previousReplicas, previousUpdatedReplicas int32, machineInfos map[int32][]machineproviders.MachineInfo) error {
+	completing := false
+
+	for _, machines := range machineInfos {
+		if isCompletingUpdate(machines) {
+			completing = true
+		}
+	}
+
+	if cpms.Spec.Strategy.Type != machinev1.RollingUpdate || getRolloutWindow(logger, cpms) <= 0 || completing {
+		if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+			state.LastIndexCompletedTime = nil
+		}); err != nil {
+			return fmt.Errorf("error clearing last index completed time: %w", err)
+		}
+
+		return nil
+	}
+
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return fmt.Errorf("error reading last index completed time: %w", err)
+	}
+
+	if state.LastIndexCompletedTime != nil || !isReplacementCompleted(cpms, previousReplicas, previousUpdatedReplicas) {
+		return nil
+	}
+
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.LastIndexCompletedTime = persistedTime(r.getClock().Now())
+	}); err != nil {
+		return fmt.Errorf("error recording last index completed time: %w", err)
+	}
+
+	return nil
+}
+
+// getDeletionGrace returns the deletion grace period configured on the ControlPlaneMachineSet,
+// or zero when outdated Machines are deleted as soon as their replacement is ready.
+func getDeletionGrace(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) time.Duration {
+	value, ok := cpms.GetAnnotations()[deletionGraceAnnotation]
+	if !ok {
+		return 0
+	}
+
+	grace, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid deletion grace period", "annotation", deletionGraceAnnotation)
+
+		return 0
+	}
+
+	return grace
+}
+
+// getMinNodeReadyDuration returns the minimum duration for which the Node of a replacement Machine must have been
+// continuously Ready before the outdated Machine is deleted, or zero when no minimum is configured.
+func getMinNodeReadyDuration(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) time.Duration {
+	value, ok := cpms.GetAnnotations()[minNodeReadyDurationAnnotation]
+	if !ok {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid minimum node ready duration", "annotation", minNodeReadyDurationAnnotation)
+
+		return 0
+	}
+
+	return duration
+}
+
+// getReplacementStabilityRemaining returns how long the Node of the replacement Machine must still remain Ready
+// before it is considered stable. As the time from which the Node has been Ready is taken from its Ready condition,
+// the wait restarts whenever the Node flaps NotReady.
+func (r *ControlPlaneMachineSetReconciler) getReplacementStabilityRemaining(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet, replacement machineproviders.MachineInfo) time.Duration {
+	minReady := getMinNodeReadyDuration(logger, cpms)
+	if minReady <= 0 || replacement.ReadySince.IsZero() {
+		return 0
+	}
+
+	if remaining := replacement.ReadySince.Add(minReady).Sub(r.getClock().Now()); remaining > 0 {
+		return remaining
+	}
+
+	return 0
+}
+
+// getReadinessGates returns the Node condition types configured as readiness gates on the ControlPlaneMachineSet.
+func getReadinessGates(cpms *machinev1.ControlPlaneMachineSet) []corev1.NodeConditionType {
+	gates := []corev1.NodeConditionType{}
+
+	for _, gate := range strings.Split(cpms.GetAnnotations()[nodeReadinessGatesAnnotation], ",") {
+		if gate = strings.TrimSpace(gate); gate != "" {
+			gates = append(gates, corev1.NodeConditionType(gate))
+		}
+	}
+
+	return gates
+}
+
+// getUnmetReadinessGates returns the readiness gates that are not yet True on the Node of the replacement Machine.
+// When the Node cannot be found, none of the readiness gates are met. When no readiness gates are configured,
+// the Node is not checked.
+func (r *ControlPlaneMachineSetReconciler) getUnmetReadinessGates(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet, replacement machineproviders.MachineInfo) ([]corev1.NodeConditionType, error) {
+	gates := getReadinessGates(cpms)
+	if len(gates) == 0 {
+		return nil, nil
+	}
+
+	if replacement.NodeRef == nil {
+		return gates, nil
+	}
+
+	nodeName := replacement.NodeRef.ObjectMeta.Name
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, node); apierrors.IsNotFound(err) {
+		return gates, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error fetching node %s: %w", nodeName, err)
+	}
+
+	unmet := []corev1.NodeConditionType{}
+
+	for _, gate := range gates {
+		if !hasTrueNodeCondition(node, gate) {
+			unmet = append(unmet, gate)
+		}
+	}
+
+	return unmet, nil
+}
+
+// hasTrueNodeCondition returns true when the Node has a condition of the given type with a True status.
+func hasTrueNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == conditionType {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// getDeletionGraceRemaining returns how long the deletion of an outdated Machine with a ready replacement must still
+// be delayed. The grace period starts the first time this is called for the replacement and the start time is recorded
+// on the ControlPlaneMachineSet, so that the grace period is not restarted by an operator restart.
+func (r *ControlPlaneMachineSetReconciler) getDeletionGraceRemaining(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) (time.Duration, error) {
+	grace := getDeletionGrace(logger, cpms)
+	if grace <= 0 {
+		return 0, nil
+	}
+
+	now := r.getClock().Now()
+
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		return 0, fmt.Errorf("error reading deletion grace start time: %w", err)
+	}
+
+	if state.DeletionGraceStartTime == nil {
+		if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+			state.DeletionGraceStartTime = persistedTime(now)
+		}); err != nil {
+			return 0, fmt.Errorf("error recording deletion grace start time: %w", err)
+		}
+
+		return grace, nil
+	}
+
+	if remaining := state.DeletionGraceStartTime.Add(grace).Sub(now); remaining > 0 {
+		return remaining, nil
+	}
+
+	return 0, nil
+}
+
+// recordReducedRedundancy emits an event noting that a replaced Machine that may still be serving has been deleted,
+// so that brief disruption of the control plane can be correlated with the replacement.
+func (r *ControlPlaneMachineSetReconciler) recordReducedRedundancy(cpms *machinev1.ControlPlaneMachineSet, deletedMachine machineproviders.MachineInfo) {
+	if r.Recorder == nil {
+		return
+	}
+
+	r.Recorder.Eventf(cpms, corev1.EventTypeWarning, reasonRemovingReplacedMachine,
+		"Deleted machine %s in index %d, control plane redundancy is reduced until it has been removed",
+		deletedMachine.MachineRef.ObjectMeta.Name, deletedMachine.Index)
+}
+
+// nodeHoldsAPIVIP returns true when the Node of the Machine carries the label or annotation configured by the API VIP
+// holder key annotation, with a value other than false. When no key is configured, the Node is not checked.
+func (r *ControlPlaneMachineSetReconciler) nodeHoldsAPIVIP(ctx context.Context, cpms *machinev1.ControlPlaneMachineSet, machine machineproviders.MachineInfo) (bool, error) {
+	key := cpms.GetAnnotations()[apiVIPHolderKeyAnnotation]
+	if key == "" || machine.NodeRef == nil {
+		return false, nil
+	}
+
+	nodeName := machine.NodeRef.ObjectMeta.Name
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, node); apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error fetching node %s: %w", nodeName, err)
+	}
+
+	for _, values := range []map[string]string{node.GetLabels(), node.GetAnnotations()} {
+		if value, ok := values[key]; ok && !strings.EqualFold(value, "false") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// setAPIVIPHold sets the APIVIPHold condition to explain why the deletion of the Machine is delayed.
+func setAPIVIPHold(cpms *machinev1.ControlPlaneMachineSet, machine machineproviders.MachineInfo) {
+	meta.SetStatusCondition(&cpms.Status.Conditions, metav1.Condition{
+		Type:               conditionAPIVIPHold,
+		Status:             metav1.ConditionTrue,
+		Reason:             reasonWaitingForAPIVIPToMove,
+		ObservedGeneration: cpms.Generation,
+		Message: fmt.Sprintf("Waiting for the API VIP to move off node %s before deleting machine %s in index %d",
+			machine.NodeRef.ObjectMeta.Name, machine.MachineRef.ObjectMeta.Name, machine.Index),
+	})
+}
+
+// clearDeletionGraceStartTime removes the recorded start of the deletion grace period.
+func clearDeletionGraceStartTime(cpms *machinev1.ControlPlaneMachineSet) error {
+	if err := updatePersistedState(cpms, func(state *machineproviders.PersistedState) {
+		state.DeletionGraceStartTime = nil
+	}); err != nil {
+		return fmt.Errorf("error clearing deletion grace start time: %w", err)
+	}
+
+	return nil
+}
+
+// isCompletingUpdate returns true when an index has an outdated Machine and an updated replacement
+// that is ready, meaning the outdated Machine is about to be, or is being, removed.
+func isCompletingUpdate(machines []machineproviders.MachineInfo) bool {
+	return hasAny(needReplacementMachines(machines)) && hasAny(updatedMachines(machines))
+}
+
+// startsUpdate returns true when an index has a Machine that needs an update, for which no replacement has yet
+// been created. Machines that need replacement because they have been deleted, or marked for remediation by a
+// MachineHealthCheck, are excluded, as creating their replacement restores the health of the index rather than
+// rolling out an update.
+func startsUpdate(machines []machineproviders.MachineInfo) bool {
+	machinesNeedingReplacement := needReplacementMachines(machines)
+
+	for _, m := range machinesNeedingReplacement {
+		if isDeletedMachine(m) || m.NeedsRemediation {
+			return false
+		}
+	}
+
+	return hasAny(machinesNeedingReplacement) && isEmpty(updatedNonDeletedMachines(machines)) && isEmpty(pendingMachines(machines))
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package controlplanemachineset
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -34,11 +36,41 @@ import (
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/mock"
 	machineprovidersresourcebuilder "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/test/resourcebuilder/machineproviders"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// BEGIN: MachineInfo fixtures shared by the reconcileMachineUpdates specs.
+
+// updatedMachineBuilder builds the MachineInfo of a Machine that is ready and up to date.
+var updatedMachineBuilder = machineprovidersresourcebuilder.MachineInfo().
+	WithMachineGVR(machinev1beta1.GroupVersion.WithResource("machines")).
+	WithNodeGVR(corev1.SchemeGroupVersion.WithResource("nodes")).
+	WithReady(true).
+	WithNeedsUpdate(false)
+
+// outdatedMachineBuilder builds the MachineInfo of a Machine that is ready, but needs an update.
+var outdatedMachineBuilder = updatedMachineBuilder.WithNeedsUpdate(true).WithDiff([]string{"InstanceType: m6i.xlarge != different"})
+
+// updatedMachine builds the MachineInfo of a ready and up to date Machine in the index, with a Node named after it.
+func updatedMachine(idx int32, name string) machineproviders.MachineInfo {
+	return updatedMachineBuilder.WithIndex(idx).WithMachineName(name).WithNodeName("node-" + name).Build()
+}
+
+// outdatedMachine builds the MachineInfo of a ready Machine in the index that needs an update, with a Node named
+// after it.
+func outdatedMachine(idx int32, name string) machineproviders.MachineInfo {
+	return outdatedMachineBuilder.WithIndex(idx).WithMachineName(name).WithNodeName("node-" + name).Build()
+}
+
+// END: MachineInfo fixtures
+
 var _ = Describe("reconcileMachineUpdates", func() {
 	var namespaceName string
 	var logger testutils.TestLogger
@@ -74,13 +106,6 @@ var _ = Describe("reconcileMachineUpdates", func() {
 	// BEGIN: MachineInfo builders for various types of MachineInfo inputs
 
 	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
-	nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
-
-	updatedMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
-		WithMachineGVR(machineGVR).
-		WithNodeGVR(nodeGVR).
-		WithReady(true).
-		WithNeedsUpdate(false)
 
 	pendingMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
 		WithMachineGVR(machineGVR).
@@ -94,6 +119,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 	// END: MachineInfo builders
 
 	instanceDiff := []string{"InstanceType: m6i.xlarge != different"}
+	remediationDiff := []string{"machine has been marked for remediation by a machine health check"}
 
 	Context("When the update strategy is RollingUpdate", func() {
 		BeforeEach(func() {
@@ -120,7 +146,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				errExpected = in.expectedErrorBuilder()
 			}
 
-			result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, in.machineInfos)
+			result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, in.machineInfos)
 			if errExpected != nil {
 				Expect(err).To(MatchError(errExpected))
 			} else {
@@ -164,7 +190,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -213,7 +239,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 
 							return mICopy
 						}(machineInfos)), nil).Times(1)
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(0)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(0)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -243,7 +269,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(transientError).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", transientError).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -412,7 +438,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 					// Note, in this case it should only create a single machine.
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -635,7 +661,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -691,8 +717,8 @@ var _ = Describe("reconcileMachineUpdates", func() {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
 					// The missing index should take priority over the index in need of an update.
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -801,9 +827,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 					// We expect this particular machine to be called for deletion.
 					machineInfo0 := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithNeedsUpdate(true).Build()
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfo0.MachineRef).Return(nil).Times(1)
-					// We expect this particular machine to be called for deletion.
-					machineInfo1 := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsUpdate(true).Build()
-					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfo1.MachineRef).Return(nil).Times(1)
+					// Only a single machine may be deleted within a reconcile, so machine-1 is deleted by a later reconcile.
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
 					return []testutils.LogEntry{
@@ -824,8 +848,9 @@ var _ = Describe("reconcileMachineUpdates", func() {
 								"index", int32(1),
 								"namespace", namespaceName,
 								"name", "machine-1",
+								"deletedMachine", "machine-0",
 							},
-							Message: removingOldMachine,
+							Message: refusingConcurrentDeletion,
 						},
 						{
 							Level: 2,
@@ -1046,8 +1071,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
 				},
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
-					machineInfo1 := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-extra-1").Build()
-					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfo1.MachineRef).Times(1)
+					// Only a single machine may be deleted within a reconcile, so machine-older-extra-1 is deleted by a later reconcile.
 					machineInfo0 := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-extra-0").Build()
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfo0.MachineRef).Times(1)
 				},
@@ -1070,8 +1094,9 @@ var _ = Describe("reconcileMachineUpdates", func() {
 								"index", int32(1),
 								"namespace", namespaceName,
 								"name", "machine-older-extra-1",
+								"deletedMachine", "machine-older-extra-0",
 							},
-							Message: removingOldMachine,
+							Message: refusingConcurrentDeletion,
 						},
 					}
 				},
@@ -1266,6 +1291,80 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				},
 				expectedResult: ctrl.Result{RequeueAfter: 5 * time.Second},
 			}),
+			Entry("with a machine marked for remediation by a machine health check", rollingUpdateTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(3),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsRemediation(true).
+						WithDiff(remediationDiff).Build()},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				},
+				expectedLogsBuilder: func() []testutils.LogEntry {
+					return []testutils.LogEntry{
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-1",
+								"diff", remediationDiff,
+							},
+							Message: machineRequiresUpdate,
+						},
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-1",
+							},
+							Message: createdReplacement,
+						},
+					}
+				},
+			}),
+			Entry("with a machine marked for remediation by a machine health check, and the replacement machine is ready", rollingUpdateTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(3),
+				machineInfos: map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+					1: {
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsRemediation(true).
+							WithDiff(remediationDiff).Build(),
+						updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+					},
+					2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				},
+				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					// We expect the machine marked for remediation to be called for deletion.
+					machineInfo := updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsRemediation(true).
+						WithDiff(remediationDiff).Build()
+					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), machineInfo.MachineRef).Return(nil).Times(1)
+				},
+				expectedLogsBuilder: func() []testutils.LogEntry {
+					return []testutils.LogEntry{
+						{
+							Level: 2,
+							KeysAndValues: []interface{}{
+								"updateStrategy", machinev1.RollingUpdate,
+								"index", int32(1),
+								"namespace", namespaceName,
+								"name", "machine-1",
+							},
+							Message: removingOldMachine,
+						},
+					}
+				},
+			}),
 			Entry("with no updates required, but a machine has been deleted, and its replacement is ready", rollingUpdateTableInput{
 				cpmsBuilder: cpmsBuilder.WithReplicas(3),
 				machineInfos: map[int32][]machineproviders.MachineInfo{
@@ -1319,7 +1418,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 			cpms := cpmsBuilder.Build()
 			originalCPMS := cpms.DeepCopy()
 
-			result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, in.machineInfos)
+			result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, in.machineInfos)
 			if in.expectedErrorBuilder != nil {
 				Expect(err).To(MatchError(in.expectedErrorBuilder()))
 			} else {
@@ -1401,7 +1500,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 
 							return mICopy
 						}(machineInfos)), nil).Times(1)
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(0)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(0)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1432,7 +1531,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1462,7 +1561,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(4)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(4)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1492,7 +1591,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(transientError).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", transientError).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1626,7 +1725,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(transientError).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", transientError).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1668,7 +1767,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1712,8 +1811,8 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return(nil).Times(1)
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -1962,7 +2061,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -2017,7 +2116,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 				setupMock: func(machineInfos map[int32][]machineproviders.MachineInfo) {
 					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
 					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
-					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return(nil).Times(1)
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(2)).Return("", nil).Times(1)
 					mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 				},
 				expectedLogsBuilder: func() []testutils.LogEntry {
@@ -2125,7 +2224,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 
 			machineInfos := map[int32][]machineproviders.MachineInfo{}
 
-			result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+			result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
 		})
 
 		It("Returns an empty result", func() {
@@ -2163,7 +2262,7 @@ var _ = Describe("reconcileMachineUpdates", func() {
 
 			machineInfos := map[int32][]machineproviders.MachineInfo{}
 
-			result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, mockMachineProvider, machineInfos)
+			result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
 		})
 
 		It("Returns an empty result", func() {
@@ -2192,87 +2291,2477 @@ var _ = Describe("reconcileMachineUpdates", func() {
 	})
 })
 
-var _ = Describe("utils tests", func() {
-	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
-	nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
-	updatedMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
-		WithMachineGVR(machineGVR).
-		WithNodeGVR(nodeGVR).
-		WithReady(true).
-		WithNeedsUpdate(false)
+var _ = Describe("reconcileForceRoll", func() {
+	var logger testutils.TestLogger
+	var fakeClock *clocktesting.FakePassiveClock
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
 
-	DescribeTable("should convert an indexed map of MachineInfo into a slice sorted by index",
-		func(input map[int32][]machineproviders.MachineInfo, expected []indexToMachineInfos) {
-			output := sortMachineInfosByIndex(input)
-			Expect(output).To(Equal(expected))
-		},
-		Entry("when index starts at 0",
-			map[int32][]machineproviders.MachineInfo{
-				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
-				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
-				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
-			},
-			[]indexToMachineInfos{
-				{index: 0, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()}},
-				{index: 1, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()}},
-				{index: 2, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()}},
-			},
-		),
-		Entry("when index starts at 1",
-			map[int32][]machineproviders.MachineInfo{
-				3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").Build()},
-				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
-				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
-			},
-			[]indexToMachineInfos{
-				{index: 1, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()}},
-				{index: 2, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()}},
-				{index: 3, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").Build()}},
-			},
-		),
-		Entry("when one index is missing",
-			map[int32][]machineproviders.MachineInfo{
-				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
-				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
-			},
-			[]indexToMachineInfos{
-				{index: 0, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()}},
-				{index: 2, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()}},
-			},
-		),
-	)
-	DescribeTable("should convert an slice of MachineInfo into a slice sorted of MachineInfo sorted by Machine's CreationTimestamp",
-		func(input []machineproviders.MachineInfo, expected []machineproviders.MachineInfo) {
-			output := sortMachineInfoByCreationTimestamp(input)
-			Expect(output).To(Equal(expected))
-		},
-		Entry("when MachineInfo is not sorted by CreationTimestamp",
-			[]machineproviders.MachineInfo{
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
-			},
-			[]machineproviders.MachineInfo{
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
-			},
-		),
-		Entry("when MachineInfo is already sorted by CreationTimestamp",
-			[]machineproviders.MachineInfo{
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
-			},
-			[]machineproviders.MachineInfo{
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
-				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
-					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
-			},
-		),
-	)
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		fakeClock = clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			clock: fakeClock,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+	})
+
+	Context("when no force roll has been requested", func() {
+		BeforeEach(func() {
+			Expect(reconciler.reconcileForceRoll(logger.Logger(), cpms)).To(Succeed())
+		})
+
+		It("does not record a force roll", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(SatisfyAll(
+				HaveField("ForceRollToken", BeEmpty()),
+				HaveField("ForceRollTime", BeNil()),
+			))
+		})
+	})
+
+	Context("when a force roll token is set", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{forceRollAnnotation: "first"})
+
+			Expect(reconciler.reconcileForceRoll(logger.Logger(), cpms)).To(Succeed())
+		})
+
+		It("consumes the token", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("ForceRollToken", Equal("first")))
+		})
+
+		It("records the time of the force roll", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("ForceRollTime", bePersistedTime("2023-06-01T12:00:00Z")))
+		})
+
+		It("logs the force roll request", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Level: 1,
+				KeysAndValues: []interface{}{
+					"token", "first",
+					"forceRollTime", "2023-06-01T12:00:00Z",
+				},
+				Message: "Observed a force roll request, machines created before the request will be replaced",
+			}))
+		})
+
+		Context("and the same token is observed again", func() {
+			BeforeEach(func() {
+				fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+
+				Expect(reconciler.reconcileForceRoll(logger.Logger(), cpms)).To(Succeed())
+			})
+
+			It("does not trigger a further force roll", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(SatisfyAll(
+					HaveField("ForceRollToken", Equal("first")),
+					HaveField("ForceRollTime", bePersistedTime("2023-06-01T12:00:00Z")),
+				))
+			})
+		})
+
+		Context("and the token is changed", func() {
+			BeforeEach(func() {
+				fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+
+				annotations := cpms.GetAnnotations()
+				annotations[forceRollAnnotation] = "second"
+				cpms.SetAnnotations(annotations)
+
+				Expect(reconciler.reconcileForceRoll(logger.Logger(), cpms)).To(Succeed())
+			})
+
+			It("consumes the new token", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("ForceRollToken", Equal("second")))
+			})
+
+			It("records the time of the new force roll", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("ForceRollTime", bePersistedTime("2023-06-01T13:00:00Z")))
+			})
+		})
+
+		Context("and the token is removed", func() {
+			BeforeEach(func() {
+				annotations := cpms.GetAnnotations()
+				delete(annotations, forceRollAnnotation)
+				cpms.SetAnnotations(annotations)
+
+				Expect(reconciler.reconcileForceRoll(logger.Logger(), cpms)).To(Succeed())
+			})
+
+			It("cancels the force roll", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(SatisfyAll(
+					HaveField("ForceRollToken", BeEmpty()),
+					HaveField("ForceRollTime", BeNil()),
+				))
+			})
+		})
+	})
+})
+
+var _ = Describe("reconcileRolloutWindow", func() {
+	const rolloutWindow = 24 * time.Hour
+
+	var logger testutils.TestLogger
+	var fakeClock *clocktesting.FakePassiveClock
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	// reconcileUpdates mimics the reconcile by first observing the status of the machines and recording the
+	// progress of the rollout window, and then performing the rolling update.
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) ctrl.Result {
+		previousReplicas, previousUpdatedReplicas := cpms.Status.Replicas, cpms.Status.UpdatedReplicas
+		Expect(reconcileStatusWithMachineInfo(logger.Logger(), cpms, *cpms.Spec.Replicas, machineInfos)).To(Succeed())
+		Expect(reconciler.reconcileRolloutWindow(logger.Logger(), cpms, previousReplicas, previousUpdatedReplicas, machineInfos)).To(Succeed())
+
+		result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		fakeClock = clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+			clock:     fakeClock,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{rolloutWindowAnnotation: rolloutWindow.String()})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+	})
+
+	Context("when the replacement for the first index has become ready", func() {
+		var result ctrl.Result
+
+		replacedMachine := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {
+					replacedMachine,
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+				},
+				1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			result = reconcileUpdates(machineInfos)
+		})
+
+		It("removes the replaced machine without starting the next index", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: rolloutWindow}))
+		})
+
+		It("does not record a completion time while the replaced machine is present", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastIndexCompletedTime", BeNil()))
+		})
+
+		Context("while the replaced machine is being removed", func() {
+			BeforeEach(func() {
+				machineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {
+						outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithMachineDeletionTimestamp(metav1.Now()).Build(),
+						updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+					},
+					1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+					2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				}
+
+				for i := 0; i < 3; i++ {
+					fakeClock.SetTime(fakeClock.Now().Add(10 * time.Minute))
+
+					result = reconcileUpdates(machineInfos)
+				}
+			})
+
+			It("keeps waiting for the full rollout window", func() {
+				Expect(result).To(Equal(ctrl.Result{RequeueAfter: rolloutWindow}))
+			})
+
+			It("does not record a completion time", func() {
+				Expect(cpms.GetAnnotations()).ToNot(HaveKey(machineproviders.PersistedStateAnnotation))
+			})
+		})
+
+		Context("once the replaced machine has been removed", func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build()},
+				1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			BeforeEach(func() {
+				fakeClock.SetTime(fakeClock.Now().Add(30 * time.Minute))
+
+				result = reconcileUpdates(machineInfos)
+			})
+
+			It("records the time the replaced machine was removed", func() {
+				Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastIndexCompletedTime", bePersistedTime("2023-06-01T12:30:00Z")))
+			})
+
+			It("does not change the recorded time over later reconciles", func() {
+				recorded := cpms.GetAnnotations()[machineproviders.PersistedStateAnnotation]
+
+				for i := 1; i <= 3; i++ {
+					fakeClock.SetTime(fakeClock.Now().Add(10 * time.Minute))
+
+					Expect(reconcileUpdates(machineInfos)).To(Equal(ctrl.Result{RequeueAfter: rolloutWindow - time.Duration(i)*10*time.Minute}))
+					Expect(cpms.GetAnnotations()).To(HaveKeyWithValue(machineproviders.PersistedStateAnnotation, recorded))
+				}
+			})
+
+			Context("and the rollout window has not elapsed", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+
+					result = reconcileUpdates(machineInfos)
+				})
+
+				It("does not start the replacement of the next index", func() {
+					Expect(result).To(Equal(ctrl.Result{RequeueAfter: rolloutWindow - time.Hour}))
+				})
+
+				It("logs that it is waiting for the rollout window", func() {
+					Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+						Level: 2,
+						KeysAndValues: []interface{}{
+							"updateStrategy", machinev1.RollingUpdate,
+							"index", int32(1),
+							"remaining", (rolloutWindow - time.Hour).String(),
+						},
+						Message: waitingForRolloutWindow,
+					}))
+				})
+
+				It("does not change the time the index was completed", func() {
+					Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastIndexCompletedTime", bePersistedTime("2023-06-01T12:30:00Z")))
+				})
+			})
+
+			Context("and the rollout window has elapsed", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(rolloutWindow))
+
+					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+					result = reconcileUpdates(machineInfos)
+				})
+
+				It("starts the replacement of the next index", func() {
+					Expect(result).To(Equal(ctrl.Result{}))
+				})
+			})
+
+			Context("and a machine is marked for remediation within the rollout window", func() {
+				BeforeEach(func() {
+					fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+
+					remediationMachineInfos := map[int32][]machineproviders.MachineInfo{
+						0: machineInfos[0],
+						1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").WithNeedsRemediation(true).
+							WithDiff([]string{"machine has been marked for remediation by a machine health check"}).Build()},
+						2: machineInfos[2],
+					}
+
+					mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+					mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(remediationMachineInfos), nil).AnyTimes()
+					mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+					result = reconcileUpdates(remediationMachineInfos)
+				})
+
+				It("replaces the machine without waiting for the rollout window", func() {
+					Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(createdReplacement))))
+				})
+			})
+		})
+	})
+
+	Context("when no rollout window is configured", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{
+				machineproviders.PersistedStateAnnotation: persistedStateAnnotation(machineproviders.PersistedState{
+					LastIndexCompletedTime: persistedTestTime("2023-06-01T12:00:00Z"),
+				}),
+			})
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build()},
+				1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+			mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+			reconcileUpdates(machineInfos)
+		})
+
+		It("removes the last index completion time", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("LastIndexCompletedTime", BeNil()))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with active indexes", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{activeIndexesAnnotation: "0"})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+	})
+
+	Context("when all indexes need an update", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+			mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("only creates a replacement for the active index", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+				},
+				Message: createdReplacement,
+			}))
+		})
+
+		It("logs that the other indexes are skipped", func() {
+			Expect(logger.Entries()).To(ContainElements(
+				testutils.LogEntry{
+					Level:         4,
+					KeysAndValues: []interface{}{"index", int32(1)},
+					Message:       skippingInactiveIndex,
+				},
+				testutils.LogEntry{
+					Level:         4,
+					KeysAndValues: []interface{}{"index", int32(2)},
+					Message:       skippingInactiveIndex,
+				},
+			))
+		})
+	})
+
+	Context("when an index that is not active is missing its machine", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not create a machine for the missing index", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(noUpdatesRequired))))
+		})
+	})
+
+	Context("when an index that is not active has a ready replacement", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {
+					outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build(),
+					updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+				},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not delete the replaced machine", func() {
+			Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", Equal(removingOldMachine))))
+		})
+	})
+
+	Context("when the update strategy is OnDelete", func() {
+		BeforeEach(func() {
+			cpms.Spec.Strategy.Type = machinev1.OnDelete
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+					WithMachineDeletionTimestamp(metav1.Now()).Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").
+					WithMachineDeletionTimestamp(metav1.Now()).Build()},
+			}
+
+			mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+			mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("only replaces the deleted machine in the active index", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(createdReplacement))))
+		})
+	})
+
+	Context("when the active indexes annotation is invalid", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{activeIndexesAnnotation: "0,one"})
+
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				1: {},
+				2: {outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not manage any index", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Error:         fmt.Errorf("%w: %q", errInvalidActiveIndexes, "0,one"),
+				KeysAndValues: []interface{}{"annotation", activeIndexesAnnotation},
+				Message:       "No indexes will be managed",
+			}))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates when the strategy changes mid-roll", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+
+	pendingMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithReady(false).
+		WithNeedsUpdate(false)
+
+	replacedMachine := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+	outdatedMachine1 := outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()
+	outdatedMachine2 := outdatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		// The replacement for index 0 was created by the RollingUpdate strategy before it was changed to OnDelete.
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.Spec.Strategy.Type = machinev1.OnDelete
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+	})
+
+	Context("when the replacement machine is pending", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {
+					replacedMachine,
+					pendingMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").Build(),
+				},
+				1: {outdatedMachine1},
+				2: {outdatedMachine2},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			var err error
+			result, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("waits for the replacement machine to become ready", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: 5 * time.Second}))
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.OnDelete,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+					"replacementName", "machine-replacement-0",
+				},
+				Message: waitingForReplacement,
+			}))
+		})
+	})
+
+	Context("when the replacement machine is ready", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {
+					replacedMachine,
+					updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+				},
+				1: {outdatedMachine1},
+				2: {outdatedMachine2},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("deletes the replaced machine to complete the replacement", func() {
+			Expect(logger.Entries()).To(ContainElements(
+				testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.OnDelete,
+						"index", int32(0),
+						"namespace", "test",
+						"name", "machine-0",
+					},
+					Message: completingRollingUpdateReplacement,
+				},
+				testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.OnDelete,
+						"index", int32(0),
+						"namespace", "test",
+						"name", "machine-0",
+					},
+					Message: removingOldMachine,
+				},
+			))
+		})
+
+		It("does not start the replacement of the remaining indexes", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.OnDelete,
+					"index", int32(1),
+					"namespace", "test",
+					"name", "machine-1",
+					"diff", outdatedMachine1.Diff,
+				},
+				Message: machineRequiresDeleteBeforeUpdate,
+			}))
+		})
+	})
+
+	Context("once the replaced machine has been removed", func() {
+		BeforeEach(func() {
+			machineInfos := map[int32][]machineproviders.MachineInfo{
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build()},
+				1: {outdatedMachine1},
+				2: {outdatedMachine2},
+			}
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("waits for the remaining machines to be deleted by the user", func() {
+			Expect(logger.Entries()).To(ConsistOf(
+				HaveField("Message", Equal(machineRequiresDeleteBeforeUpdate)),
+				HaveField("Message", Equal(machineRequiresDeleteBeforeUpdate)),
+			))
+		})
+	})
+})
+
+// capacityCheckingMachineProvider stubs the optional capacity preflight on top of the mock machine provider.
+type capacityCheckingMachineProvider struct {
+	*mock.MockMachineProvider
+
+	capacityErr error
+}
+
+// CheckCapacity returns the configured capacity error.
+func (c capacityCheckingMachineProvider) CheckCapacity(context.Context, logr.Logger, int32) error {
+	return c.capacityErr
+}
+
+var _ = Describe("reconcileMachineUpdates with a capacity preflight", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+	})
+
+	Context("when the preflight reports no capacity", func() {
+		quotaErr := fmt.Errorf("%w: 0 vCPUs available in us-east-1", machineproviders.ErrInsufficientQuota)
+
+		var err error
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			provider := capacityCheckingMachineProvider{MockMachineProvider: mockMachineProvider, capacityErr: quotaErr}
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("marks the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonInsufficientQuota)),
+				HaveField("Message", Equal("Unable to create a machine for index 1: insufficient quota: 0 vCPUs available in us-east-1")),
+			))
+		})
+
+		It("logs that the machine creation was skipped", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Error: quotaErr,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(1),
+					"namespace", "test",
+					"name", "machine-1",
+				},
+				Message: insufficientQuotaForMachine,
+			}))
+		})
+	})
+
+	Context("when the preflight reports sufficient capacity", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+			provider := capacityCheckingMachineProvider{MockMachineProvider: mockMachineProvider}
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("creates the replacement machine", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(createdReplacement))))
+		})
+
+		It("does not set any condition", func() {
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+
+	Context("when the preflight fails", func() {
+		var err error
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			provider := capacityCheckingMachineProvider{MockMachineProvider: mockMachineProvider, capacityErr: errors.New("unauthorized")}
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError("error checking capacity for new Machine for index 1: unauthorized"))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates when the machine creation fails", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+	})
+
+	Context("when the API rejects the machine creation as forbidden", func() {
+		var err error
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+			forbiddenErr := apierrors.NewForbidden(machinev1beta1.Resource("machines"), "", errors.New("cannot create resource"))
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", fmt.Errorf("cannot create machine: %w", forbiddenErr)).Times(1)
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		})
+
+		It("returns the error to be retried with the error backoff", func() {
+			Expect(err).To(MatchError(ContainSubstring("error creating new Machine for index 1: cannot create machine: machines.machine.openshift.io is forbidden")))
+		})
+
+		It("does not report a provider authentication failure", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(BeNil())
+		})
+	})
+
+	Context("when the machine creation fails for another reason", func() {
+		var err error
+
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", errors.New("instance limit exceeded")).Times(1)
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError("error creating new Machine for index 1: instance limit exceeded"))
+		})
+
+		It("does not mark the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(BeNil())
+		})
+	})
+})
+
+// secretCheckingMachineProvider stubs the optional referenced secrets preflight on top of the mock machine provider.
+type secretCheckingMachineProvider struct {
+	*mock.MockMachineProvider
+
+	secretsErr error
+}
+
+// CheckReferencedSecrets returns the configured secrets error.
+func (c secretCheckingMachineProvider) CheckReferencedSecrets(context.Context, logr.Logger) error {
+	return c.secretsErr
+}
+
+var _ = Describe("reconcileMachineUpdates with a referenced secrets preflight", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+	})
+
+	Context("when the user data secret is missing", func() {
+		secretErr := fmt.Errorf("%w: secret test/master-user-data does not exist", machineproviders.ErrMissingReferencedSecret)
+
+		var err error
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			provider := secretCheckingMachineProvider{MockMachineProvider: mockMachineProvider, secretsErr: secretErr}
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("marks the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMissingReferencedSecret)),
+				HaveField("Message", Equal("Unable to create a machine for index 1: missing referenced secret: secret test/master-user-data does not exist")),
+			))
+		})
+
+		It("logs that the machine creation was skipped", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Error: secretErr,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(1),
+					"namespace", "test",
+					"name", "machine-1",
+				},
+				Message: missingReferencedSecretForMachine,
+			}))
+		})
+	})
+
+	Context("when the referenced secrets exist", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+			provider := secretCheckingMachineProvider{MockMachineProvider: mockMachineProvider}
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("creates the replacement machine", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", Equal(createdReplacement))))
+		})
+
+		It("does not set any condition", func() {
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+
+	Context("when the preflight fails", func() {
+		var err error
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			provider := secretCheckingMachineProvider{MockMachineProvider: mockMachineProvider, secretsErr: errors.New("forbidden")}
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError("error checking referenced secrets for new Machine for index 1: forbidden"))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with a deletion grace period", func() {
+	var logger testutils.TestLogger
+	var fakeClock *clocktesting.FakePassiveClock
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	deletionGrace := 10 * time.Minute
+
+	replacedMachine := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {
+			replacedMachine,
+			updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+		},
+		1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	reconcileUpdates := func() ctrl.Result {
+		result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		fakeClock = clocktesting.NewFakePassiveClock(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+			clock:     fakeClock,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{deletionGraceAnnotation: deletionGrace.String()})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the replacement has just become ready", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			result = reconcileUpdates()
+		})
+
+		It("does not delete the old machine and requeues once the grace period has elapsed", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: deletionGrace}))
+		})
+
+		It("records the start of the grace period", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("DeletionGraceStartTime", bePersistedTime("2023-06-01T12:00:00Z")))
+		})
+
+		It("logs that it is waiting for the grace period", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+					"remaining", deletionGrace.String(),
+				},
+				Message: waitingForDeletionGrace,
+			}))
+		})
+	})
+
+	Context("when part of the grace period has elapsed", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates()
+
+			fakeClock.SetTime(fakeClock.Now().Add(4 * time.Minute))
+
+			result = reconcileUpdates()
+		})
+
+		It("does not delete the old machine and requeues for the remainder of the grace period", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: deletionGrace - 4*time.Minute}))
+		})
+
+		It("does not restart the grace period", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("DeletionGraceStartTime", bePersistedTime("2023-06-01T12:00:00Z")))
+		})
+	})
+
+	Context("when the grace period has elapsed", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			reconcileUpdates()
+
+			fakeClock.SetTime(fakeClock.Now().Add(deletionGrace))
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+			result = reconcileUpdates()
+		})
+
+		It("deletes the old machine without requeueing", func() {
+			Expect(result).To(Equal(ctrl.Result{}))
+		})
+
+		It("removes the start of the grace period", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("DeletionGraceStartTime", BeNil()))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with a minimum node ready duration", func() {
+	var logger testutils.TestLogger
+	var fakeClock *clocktesting.FakePassiveClock
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	minNodeReadyDuration := 5 * time.Minute
+	startTime := time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	replacedMachine := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+	replacementBuilder := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0")
+
+	// machineInfosWithReplacement returns the machine infos with the given state of the replacement in index 0.
+	machineInfosWithReplacement := func(replacement machineproviders.MachineInfo) map[int32][]machineproviders.MachineInfo {
+		return map[int32][]machineproviders.MachineInfo{
+			0: {replacedMachine, replacement},
+			1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+	}
+
+	reconcileUpdates := func(replacement machineproviders.MachineInfo) ctrl.Result {
+		result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfosWithReplacement(replacement))
+		Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		fakeClock = clocktesting.NewFakePassiveClock(startTime)
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+			clock:     fakeClock,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{minNodeReadyDurationAnnotation: minNodeReadyDuration.String()})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the node of the replacement has just become ready", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			result = reconcileUpdates(replacementBuilder.WithReadySince(startTime).Build())
+		})
+
+		It("does not delete the old machine and requeues once the node is stable", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: minNodeReadyDuration}))
+		})
+
+		It("logs that it is waiting for the replacement to be stable", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+					"replacement", "machine-replacement-0",
+					"remaining", minNodeReadyDuration.String(),
+				},
+				Message: waitingForStableReplacement,
+			}))
+		})
+	})
+
+	Context("when the node of the replacement flaps and then stabilises", func() {
+		// The node becomes ready again after the flap, 4 minutes after it first became ready.
+		flapReadySince := startTime.Add(4 * time.Minute)
+
+		var flapResult, unstableResult, stableResult ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			reconcileUpdates(replacementBuilder.WithReadySince(startTime).Build())
+
+			By("reporting the node as not ready")
+			fakeClock.SetTime(startTime.Add(3 * time.Minute))
+			flapResult = reconcileUpdates(replacementBuilder.WithReady(false).Build())
+
+			By("reporting the node as ready again, after the minimum duration since it first became ready")
+			fakeClock.SetTime(startTime.Add(6 * time.Minute))
+			unstableResult = reconcileUpdates(replacementBuilder.WithReadySince(flapReadySince).Build())
+
+			By("waiting for the node to have been ready for the minimum duration since the flap")
+			fakeClock.SetTime(flapReadySince.Add(minNodeReadyDuration))
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+			stableResult = reconcileUpdates(replacementBuilder.WithReadySince(flapReadySince).Build())
+		})
+
+		It("waits for the replacement to become ready while the node is not ready", func() {
+			Expect(flapResult.RequeueAfter).To(BeNumerically(">", 0))
+		})
+
+		It("restarts the minimum duration once the node is ready again", func() {
+			Expect(unstableResult).To(Equal(ctrl.Result{RequeueAfter: 3 * time.Minute}))
+		})
+
+		It("deletes the old machine once the node has been continuously ready for the minimum duration", func() {
+			Expect(stableResult).To(Equal(ctrl.Result{}))
+		})
+	})
+
+	Context("when the annotation is invalid", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{minNodeReadyDurationAnnotation: "soon"})
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+			result = reconcileUpdates(replacementBuilder.WithReadySince(startTime).Build())
+		})
+
+		It("deletes the old machine without waiting", func() {
+			Expect(result).To(Equal(ctrl.Result{}))
+		})
+
+		It("logs an error", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", "Ignoring invalid minimum node ready duration")))
+		})
+	})
+})
+
+// nodeGettingClient stubs the client calls used to fetch the Node of a Machine.
+type nodeGettingClient struct {
+	client.Client
+
+	nodes map[string]*corev1.Node
+}
+
+// Get returns the stored Node with the given name.
+func (c nodeGettingClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	node, ok := c.nodes[key.Name]
+	if !ok {
+		return apierrors.NewNotFound(corev1.Resource("nodes"), key.Name)
+	}
+
+	node.DeepCopyInto(obj.(*corev1.Node))
+
+	return nil
+}
+
+var _ = Describe("reconcileMachineUpdates with an API VIP holder key", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var nodeClient nodeGettingClient
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	vipHolderKey := "example.com/api-vip"
+
+	replacedMachine := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {
+			replacedMachine,
+			updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+		},
+		1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	reconcileUpdates := func() ctrl.Result {
+		result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		nodeClient = nodeGettingClient{
+			nodes: map[string]*corev1.Node{
+				"node-0": corev1resourcebuilder.Node().WithName("node-0").WithLabels(map[string]string{vipHolderKey: "true"}).Build(),
+			},
+		}
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    nodeClient,
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{apiVIPHolderKeyAnnotation: vipHolderKey})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the node of the old machine holds the API VIP", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			result = reconcileUpdates()
+		})
+
+		It("does not delete the old machine and requeues to check the node again", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: apiVIPRecheckInterval}))
+		})
+
+		It("sets the APIVIPHold condition", func() {
+			Expect(cpms.Status.Conditions).To(ConsistOf(testutils.MatchCondition(metav1.Condition{
+				Type:    conditionAPIVIPHold,
+				Status:  metav1.ConditionTrue,
+				Reason:  reasonWaitingForAPIVIPToMove,
+				Message: "Waiting for the API VIP to move off node node-0 before deleting machine machine-0 in index 0",
+			})))
+		})
+
+		It("logs that it is waiting for the API VIP to move", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+					"node", "node-0",
+				},
+				Message: waitingForAPIVIPToMove,
+			}))
+		})
+
+		Context("and the API VIP then moves", func() {
+			BeforeEach(func() {
+				nodeClient.nodes["node-0"].SetLabels(map[string]string{vipHolderKey: "false"})
+
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+				result = reconcileUpdates()
+			})
+
+			It("deletes the old machine without requeueing", func() {
+				Expect(result).To(Equal(ctrl.Result{}))
+			})
+
+			It("removes the APIVIPHold condition", func() {
+				Expect(cpms.Status.Conditions).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the node of the old machine is marked by an annotation", func() {
+		BeforeEach(func() {
+			node := corev1resourcebuilder.Node().WithName("node-0").Build()
+			node.SetAnnotations(map[string]string{vipHolderKey: ""})
+			nodeClient.nodes["node-0"] = node
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		})
+
+		It("does not delete the old machine", func() {
+			Expect(reconcileUpdates()).To(Equal(ctrl.Result{RequeueAfter: apiVIPRecheckInterval}))
+		})
+	})
+
+	Context("when the node of the old machine does not hold the API VIP", func() {
+		BeforeEach(func() {
+			nodeClient.nodes["node-0"] = corev1resourcebuilder.Node().WithName("node-0").Build()
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+		})
+
+		It("deletes the old machine", func() {
+			Expect(reconcileUpdates()).To(Equal(ctrl.Result{}))
+			Expect(cpms.Status.Conditions).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with node readiness gates", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var nodeClient nodeGettingClient
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	certificatesApproved := corev1.NodeConditionType("CertificatesApproved")
+
+	replacedMachine := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {
+			replacedMachine,
+			updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+		},
+		1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	replacementNode := func(certificatesApprovedStatus corev1.ConditionStatus) *corev1.Node {
+		return corev1resourcebuilder.Node().WithName("node-replacement-0").WithConditions([]corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: certificatesApproved, Status: certificatesApprovedStatus},
+		}).Build()
+	}
+
+	reconcileUpdates := func() ctrl.Result {
+		result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		return result
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		nodeClient = nodeGettingClient{
+			nodes: map[string]*corev1.Node{
+				"node-replacement-0": replacementNode(corev1.ConditionFalse),
+			},
+		}
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Client:    nodeClient,
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		cpms.SetAnnotations(map[string]string{nodeReadinessGatesAnnotation: string(certificatesApproved)})
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the replacement node is Ready but the readiness gate is not yet True", func() {
+		var result ctrl.Result
+
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			result = reconcileUpdates()
+		})
+
+		It("does not delete the old machine and requeues to check the node again", func() {
+			Expect(result).To(Equal(ctrl.Result{RequeueAfter: readinessGateRecheckInterval}))
+		})
+
+		It("logs that it is waiting for the readiness gates", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+					"replacement", "machine-replacement-0",
+					"conditions", []corev1.NodeConditionType{certificatesApproved},
+				},
+				Message: waitingForReadinessGates,
+			}))
+		})
+
+		Context("and the readiness gate then becomes True", func() {
+			BeforeEach(func() {
+				nodeClient.nodes["node-replacement-0"] = replacementNode(corev1.ConditionTrue)
+
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+				result = reconcileUpdates()
+			})
+
+			It("deletes the old machine without requeueing", func() {
+				Expect(result).To(Equal(ctrl.Result{}))
+			})
+		})
+	})
+
+	Context("when the replacement node does not have the readiness gate condition", func() {
+		BeforeEach(func() {
+			nodeClient.nodes["node-replacement-0"] = corev1resourcebuilder.Node().WithName("node-replacement-0").Build()
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		})
+
+		It("does not delete the old machine", func() {
+			Expect(reconcileUpdates()).To(Equal(ctrl.Result{RequeueAfter: readinessGateRecheckInterval}))
+		})
+	})
+
+	Context("when no readiness gates are configured", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(nil)
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine.MachineRef).Return(nil).Times(1)
+		})
+
+		It("deletes the old machine", func() {
+			Expect(reconcileUpdates()).To(Equal(ctrl.Result{}))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates while waiting for a replacement to become ready", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	pendingMachineBuilder := updatedMachineBuilder.WithReady(false)
+
+	waitingMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {
+			updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").WithNeedsUpdate(true).Build(),
+			pendingMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").Build(),
+		},
+		1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	settledMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build()},
+		1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) time.Duration {
+		result, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+
+		return result.RequeueAfter
+	}
+
+	waitingLogLevels := func() []int {
+		levels := []int{}
+
+		for _, entry := range logger.Entries() {
+			if entry.Message == waitingForReplacement {
+				levels = append(levels, entry.Level)
+			}
+		}
+
+		return levels
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	It("escalates the requeue interval up to the maximum", func() {
+		intervals := []time.Duration{}
+		for i := 0; i < 7; i++ {
+			intervals = append(intervals, reconcileUpdates(waitingMachineInfos))
+		}
+
+		Expect(intervals).To(Equal([]time.Duration{
+			5 * time.Second,
+			10 * time.Second,
+			20 * time.Second,
+			40 * time.Second,
+			readinessWaitMaxInterval,
+			readinessWaitMaxInterval,
+			readinessWaitMaxInterval,
+		}))
+	})
+
+	It("logs the waiting message less often as the wait continues", func() {
+		for i := 0; i < 8; i++ {
+			reconcileUpdates(waitingMachineInfos)
+		}
+
+		Expect(waitingLogLevels()).To(Equal([]int{2, 2, 4, 2, 4, 4, 4, 2}))
+	})
+
+	It("restarts the backoff once the replacement is ready", func() {
+		reconcileUpdates(waitingMachineInfos)
+		reconcileUpdates(waitingMachineInfos)
+
+		Expect(reconcileUpdates(settledMachineInfos)).To(BeZero())
+		Expect(reconcileUpdates(waitingMachineInfos)).To(Equal(readinessWaitBaseInterval))
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates when removing a replaced machine", func() {
+	var logger testutils.TestLogger
+	var recorder *record.FakeRecorder
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	replacedMachineBuilder := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0")
+
+	machineInfosWithReplaced := func(replacedMachine machineproviders.MachineInfo) map[int32][]machineproviders.MachineInfo {
+		return map[int32][]machineproviders.MachineInfo{
+			0: {
+				replacedMachine,
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+			},
+			1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		recorder = record.NewFakeRecorder(10)
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+			Recorder:  recorder,
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the replacement is ready", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfosWithReplaced(replacedMachineBuilder.Build()))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("emits a warning event for the reduced redundancy", func() {
+			Expect(recorder.Events).To(Receive(Equal(fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, reasonRemovingReplacedMachine,
+				"Deleted machine machine-0 in index 0, control plane redundancy is reduced until it has been removed"))))
+		})
+	})
+
+	Context("when the replaced machine is already being removed", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			deletingMachine := replacedMachineBuilder.WithMachineDeletionTimestamp(metav1.Now()).Build()
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfosWithReplaced(deletingMachine))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not emit another event", func() {
+			Expect(recorder.Events).ToNot(Receive())
+		})
+	})
+
+	Context("when the replacement is not ready", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			machineInfos := machineInfosWithReplaced(replacedMachineBuilder.Build())
+			machineInfos[0][1] = updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithReady(false).Build()
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not emit an event", func() {
+			Expect(recorder.Events).ToNot(Receive())
+		})
+	})
+})
+
+// mislabellingMachineProvider stubs a machine provider that creates Machines labelled with the wrong index.
+// Once CreateMachine has been called, the created Machine, and any Machine created concurrently by another actor,
+// is included in the Machines it reports.
+type mislabellingMachineProvider struct {
+	*mock.MockMachineProvider
+
+	machineInfos      []machineproviders.MachineInfo
+	createdMachine    machineproviders.MachineInfo
+	concurrentMachine *machineproviders.MachineInfo
+	created           *bool
+}
+
+// WithClient returns the provider unchanged, so that the uncached provider reports the same Machines.
+func (m mislabellingMachineProvider) WithClient(client.Client) machineproviders.MachineProvider {
+	return m
+}
+
+// GetMachineInfos returns the configured Machines, including the created Machine once it has been created.
+func (m mislabellingMachineProvider) GetMachineInfos(context.Context, logr.Logger) ([]machineproviders.MachineInfo, error) {
+	if !*m.created {
+		return m.machineInfos, nil
+	}
+
+	machineInfos := append(append([]machineproviders.MachineInfo{}, m.machineInfos...), m.createdMachine)
+	if m.concurrentMachine != nil {
+		machineInfos = append(machineInfos, *m.concurrentMachine)
+	}
+
+	return machineInfos, nil
+}
+
+// CreateMachine records that the mislabelled Machine has been created.
+func (m mislabellingMachineProvider) CreateMachine(context.Context, logr.Logger, int32) (string, error) {
+	*m.created = true
+
+	return m.createdMachine.MachineRef.ObjectMeta.Name, nil
+}
+
+var _ = Describe("reconcileMachineUpdates with a machine provider that mislabels created machines", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+	var provider mislabellingMachineProvider
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+		1: {outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		created := false
+		provider = mislabellingMachineProvider{
+			MockMachineProvider: mockMachineProvider,
+			machineInfos:        machineInfosMaptoSlice(machineInfos),
+			createdMachine: updatedMachineBuilder.WithIndex(2).WithMachineName("machine-replacement-1").
+				WithMachineLabels(map[string]string{machineproviders.MachineIndexLabel: "2"}).Build(),
+			created: &created,
+		}
+	})
+
+	Context("when the replacement machine is created with the index label of another index", func() {
+		var err error
+
+		BeforeEach(func() {
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(errMismatchedMachineIndex))
+		})
+
+		It("creates the replacement machine", func() {
+			Expect(*provider.created).To(BeTrue())
+		})
+
+		It("marks the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonMismatchedMachineIndex)),
+				HaveField("Message", Equal("Machine machine-replacement-1 was created for index 1 but is labelled with index \"2\", remove it to continue")),
+			))
+		})
+
+		It("records the mislabelled machine", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("MismatchedIndexMachine", Equal("machine-replacement-1")))
+		})
+
+		It("logs the mismatched index", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Error: fmt.Errorf("%w: machine machine-replacement-1 created for index 1 has index label %q", errMismatchedMachineIndex, "2"),
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(1),
+					"namespace", "test",
+					"name", "machine-1",
+				},
+				Message: createdMachineWithMismatchedIndex,
+			}))
+		})
+	})
+
+	Context("when another actor concurrently creates a machine with the index label of another index", func() {
+		var err error
+
+		BeforeEach(func() {
+			provider.createdMachine = updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").
+				WithMachineLabels(map[string]string{machineproviders.MachineIndexLabel: "1"}).Build()
+
+			concurrentMachine := updatedMachineBuilder.WithIndex(2).WithMachineName("machine-other-2").
+				WithMachineLabels(map[string]string{machineproviders.MachineIndexLabel: "2"}).Build()
+			provider.concurrentMachine = &concurrentMachine
+
+			_, err = reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, provider, machineInfos)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("creates the replacement machine", func() {
+			Expect(*provider.created).To(BeTrue())
+		})
+
+		It("does not mark the control plane machine set as degraded", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDegraded)).To(BeNil())
+		})
+
+		It("does not record a mislabelled machine", func() {
+			Expect(machineproviders.GetPersistedState(cpms)).To(HaveField("MismatchedIndexMachine", BeEmpty()))
+		})
+	})
+})
+
+var _ = Describe("utils tests", func() {
+	machineGVR := machinev1beta1.GroupVersion.WithResource("machines")
+	nodeGVR := corev1.SchemeGroupVersion.WithResource("nodes")
+	updatedMachineBuilder := machineprovidersresourcebuilder.MachineInfo().
+		WithMachineGVR(machineGVR).
+		WithNodeGVR(nodeGVR).
+		WithReady(true).
+		WithNeedsUpdate(false)
+
+	DescribeTable("should convert an indexed map of MachineInfo into a slice sorted by index",
+		func(input map[int32][]machineproviders.MachineInfo, expected []indexToMachineInfos) {
+			output := sortMachineInfosByIndex(input)
+			Expect(output).To(Equal(expected))
+		},
+		Entry("when index starts at 0",
+			map[int32][]machineproviders.MachineInfo{
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+			},
+			[]indexToMachineInfos{
+				{index: 0, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()}},
+				{index: 1, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()}},
+				{index: 2, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()}},
+			},
+		),
+		Entry("when index starts at 1",
+			map[int32][]machineproviders.MachineInfo{
+				3: {updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").Build()},
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			},
+			[]indexToMachineInfos{
+				{index: 1, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()}},
+				{index: 2, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()}},
+				{index: 3, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(3).WithMachineName("machine-3").WithNodeName("node-3").Build()}},
+			},
+		),
+		Entry("when one index is missing",
+			map[int32][]machineproviders.MachineInfo{
+				2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+				0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+			},
+			[]indexToMachineInfos{
+				{index: 0, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()}},
+				{index: 2, machineInfos: []machineproviders.MachineInfo{updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()}},
+			},
+		),
+	)
+	DescribeTable("should convert an slice of MachineInfo into a slice sorted of MachineInfo sorted by Machine's CreationTimestamp",
+		func(input []machineproviders.MachineInfo, expected []machineproviders.MachineInfo) {
+			output := sortMachineInfoByCreationTimestamp(input)
+			Expect(output).To(Equal(expected))
+		},
+		Entry("when MachineInfo is not sorted by CreationTimestamp",
+			[]machineproviders.MachineInfo{
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
+			},
+			[]machineproviders.MachineInfo{
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
+			},
+		),
+		Entry("when MachineInfo is already sorted by CreationTimestamp",
+			[]machineproviders.MachineInfo{
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
+			},
+			[]machineproviders.MachineInfo{
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-older-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 01, 01, 01, 01, 01, 01, time.UTC))).Build(),
+				updatedMachineBuilder.WithIndex(0).WithMachineName("machine-newer-0").WithNodeName("node-0").
+					WithMachineCreationTimestamp(metav1.NewTime(time.Date(2022, 02, 01, 01, 01, 01, 01, time.UTC))).Build(),
+			},
+		),
+	)
+})
+
+var _ = Describe("reconcileMachineUpdates with multiple machines to delete", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	replacedMachine0 := outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()
+	replacedMachine1 := outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()
+
+	// Both index 0 and index 1 have an outdated machine alongside a ready replacement, so both outdated machines
+	// would otherwise be deleted within the same reconcile.
+	machineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {
+			replacedMachine0,
+			updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+		},
+		1: {
+			replacedMachine1,
+			updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build(),
+		},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine0.MachineRef).Return(nil).Times(1)
+	})
+
+	expectSingleDeletion := func(strategy machinev1.ControlPlaneMachineSetStrategyType) {
+		It("deletes only the first replaced machine", func() {
+			Expect(logger.Entries()).To(ContainElements(
+				testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", strategy,
+						"index", int32(0),
+						"namespace", "test",
+						"name", "machine-0",
+					},
+					Message: removingOldMachine,
+				},
+				testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", strategy,
+						"index", int32(1),
+						"namespace", "test",
+						"name", "machine-1",
+						"deletedMachine", "machine-0",
+					},
+					Message: refusingConcurrentDeletion,
+				},
+			))
+		})
+	}
+
+	Context("with the RollingUpdate strategy", func() {
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		expectSingleDeletion(machinev1.RollingUpdate)
+
+		It("sets the DeletionHeld condition", func() {
+			Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDeletionHeld)).To(SatisfyAll(
+				HaveField("Status", Equal(metav1.ConditionTrue)),
+				HaveField("Reason", Equal(reasonAwaitingObservedDeletion)),
+				HaveField("Message", Equal("Not deleting machine machine-1 in index 1 until the deletion of machine machine-0 has been observed")),
+			))
+		})
+
+		Context("and the deletion has not yet been observed by later reconciles", func() {
+			BeforeEach(func() {
+				// The cache still shows both replaced machines, so neither may be deleted again.
+				for i := 0; i < 3; i++ {
+					_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+					Expect(err).ToNot(HaveOccurred())
+				}
+			})
+
+			It("does not delete any further machine", func() {
+				Expect(logger.Entries()).ToNot(ContainElement(testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.RollingUpdate,
+						"index", int32(1),
+						"namespace", "test",
+						"name", "machine-1",
+					},
+					Message: removingOldMachine,
+				}))
+			})
+
+			It("keeps the DeletionHeld condition", func() {
+				Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDeletionHeld)).To(BeTrue())
+			})
+		})
+
+		Context("and the deletion has then been observed", func() {
+			BeforeEach(func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), replacedMachine1.MachineRef).Return(nil).Times(1)
+
+				_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, map[int32][]machineproviders.MachineInfo{
+					0: {
+						outdatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+							WithMachineDeletionTimestamp(metav1.Now()).Build(),
+						updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build(),
+					},
+					1: machineInfos[1],
+					2: machineInfos[2],
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("deletes the second replaced machine", func() {
+				Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.RollingUpdate,
+						"index", int32(1),
+						"namespace", "test",
+						"name", "machine-1",
+					},
+					Message: removingOldMachine,
+				}))
+			})
+		})
+
+		Context("and the deleted machine has then been removed", func() {
+			BeforeEach(func() {
+				mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, map[int32][]machineproviders.MachineInfo{
+					0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").WithNodeName("node-replacement-0").Build()},
+					1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-replacement-1").WithNodeName("node-replacement-1").Build()},
+					2: machineInfos[2],
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("removes the DeletionHeld condition", func() {
+				Expect(meta.FindStatusCondition(cpms.Status.Conditions, conditionDeletionHeld)).To(BeNil())
+			})
+		})
+	})
+
+	Context("with the OnDelete strategy", func() {
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.OnDelete).Build()
+
+			_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		expectSingleDeletion(machinev1.OnDelete)
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with a machine stuck deleting", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	// The Machine in index 0 has been deleted and is no longer held by a pre-drain hook,
+	// but is still present while it is drained and its finalizers complete.
+	stuckMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {
+			updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+				WithReady(false).WithAwaitingRemoval(true).WithMachineDeletionTimestamp(metav1.Now()).Build(),
+		},
+		1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+		2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+	}
+
+	// The Machine in index 0 has since been removed.
+	removedMachineInfos := map[int32][]machineproviders.MachineInfo{
+		0: {},
+		1: stuckMachineInfos[1],
+		2: stuckMachineInfos[2],
+	}
+
+	expectReplacement := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(machineInfos), nil).AnyTimes()
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(0)).Return("", nil).Times(1)
+	}
+
+	reconcileUpdates := func(machineInfos map[int32][]machineproviders.MachineInfo) {
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	expectWaitForRemoval := func(strategy machinev1.ControlPlaneMachineSetStrategyType) {
+		It("waits for the machine to be removed before creating its replacement", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", strategy,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-0",
+				},
+				Message: waitingForRemovalBeforeReplacement,
+			}))
+		})
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("with the RollingUpdate strategy", func() {
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.RollingUpdate).Build()
+		})
+
+		Context("while the machine is still being deleted", func() {
+			BeforeEach(func() {
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				reconcileUpdates(stuckMachineInfos)
+			})
+
+			expectWaitForRemoval(machinev1.RollingUpdate)
+		})
+
+		Context("once the machine has been removed", func() {
+			BeforeEach(func() {
+				expectReplacement(removedMachineInfos)
+
+				reconcileUpdates(removedMachineInfos)
+			})
+
+			It("creates the replacement", func() {
+				Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.RollingUpdate,
+						"index", int32(0),
+						"namespace", "test",
+						"name", unknownMachineName,
+					},
+					Message: createdReplacement,
+				}))
+			})
+		})
+
+		Context("while the machine is held by a pre-drain hook", func() {
+			BeforeEach(func() {
+				heldMachineInfos := map[int32][]machineproviders.MachineInfo{
+					0: {
+						updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+							WithMachineDeletionTimestamp(metav1.Now()).Build(),
+					},
+					1: stuckMachineInfos[1],
+					2: stuckMachineInfos[2],
+				}
+
+				expectReplacement(heldMachineInfos)
+
+				reconcileUpdates(heldMachineInfos)
+			})
+
+			It("creates the replacement so that the hook can be released", func() {
+				Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.RollingUpdate,
+						"index", int32(0),
+						"namespace", "test",
+						"name", "machine-0",
+					},
+					Message: createdReplacement,
+				}))
+			})
+		})
+	})
+
+	Context("with the OnDelete strategy", func() {
+		BeforeEach(func() {
+			cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(machinev1.OnDelete).Build()
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(stuckMachineInfos)
+		})
+
+		expectWaitForRemoval(machinev1.OnDelete)
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with a replacement provisioning deadline", func() {
+	var logger testutils.TestLogger
+	var recorder *record.FakeRecorder
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	provisioningDeadline := 15 * time.Minute
+	startTime := time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	replacedMachine := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").
+		WithNeedsUpdate(true).WithDiff([]string{"InstanceType: m6i.xlarge != different"}).Build()
+
+	// The replacement in index 0 has no Node, and has been provisioning since its creation.
+	stuckReplacementBuilder := updatedMachineBuilder.WithIndex(0).WithMachineName("machine-replacement-0").
+		WithReady(false).WithProvisioning(true).WithMachineCreationTimestamp(metav1.NewTime(startTime))
+
+	reconcileUpdates := func(strategy machinev1.ControlPlaneMachineSetStrategyType, replacement machineproviders.MachineInfo) {
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(strategy).Build()
+
+		machineInfos := map[int32][]machineproviders.MachineInfo{
+			0: {replacedMachine, replacement},
+			1: {updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1").Build()},
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, machineInfos)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		recorder = record.NewFakeRecorder(10)
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace:                       "test",
+			Recorder:                        recorder,
+			ReplacementProvisioningDeadline: provisioningDeadline,
+			clock:                           clocktesting.NewFakePassiveClock(startTime.Add(20 * time.Minute)),
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	expectStuckReplacementDeleted := func(strategy machinev1.ControlPlaneMachineSetStrategyType) {
+		BeforeEach(func() {
+			stuckReplacement := stuckReplacementBuilder.Build()
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), stuckReplacement.MachineRef).Return(nil).Times(1)
+
+			reconcileUpdates(strategy, stuckReplacement)
+		})
+
+		It("deletes the replacement so that it is created again", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", strategy,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-replacement-0",
+					"deadline", provisioningDeadline.String(),
+				},
+				Message: deletingUnprovisionedMachine,
+			}))
+		})
+
+		It("emits an event for the deleted replacement", func() {
+			Expect(recorder.Events).To(Receive(Equal(fmt.Sprintf("%s %s Deleted machine machine-replacement-0 in index 0, it was not provisioned within %s of its creation",
+				corev1.EventTypeWarning, reasonProvisioningDeadlineExceeded, provisioningDeadline))))
+		})
+	}
+
+	Context("with a replacement stuck provisioning past the deadline", func() {
+		Context("with the RollingUpdate strategy", func() {
+			expectStuckReplacementDeleted(machinev1.RollingUpdate)
+		})
+
+		Context("with the OnDelete strategy", func() {
+			expectStuckReplacementDeleted(machinev1.OnDelete)
+		})
+	})
+
+	Context("with a replacement stuck provisioning while an earlier deletion has not been observed", func() {
+		BeforeEach(func() {
+			reconciler.deletions.record(updatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").Build())
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(machinev1.RollingUpdate, stuckReplacementBuilder.Build())
+		})
+
+		It("does not delete the replacement", func() {
+			Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+				Level: 2,
+				KeysAndValues: []interface{}{
+					"updateStrategy", machinev1.RollingUpdate,
+					"index", int32(0),
+					"namespace", "test",
+					"name", "machine-replacement-0",
+					"deletedMachine", "machine-1",
+				},
+				Message: refusingConcurrentDeletion,
+			}))
+		})
+
+		It("sets the DeletionHeld condition", func() {
+			Expect(meta.IsStatusConditionTrue(cpms.Status.Conditions, conditionDeletionHeld)).To(BeTrue())
+		})
+	})
+
+	Context("with a replacement provisioning within the deadline", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(machinev1.RollingUpdate, stuckReplacementBuilder.WithMachineCreationTimestamp(metav1.NewTime(startTime.Add(10*time.Minute))).Build())
+		})
+
+		It("waits for the replacement", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", waitingForReplacement)))
+		})
+	})
+
+	Context("with a provisioned replacement that is not yet ready past the deadline", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(machinev1.RollingUpdate, stuckReplacementBuilder.WithProvisioning(false).Build())
+		})
+
+		It("waits for the replacement", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", waitingForReplacement)))
+		})
+	})
+
+	Context("when the deadline is disabled", func() {
+		BeforeEach(func() {
+			reconciler.ReplacementProvisioningDeadline = 0
+
+			mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(machinev1.RollingUpdate, stuckReplacementBuilder.Build())
+		})
+
+		It("waits for the replacement", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", waitingForReplacement)))
+		})
+	})
+})
+
+var _ = Describe("reconcileMachineUpdates with the OnDelete strategy and rolling update settings", func() {
+	var logger testutils.TestLogger
+	var reconciler *ControlPlaneMachineSetReconciler
+	var cpms *machinev1.ControlPlaneMachineSet
+
+	var mockCtrl *gomock.Controller
+	var mockMachineProvider *mock.MockMachineProvider
+
+	outdatedIndex1Builder := outdatedMachineBuilder.WithIndex(1).WithMachineName("machine-1").WithNodeName("node-1")
+
+	machineInfos := func(machines ...machineproviders.MachineInfo) map[int32][]machineproviders.MachineInfo {
+		return map[int32][]machineproviders.MachineInfo{
+			0: {updatedMachineBuilder.WithIndex(0).WithMachineName("machine-0").WithNodeName("node-0").Build()},
+			1: machines,
+			2: {updatedMachineBuilder.WithIndex(2).WithMachineName("machine-2").WithNodeName("node-2").Build()},
+		}
+	}
+
+	// reconcileUpdates reconciles the cached machine infos, while the uncached machine provider observes the
+	// uncached machine infos.
+	reconcileUpdates := func(strategy machinev1.ControlPlaneMachineSetStrategyType, cached, uncached map[int32][]machineproviders.MachineInfo) {
+		// The canary and the rollout window only throttle a RollingUpdate, neither allows the OnDelete strategy to surge.
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().WithReplicas(3).WithStrategyType(strategy).Build()
+		cpms.SetAnnotations(map[string]string{
+			canaryAnnotation:        "true",
+			rolloutWindowAnnotation: "1h",
+		})
+
+		mockMachineProvider.EXPECT().WithClient(gomock.Any()).Return(mockMachineProvider).AnyTimes()
+		mockMachineProvider.EXPECT().GetMachineInfos(gomock.Any(), gomock.Any()).Return(machineInfosMaptoSlice(uncached), nil).AnyTimes()
+
+		_, err := reconciler.reconcileMachineUpdates(ctx, logger.Logger(), cpms, *cpms.Spec.Replicas, mockMachineProvider, cached)
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+
+		reconciler = &ControlPlaneMachineSetReconciler{
+			Namespace: "test",
+		}
+
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockMachineProvider = mock.NewMockMachineProvider(mockCtrl)
+		mockMachineProvider.EXPECT().DeleteMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	})
+
+	Context("when the outdated machine has not been deleted", func() {
+		BeforeEach(func() {
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			reconcileUpdates(machinev1.OnDelete, machineInfos(outdatedIndex1Builder.Build()), machineInfos(outdatedIndex1Builder.Build()))
+		})
+
+		It("asks for the machine to be deleted, without creating a replacement", func() {
+			Expect(logger.Entries()).To(ContainElement(HaveField("Message", machineRequiresDeleteBeforeUpdate)))
+		})
+	})
+
+	Context("when the cache has not yet observed the outdated machine", func() {
+		Context("with the OnDelete strategy", func() {
+			BeforeEach(func() {
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				reconcileUpdates(machinev1.OnDelete, machineInfos(), machineInfos(outdatedIndex1Builder.Build()))
+			})
+
+			It("does not create a machine before the outdated machine is deleted", func() {
+				Expect(logger.Entries()).To(ContainElement(testutils.LogEntry{
+					Level: 2,
+					KeysAndValues: []interface{}{
+						"updateStrategy", machinev1.OnDelete,
+						"index", int32(1),
+						"namespace", "test",
+						"name", unknownMachineName,
+						"machine", "machine-1",
+					},
+					Message: onDeleteMachineNotDeleted,
+				}))
+			})
+		})
+
+		Context("with the RollingUpdate strategy", func() {
+			BeforeEach(func() {
+				mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+				reconcileUpdates(machinev1.RollingUpdate, machineInfos(), machineInfos(outdatedIndex1Builder.Build()))
+			})
+
+			It("surges by creating a replacement alongside the outdated machine", func() {
+				Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", onDeleteMachineNotDeleted)))
+			})
+		})
+	})
+
+	Context("when the outdated machine has been deleted", func() {
+		BeforeEach(func() {
+			deletedMachine := outdatedIndex1Builder.WithMachineDeletionTimestamp(metav1.Now()).Build()
+
+			mockMachineProvider.EXPECT().CreateMachine(gomock.Any(), gomock.Any(), int32(1)).Return("", nil).Times(1)
+
+			reconcileUpdates(machinev1.OnDelete, machineInfos(deletedMachine), machineInfos(deletedMachine))
+		})
+
+		It("creates the replacement", func() {
+			Expect(logger.Entries()).ToNot(ContainElement(HaveField("Message", onDeleteMachineNotDeleted)))
+		})
+	})
 })
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+)
+
+var (
+	// errInvalidLogVerbosity is used to denote that the log verbosity annotation is not a non-negative integer.
+	errInvalidLogVerbosity = errors.New("invalid value for log verbosity annotation")
+)
+
+// logVerbosityApplier applies the log verbosity requested by the log verbosity annotation on the
+// ControlPlaneMachineSet, and restores the verbosity configured at startup once the annotation is removed.
+// It is shared by concurrent reconciles, so access is guarded by a lock.
+type logVerbosityApplier struct {
+	lock sync.Mutex
+
+	// level is the klog verbosity flag.
+	// When not set, the "v" flag registered on the global flag set by klog.InitFlags is used.
+	level flag.Value
+
+	// defaultLevel is the verbosity configured at startup.
+	// It is empty until the verbosity has been changed by the annotation.
+	defaultLevel string
+}
+
+// apply sets the log verbosity to the value of the log verbosity annotation on the ControlPlaneMachineSet.
+// When the ControlPlaneMachineSet is nil, or the annotation is not set, the verbosity configured at startup is restored.
+// An invalid annotation is logged and leaves the current verbosity in place.
+func (a *logVerbosityApplier) apply(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	level := a.levelFlag()
+	if level == nil {
+		return
+	}
+
+	var annotations map[string]string
+	if cpms != nil {
+		annotations = cpms.GetAnnotations()
+	}
+
+	value, ok := annotations[logVerbosityAnnotation]
+	if !ok {
+		a.restore(logger, level)
+
+		return
+	}
+
+	verbosity, err := strconv.Atoi(value)
+	if err != nil || verbosity < 0 {
+		logger.Error(fmt.Errorf("%w: %q", errInvalidLogVerbosity, value), "Ignoring invalid log verbosity", "annotation", logVerbosityAnnotation)
+
+		return
+	}
+
+	if a.defaultLevel == "" {
+		a.defaultLevel = level.String()
+	}
+
+	if level.String() == strconv.Itoa(verbosity) {
+		return
+	}
+
+	if err := level.Set(strconv.Itoa(verbosity)); err != nil {
+		logger.Error(err, "Unable to change log verbosity", "verbosity", verbosity)
+
+		return
+	}
+
+	logger.Info("Changed log verbosity", "verbosity", verbosity)
+}
+
+// restore sets the log verbosity back to the verbosity configured at startup, if it has been changed.
+func (a *logVerbosityApplier) restore(logger logr.Logger, level flag.Value) {
+	if a.defaultLevel == "" {
+		return
+	}
+
+	if err := level.Set(a.defaultLevel); err != nil {
+		logger.Error(err, "Unable to restore log verbosity", "verbosity", a.defaultLevel)
+
+		return
+	}
+
+	logger.Info("Restored log verbosity", "verbosity", a.defaultLevel)
+
+	a.defaultLevel = ""
+}
+
+// levelFlag returns the klog verbosity flag, or nil when klog has not registered its flags.
+func (a *logVerbosityApplier) levelFlag() flag.Value {
+	if a.level != nil {
+		return a.level
+	}
+
+	if f := flag.CommandLine.Lookup("v"); f != nil {
+		return f.Value
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"flag"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	"k8s.io/klog/v2"
+)
+
+var _ = Describe("logVerbosityApplier", func() {
+	var logger testutils.TestLogger
+	var applier *logVerbosityApplier
+	var cpms *machinev1.ControlPlaneMachineSet
+	var level flag.Value
+	var originalLevel string
+
+	BeforeEach(func() {
+		flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+		klog.InitFlags(flags)
+
+		level = flags.Lookup("v").Value
+		originalLevel = level.String()
+		Expect(level.Set("2")).To(Succeed())
+
+		logger = testutils.NewTestLogger()
+		applier = &logVerbosityApplier{level: level}
+		cpms = machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+	})
+
+	AfterEach(func() {
+		Expect(level.Set(originalLevel)).To(Succeed())
+	})
+
+	Context("when the log verbosity annotation is set to 5", func() {
+		BeforeEach(func() {
+			Expect(klog.V(5).Enabled()).To(BeFalse())
+
+			cpms.SetAnnotations(map[string]string{logVerbosityAnnotation: "5"})
+			applier.apply(logger.Logger(), cpms)
+		})
+
+		It("raises the effective log verbosity", func() {
+			Expect(klog.V(5).Enabled()).To(BeTrue())
+			Expect(klog.V(6).Enabled()).To(BeFalse())
+		})
+
+		It("logs the change", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				KeysAndValues: []interface{}{"verbosity", 5},
+				Message:       "Changed log verbosity",
+			}))
+		})
+
+		Context("and the annotation is then removed", func() {
+			BeforeEach(func() {
+				cpms.SetAnnotations(nil)
+				applier.apply(logger.Logger(), cpms)
+			})
+
+			It("restores the log verbosity configured at startup", func() {
+				Expect(level.String()).To(Equal("2"))
+				Expect(klog.V(3).Enabled()).To(BeFalse())
+			})
+		})
+
+		Context("and the control plane machine set is then removed", func() {
+			BeforeEach(func() {
+				applier.apply(logger.Logger(), nil)
+			})
+
+			It("restores the log verbosity configured at startup", func() {
+				Expect(level.String()).To(Equal("2"))
+			})
+		})
+	})
+
+	Context("when the log verbosity annotation is invalid", func() {
+		BeforeEach(func() {
+			cpms.SetAnnotations(map[string]string{logVerbosityAnnotation: "loud"})
+			applier.apply(logger.Logger(), cpms)
+		})
+
+		It("does not change the log verbosity", func() {
+			Expect(level.String()).To(Equal("2"))
+		})
+
+		It("logs an error", func() {
+			Expect(logger.Entries()).To(ConsistOf(testutils.LogEntry{
+				Error:         fmt.Errorf("%w: %q", errInvalidLogVerbosity, "loud"),
+				KeysAndValues: []interface{}{"annotation", logVerbosityAnnotation},
+				Message:       "Ignoring invalid log verbosity",
+			}))
+		})
+	})
+
+	Context("when the log verbosity annotation is not set", func() {
+		BeforeEach(func() {
+			applier.apply(logger.Logger(), cpms)
+		})
+
+		It("does not change the log verbosity", func() {
+			Expect(level.String()).To(Equal("2"))
+		})
+
+		It("does not log", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+	})
+})
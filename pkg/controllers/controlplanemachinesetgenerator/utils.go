@@ -166,27 +166,28 @@ func convertViaJSON(in, out interface{}) error {
 
 // buildFailureDomains builds a flavored FailureDomain for the ControlPlaneMachineSet according to what platform we are on.
 func buildFailureDomains(logger logr.Logger, machineSets []machinev1beta1.MachineSet, machines []machinev1beta1.Machine) (*machinev1builder.FailureDomainsApplyConfiguration, error) {
-	// Fetch failure domains from the machines
-	machineFailureDomains, err := providerconfig.ExtractFailureDomainsFromMachines(logger, machines)
+	// Fetch the set of failure domains occupied by the machines.
+	failureDomains, err := failuredomain.NewSetFromMachines(machines, func(machine machinev1beta1.Machine) (failuredomain.FailureDomain, error) {
+		return providerconfig.ExtractFailureDomainFromMachine(logger, machine)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract failure domains from machines: %w", err)
 	}
 
+	platformType := failureDomains.List()[0].Type()
+
 	// Fetch failure domains from the machineSets
 	machineSetFailureDomains, err := providerconfig.ExtractFailureDomainsFromMachineSets(logger, machineSets)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract failure domains from machine sets: %w", err)
 	}
 
-	// We have to get rid of duplicates from the failure domains.
-	// We construct a set from the failure domains, since a set can't have duplicates.
-	failureDomains := failuredomain.NewSet(machineFailureDomains...)
 	// Construction of a union of failure domains of machines and machineSets.
 	failureDomains.Insert(machineSetFailureDomains...)
 
 	var cpmsFailureDomain machinev1.FailureDomains
 
-	switch machineFailureDomains[0].Type() {
+	switch platformType {
 	case configv1.AWSPlatformType:
 		cpmsFailureDomain = buildAWSFailureDomains(failureDomains)
 	case configv1.AzurePlatformType:
@@ -194,7 +195,7 @@ func buildFailureDomains(logger logr.Logger, machineSets []machinev1beta1.Machin
 	case configv1.GCPPlatformType:
 		cpmsFailureDomain = buildGCPFailureDomains(failureDomains)
 	default:
-		return nil, fmt.Errorf("%w: %sFailureDomain{}", errUnsupportedPlatform, machineFailureDomains[0].Type())
+		return nil, fmt.Errorf("%w: %sFailureDomain{}", errUnsupportedPlatform, platformType)
 	}
 
 	cpmsFailureDomainsApplyConfig := &machinev1builder.FailureDomainsApplyConfiguration{}
@@ -38,11 +38,12 @@ func (m *MockMachineProvider) EXPECT() *MockMachineProviderMockRecorder {
 }
 
 // CreateMachine mocks base method.
-func (m *MockMachineProvider) CreateMachine(arg0 context.Context, arg1 logr.Logger, arg2 int32) error {
+func (m *MockMachineProvider) CreateMachine(arg0 context.Context, arg1 logr.Logger, arg2 int32) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateMachine", arg0, arg1, arg2)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateMachine indicates an expected call of CreateMachine.
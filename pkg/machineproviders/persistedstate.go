@@ -0,0 +1,86 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machineproviders
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PersistedState is the state that the controller records in the persisted state annotation of the
+// ControlPlaneMachineSet. The ControlPlaneMachineSet API is owned by openshift/api, so state that is not
+// represented in the API, but must survive an operator restart, is stored here.
+// Each field is omitted when it does not apply.
+type PersistedState struct {
+	// LastProgressTime is the last time that the number of updated replicas changed during a RollingUpdate rollout.
+	// It is cleared when no rollout is in progress.
+	LastProgressTime *metav1.Time `json:"lastProgressTime,omitempty"`
+
+	// LastReplacementCompletedTime is the last time that a replacement Machine was observed as Ready after its
+	// predecessor had been removed, regardless of the update strategy.
+	LastReplacementCompletedTime *metav1.Time `json:"lastReplacementCompletedTime,omitempty"`
+
+	// LastIndexCompletedTime is the time at which the replaced Machine of the last completed index was removed during
+	// a throttled RollingUpdate. It is cleared while an index is completing, and when no rollout window is configured.
+	LastIndexCompletedTime *metav1.Time `json:"lastIndexCompletedTime,omitempty"`
+
+	// DeletionGraceStartTime is the time at which the replacement of the Machine currently awaiting deletion was
+	// first observed to be ready.
+	DeletionGraceStartTime *metav1.Time `json:"deletionGraceStartTime,omitempty"`
+
+	// ForceRollToken is the force roll token that has last been acted upon, so that the same token does not trigger
+	// a further roll.
+	ForceRollToken string `json:"forceRollToken,omitempty"`
+
+	// ForceRollTime is the time at which the forced roll was requested.
+	// Machines created before this time are reported as needing an update.
+	ForceRollTime *metav1.Time `json:"forceRollTime,omitempty"`
+
+	// CanaryIndex is the index chosen as the canary for the current RollingUpdate.
+	CanaryIndex *int32 `json:"canaryIndex,omitempty"`
+
+	// CanaryApprovalObserved is the value of the canary approval annotation when the canary index was chosen, so
+	// that a later change can be recognised as an approval.
+	CanaryApprovalObserved string `json:"canaryApprovalObserved,omitempty"`
+
+	// StepObserved is the value of the step annotation that was last acted upon, so that each change to the step
+	// annotation allows exactly one Machine to be created or deleted.
+	StepObserved *string `json:"stepObserved,omitempty"`
+
+	// MismatchedIndexMachine is the name of a Machine that was created for an index, but that does not carry the
+	// index label for that index. Operations are halted until this Machine has been removed.
+	MismatchedIndexMachine string `json:"mismatchedIndexMachine,omitempty"`
+}
+
+// GetPersistedState returns the state recorded in the persisted state annotation of the ControlPlaneMachineSet.
+// An empty state is returned when the annotation is not set.
+func GetPersistedState(cpms metav1.Object) (PersistedState, error) {
+	state := PersistedState{}
+
+	value, ok := cpms.GetAnnotations()[PersistedStateAnnotation]
+	if !ok {
+		return state, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return PersistedState{}, fmt.Errorf("error parsing %s annotation: %w", PersistedStateAnnotation, err)
+	}
+
+	return state, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Diagnostics is a snapshot of the view the operator has of the control plane, intended to be included in a
+// support bundle, such as the output of must-gather.
+type Diagnostics struct {
+	// ControlPlaneMachineSet is the ControlPlaneMachineSet the snapshot was collected for.
+	ControlPlaneMachineSet *machinev1.ControlPlaneMachineSet `json:"controlPlaneMachineSet"`
+
+	// Machines are all of the Machines matched by the selector of the ControlPlaneMachineSet, sorted by name.
+	Machines []machinev1beta1.Machine `json:"machines"`
+
+	// MachineInfos are the MachineInfos computed by the machine provider for the Machines, sorted by index.
+	MachineInfos []machineproviders.MachineInfo `json:"machineInfos"`
+
+	// FailureDomainMapping describes the failure domain mapped to each index. This is empty when the machine provider
+	// does not map indexes to failure domains, or when no failure domains are defined.
+	FailureDomainMapping map[int32]string `json:"failureDomainMapping"`
+
+	// MachineGenerations compare the generation of the ControlPlaneMachineSet that each Machine was created for with
+	// the current generation, sorted by index. This shows how many changes a Machine predates, even when its spec
+	// still matches the template.
+	MachineGenerations []MachineGeneration `json:"machineGenerations"`
+
+	// Conditions are the current conditions of the ControlPlaneMachineSet.
+	Conditions []metav1.Condition `json:"conditions"`
+
+	// EtcdMembers maps each member of the etcd cluster to the Machine, and index, on which it runs.
+	// This is omitted when no EtcdMemberLister is provided, or when the etcd members cannot be listed.
+	EtcdMembers []EtcdMemberMapping `json:"etcdMembers,omitempty"`
+}
+
+// CollectDiagnostics assembles a Diagnostics snapshot for the ControlPlaneMachineSet.
+// The MachineInfos and the failure domain mapping are computed by the machine provider, in the same way as the
+// ControlPlaneMachineSet controller computes them. Nothing within the cluster is modified.
+// When an EtcdMemberLister is provided, the snapshot is enriched with the mapping of etcd members to Machines.
+// The etcd members are only listed on a best effort basis, so that the snapshot can still be collected while etcd is
+// unhealthy, which is often when it is most needed.
+func CollectDiagnostics(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet, etcdMembers EtcdMemberLister) (*Diagnostics, error) {
+	provider, err := NewMachineProvider(ctx, logger, cl, cpms, pointer.Int32Deref(cpms.Spec.Replicas, 0))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing machine provider: %w", err)
+	}
+
+	machineInfos, err := provider.GetMachineInfos(ctx, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching machine info: %w", err)
+	}
+
+	sort.SliceStable(machineInfos, func(i, j int) bool {
+		return machineInfos[i].Index < machineInfos[j].Index
+	})
+
+	selector, err := metav1.LabelSelectorAsSelector(&cpms.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing selector: %w", err)
+	}
+
+	machineList := &machinev1beta1.MachineList{}
+	if err := cl.List(ctx, machineList, client.InNamespace(cpms.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("error listing machines: %w", err)
+	}
+
+	sort.SliceStable(machineList.Items, func(i, j int) bool {
+		return machineList.Items[i].Name < machineList.Items[j].Name
+	})
+
+	failureDomainMapping := map[int32]string{}
+	if mapper, ok := provider.(machineproviders.FailureDomainMapper); ok {
+		failureDomainMapping = mapper.FailureDomainMapping()
+	}
+
+	diagnostics := &Diagnostics{
+		ControlPlaneMachineSet: cpms,
+		Machines:               machineList.Items,
+		MachineInfos:           machineInfos,
+		FailureDomainMapping:   failureDomainMapping,
+		MachineGenerations:     MapMachineGenerations(cpms.Generation, machineInfos),
+		Conditions:             cpms.Status.Conditions,
+	}
+
+	if etcdMembers != nil {
+		members, err := etcdMembers.ListEtcdMembers(ctx)
+		if err != nil {
+			logger.Error(err, "Unable to list etcd members, the etcd member mapping is omitted from the diagnostics")
+		} else {
+			diagnostics.EtcdMembers = MapEtcdMembers(members, machineList.Items, machineInfos)
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// WriteDiagnostics writes the Diagnostics snapshot as a single, indented, JSON document.
+func WriteDiagnostics(w io.Writer, diagnostics *Diagnostics) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(diagnostics); err != nil {
+		return fmt.Errorf("error writing diagnostics: %w", err)
+	}
+
+	return nil
+}
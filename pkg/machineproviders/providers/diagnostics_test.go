@@ -0,0 +1,158 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("CollectDiagnostics", func() {
+	var logger testutils.TestLogger
+	var namespaceName string
+
+	zones := []string{"us-east-1a", "us-east-1b", "us-east-1c"}
+	createdForGenerations := map[int]string{0: "1", 1: "3"}
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-diagnostics-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		logger = testutils.NewTestLogger()
+
+		By("Creating some master machines")
+		machineBuilder := machinev1beta1resourcebuilder.Machine().AsMaster().WithNamespace(namespaceName)
+		for i, zone := range zones {
+			providerSpec := machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone(zone).WithSubnet(machinev1beta1.AWSResourceReference{
+				ID: pointer.String(fmt.Sprintf("subenet-%s", zone)),
+			})
+
+			machine := machineBuilder.WithName(fmt.Sprintf("master-%d", i)).WithProviderSpecBuilder(providerSpec).Build()
+
+			// Stamp the first two Machines as if created for earlier generations, the last predates the annotation.
+			if generation, ok := createdForGenerations[i]; ok {
+				machine.SetAnnotations(map[string]string{machineproviders.MachineGenerationAnnotation: generation})
+			}
+
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&machinev1beta1.Machine{},
+		)
+	})
+
+	Context("with a ControlPlaneMachineSet spreading the Machines across failure domains", func() {
+		var diagnostics *Diagnostics
+		var err error
+
+		conditions := []metav1.Condition{
+			{
+				Type:   "Available",
+				Status: metav1.ConditionTrue,
+				Reason: "AllReplicasAvailable",
+			},
+		}
+
+		BeforeEach(func() {
+			cpms := machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithGeneration(4).WithMachineTemplateBuilder(
+				machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+					WithFailureDomainsBuilder(machinev1resourcebuilder.AWSFailureDomains()).
+					WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec()),
+			).Build()
+			cpms.Status.Conditions = conditions
+
+			diagnostics, err = CollectDiagnostics(ctx, logger.Logger(), k8sClient, cpms, nil)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("includes all of the Machines", func() {
+			Expect(diagnostics.Machines).To(HaveLen(3))
+
+			for i, machine := range diagnostics.Machines {
+				Expect(machine.Name).To(Equal(fmt.Sprintf("master-%d", i)))
+			}
+		})
+
+		It("includes the MachineInfo and index of every Machine", func() {
+			Expect(diagnostics.MachineInfos).To(HaveLen(3))
+
+			for i, machineInfo := range diagnostics.MachineInfos {
+				Expect(machineInfo.Index).To(BeEquivalentTo(i))
+				Expect(machineInfo.MachineRef).ToNot(BeNil())
+				Expect(machineInfo.MachineRef.ObjectMeta.Name).To(Equal(fmt.Sprintf("master-%d", i)))
+			}
+		})
+
+		It("includes the failure domain mapped to each index", func() {
+			Expect(diagnostics.FailureDomainMapping).To(HaveLen(3))
+
+			for i, zone := range zones {
+				Expect(diagnostics.FailureDomainMapping).To(HaveKeyWithValue(int32(i), ContainSubstring(zone)))
+			}
+		})
+
+		It("includes the generation that each Machine was created for", func() {
+			Expect(diagnostics.MachineGenerations).To(Equal([]MachineGeneration{
+				{MachineName: "master-0", Index: 0, CreatedForGeneration: pointer.Int64(1), GenerationsBehind: pointer.Int64(3)},
+				{MachineName: "master-1", Index: 1, CreatedForGeneration: pointer.Int64(3), GenerationsBehind: pointer.Int64(1)},
+				{MachineName: "master-2", Index: 2},
+			}))
+		})
+
+		It("includes the conditions", func() {
+			Expect(diagnostics.Conditions).To(Equal(conditions))
+		})
+
+		It("writes the snapshot as a single JSON document", func() {
+			buf := &bytes.Buffer{}
+			Expect(WriteDiagnostics(buf, diagnostics)).To(Succeed())
+
+			decoded := &Diagnostics{}
+			Expect(json.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+
+			Expect(decoded.Machines).To(HaveLen(3))
+			Expect(decoded.MachineInfos).To(HaveLen(3))
+
+			for i, machineInfo := range decoded.MachineInfos {
+				Expect(machineInfo.Index).To(BeEquivalentTo(i))
+				Expect(machineInfo.MachineRef.ObjectMeta.Name).To(Equal(fmt.Sprintf("master-%d", i)))
+			}
+		})
+	})
+})
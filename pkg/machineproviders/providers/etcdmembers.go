@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// etcdNamespace is the namespace in which the etcd operator runs.
+	etcdNamespace = "openshift-etcd"
+
+	// etcdEndpointsConfigMapName is the name of the ConfigMap, maintained by the etcd operator, that maps the ID of
+	// each member of the etcd cluster to its IP address.
+	etcdEndpointsConfigMapName = "etcd-endpoints"
+)
+
+// EtcdMember is a member of the etcd cluster.
+type EtcdMember struct {
+	// ID is the hexadecimal ID of the etcd member.
+	ID string `json:"id"`
+
+	// Address is the IP address on which the etcd member serves its peers.
+	Address string `json:"address"`
+}
+
+// EtcdMemberLister lists the members of the etcd cluster.
+type EtcdMemberLister interface {
+	// ListEtcdMembers returns the members of the etcd cluster, sorted by ID.
+	ListEtcdMembers(ctx context.Context) ([]EtcdMember, error)
+}
+
+// etcdEndpointsMemberLister lists the members of the etcd cluster from the ConfigMap published by the etcd operator,
+// so that the members can be listed without connecting to etcd.
+type etcdEndpointsMemberLister struct {
+	reader client.Reader
+}
+
+// NewEtcdEndpointsMemberLister creates an EtcdMemberLister that reads the etcd endpoints ConfigMap published by the
+// etcd operator. The reader must be able to read ConfigMaps in the openshift-etcd namespace.
+func NewEtcdEndpointsMemberLister(reader client.Reader) EtcdMemberLister {
+	return etcdEndpointsMemberLister{reader: reader}
+}
+
+// ListEtcdMembers returns the members of the etcd cluster, sorted by ID.
+func (l etcdEndpointsMemberLister) ListEtcdMembers(ctx context.Context) ([]EtcdMember, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := l.reader.Get(ctx, client.ObjectKey{Namespace: etcdNamespace, Name: etcdEndpointsConfigMapName}, configMap); err != nil {
+		return nil, fmt.Errorf("error getting etcd endpoints: %w", err)
+	}
+
+	members := []EtcdMember{}
+	for id, address := range configMap.Data {
+		members = append(members, EtcdMember{ID: id, Address: address})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+
+	return members, nil
+}
+
+// EtcdMemberMapping describes the control plane Machine on which a member of the etcd cluster runs.
+type EtcdMemberMapping struct {
+	EtcdMember
+
+	// MachineName is the name of the Machine with the address of the etcd member.
+	// This is empty when no control plane Machine has the address, for example, because the member was not removed
+	// from the etcd cluster when its Machine was.
+	MachineName string `json:"machineName,omitempty"`
+
+	// Index is the index of the Machine with the address of the etcd member.
+	// This is nil when no control plane Machine has the address.
+	Index *int32 `json:"index,omitempty"`
+}
+
+// MapEtcdMembers maps each etcd member to the control plane Machine with the address of the member, and so to the
+// index of that Machine. The mapping is sorted by index, followed by any members that could not be mapped to an index,
+// sorted by ID.
+func MapEtcdMembers(members []EtcdMember, machines []machinev1beta1.Machine, machineInfos []machineproviders.MachineInfo) []EtcdMemberMapping {
+	machineNamesByAddress := map[string]string{}
+
+	for _, machine := range machines {
+		for _, address := range machine.Status.Addresses {
+			machineNamesByAddress[address.Address] = machine.Name
+		}
+	}
+
+	indexesByMachineName := map[string]int32{}
+
+	for _, machineInfo := range machineInfos {
+		if machineInfo.MachineRef != nil {
+			indexesByMachineName[machineInfo.MachineRef.ObjectMeta.Name] = machineInfo.Index
+		}
+	}
+
+	mapping := []EtcdMemberMapping{}
+
+	for _, member := range members {
+		memberMapping := EtcdMemberMapping{EtcdMember: member}
+
+		if machineName, ok := machineNamesByAddress[member.Address]; ok {
+			memberMapping.MachineName = machineName
+
+			if index, ok := indexesByMachineName[machineName]; ok {
+				memberIndex := index
+				memberMapping.Index = &memberIndex
+			}
+		}
+
+		mapping = append(mapping, memberMapping)
+	}
+
+	sort.SliceStable(mapping, func(i, j int) bool {
+		switch {
+		case mapping[i].Index == nil:
+			return false
+		case mapping[j].Index == nil:
+			return true
+		default:
+			return *mapping[i].Index < *mapping[j].Index
+		}
+	})
+
+	return mapping
+}
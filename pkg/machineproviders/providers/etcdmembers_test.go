@@ -0,0 +1,101 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("MapEtcdMembers", func() {
+	type mapEtcdMembersTableInput struct {
+		members         []EtcdMember
+		expectedMapping []EtcdMemberMapping
+	}
+
+	// machineWithAddress builds a control plane Machine with the given internal IP address.
+	machineWithAddress := func(name, address string) machinev1beta1.Machine {
+		machine := machinev1beta1resourcebuilder.Machine().AsMaster().WithName(name).Build()
+		machine.Status.Addresses = []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: address},
+			{Type: corev1.NodeInternalDNS, Address: name},
+		}
+
+		return *machine
+	}
+
+	machines := []machinev1beta1.Machine{
+		machineWithAddress("master-0", "10.0.0.10"),
+		machineWithAddress("master-1", "10.0.0.11"),
+		machineWithAddress("master-2", "10.0.0.12"),
+		machineWithAddress("master-unindexed", "10.0.0.13"),
+	}
+
+	machineInfos := []machineproviders.MachineInfo{
+		{MachineRef: &machineproviders.ObjectRef{ObjectMeta: machines[0].ObjectMeta}, Index: 0},
+		{MachineRef: &machineproviders.ObjectRef{ObjectMeta: machines[1].ObjectMeta}, Index: 1},
+		{MachineRef: &machineproviders.ObjectRef{ObjectMeta: machines[2].ObjectMeta}, Index: 2},
+		{Index: 3},
+	}
+
+	DescribeTable("maps each etcd member to a machine", func(in mapEtcdMembersTableInput) {
+		Expect(MapEtcdMembers(in.members, machines, machineInfos)).To(Equal(in.expectedMapping))
+	},
+		Entry("with a member on the machine of each index", mapEtcdMembersTableInput{
+			members: []EtcdMember{
+				{ID: "1a2b", Address: "10.0.0.12"},
+				{ID: "3c4d", Address: "10.0.0.10"},
+				{ID: "5e6f", Address: "10.0.0.11"},
+			},
+			expectedMapping: []EtcdMemberMapping{
+				{EtcdMember: EtcdMember{ID: "3c4d", Address: "10.0.0.10"}, MachineName: "master-0", Index: pointer.Int32(0)},
+				{EtcdMember: EtcdMember{ID: "5e6f", Address: "10.0.0.11"}, MachineName: "master-1", Index: pointer.Int32(1)},
+				{EtcdMember: EtcdMember{ID: "1a2b", Address: "10.0.0.12"}, MachineName: "master-2", Index: pointer.Int32(2)},
+			},
+		}),
+		Entry("with a member left behind by a removed machine", mapEtcdMembersTableInput{
+			members: []EtcdMember{
+				{ID: "1a2b", Address: "10.0.0.99"},
+				{ID: "3c4d", Address: "10.0.0.10"},
+			},
+			expectedMapping: []EtcdMemberMapping{
+				{EtcdMember: EtcdMember{ID: "3c4d", Address: "10.0.0.10"}, MachineName: "master-0", Index: pointer.Int32(0)},
+				{EtcdMember: EtcdMember{ID: "1a2b", Address: "10.0.0.99"}},
+			},
+		}),
+		Entry("with a member on a machine without an index", mapEtcdMembersTableInput{
+			members: []EtcdMember{
+				{ID: "1a2b", Address: "10.0.0.13"},
+				{ID: "3c4d", Address: "10.0.0.11"},
+			},
+			expectedMapping: []EtcdMemberMapping{
+				{EtcdMember: EtcdMember{ID: "3c4d", Address: "10.0.0.11"}, MachineName: "master-1", Index: pointer.Int32(1)},
+				{EtcdMember: EtcdMember{ID: "1a2b", Address: "10.0.0.13"}, MachineName: "master-unindexed"},
+			},
+		}),
+		Entry("with no members", mapEtcdMembersTableInput{
+			members:         []EtcdMember{},
+			expectedMapping: []EtcdMemberMapping{},
+		}),
+	)
+})
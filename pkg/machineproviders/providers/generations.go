@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+)
+
+// MachineGeneration describes the generation of the ControlPlaneMachineSet that a Machine was created from,
+// compared with the current generation of the ControlPlaneMachineSet.
+type MachineGeneration struct {
+	// MachineName is the name of the Machine.
+	MachineName string `json:"machineName"`
+
+	// Index is the index of the Machine.
+	Index int32 `json:"index"`
+
+	// CreatedForGeneration is the generation of the ControlPlaneMachineSet that the Machine was created for, as
+	// recorded in the generation annotation when the Machine was created.
+	// This is nil when the Machine was not created by the ControlPlaneMachineSet, or was created before the
+	// annotation was recorded.
+	CreatedForGeneration *int64 `json:"createdForGeneration,omitempty"`
+
+	// GenerationsBehind is the number of times the ControlPlaneMachineSet has changed since the Machine was created.
+	// A Machine may be behind while its spec still matches the template, for example, when a change to the template
+	// has since been reverted. This is nil when the generation the Machine was created for is not known.
+	GenerationsBehind *int64 `json:"generationsBehind,omitempty"`
+}
+
+// MapMachineGenerations compares the generation that each Machine was created for with the current generation of
+// the ControlPlaneMachineSet. The mapping is sorted by index, and then by the name of the Machine.
+func MapMachineGenerations(generation int64, machineInfos []machineproviders.MachineInfo) []MachineGeneration {
+	mapping := []MachineGeneration{}
+
+	for _, machineInfo := range machineInfos {
+		if machineInfo.MachineRef == nil {
+			continue
+		}
+
+		machineGeneration := MachineGeneration{
+			MachineName: machineInfo.MachineRef.ObjectMeta.Name,
+			Index:       machineInfo.Index,
+		}
+
+		value, ok := machineInfo.MachineRef.ObjectMeta.Annotations[machineproviders.MachineGenerationAnnotation]
+		if createdFor, err := strconv.ParseInt(value, 10, 64); ok && err == nil {
+			behind := generation - createdFor
+			machineGeneration.CreatedForGeneration = &createdFor
+			machineGeneration.GenerationsBehind = &behind
+		}
+
+		mapping = append(mapping, machineGeneration)
+	}
+
+	sort.SliceStable(mapping, func(i, j int) bool {
+		if mapping[i].Index != mapping[j].Index {
+			return mapping[i].Index < mapping[j].Index
+		}
+
+		return mapping[i].MachineName < mapping[j].MachineName
+	})
+
+	return mapping
+}
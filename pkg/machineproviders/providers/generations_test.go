@@ -0,0 +1,90 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("MapMachineGenerations", func() {
+	type mapMachineGenerationsTableInput struct {
+		machineInfos    []machineproviders.MachineInfo
+		expectedMapping []MachineGeneration
+	}
+
+	// machineCreatedFor builds a MachineInfo for a Machine stamped with the generation it was created for.
+	machineCreatedFor := func(name string, idx int32, generation string) machineproviders.MachineInfo {
+		return machineproviders.MachineInfo{
+			MachineRef: &machineproviders.ObjectRef{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Annotations: map[string]string{machineproviders.MachineGenerationAnnotation: generation},
+			}},
+			Index: idx,
+		}
+	}
+
+	DescribeTable("compares the generation of each machine with the current generation", func(in mapMachineGenerationsTableInput) {
+		Expect(MapMachineGenerations(5, in.machineInfos)).To(Equal(in.expectedMapping))
+	},
+		Entry("with machines created for the current generation", mapMachineGenerationsTableInput{
+			machineInfos: []machineproviders.MachineInfo{
+				machineCreatedFor("master-0", 0, "5"),
+				machineCreatedFor("master-1", 1, "5"),
+			},
+			expectedMapping: []MachineGeneration{
+				{MachineName: "master-0", Index: 0, CreatedForGeneration: pointer.Int64(5), GenerationsBehind: pointer.Int64(0)},
+				{MachineName: "master-1", Index: 1, CreatedForGeneration: pointer.Int64(5), GenerationsBehind: pointer.Int64(0)},
+			},
+		}),
+		Entry("with machines created for different generations", mapMachineGenerationsTableInput{
+			machineInfos: []machineproviders.MachineInfo{
+				machineCreatedFor("master-2", 2, "4"),
+				machineCreatedFor("master-0", 0, "2"),
+				machineCreatedFor("master-replacement-0", 0, "5"),
+			},
+			expectedMapping: []MachineGeneration{
+				{MachineName: "master-0", Index: 0, CreatedForGeneration: pointer.Int64(2), GenerationsBehind: pointer.Int64(3)},
+				{MachineName: "master-replacement-0", Index: 0, CreatedForGeneration: pointer.Int64(5), GenerationsBehind: pointer.Int64(0)},
+				{MachineName: "master-2", Index: 2, CreatedForGeneration: pointer.Int64(4), GenerationsBehind: pointer.Int64(1)},
+			},
+		}),
+		Entry("with machines without a valid generation", mapMachineGenerationsTableInput{
+			machineInfos: []machineproviders.MachineInfo{
+				{MachineRef: &machineproviders.ObjectRef{ObjectMeta: metav1.ObjectMeta{Name: "master-0"}}, Index: 0},
+				machineCreatedFor("master-1", 1, "first"),
+			},
+			expectedMapping: []MachineGeneration{
+				{MachineName: "master-0", Index: 0},
+				{MachineName: "master-1", Index: 1},
+			},
+		}),
+		Entry("with an index without a machine", mapMachineGenerationsTableInput{
+			machineInfos: []machineproviders.MachineInfo{
+				{Index: 0},
+				machineCreatedFor("master-1", 1, "3"),
+			},
+			expectedMapping: []MachineGeneration{
+				{MachineName: "master-1", Index: 1, CreatedForGeneration: pointer.Int64(3), GenerationsBehind: pointer.Int64(2)},
+			},
+		}),
+	)
+})
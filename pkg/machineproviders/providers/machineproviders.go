@@ -26,6 +26,7 @@ import (
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	openshiftmachinev1beta1 "github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,10 +40,11 @@ var (
 
 // NewMachineProvider constructs a MachineProvider based on the machine type passed.
 // This can then be used to access and manipulate machines within the cluster.
-func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet) (machineproviders.MachineProvider, error) {
+// The replicas are the desired number of Control Plane Machines, which may differ from the spec.
+func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet, replicas int32) (machineproviders.MachineProvider, error) {
 	switch cpms.Spec.Template.MachineType {
 	case machinev1.OpenShiftMachineV1Beta1MachineType:
-		provider, err := openshiftmachinev1beta1.NewMachineProvider(ctx, logger, cl, cpms)
+		provider, err := openshiftmachinev1beta1.NewMachineProvider(ctx, logger, cl, cpms, replicas)
 		if err != nil {
 			return nil, fmt.Errorf("error constructing %s machine provider: %w", machinev1.OpenShiftMachineV1Beta1MachineType, err)
 		}
@@ -53,6 +55,26 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 	}
 }
 
+// CheckFailureDomainsPlatform checks that the platform of the failure domains within the ControlPlaneMachineSet
+// template matches the platform of the template provider spec. When they do not match, the error returned wraps
+// machineproviders.ErrFailureDomainsPlatformMismatch.
+func CheckFailureDomainsPlatform(cpms *machinev1.ControlPlaneMachineSet) error {
+	switch cpms.Spec.Template.MachineType {
+	case machinev1.OpenShiftMachineV1Beta1MachineType:
+		if cpms.Spec.Template.OpenShiftMachineV1Beta1Machine == nil {
+			return nil
+		}
+
+		if err := providerconfig.CheckFailureDomainsPlatform(*cpms.Spec.Template.OpenShiftMachineV1Beta1Machine); err != nil {
+			return fmt.Errorf("error checking %s failure domains platform: %w", machinev1.OpenShiftMachineV1Beta1MachineType, err)
+		}
+
+		return nil
+	default:
+		return nil
+	}
+}
+
 // GetMachineTypeMeta returns proper TypeMeta from ControlPlaneMachineSetMachineType.
 func GetMachineTypeMeta(cpmsMachineType machinev1.ControlPlaneMachineSetMachineType) (metav1.TypeMeta, error) {
 	switch cpmsMachineType {
@@ -174,7 +174,7 @@ var _ = Describe("MachineProviders", func() {
 				cpms := cpmsBuilder.Build()
 				cpms.Spec.Template.MachineType = invalidCPMSType
 
-				provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms)
+				provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms, *cpms.Spec.Replicas)
 			})
 
 			It("returns an error", func() {
@@ -222,7 +222,8 @@ var _ = Describe("MachineProviders", func() {
 				var err error
 
 				BeforeEach(func() {
-					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpmsBuilder.Build())
+					cpms := cpmsBuilder.Build()
+					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms, *cpms.Spec.Replicas)
 				})
 
 				It("does not error", func() {
@@ -284,7 +285,8 @@ var _ = Describe("MachineProviders", func() {
 
 				BeforeEach(func() {
 
-					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpmsBuilder.Build())
+					cpms := cpmsBuilder.Build()
+					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms, *cpms.Spec.Replicas)
 				})
 
 				It("does not error", func() {
@@ -321,7 +323,7 @@ var _ = Describe("MachineProviders", func() {
 					cpms := cpmsBuilder.Build()
 					cpms.Spec.Template.OpenShiftMachineV1Beta1Machine = nil
 
-					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms)
+					provider, err = NewMachineProvider(ctx, logger.Logger(), k8sClient, cpms, *cpms.Spec.Replicas)
 				})
 
 				It("returns an error", func() {
@@ -16,7 +16,11 @@ limitations under the License.
 
 package failuredomain
 
-import "sort"
+import (
+	"sort"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+)
 
 // Set implements a set symantic for a FailureDomain.
 // As this is an interface we cannot use a map directly.
@@ -33,6 +37,24 @@ func NewSet(items ...FailureDomain) *Set {
 	return &s
 }
 
+// NewSetFromMachines creates a new set from the failure domains occupied by the given machines.
+// The failure domain of each machine is determined by the extract function, so that the platform
+// specific extraction can be provided by the caller.
+func NewSetFromMachines(machines []machinev1beta1.Machine, extract func(machinev1beta1.Machine) (FailureDomain, error)) (*Set, error) {
+	s := NewSet()
+
+	for _, machine := range machines {
+		fd, err := extract(machine)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Insert(fd)
+	}
+
+	return s, nil
+}
+
 // Has returns true if the item is in the set.
 func (s *Set) Has(item FailureDomain) bool {
 	for _, fd := range s.items {
@@ -53,6 +75,11 @@ func (s *Set) Insert(items ...FailureDomain) {
 	}
 }
 
+// Len returns the number of items in the set.
+func (s *Set) Len() int {
+	return len(s.items)
+}
+
 // List returns the items in the set as a sorted slice.
 func (s *Set) List() []FailureDomain {
 	out := []FailureDomain{}
@@ -17,11 +17,15 @@ limitations under the License.
 package failuredomain
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("Set suite", func() {
@@ -217,4 +221,92 @@ var _ = Describe("Set suite", func() {
 			})
 		})
 	})
+
+	Context("when creating a new set from machines", func() {
+		awsFailureDomain := func(az string) FailureDomain {
+			return NewAWSFailureDomain(machinev1.AWSFailureDomain{
+				Placement: machinev1.AWSFailureDomainPlacement{
+					AvailabilityZone: az,
+				},
+			})
+		}
+
+		machine := func(name string) machinev1beta1.Machine {
+			return machinev1beta1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		}
+
+		machineFailureDomains := map[string]FailureDomain{
+			"master-0": awsFailureDomain("us-east-1a"),
+			"master-1": awsFailureDomain("us-east-1b"),
+			"master-2": awsFailureDomain("us-east-1c"),
+			"master-3": awsFailureDomain("us-east-1a"),
+		}
+
+		extract := func(m machinev1beta1.Machine) (FailureDomain, error) {
+			fd, ok := machineFailureDomains[m.Name]
+			if !ok {
+				return nil, errors.New("unknown machine")
+			}
+
+			return fd, nil
+		}
+
+		It("should return the failure domains of the machines without duplicates", func() {
+			set, err := NewSetFromMachines([]machinev1beta1.Machine{
+				machine("master-0"),
+				machine("master-1"),
+				machine("master-2"),
+				machine("master-3"),
+			}, extract)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(set.List()).To(Equal([]FailureDomain{
+				awsFailureDomain("us-east-1a"),
+				awsFailureDomain("us-east-1b"),
+				awsFailureDomain("us-east-1c"),
+			}))
+		})
+
+		It("should return an empty set when there are no machines", func() {
+			set, err := NewSetFromMachines([]machinev1beta1.Machine{}, extract)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(set.Len()).To(BeZero())
+			Expect(set.List()).To(BeEmpty())
+		})
+
+		It("should return an error when a failure domain cannot be extracted", func() {
+			_, err := NewSetFromMachines([]machinev1beta1.Machine{
+				machine("master-0"),
+				machine("worker-0"),
+			}, extract)
+			Expect(err).To(MatchError("unknown machine"))
+		})
+	})
+
+	Context("when creating a new set from vSphere machines", func() {
+		// The vendored API defines no vSphere failure domain, so the failure domain extracted from each vSphere
+		// machine is the generic failure domain, whichever topology the machine is in.
+		vsphereMachine := func(name, datacenter string) machinev1beta1.Machine {
+			return machinev1beta1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"datacenter": datacenter}},
+			}
+		}
+
+		extract := func(machinev1beta1.Machine) (FailureDomain, error) {
+			return NewGenericFailureDomain(), nil
+		}
+
+		It("should return a single failure domain for machines across two topologies", func() {
+			set, err := NewSetFromMachines([]machinev1beta1.Machine{
+				vsphereMachine("master-0", "datacenter-1"),
+				vsphereMachine("master-1", "datacenter-2"),
+				vsphereMachine("master-2", "datacenter-1"),
+			}, extract)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(set.Len()).To(Equal(1))
+			Expect(set.List()).To(Equal([]FailureDomain{NewGenericFailureDomain()}))
+		})
+	})
 })
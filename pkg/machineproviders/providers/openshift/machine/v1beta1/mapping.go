@@ -26,11 +26,13 @@ import (
 	"strings"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
 
@@ -45,13 +47,39 @@ var (
 	// errNoFailureDomains is used to indicate that no failure domain mapping is required in the
 	// provider because no failure domains are configured on the ControlPlaneMachineSet.
 	errNoFailureDomains = errors.New("no failure domains configured")
+
+	// errInvalidFailureDomainWeight is used to indicate that an entry in the failure domain weights
+	// annotation could not be parsed.
+	errInvalidFailureDomainWeight = errors.New("failure domain weight must be a zone and a positive integer in the form zone=weight")
+
+	// errInvalidScalePreference is used to indicate that the scale preference annotation is not a known preference.
+	errInvalidScalePreference = errors.New("scale preference must be one of Index or BalanceZones")
+)
+
+// failureDomainWeights maps the zone of a failure domain to the relative number of indexes that should be
+// placed within it.
+type failureDomainWeights map[string]int
+
+// scalePreference determines the failure domain of a new index when scaling up, and which indexes are removed
+// when scaling down.
+type scalePreference string
+
+const (
+	// scalePreferenceIndex places new indexes in the failure domain of the base mapping for the index,
+	// and removes the highest indexes first.
+	scalePreferenceIndex scalePreference = "Index"
+
+	// scalePreferenceBalanceZones places new indexes in the least crowded failure domain, and removes indexes
+	// from the most crowded failure domain first. A failure domain is more crowded when it holds more indexes
+	// relative to its weight.
+	scalePreferenceBalanceZones scalePreference = "BalanceZones"
 )
 
 // mapMachineIndexesToFailureDomains creates a mapping of the given failure domains into an index that can be used
 // to by external code to create new Machines in the same failure domain. It should start with a basic mapping and
 // then use existing Machine information to map failure domains, if possible, so that the Machine names match the
 // index of the failure domain in which they currently reside.
-func mapMachineIndexesToFailureDomains(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet, failureDomains []failuredomain.FailureDomain) (map[int32]failuredomain.FailureDomain, error) {
+func mapMachineIndexesToFailureDomains(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet, replicas int32, failureDomains []failuredomain.FailureDomain, weights failureDomainWeights, preference scalePreference) (map[int32]failuredomain.FailureDomain, error) {
 	if len(failureDomains) == 0 {
 		logger.V(4).Info("No failure domains provided")
 
@@ -65,12 +93,12 @@ func mapMachineIndexesToFailureDomains(ctx context.Context, logger logr.Logger,
 
 	failureDomainsSet := failuredomain.NewSet(failureDomains...)
 
-	baseMapping, err := createBaseFailureDomainMapping(cpms, failureDomainsSet.List(), machineMapping)
+	baseMapping, err := createBaseFailureDomainMapping(replicas, failureDomainsSet.List(), machineMapping, weights)
 	if err != nil {
 		return nil, fmt.Errorf("could not construct base failure domain mapping: %w", err)
 	}
 
-	out := reconcileMappings(logger, baseMapping, machineMapping, deletingIndexes)
+	out := reconcileMappings(logger, baseMapping, machineMapping, deletingIndexes, weights, preference)
 
 	logger.V(4).Info(
 		"Mapped provided failure domains",
@@ -81,15 +109,17 @@ func mapMachineIndexesToFailureDomains(ctx context.Context, logger logr.Logger,
 }
 
 // createBaseFailureDomainMapping is used to create the basic failure domain mapping based on the number of failure
-// domains provided and the number of replicas within the ControlPlaneMachineSet or control plane Machine indexes.
+// domains provided and the desired number of replicas or control plane Machine indexes.
 // To ensure consistency, we expect the function to create a stable output no matter the order of the input failure
 // domains.
 // Create the output based on the longer of the number of Machines or replicas so that when we reconcile the machine
 // mappings we always have enough candidates which are balanced between the available failure domains.
-func createBaseFailureDomainMapping(cpms *machinev1.ControlPlaneMachineSet, failureDomains []failuredomain.FailureDomain, machineMapping map[int32]failuredomain.FailureDomain) (map[int32]failuredomain.FailureDomain, error) {
+// When the failure domains are weighted, each failure domain receives a share of the indexes proportional to its
+// weight. Without weights, the failure domains are assigned in a round-robin.
+func createBaseFailureDomainMapping(replicas int32, failureDomains []failuredomain.FailureDomain, machineMapping map[int32]failuredomain.FailureDomain, weights failureDomainWeights) (map[int32]failuredomain.FailureDomain, error) {
 	out := make(map[int32]failuredomain.FailureDomain)
 
-	if cpms.Spec.Replicas == nil || *cpms.Spec.Replicas < 1 {
+	if replicas < 1 {
 		return nil, errReplicasRequired
 	}
 
@@ -102,8 +132,8 @@ func createBaseFailureDomainMapping(cpms *machinev1.ControlPlaneMachineSet, fail
 
 	// Create a base mapping which account for the larger of the number of machines or
 	// the desired replica count.
-	if *cpms.Spec.Replicas > int32(machineIndexCount) {
-		machineIndexCount = int(*cpms.Spec.Replicas)
+	if replicas > int32(machineIndexCount) {
+		machineIndexCount = int(replicas)
 	}
 
 	if len(failureDomains) == 0 {
@@ -118,13 +148,145 @@ func createBaseFailureDomainMapping(cpms *machinev1.ControlPlaneMachineSet, fail
 		return machineFailureDomains.Has(failureDomains[i]) && !machineFailureDomains.Has(failureDomains[j])
 	})
 
+	// Use a smooth weighted round-robin so that the indexes of heavier failure domains are interleaved with the
+	// others. With equal weights, this assigns the failure domains in order, as a plain round-robin would.
+	totalWeight := 0
+	currentWeights := make([]int, len(failureDomains))
+
+	for _, failureDomain := range failureDomains {
+		totalWeight += weights.weight(failureDomain)
+	}
+
 	for i := int32(0); i < int32(machineIndexCount); i++ {
-		out[i] = failureDomains[i%int32(len(failureDomains))]
+		selected := 0
+
+		for j, failureDomain := range failureDomains {
+			currentWeights[j] += weights.weight(failureDomain)
+
+			if currentWeights[j] > currentWeights[selected] {
+				selected = j
+			}
+		}
+
+		currentWeights[selected] -= totalWeight
+		out[i] = failureDomains[selected]
 	}
 
 	return out, nil
 }
 
+// getFailureDomainWeights parses the failure domain weights annotation on the ControlPlaneMachineSet.
+// When the annotation is not set, or is invalid, no weights are returned and the indexes are spread
+// evenly across the failure domains.
+func getFailureDomainWeights(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) failureDomainWeights {
+	value, ok := cpms.GetAnnotations()[machineproviders.FailureDomainWeightsAnnotation]
+	if !ok {
+		return nil
+	}
+
+	weights, err := parseFailureDomainWeights(value)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid failure domain weights", "annotation", machineproviders.FailureDomainWeightsAnnotation)
+
+		return nil
+	}
+
+	return weights
+}
+
+// getScalePreference parses the scale preference annotation on the ControlPlaneMachineSet.
+// When the annotation is not set, or is invalid, indexes are scaled in order.
+func getScalePreference(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) scalePreference {
+	value, ok := cpms.GetAnnotations()[machineproviders.ScalePreferenceAnnotation]
+	if !ok {
+		return scalePreferenceIndex
+	}
+
+	switch preference := scalePreference(value); preference {
+	case scalePreferenceIndex, scalePreferenceBalanceZones:
+		return preference
+	default:
+		logger.Error(fmt.Errorf("%w: %q", errInvalidScalePreference, value), "Ignoring invalid scale preference", "annotation", machineproviders.ScalePreferenceAnnotation)
+
+		return scalePreferenceIndex
+	}
+}
+
+// parseFailureDomainWeights parses a comma separated list of zone=weight pairs.
+func parseFailureDomainWeights(value string) (failureDomainWeights, error) {
+	weights := failureDomainWeights{}
+
+	for _, entry := range strings.Split(value, ",") {
+		zone, weightValue, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || zone == "" {
+			return nil, fmt.Errorf("%w: %q", errInvalidFailureDomainWeight, entry)
+		}
+
+		weight, err := strconv.Atoi(weightValue)
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("%w: %q", errInvalidFailureDomainWeight, entry)
+		}
+
+		weights[zone] = weight
+	}
+
+	return weights, nil
+}
+
+// weight returns the weight of the failure domain, based on its zone.
+// Failure domains without a configured weight have a weight of 1.
+func (w failureDomainWeights) weight(failureDomain failuredomain.FailureDomain) int {
+	if weight, ok := w[failureDomainZone(failureDomain)]; ok {
+		return weight
+	}
+
+	return 1
+}
+
+// totalWeight returns the sum of the weights of the unique failure domains within the mapping.
+func (w failureDomainWeights) totalWeight(mapping map[int32]failuredomain.FailureDomain) int {
+	total := 0
+	seen := []failuredomain.FailureDomain{}
+
+	for _, idx := range sortedIndexes(mapping) {
+		failureDomain := mapping[idx]
+
+		if containsFailureDomain(seen, failureDomain) {
+			continue
+		}
+
+		seen = append(seen, failureDomain)
+		total += w.weight(failureDomain)
+	}
+
+	return total
+}
+
+// failureDomainZone returns the zone of the failure domain, which identifies it within the failure domain weights.
+func failureDomainZone(failureDomain failuredomain.FailureDomain) string {
+	switch failureDomain.Type() {
+	case configv1.AWSPlatformType:
+		return failureDomain.AWS().Placement.AvailabilityZone
+	case configv1.AzurePlatformType:
+		return failureDomain.Azure().Zone
+	case configv1.GCPPlatformType:
+		return failureDomain.GCP().Zone
+	default:
+		return ""
+	}
+}
+
+// containsFailureDomain checks if the failure domain is present in the list.
+func containsFailureDomain(list []failuredomain.FailureDomain, failureDomain failuredomain.FailureDomain) bool {
+	for _, fd := range list {
+		if fd.Equal(failureDomain) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // createMachineMapping inspects the state of the Machines on the cluster, selected by the ControlPlaneMachineSet, and
 // creates a mapping of their indexes (if available) to their failure domain to allow the mapping to be customised
 // to the state of the cluster.
@@ -163,9 +325,9 @@ func mapIndexesToFailureDomainsForMachines(logger logr.Logger, machineList *mach
 			return nil, fmt.Errorf("could not extract failure domain from machine %s: %w", machine.Name, err)
 		}
 
-		machineNameIndex, ok := parseMachineNameIndex(machine.Name)
+		machineNameIndex, ok := parseMachineIndex(machine)
 		if !ok {
-			// Ignore the machine as it doesn't contain an index in its name.
+			// Ignore the machine as it doesn't contain an index in its labels or name.
 			logger.V(4).Info(
 				"Ignoring machine in failure domain mapping with unexpected name",
 				"machine", machine.Name,
@@ -211,7 +373,7 @@ func listDeletingIndexes(machines []machinev1beta1.Machine) sets.Set[int32] {
 	// Add all machines that are being deleted to the set.
 	for _, machine := range machines {
 		if !machine.DeletionTimestamp.IsZero() {
-			index, ok := parseMachineNameIndex(machine.Name)
+			index, ok := parseMachineIndex(machine)
 			if !ok {
 				continue
 			}
@@ -223,7 +385,7 @@ func listDeletingIndexes(machines []machinev1beta1.Machine) sets.Set[int32] {
 	// Remove any index that has a non-deleting machine.
 	for _, machine := range machines {
 		if machine.DeletionTimestamp.IsZero() {
-			index, ok := parseMachineNameIndex(machine.Name)
+			index, ok := parseMachineIndex(machine)
 			if !ok {
 				continue
 			}
@@ -244,7 +406,7 @@ func listDeletingIndexes(machines []machinev1beta1.Machine) sets.Set[int32] {
 // When processing the indexes, everything must be sorted to ensure the output is stable (note iterating over a map
 // is randomised by golang).
 // The base mapping should always be at least as long as the machine mapping for this to work.
-func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomain.FailureDomain, deletingIndexes sets.Set[int32]) map[int32]failuredomain.FailureDomain {
+func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomain.FailureDomain, deletingIndexes sets.Set[int32], weights failureDomainWeights, preference scalePreference) map[int32]failuredomain.FailureDomain {
 	if len(base) < len(machines) {
 		// This is a programming error since user input doesn't affect this.
 		panic("base must have at least as many indexes as machines")
@@ -269,13 +431,11 @@ func reconcileMappings(logger logr.Logger, base, machines map[int32]failuredomai
 	// Run through the mappings and match these to candidates where possible.
 	matchMachinesToCandidates(out, candidates, unmatchedIndexes, deletingIndexes)
 
-	// Get the maximum number of replicas per failure domain. This is needed
-	// to ensure we balance appropriately across the available failure domains.
-	maxPerFailureDomain := maxIndexesPerFailureDomain(base)
-
 	// Handle any remaining unmatched indexes.
+	// The maximum number of replicas per failure domain is used to ensure
+	// we balance appropriately across the available failure domains.
 	for _, idx := range sortedIndexes(unmatchedIndexes) {
-		handleUnmatchedIndex(logger, idx, out, base, candidates, unmatchedIndexes, maxPerFailureDomain)
+		handleUnmatchedIndex(logger, idx, out, base, candidates, unmatchedIndexes, weights, preference)
 	}
 
 	return out
@@ -327,8 +487,16 @@ func matchMachinesToCandidates(out, candidates map[int32]failuredomain.FailureDo
 // - The failure domain from the machine mapping was removed from the base.
 // - A new failure domain was added to the base mapping.
 // - The machine mapping is balanced in a different weighting to the machine mapping.
-func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates map[int32]failuredomain.FailureDomain, unmatchedIndexes sets.Set[int32], maxPerFailureDomain int) {
+//
+// When scaling up with the BalanceZones preference, an index without a machine is placed in the least crowded
+// failure domain rather than in the candidate for the index.
+func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates map[int32]failuredomain.FailureDomain, unmatchedIndexes sets.Set[int32], weights failureDomainWeights, preference scalePreference) {
 	switch {
+	case !indexExists(out, idx) && preference == scalePreferenceBalanceZones:
+		// There is no machine in this index presently,
+		// so place it where it best balances the failure domains.
+		out[idx] = leastCrowdedFailureDomain(out, base, weights)
+		useCandidate(candidates, unmatchedIndexes, idx)
 	case !indexExists(out, idx):
 		// There is no machine in this index presently,
 		// so just use the candidate for this index.
@@ -346,7 +514,7 @@ func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates m
 
 		out[idx] = candidates[idx]
 		useCandidate(candidates, unmatchedIndexes, idx)
-	case countForFailureDomain(out, out[idx]) > maxPerFailureDomain:
+	case countForFailureDomain(out, out[idx]) > maxIndexesPerFailureDomain(base, weights, out[idx]):
 		// This failure domain is over represented in the mapping.
 		// In this case, we must switch it to the candidate failure domain to rebalance
 		// the mapping.
@@ -370,6 +538,79 @@ func handleUnmatchedIndex(logger logr.Logger, idx int32, out, base, candidates m
 	}
 }
 
+// leastCrowdedFailureDomain returns the failure domain of the base mapping that holds the fewest indexes within the
+// mapping, relative to its weight. Ties are broken by the order of the failure domains within the base mapping.
+func leastCrowdedFailureDomain(mapping, base map[int32]failuredomain.FailureDomain, weights failureDomainWeights) failuredomain.FailureDomain {
+	var selected failuredomain.FailureDomain
+
+	for _, idx := range sortedIndexes(base) {
+		failureDomain := base[idx]
+
+		if selected == nil || isMoreCrowded(mapping, weights, selected, failureDomain) {
+			selected = failureDomain
+		}
+	}
+
+	return selected
+}
+
+// selectScaleDownIndexes chooses count indexes to remove from the indexes provided, based on the failure domain
+// of each index within the mapping. With the Index preference, the highest indexes are removed. With the
+// BalanceZones preference, the highest index of the most crowded failure domain is removed, one at a time, so
+// that the remaining indexes are as balanced as possible. The indexes returned are sorted in ascending order.
+func selectScaleDownIndexes(mapping map[int32]failuredomain.FailureDomain, indexes []int32, count int, weights failureDomainWeights, preference scalePreference) []int32 {
+	remaining := make(map[int32]failuredomain.FailureDomain)
+
+	for _, idx := range indexes {
+		remaining[idx] = mapping[idx]
+	}
+
+	selected := []int32{}
+
+	for len(selected) < count && len(remaining) > 0 {
+		sorted := sortedIndexes(remaining)
+		victim := sorted[len(sorted)-1]
+
+		if preference == scalePreferenceBalanceZones {
+			victim = mostCrowdedIndex(remaining, sorted, weights)
+		}
+
+		selected = append(selected, victim)
+		delete(remaining, victim)
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i] < selected[j] })
+
+	return selected
+}
+
+// mostCrowdedIndex returns the highest index within the most crowded failure domain of the mapping.
+// Indexes without a failure domain are only chosen when no index has a failure domain.
+func mostCrowdedIndex(mapping map[int32]failuredomain.FailureDomain, sorted []int32, weights failureDomainWeights) int32 {
+	victim := sorted[len(sorted)-1]
+
+	var victimFailureDomain failuredomain.FailureDomain
+
+	for i := len(sorted) - 1; i >= 0; i-- {
+		failureDomain := mapping[sorted[i]]
+		if failureDomain == nil {
+			continue
+		}
+
+		if victimFailureDomain == nil || isMoreCrowded(mapping, weights, failureDomain, victimFailureDomain) {
+			victim, victimFailureDomain = sorted[i], failureDomain
+		}
+	}
+
+	return victim
+}
+
+// isMoreCrowded checks whether failure domain a holds more indexes within the mapping than failure domain b,
+// relative to their weights.
+func isMoreCrowded(mapping map[int32]failuredomain.FailureDomain, weights failureDomainWeights, a, b failuredomain.FailureDomain) bool {
+	return countForFailureDomain(mapping, a)*weights.weight(b) > countForFailureDomain(mapping, b)*weights.weight(a)
+}
+
 // reconcileIndexes tries to adjust the output mapping based on a list of preferred
 // indexes. This is used so that when Machines aren't indexed exactly as in the base
 // mapping, we still respect their original mappings.
@@ -450,14 +691,21 @@ func copyMapping(mapping map[int32]failuredomain.FailureDomain) map[int32]failur
 	return out
 }
 
-// maxIndexesPerFailureDomain is used to calculate the maximum number of allowed indexes per failure domain.
-// That is, based on how many failure domains are in the base mapping and the total number of indexes, to
-// create a balanced mapping, what is the maximum number of Machines we want to create per failure domain.
-func maxIndexesPerFailureDomain(base map[int32]failuredomain.FailureDomain) int {
-	uniqueFailureDomains := countUniqueFailureDomain(base)
+// maxIndexesPerFailureDomain is used to calculate the maximum number of allowed indexes for a failure domain.
+// That is, based on how many failure domains are in the base mapping, their weights and the total number of
+// indexes, to create a balanced mapping, what is the maximum number of Machines we want to create in the
+// failure domain. Without weights, this is the same for every failure domain.
+func maxIndexesPerFailureDomain(base map[int32]failuredomain.FailureDomain, weights failureDomainWeights, failureDomain failuredomain.FailureDomain) int {
+	if len(weights) == 0 {
+		uniqueFailureDomains := countUniqueFailureDomain(base)
+
+		// To get an accurate division we must work in floats.
+		d := float64(len(base)) / float64(uniqueFailureDomains)
 
-	// To get an accurate division we must work in floats.
-	d := float64(len(base)) / float64(uniqueFailureDomains)
+		return int(math.Ceil(d))
+	}
+
+	d := float64(len(base)*weights.weight(failureDomain)) / float64(weights.totalWeight(base))
 
 	return int(math.Ceil(d))
 }
@@ -522,6 +770,18 @@ func pop[V any](in []V) (V, []V) {
 	return in[0], in[1:]
 }
 
+// parseMachineIndex returns the index of the machine from the index label when present, falling back to the integer
+// suffix of the machine name. If neither contains a valid index, it returns "false" as a second value.
+func parseMachineIndex(machine machinev1beta1.Machine) (int, bool) {
+	if value, ok := machine.Labels[machineproviders.MachineIndexLabel]; ok {
+		if index, err := strconv.ParseInt(value, 10, 32); err == nil && index >= 0 {
+			return int(index), true
+		}
+	}
+
+	return parseMachineNameIndex(machine.Name)
+}
+
 // parseMachineNameIndex returns an integer suffix from the machine name. If there is no sufficient suffix, it
 // returns "false" as a second value.
 // Example:
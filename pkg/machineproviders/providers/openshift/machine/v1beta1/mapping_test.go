@@ -25,6 +25,7 @@ import (
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 
 	"github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder"
@@ -246,7 +247,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 
 			originalCPMS := cpms.DeepCopy()
 
-			mapping, err := mapMachineIndexesToFailureDomains(ctx, logger.Logger(), k8sClient, cpms, failureDomains)
+			mapping, err := mapMachineIndexesToFailureDomains(ctx, logger.Logger(), k8sClient, cpms, *cpms.Spec.Replicas, failureDomains, getFailureDomainWeights(logger.Logger(), cpms), scalePreferenceIndex)
 			if in.expectedError != nil {
 				Expect(err).To(MatchError(in.expectedError))
 			} else {
@@ -1215,6 +1216,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 			cpmsBuilder     machinev1resourcebuilder.ControlPlaneMachineSetInterface
 			machineMapping  map[int32]failuredomain.FailureDomain
 			failureDomains  machinev1.FailureDomains
+			weights         failureDomainWeights
 			expectedMapping map[int32]failuredomain.FailureDomain
 			expectedError   error
 		}
@@ -1224,7 +1226,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			cpms := in.cpmsBuilder.Build()
-			mapping, err := createBaseFailureDomainMapping(cpms, failureDomains, in.machineMapping)
+			mapping, err := createBaseFailureDomainMapping(*cpms.Spec.Replicas, failureDomains, in.machineMapping, in.weights)
 			if in.expectedError != nil {
 				Expect(err).To(MatchError(in.expectedError))
 			} else {
@@ -1368,6 +1370,69 @@ var _ = Describe("Failure Domain Mapping", func() {
 					4: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
 				},
 			}),
+			Entry("with five replicas and three failure domains with equal weights, matches the unweighted mapping", createBaseMappingTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(5),
+				machineMapping: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+				},
+				failureDomains: machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+					usEast1aFailureDomainBuilder,
+					usEast1bFailureDomainBuilder,
+					usEast1cFailureDomainBuilder,
+				).BuildFailureDomains(),
+				weights: failureDomainWeights{"us-east-1a": 1, "us-east-1b": 1, "us-east-1c": 1},
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+					3: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					4: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+				},
+			}),
+			Entry("with five replicas and three failure domains, weighted towards the first failure domain", createBaseMappingTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(5),
+				machineMapping: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+				},
+				failureDomains: machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+					usEast1aFailureDomainBuilder,
+					usEast1bFailureDomainBuilder,
+					usEast1cFailureDomainBuilder,
+				).BuildFailureDomains(),
+				weights: failureDomainWeights{"us-east-1a": 2},
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+					3: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					4: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+				},
+			}),
+			Entry("with five replicas and three failure domains, weighted towards the last failure domain", createBaseMappingTableInput{
+				cpmsBuilder: cpmsBuilder.WithReplicas(5),
+				machineMapping: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					1: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+				},
+				failureDomains: machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+					usEast1aFailureDomainBuilder,
+					usEast1bFailureDomainBuilder,
+					usEast1cFailureDomainBuilder,
+				).BuildFailureDomains(),
+				weights: failureDomainWeights{"us-east-1c": 3},
+				expectedMapping: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+					1: failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build()),
+					2: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+					3: failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build()),
+					4: failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build()),
+				},
+			}),
 			Entry("with five replicas and three failure domains (order b,c,a)", createBaseMappingTableInput{
 				cpmsBuilder: cpmsBuilder.WithReplicas(5),
 				machineMapping: map[int32]failuredomain.FailureDomain{
@@ -1797,6 +1862,46 @@ var _ = Describe("Failure Domain Mapping", func() {
 		)
 	})
 
+	Context("parseFailureDomainWeights", func() {
+		type parseFailureDomainWeightsTableInput struct {
+			value           string
+			expectedWeights failureDomainWeights
+			expectedError   error
+		}
+
+		DescribeTable("should parse the failure domain weights annotation", func(in parseFailureDomainWeightsTableInput) {
+			weights, err := parseFailureDomainWeights(in.value)
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(weights).To(Equal(in.expectedWeights))
+		},
+			Entry("with a single weight", parseFailureDomainWeightsTableInput{
+				value:           "us-east-1a=2",
+				expectedWeights: failureDomainWeights{"us-east-1a": 2},
+			}),
+			Entry("with multiple weights", parseFailureDomainWeightsTableInput{
+				value:           "us-east-1a=2, us-east-1b=1,us-east-1c=3",
+				expectedWeights: failureDomainWeights{"us-east-1a": 2, "us-east-1b": 1, "us-east-1c": 3},
+			}),
+			Entry("with a missing weight", parseFailureDomainWeightsTableInput{
+				value:         "us-east-1a",
+				expectedError: fmt.Errorf("%w: %q", errInvalidFailureDomainWeight, "us-east-1a"),
+			}),
+			Entry("with a weight of zero", parseFailureDomainWeightsTableInput{
+				value:         "us-east-1a=2,us-east-1b=0",
+				expectedError: fmt.Errorf("%w: %q", errInvalidFailureDomainWeight, "us-east-1b=0"),
+			}),
+			Entry("with a weight that is not a number", parseFailureDomainWeightsTableInput{
+				value:         "us-east-1a=heavy",
+				expectedError: fmt.Errorf("%w: %q", errInvalidFailureDomainWeight, "us-east-1a=heavy"),
+			}),
+		)
+	})
+
 	Context("reconcileMappings", func() {
 		type reconcileMappingsTableInput struct {
 			baseMapping     map[int32]failuredomain.FailureDomain
@@ -1811,7 +1916,7 @@ var _ = Describe("Failure Domain Mapping", func() {
 			for i := 0; i < 10; i++ {
 				logger := testutils.NewTestLogger()
 
-				mapping := reconcileMappings(logger.Logger(), in.baseMapping, in.machineMapping, in.deletingIndexes)
+				mapping := reconcileMappings(logger.Logger(), in.baseMapping, in.machineMapping, in.deletingIndexes, nil, scalePreferenceIndex)
 
 				Expect(mapping).To(Equal(in.expectedMapping))
 				Expect(logger.Entries()).To(Equal(in.expectedLogs))
@@ -2217,6 +2322,124 @@ var _ = Describe("Failure Domain Mapping", func() {
 		)
 	})
 
+	Context("getScalePreference", func() {
+		type getScalePreferenceTableInput struct {
+			annotations        map[string]string
+			expectedPreference scalePreference
+			expectedLogs       []testutils.LogEntry
+		}
+
+		DescribeTable("should parse the scale preference annotation", func(in getScalePreferenceTableInput) {
+			logger := testutils.NewTestLogger()
+
+			cpms := cpmsBuilder.Build()
+			cpms.SetAnnotations(in.annotations)
+
+			Expect(getScalePreference(logger.Logger(), cpms)).To(Equal(in.expectedPreference))
+			Expect(logger.Entries()).To(ConsistOf(in.expectedLogs))
+		},
+			Entry("when the annotation is not set", getScalePreferenceTableInput{
+				expectedPreference: scalePreferenceIndex,
+			}),
+			Entry("with the BalanceZones preference", getScalePreferenceTableInput{
+				annotations:        map[string]string{machineproviders.ScalePreferenceAnnotation: "BalanceZones"},
+				expectedPreference: scalePreferenceBalanceZones,
+			}),
+			Entry("with an unknown preference", getScalePreferenceTableInput{
+				annotations:        map[string]string{machineproviders.ScalePreferenceAnnotation: "Cheapest"},
+				expectedPreference: scalePreferenceIndex,
+				expectedLogs: []testutils.LogEntry{
+					{
+						Error:         fmt.Errorf("%w: %q", errInvalidScalePreference, "Cheapest"),
+						KeysAndValues: []interface{}{"annotation", machineproviders.ScalePreferenceAnnotation},
+						Message:       "Ignoring invalid scale preference",
+					},
+				},
+			}),
+		)
+	})
+
+	Context("selectScaleDownIndexes", func() {
+		usEast1a := failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build())
+		usEast1b := failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build())
+		usEast1c := failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build())
+
+		type selectScaleDownIndexesTableInput struct {
+			mapping         map[int32]failuredomain.FailureDomain
+			count           int
+			weights         failureDomainWeights
+			preference      scalePreference
+			expectedIndexes []int32
+		}
+
+		DescribeTable("should choose the indexes to remove", func(in selectScaleDownIndexesTableInput) {
+			// Run each test 10 times in an attempt to make sure the output is stable.
+			for i := 0; i < 10; i++ {
+				Expect(selectScaleDownIndexes(in.mapping, sortedIndexes(in.mapping), in.count, in.weights, in.preference)).To(Equal(in.expectedIndexes))
+			}
+		},
+			Entry("with the Index preference, removes the highest indexes", selectScaleDownIndexesTableInput{
+				mapping:         map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1a, 2: usEast1b, 3: usEast1c, 4: usEast1c},
+				count:           2,
+				preference:      scalePreferenceIndex,
+				expectedIndexes: []int32{3, 4},
+			}),
+			Entry("with the BalanceZones preference, removes from the most crowded zone first", selectScaleDownIndexesTableInput{
+				mapping:         map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1a, 2: usEast1a, 3: usEast1b, 4: usEast1c},
+				count:           1,
+				preference:      scalePreferenceBalanceZones,
+				expectedIndexes: []int32{2},
+			}),
+			Entry("with the BalanceZones preference, rebalances as each index is removed", selectScaleDownIndexesTableInput{
+				mapping:         map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1a, 2: usEast1b, 3: usEast1b, 4: usEast1c},
+				count:           2,
+				preference:      scalePreferenceBalanceZones,
+				expectedIndexes: []int32{1, 3},
+			}),
+			Entry("with the BalanceZones preference and balanced zones, removes the highest index", selectScaleDownIndexesTableInput{
+				mapping:         map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c, 3: usEast1a},
+				count:           1,
+				preference:      scalePreferenceBalanceZones,
+				expectedIndexes: []int32{3},
+			}),
+			Entry("with the BalanceZones preference and weights, removes from the zone most crowded for its weight", selectScaleDownIndexesTableInput{
+				mapping:         map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1a, 2: usEast1b, 3: usEast1b, 4: usEast1c},
+				count:           1,
+				weights:         failureDomainWeights{"us-east-1a": 2},
+				preference:      scalePreferenceBalanceZones,
+				expectedIndexes: []int32{3},
+			}),
+			Entry("with the BalanceZones preference and no failure domains, removes the highest index", selectScaleDownIndexesTableInput{
+				mapping:         map[int32]failuredomain.FailureDomain{0: nil, 1: nil, 2: nil, 3: nil},
+				count:           1,
+				preference:      scalePreferenceBalanceZones,
+				expectedIndexes: []int32{3},
+			}),
+		)
+	})
+
+	Context("reconcileMappings with the BalanceZones preference", func() {
+		usEast1a := failuredomain.NewAWSFailureDomain(usEast1aFailureDomainBuilder.Build())
+		usEast1b := failuredomain.NewAWSFailureDomain(usEast1bFailureDomainBuilder.Build())
+		usEast1c := failuredomain.NewAWSFailureDomain(usEast1cFailureDomainBuilder.Build())
+
+		It("places a new index in the least crowded zone", func() {
+			logger := testutils.NewTestLogger()
+
+			// Scaling from 3 to 4 replicas, the candidate for index 3 is us-east-1a,
+			// while us-east-1c has no machine.
+			base := map[int32]failuredomain.FailureDomain{0: usEast1a, 1: usEast1b, 2: usEast1c, 3: usEast1a}
+			machines := map[int32]failuredomain.FailureDomain{0: usEast1b, 1: usEast1b, 2: usEast1a}
+
+			Expect(reconcileMappings(logger.Logger(), base, machines, sets.New[int32](), nil, scalePreferenceIndex)).To(Equal(map[int32]failuredomain.FailureDomain{
+				0: usEast1b, 1: usEast1b, 2: usEast1a, 3: usEast1a,
+			}))
+			Expect(reconcileMappings(logger.Logger(), base, machines, sets.New[int32](), nil, scalePreferenceBalanceZones)).To(Equal(map[int32]failuredomain.FailureDomain{
+				0: usEast1b, 1: usEast1b, 2: usEast1a, 3: usEast1c,
+			}))
+		})
+	})
+
 	Context("reconcileIndexes", func() {
 		type reconcileIndexesTableInput struct {
 			reconciledMapping map[int32]failuredomain.FailureDomain
@@ -18,10 +18,13 @@ package v1beta1
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	machinev1 "github.com/openshift/api/machine/v1"
@@ -32,6 +35,9 @@ import (
 	apimachineryruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/pointer"
 
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
@@ -49,9 +55,16 @@ const (
 	// deletingPhase defines the phase when the machine is being deleted.
 	deletingPhase = "Deleting"
 
+	// provisioningPhase defines the phase when the instance backing the machine is being created.
+	provisioningPhase = "Provisioning"
+
 	// openshiftMachineRoleLabel is the OpenShift Machine API machine role label.
 	// This must be present on all OpenShift Machine API Machine templates.
 	openshiftMachineRoleLabel = "machine.openshift.io/cluster-api-machine-role"
+
+	// nodeNotFoundGracePeriod is how long after the last update to the Machine status a missing Node is
+	// treated as not yet present, rather than as having gone away.
+	nodeNotFoundGracePeriod = 5 * time.Minute
 )
 
 var (
@@ -81,6 +94,18 @@ var (
 	// the new Machines.
 	errMissingMachineRoleLabel = fmt.Errorf("missing required label on machine template metadata: %s", openshiftMachineRoleLabel)
 
+	// errInvalidDesiredNodeVersion is used to denote that the desired node version annotation on the
+	// ControlPlaneMachineSet is not a valid label=version pair.
+	errInvalidDesiredNodeVersion = fmt.Errorf("invalid value for annotation %s", machineproviders.DesiredNodeVersionAnnotation)
+
+	// errInvalidMachineNamePrefix is used to denote that the machine name prefix annotation on the
+	// ControlPlaneMachineSet is not a valid RFC1123 label, and therefore a Machine cannot be named using it.
+	errInvalidMachineNamePrefix = fmt.Errorf("invalid value for annotation %s", machineproviders.MachineNamePrefixAnnotation)
+
+	// errInvalidMachineOwnerReferences is used to denote that the machine owner references annotation on the
+	// ControlPlaneMachineSet is not a valid list of owner references.
+	errInvalidMachineOwnerReferences = fmt.Errorf("invalid value for annotation %s", machineproviders.MachineOwnerReferencesAnnotation)
+
 	// errUnexpectedMachineType is used to denote that the machine provider was requested
 	// for an unsupported machine provider type (ie not OpenShift Machine v1beta1).
 	errUnexpectedMachineType = fmt.Errorf("unexpected machine type while initialising %s provider", machinev1.OpenShiftMachineV1Beta1MachineType)
@@ -91,7 +116,8 @@ var (
 )
 
 // NewMachineProvider creates a new OpenShift Machine v1beta1 machine provider implementation.
-func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet) (machineproviders.MachineProvider, error) {
+// The replicas are the desired number of Control Plane Machines, used to map each index to a failure domain.
+func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet, replicas int32) (machineproviders.MachineProvider, error) {
 	if cpms.Spec.Template.MachineType != machinev1.OpenShiftMachineV1Beta1MachineType {
 		return nil, fmt.Errorf("%w: %s", errUnexpectedMachineType, cpms.Spec.Template.MachineType)
 	}
@@ -100,6 +126,11 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 		return nil, errEmptyConfig
 	}
 
+	// The provider config is decoded for the failure domains platform, so it must match the provider spec.
+	if err := providerconfig.CheckFailureDomainsPlatform(*cpms.Spec.Template.OpenShiftMachineV1Beta1Machine); err != nil {
+		return nil, fmt.Errorf("error checking failure domains platform: %w", err)
+	}
+
 	providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(logger, *cpms.Spec.Template.OpenShiftMachineV1Beta1Machine)
 	if err != nil {
 		return nil, fmt.Errorf("error building a provider config: %w", err)
@@ -110,11 +141,19 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 		return nil, fmt.Errorf("error constructing failure domain config: %w", err)
 	}
 
-	indexToFailureDomain, err := mapMachineIndexesToFailureDomains(ctx, logger, cl, cpms, failureDomains)
+	weights := getFailureDomainWeights(logger, cpms)
+	preference := getScalePreference(logger, cpms)
+
+	indexToFailureDomain, err := mapMachineIndexesToFailureDomains(ctx, logger, cl, cpms, replicas, failureDomains, weights, preference)
 	if err != nil && !errors.Is(err, errNoFailureDomains) {
 		return nil, fmt.Errorf("error mapping machine indexes: %w", err)
 	}
 
+	instanceTypeEquivalence, err := getInstanceTypeEquivalence(ctx, cl, cpms.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error loading instance type equivalence: %w", err)
+	}
+
 	machineAPIScheme := apimachineryruntime.NewScheme()
 	if err := machinev1.Install(machineAPIScheme); err != nil {
 		return nil, fmt.Errorf("unable to add machine.openshift.io/v1 scheme: %w", err)
@@ -125,17 +164,82 @@ func NewMachineProvider(ctx context.Context, logger logr.Logger, cl client.Clien
 	}
 
 	return &openshiftMachineProvider{
-		client:               cl,
-		indexToFailureDomain: indexToFailureDomain,
-		machineSelector:      cpms.Spec.Selector,
-		machineTemplate:      *cpms.Spec.Template.OpenShiftMachineV1Beta1Machine,
-		ownerMetadata:        cpms.ObjectMeta,
-		providerConfig:       providerConfig,
-		namespace:            cpms.Namespace,
-		machineAPIScheme:     machineAPIScheme,
+		client:                  cl,
+		indexToFailureDomain:    indexToFailureDomain,
+		failureDomains:          failuredomain.NewSet(failureDomains...),
+		machineSelector:         cpms.Spec.Selector,
+		machineTemplate:         *cpms.Spec.Template.OpenShiftMachineV1Beta1Machine,
+		ownerMetadata:           cpms.ObjectMeta,
+		providerConfig:          providerConfig,
+		namespace:               cpms.Namespace,
+		machineAPIScheme:        machineAPIScheme,
+		instanceTypeEquivalence: instanceTypeEquivalence,
+		forceRollTime:           getForceRollTime(logger, cpms),
+		desiredNodeVersion:      getDesiredNodeVersion(logger, cpms),
+		failureDomainWeights:    weights,
+		scalePreference:         preference,
 	}, nil
 }
 
+// getForceRollTime returns the time at which a forced roll of the Machines was last requested,
+// or the zero time if no forced roll has been requested.
+func getForceRollTime(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) time.Time {
+	state, err := machineproviders.GetPersistedState(cpms)
+	if err != nil {
+		logger.Error(err, "Ignoring invalid force roll time", "annotation", machineproviders.PersistedStateAnnotation)
+
+		return time.Time{}
+	}
+
+	if state.ForceRollTime == nil {
+		return time.Time{}
+	}
+
+	return state.ForceRollTime.Time
+}
+
+// desiredNodeVersion is the version that the Nodes of the Control Plane Machines should report in a Node label.
+type desiredNodeVersion struct {
+	// label is the key of the Node label that reports the version.
+	label string
+
+	// version is the desired value of the label.
+	version string
+}
+
+// getDesiredNodeVersion parses the desired node version annotation on the ControlPlaneMachineSet.
+// When the annotation is not set, or is invalid, nil is returned and Machines are not compared with their Node version.
+func getDesiredNodeVersion(logger logr.Logger, cpms *machinev1.ControlPlaneMachineSet) *desiredNodeVersion {
+	value, ok := cpms.GetAnnotations()[machineproviders.DesiredNodeVersionAnnotation]
+	if !ok {
+		return nil
+	}
+
+	label, version, ok := strings.Cut(value, "=")
+	if !ok || len(validation.IsQualifiedName(label)) > 0 || version == "" {
+		logger.Error(fmt.Errorf("%w: %q", errInvalidDesiredNodeVersion, value), "Ignoring invalid desired node version", "annotation", machineproviders.DesiredNodeVersionAnnotation)
+
+		return nil
+	}
+
+	return &desiredNodeVersion{label: label, version: version}
+}
+
+// getInstanceTypeEquivalence loads the instance type equivalence from the optional ConfigMap
+// within the namespace provided. When the ConfigMap does not exist, instance types are compared strictly.
+func getInstanceTypeEquivalence(ctx context.Context, cl client.Client, namespace string) (providerconfig.InstanceTypeEquivalence, error) {
+	configMap := &corev1.ConfigMap{}
+	configMapKey := client.ObjectKey{Namespace: namespace, Name: machineproviders.InstanceTypeEquivalenceConfigMapName}
+
+	if err := cl.Get(ctx, configMapKey, configMap); apierrors.IsNotFound(err) {
+		return providerconfig.NewInstanceTypeEquivalence(nil), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get config map %s: %w", configMapKey, err)
+	}
+
+	return providerconfig.NewInstanceTypeEquivalence(configMap.Data), nil
+}
+
 // openshiftMachineProvider holds the implementation of the MachineProvider interface.
 type openshiftMachineProvider struct {
 	// client is used to make API calls to fetch Machines and Nodes.
@@ -147,6 +251,10 @@ type openshiftMachineProvider struct {
 	// We use a built in type to avoid leaking implementation specific details.
 	indexToFailureDomain map[int32]failuredomain.FailureDomain
 
+	// failureDomains holds all of the failure domains defined on the ControlPlaneMachineSet.
+	// Machines whose failure domain is not one of these are reported as unmatched.
+	failureDomains *failuredomain.Set
+
 	// machineSelector is used to identify which Machines should be considered by
 	// the machine provider when constructing machine information.
 	machineSelector metav1.LabelSelector
@@ -166,6 +274,37 @@ type openshiftMachineProvider struct {
 
 	// machineAPIScheme contains scheme for Machine API v1 and v1beta1.
 	machineAPIScheme *apimachineryruntime.Scheme
+
+	// instanceTypeEquivalence declares instance types that are interchangeable,
+	// so that Machines using an equivalent instance type do not need an update.
+	instanceTypeEquivalence providerconfig.InstanceTypeEquivalence
+
+	// forceRollTime is the time at which a forced roll was last requested.
+	// Machines created before this time need an update, regardless of their configuration.
+	forceRollTime time.Time
+
+	// desiredNodeVersion is the version that the Nodes of the Machines should report.
+	// Machines whose Node reports a different version need an update. When nil, Node versions are not compared.
+	desiredNodeVersion *desiredNodeVersion
+
+	// failureDomainWeights biases the number of indexes placed within each failure domain.
+	failureDomainWeights failureDomainWeights
+
+	// scalePreference determines which indexes are removed when there are more indexes than desired replicas.
+	scalePreference scalePreference
+
+	// clock is used to determine the current time.
+	// When not set, the real clock is used.
+	clock clock.PassiveClock
+}
+
+// getClock returns the clock used to determine the current time.
+func (m *openshiftMachineProvider) getClock() clock.PassiveClock {
+	if m.clock == nil {
+		return clock.RealClock{}
+	}
+
+	return m.clock
 }
 
 // WithClient sets the desired client to the Machine Provider.
@@ -200,8 +339,15 @@ func (m *openshiftMachineProvider) GetMachineInfos(ctx context.Context, logger l
 		return nil, fmt.Errorf("failed to list machines: %w", err)
 	}
 
+	machineList.Items = m.filterOwnedMachines(logger, machineList.Items)
+
+	machineIndexes, err := m.getMachineIndexes(logger, machineList.Items)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine machine indexes: %w", err)
+	}
+
 	for _, machine := range machineList.Items {
-		machineInfo, err := m.generateMachineInfo(ctx, logger, machine)
+		machineInfo, err := m.generateMachineInfo(ctx, logger, machine, machineIndexes[machine.Name])
 		if err != nil {
 			return nil, fmt.Errorf("could not generate machine info for machine %s: %w", machine.Name, err)
 		}
@@ -231,21 +377,37 @@ func (m *openshiftMachineProvider) GetMachineInfos(ctx context.Context, logger l
 	return machineInfos, nil
 }
 
+// filterOwnedMachines removes any Machine labelled as owned by a different ControlPlaneMachineSet.
+// Machines without the owner label, for example those created by the installer, are kept.
+func (m *openshiftMachineProvider) filterOwnedMachines(logger logr.Logger, machines []machinev1beta1.Machine) []machinev1beta1.Machine {
+	owned := []machinev1beta1.Machine{}
+
+	for _, machine := range machines {
+		if owner, ok := machine.Labels[machineproviders.MachineOwnerLabel]; ok && owner != m.ownerMetadata.Name {
+			logger.V(4).Info("Ignoring machine owned by a different control plane machine set", "machineName", machine.Name, "owner", owner)
+
+			continue
+		}
+
+		owned = append(owned, machine)
+	}
+
+	return owned
+}
+
 // generateMachineInfo creates a MachineInfo object for a given machine.
-func (m *openshiftMachineProvider) generateMachineInfo(ctx context.Context, logger logr.Logger, machine machinev1beta1.Machine) (machineproviders.MachineInfo, error) {
+func (m *openshiftMachineProvider) generateMachineInfo(ctx context.Context, logger logr.Logger, machine machinev1beta1.Machine, machineIndex int32) (machineproviders.MachineInfo, error) {
 	machineRef := getMachineRef(machine)
 	nodeRef := getNodeRef(machine)
 
-	machineIndex, err := m.getMachineIndex(logger, machine)
-	if err != nil {
-		return machineproviders.MachineInfo{}, fmt.Errorf("could not determine machine index: %w", err)
-	}
-
 	providerConfig, err := providerconfig.NewProviderConfigFromMachineSpec(logger, machine.Spec)
 	if err != nil {
 		return machineproviders.MachineInfo{}, fmt.Errorf("could not compare existing and desired provider configs: %w", err)
 	}
 
+	// A concrete image is only up to date with an image reference in the template when it was resolved from it.
+	providerConfig = providerConfig.WithResolvedImageReference(machine.GetAnnotations()[machineproviders.MachineImageReferenceAnnotation])
+
 	templateProviderConfig := m.providerConfig
 
 	if len(m.indexToFailureDomain) > 0 {
@@ -268,16 +430,48 @@ func (m *openshiftMachineProvider) generateMachineInfo(ctx context.Context, logg
 		return machineproviders.MachineInfo{}, fmt.Errorf("cannot ensure that the provider config is valid: %w", err)
 	}
 
-	diff, err := validProviderConfig.Diff(providerConfig)
+	diff, err := validProviderConfig.WithInstanceTypeEquivalence(m.instanceTypeEquivalence).Diff(providerConfig)
 	if err != nil {
 		return machineproviders.MachineInfo{}, fmt.Errorf("cannot compare provider configs: %w", err)
 	}
 
-	configsEqual := len(diff) == 0
+	diff = append(diff, diffTaints(m.machineTemplate.Spec.Taints, machine.Spec.Taints)...)
+
+	if !m.forceRollTime.IsZero() && machine.CreationTimestamp.Time.Before(m.forceRollTime) {
+		diff = append(diff, fmt.Sprintf("machine was created before the forced roll requested at %s", m.forceRollTime.UTC().Format(time.RFC3339)))
+	}
+
+	unmatchedFailureDomain := m.getUnmatchedFailureDomain(providerConfig)
 
-	ready, err := m.isMachineReady(ctx, machine)
+	node, err := m.getMachineNode(ctx, machine)
 	if err != nil {
-		return machineproviders.MachineInfo{}, fmt.Errorf("error checking machine readiness: %w", err)
+		return machineproviders.MachineInfo{}, fmt.Errorf("error checking machine node: %w", err)
+	}
+
+	nodePending := isNodePending(machine, node, m.getClock().Now())
+
+	inconsistentProviderID := getInconsistentProviderID(machine, node, nodePending)
+	if inconsistentProviderID != "" {
+		diff = append(diff, fmt.Sprintf("machine providerID is inconsistent with its node: %s", inconsistentProviderID))
+	}
+
+	if nodeVersionDiff := m.getNodeVersionDiff(machine, node, nodePending); nodeVersionDiff != "" {
+		diff = append(diff, nodeVersionDiff)
+	}
+
+	needsRemediation := hasMachineDeleteAnnotation(machine)
+	if needsRemediation {
+		diff = append(diff, "machine has been marked for remediation by a machine health check")
+	}
+
+	configsEqual := len(diff) == 0
+
+	ready := isMachineReady(machine, node)
+
+	var readySince time.Time
+
+	if ready {
+		readySince = getNodeReadySince(node)
 	}
 
 	return machineproviders.MachineInfo{
@@ -288,9 +482,104 @@ func (m *openshiftMachineProvider) generateMachineInfo(ctx context.Context, logg
 		Diff:         diff,
 		Index:        machineIndex,
 		ErrorMessage: pointer.StringDeref(machine.Status.ErrorMessage, ""),
+		ErrorReason:  getMachineErrorReason(machine),
+
+		NeedsRemediation:       needsRemediation,
+		UnmatchedFailureDomain: unmatchedFailureDomain,
+		InconsistentProviderID: inconsistentProviderID,
+		NodePending:            nodePending,
+		AwaitingRemoval:        isAwaitingRemoval(machine),
+		Provisioning:           isProvisioning(machine),
+		ReadySince:             readySince,
 	}, nil
 }
 
+// SelectScaleDownIndexes chooses count indexes to remove from the indexes provided, based on the failure domain
+// mapped to each index and the scale preference of the ControlPlaneMachineSet.
+func (m *openshiftMachineProvider) SelectScaleDownIndexes(logger logr.Logger, indexes []int32, count int) []int32 {
+	selected := selectScaleDownIndexes(m.indexToFailureDomain, indexes, count, m.failureDomainWeights, m.scalePreference)
+
+	logger.V(4).Info(
+		"Selected indexes to remove when scaling down",
+		"scalePreference", m.scalePreference,
+		"indexes", selected,
+	)
+
+	return selected
+}
+
+// FailureDomainMapping returns a description of the failure domain mapped to each index.
+// When no failure domains are defined on the ControlPlaneMachineSet, the mapping is empty.
+func (m *openshiftMachineProvider) FailureDomainMapping() map[int32]string {
+	mapping := make(map[int32]string, len(m.indexToFailureDomain))
+
+	for idx, failureDomain := range m.indexToFailureDomain {
+		mapping[idx] = failureDomain.String()
+	}
+
+	return mapping
+}
+
+// getUnmatchedFailureDomain returns the failure domain of the Machine, as a string, when it does not match any of the
+// failure domains defined on the ControlPlaneMachineSet. An empty string is returned when the failure domain matches,
+// or when no failure domains are defined.
+func (m *openshiftMachineProvider) getUnmatchedFailureDomain(providerConfig providerconfig.ProviderConfig) string {
+	if m.failureDomains == nil || m.failureDomains.Len() == 0 {
+		return ""
+	}
+
+	machineFailureDomain := providerConfig.ExtractFailureDomain()
+
+	if m.failureDomains.Has(machineFailureDomain) {
+		return ""
+	}
+
+	return machineFailureDomain.String()
+}
+
+// diffTaints compares the taints within the Machine template with those on the Machine.
+// The ordering of the taints has no meaning, so taints are compared after sorting.
+func diffTaints(desired, current []corev1.Taint) []string {
+	desiredTaints := sortedTaintStrings(desired)
+	currentTaints := sortedTaintStrings(current)
+
+	if strings.Join(desiredTaints, ",") == strings.Join(currentTaints, ",") {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("Spec.Taints: [%s] != [%s]", strings.Join(desiredTaints, ", "), strings.Join(currentTaints, ", "))}
+}
+
+// sortedTaintStrings returns the taints in their key=value:effect form, sorted so that they can be compared.
+// This form does not include the time at which the taint was added.
+func sortedTaintStrings(taints []corev1.Taint) []string {
+	out := []string{}
+
+	for i := range taints {
+		out = append(out, taints[i].ToString())
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// hasMachineDeleteAnnotation returns true when a MachineHealthCheck has marked the Machine for deletion.
+func hasMachineDeleteAnnotation(machine machinev1beta1.Machine) bool {
+	_, ok := machine.GetAnnotations()[machineproviders.MachineDeleteAnnotation]
+
+	return ok
+}
+
+// getMachineErrorReason returns the error reason reported by the provider within the Machine status, if any.
+func getMachineErrorReason(machine machinev1beta1.Machine) string {
+	if machine.Status.ErrorReason == nil {
+		return ""
+	}
+
+	return string(*machine.Status.ErrorReason)
+}
+
 // ensureValidProviderConfig makes sure that the provider config is valid by dry-run creating a machine.
 func (m *openshiftMachineProvider) ensureValidProviderConfig(ctx context.Context, logger logr.Logger, providerConfig providerconfig.ProviderConfig) (providerconfig.ProviderConfig, error) {
 	dryRunMachine := &machinev1beta1.Machine{
@@ -300,9 +589,12 @@ func (m *openshiftMachineProvider) ensureValidProviderConfig(ctx context.Context
 			Annotations:  m.ownerMetadata.Annotations,
 			Labels:       m.ownerMetadata.Labels,
 		},
-		Spec: m.machineTemplate.Spec,
+		Spec: *m.machineTemplate.Spec.DeepCopy(),
 	}
 
+	// As when creating a Machine, the provider ID is never copied from the template.
+	dryRunMachine.Spec.ProviderID = nil
+
 	rawConfig, err := providerConfig.RawConfig()
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch raw config from provider config: %w", err)
@@ -323,21 +615,67 @@ func (m *openshiftMachineProvider) ensureValidProviderConfig(ctx context.Context
 	return machineProviderConfig, nil
 }
 
-func (m *openshiftMachineProvider) getMachineIndex(logger logr.Logger, machine machinev1beta1.Machine) (int32, error) {
-	machineNameIndex, correctFormat := getMachineNameIndex(machine)
-	if correctFormat {
-		// If the machine name has the correct format we implicitly trust it to be correct.
-		return machineNameIndex, nil
+// getMachineIndexes determines the index of each of the Machines, keyed by the Machine name.
+// Machines are indexed, in order of precedence, by the index label, by the index suffix of the Machine name, or by
+// matching the failure domain of the Machine against the failure domain mapping.
+// When no failure domains are configured, Machines that cannot be indexed by label or name, for example, pre-existing
+// Machines created outside of the ControlPlaneMachineSet, are adopted into the lowest free indexes in order of
+// creation, so that the assignment is deterministic.
+func (m *openshiftMachineProvider) getMachineIndexes(logger logr.Logger, machines []machinev1beta1.Machine) (map[string]int32, error) {
+	out := make(map[string]int32)
+	usedIndexes := sets.New[int32]()
+	unindexedMachines := []machinev1beta1.Machine{}
+
+	for _, machine := range machines {
+		index, ok := parseMachineIndex(machine)
+		if !ok {
+			unindexedMachines = append(unindexedMachines, machine)
+			continue
+		}
+
+		out[machine.Name] = int32(index)
+		usedIndexes.Insert(int32(index))
 	}
 
-	// If the Machine name format doesn't match, try to fallback to matching based on the failure domain
-	// with the Machine's provider spec.
+	// Sort the remaining Machines so that the indexes are assigned in a stable order.
+	sort.Slice(unindexedMachines, func(i, j int) bool {
+		if unindexedMachines[i].CreationTimestamp.Equal(&unindexedMachines[j].CreationTimestamp) {
+			return unindexedMachines[i].Name < unindexedMachines[j].Name
+		}
+
+		return unindexedMachines[i].CreationTimestamp.Before(&unindexedMachines[j].CreationTimestamp)
+	})
+
+	for _, machine := range unindexedMachines {
+		var index int32
+
+		if len(m.indexToFailureDomain) == 0 {
+			index = lowestFreeIndex(usedIndexes)
+			logger.V(4).Info("Adopting machine into free index", "machineName", machine.Name, "index", index)
+		} else {
+			var err error
+
+			index, err = m.getMachineFailureDomainIndex(logger, machine, usedIndexes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		out[machine.Name] = index
+		usedIndexes.Insert(index)
+	}
+
+	return out, nil
+}
+
+// getMachineFailureDomainIndex determines the index of a Machine based on the failure domain within its provider spec.
+func (m *openshiftMachineProvider) getMachineFailureDomainIndex(logger logr.Logger, machine machinev1beta1.Machine, usedIndexes sets.Set[int32]) (int32, error) {
 	failureDomain, err := providerconfig.ExtractFailureDomainFromMachine(logger, machine)
 	if err != nil {
 		return 0, fmt.Errorf("cannot extract failure domain from machine: %w", err)
 	}
 
-	index, indexFound := m.failureDomainToIndex(failureDomain)
+	index, indexFound := m.failureDomainToIndex(failureDomain, usedIndexes)
 	if !indexFound {
 		// When the machine names do not fit the pattern, and the failure domains are
 		// not recognised, returns an error.
@@ -356,59 +694,174 @@ func (m *openshiftMachineProvider) getMachineIndex(logger logr.Logger, machine m
 	return index, nil
 }
 
-// failureDomainToIndex returns the index of failure domain in the list. If there is nothing found, it returns false as
-// a second parameter.
-func (m *openshiftMachineProvider) failureDomainToIndex(failureDomain failuredomain.FailureDomain) (int32, bool) {
-	for i, fd := range m.indexToFailureDomain {
-		if fd.Equal(failureDomain) {
+// failureDomainToIndex returns the index of failure domain in the mapping. If there is nothing found, it returns false
+// as a second parameter.
+// Where multiple indexes share the failure domain, the lowest index not already in use is preferred, falling back to
+// the lowest index.
+func (m *openshiftMachineProvider) failureDomainToIndex(failureDomain failuredomain.FailureDomain, usedIndexes sets.Set[int32]) (int32, bool) {
+	matchingIndexes := []int32{}
+
+	for _, i := range sortedIndexes(m.indexToFailureDomain) {
+		if m.indexToFailureDomain[i].Equal(failureDomain) {
+			matchingIndexes = append(matchingIndexes, i)
+		}
+	}
+
+	for _, i := range matchingIndexes {
+		if !usedIndexes.Has(i) {
 			return i, true
 		}
 	}
 
+	if len(matchingIndexes) > 0 {
+		return matchingIndexes[0], true
+	}
+
 	return 0, false
 }
 
-// isMachineReady determines whether a CPMS Machine is Ready or not.
-// A CPMS Machine is considered Ready when:
-// - the underlying Machine is Running and its Node is Ready
-// - the underlying Machine is Deleting and is still has a NodeRef.
-func (m *openshiftMachineProvider) isMachineReady(ctx context.Context, machine machinev1beta1.Machine) (bool, error) {
+// lowestFreeIndex returns the lowest non-negative index not present in the used indexes.
+func lowestFreeIndex(usedIndexes sets.Set[int32]) int32 {
+	index := int32(0)
+	for usedIndexes.Has(index) {
+		index++
+	}
+
+	return index
+}
+
+// getMachineNode fetches the Node referenced by the Machine, so that it is only fetched once for each Machine.
+// It returns nil when the Machine has not yet been linked to a Node, or when the Node cannot be found.
+func (m *openshiftMachineProvider) getMachineNode(ctx context.Context, machine machinev1beta1.Machine) (*corev1.Node, error) {
 	if machine.Status.NodeRef == nil {
-		return false, nil
+		return nil, nil
 	}
 
 	nodeName := machine.Status.NodeRef.Name
 
 	node := &corev1.Node{}
-	if err := m.client.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
-		return false, fmt.Errorf("failed to get Node %q: %w", nodeName, err)
+	if err := m.client.Get(ctx, types.NamespacedName{Name: nodeName}, node); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get Node %q: %w", nodeName, err)
+	}
+
+	return node, nil
+}
+
+// isMachineReady determines whether a CPMS Machine is Ready or not, given its Node.
+// A CPMS Machine is considered Ready when:
+// - the underlying Machine is Running and its Node is Ready
+// - the underlying Machine is Deleting, its Node is Ready and a pre-drain lifecycle hook still holds its deletion.
+// A Deleting Machine that is no longer held by any pre-drain hook is about to be drained, so is not considered Ready.
+// A Machine whose Node is either not yet present, or has been removed, is not Ready. A removed Node is reported as
+// an inconsistent providerID.
+func isMachineReady(machine machinev1beta1.Machine, node *corev1.Node) bool {
+	if node == nil {
+		return false
 	}
 
 	if pointer.StringDeref(machine.Status.Phase, "") == runningPhase && isNodeReady(node) {
 		// The machine is running and its node is ready, so everything is working as expected.
-		return true, nil
+		return true
 	}
 
-	if pointer.StringDeref(machine.Status.Phase, "") == deletingPhase && isNodeReady(node) {
+	if pointer.StringDeref(machine.Status.Phase, "") == deletingPhase && isNodeReady(node) && !isAwaitingRemoval(machine) {
 		// The machine was previously running but is now being deleted.
-		// The machine is still ready until the node is drained and removed from the cluster.
-		return true, nil
+		// The machine is still ready until its pre-drain hooks are removed and the node is drained.
+		return true
 	}
 
-	return false, nil
+	return false
 }
 
-// getMachineNameIndex tries to fetch machine index from its name. If it's not possible,
-// it returns false as a second parameter.
-func getMachineNameIndex(machine machinev1beta1.Machine) (int32, bool) {
-	// Get a substring after the last occurrence of "-" to find the machine index suffix.
-	// Then try to convert it to a number.
-	machineNameIndex, err := strconv.ParseInt(machine.Name[strings.LastIndex(machine.Name, "-")+1:], 10, 32)
-	if err != nil {
-		return 0, false
+// getNodeReadySince returns the time at which the Node last became Ready.
+// It returns the zero time when there is no Node, or the Node is not Ready.
+func getNodeReadySince(node *corev1.Node) time.Time {
+	if node == nil {
+		return time.Time{}
+	}
+
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+			return c.LastTransitionTime.Time
+		}
+	}
+
+	return time.Time{}
+}
+
+// isAwaitingRemoval determines whether a Machine is being deleted and no pre-drain lifecycle hook holds its deletion.
+// The etcd operator holds the deletion of a control plane Machine with a pre-drain hook until its etcd member has
+// moved to a replacement, so a Machine that is still held must have its replacement created for it to be removed.
+func isAwaitingRemoval(machine machinev1beta1.Machine) bool {
+	return pointer.StringDeref(machine.Status.Phase, "") == deletingPhase && len(machine.Spec.LifecycleHooks.PreDrain) == 0
+}
+
+// isProvisioning determines whether the instance backing a Machine has not yet been created.
+// A new Machine has no phase until it is first reconciled by the machine controller, so this is also provisioning.
+func isProvisioning(machine machinev1beta1.Machine) bool {
+	if machine.Status.NodeRef != nil || machine.DeletionTimestamp != nil {
+		return false
+	}
+
+	phase := pointer.StringDeref(machine.Status.Phase, "")
+
+	return phase == "" || phase == provisioningPhase
+}
+
+// isNodePending determines whether the Node referenced by the Machine cannot be found, but is expected to appear
+// shortly. A missing Node is only considered to have gone away once the Machine status has not been updated for
+// the node not found grace period, so that a transient failure to observe the Node does not cause the Machine
+// to be replaced.
+func isNodePending(machine machinev1beta1.Machine, node *corev1.Node, now time.Time) bool {
+	if machine.Status.NodeRef == nil || node != nil {
+		return false
+	}
+
+	return machine.Status.LastUpdated != nil && now.Sub(machine.Status.LastUpdated.Time) < nodeNotFoundGracePeriod
+}
+
+// getInconsistentProviderID returns a description of how the providerID of the Machine is inconsistent with its Node.
+// It returns an empty string when the Machine has not yet been linked to a Node, when its Node is still pending,
+// or when the providerIDs match.
+func getInconsistentProviderID(machine machinev1beta1.Machine, node *corev1.Node, nodePending bool) string {
+	if nodePending {
+		return ""
+	}
+
+	if machine.Status.NodeRef == nil || pointer.StringDeref(machine.Spec.ProviderID, "") == "" {
+		return ""
+	}
+
+	nodeName := machine.Status.NodeRef.Name
+
+	if node == nil {
+		return fmt.Sprintf("node %s does not exist", nodeName)
+	}
+
+	if node.Spec.ProviderID != "" && node.Spec.ProviderID != *machine.Spec.ProviderID {
+		return fmt.Sprintf("machine providerID %s does not match node %s providerID %s", *machine.Spec.ProviderID, nodeName, node.Spec.ProviderID)
 	}
 
-	return int32(machineNameIndex), true
+	return ""
+}
+
+// getNodeVersionDiff returns a description of how the version reported by the Node of the Machine differs from the
+// desired node version. It returns an empty string when no desired node version is set, when the Machine has not
+// yet been linked to a Node, when its Node is still pending or has gone away, or when the Node does not carry the
+// version label, as the version of such a Node cannot be determined.
+func (m *openshiftMachineProvider) getNodeVersionDiff(machine machinev1beta1.Machine, node *corev1.Node, nodePending bool) string {
+	if m.desiredNodeVersion == nil || nodePending || node == nil {
+		return ""
+	}
+
+	version, ok := node.GetLabels()[m.desiredNodeVersion.label]
+	if !ok || version == m.desiredNodeVersion.version {
+		return ""
+	}
+
+	return fmt.Sprintf("node %s label %s: %s != %s", node.GetName(), m.desiredNodeVersion.label, m.desiredNodeVersion.version, version)
 }
 
 // getMachineRef returns returns machine object reference for the given machine.
@@ -449,41 +902,81 @@ func isNodeReady(node *corev1.Node) bool {
 }
 
 // CreateMachine creates a new Machine from the template provider config based on the
-// failure domain index provided.
-func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger logr.Logger, index int32) error {
+// failure domain index provided, and returns the name of the created Machine.
+func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger logr.Logger, index int32) (string, error) {
 	machineName, err := m.getMachineName(index)
 	if err != nil {
-		return fmt.Errorf("could not generate machine name: %w", err)
+		return "", fmt.Errorf("could not generate machine name: %w", err)
 	}
 
 	cpms := &machinev1.ControlPlaneMachineSet{
 		ObjectMeta: m.ownerMetadata,
 	}
 
+	// Copy the template labels so that adding the index label does not modify the template.
+	labels := map[string]string{}
+	for k, v := range m.machineTemplate.ObjectMeta.Labels {
+		labels[k] = v
+	}
+
+	labels[machineproviders.MachineIndexLabel] = strconv.Itoa(int(index))
+	labels[machineproviders.MachineOwnerLabel] = m.ownerMetadata.Name
+
+	providerConfig, err := m.getProviderConfigForIndex(index)
+	if err != nil {
+		return "", fmt.Errorf("could not get provider config for index %d: %w", index, err)
+	}
+
+	// Copy the template annotations so that recording the origin of the Machine does not modify the template.
+	annotations := map[string]string{}
+	for k, v := range m.machineTemplate.ObjectMeta.Annotations {
+		annotations[k] = v
+	}
+
+	annotations[machineproviders.MachineGenerationAnnotation] = strconv.FormatInt(m.ownerMetadata.Generation, 10)
+	annotations[machineproviders.MachineFailureDomainAnnotation] = providerConfig.ExtractFailureDomain().String()
+
+	imageReference, err := providerConfig.ImageReference()
+	if err != nil {
+		return "", fmt.Errorf("could not get image reference: %w", err)
+	}
+
+	if imageReference != "" {
+		annotations[machineproviders.MachineImageReferenceAnnotation] = imageReference
+	} else {
+		delete(annotations, machineproviders.MachineImageReferenceAnnotation)
+	}
+
 	machine := &machinev1beta1.Machine{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        machineName,
 			Namespace:   m.namespace,
-			Annotations: m.machineTemplate.ObjectMeta.Annotations,
-			Labels:      m.machineTemplate.ObjectMeta.Labels,
+			Annotations: annotations,
+			Labels:      labels,
 		},
-		Spec: m.machineTemplate.Spec,
+		Spec: *m.machineTemplate.Spec.DeepCopy(),
 	}
 
-	providerConfig, err := m.getProviderConfigForIndex(index)
-	if err != nil {
-		return fmt.Errorf("could not get provider config for index %d: %w", index, err)
-	}
+	// The provider ID is set by the machine controller once the instance exists. A provider ID carried over from the
+	// template would link the new Machine to an instance it does not own, so it is never copied.
+	machine.Spec.ProviderID = nil
 
 	rawConfig, err := providerConfig.RawConfig()
 	if err != nil {
-		return fmt.Errorf("cannot fetch raw config from provider config: %w", err)
+		return "", fmt.Errorf("cannot fetch raw config from provider config: %w", err)
 	}
 
 	machine.Spec.ProviderSpec.Value.Raw = rawConfig
 
+	ownerReferences, err := m.getMachineOwnerReferences()
+	if err != nil {
+		return "", fmt.Errorf("could not get machine owner references: %w", err)
+	}
+
+	machine.SetOwnerReferences(ownerReferences)
+
 	if err := controllerutil.SetControllerReference(cpms, machine, m.machineAPIScheme); err != nil {
-		return fmt.Errorf("could not set owner reference: %w", err)
+		return "", fmt.Errorf("could not set owner reference: %w", err)
 	}
 
 	if err := m.client.Create(ctx, machine); err != nil {
@@ -495,7 +988,7 @@ func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger log
 			"version", machinev1beta1.GroupVersion.Version,
 		)
 
-		return fmt.Errorf("cannot create machine: %w", err)
+		return "", fmt.Errorf("cannot create machine: %w", err)
 	}
 
 	logger.V(2).Info(
@@ -505,11 +998,67 @@ func (m *openshiftMachineProvider) CreateMachine(ctx context.Context, logger log
 		"failureDomain", providerConfig.ExtractFailureDomain().String(),
 	)
 
+	return machine.Name, nil
+}
+
+// CheckReferencedSecrets checks that the secrets referenced by the Machine template exist in the namespace in
+// which new Machines are created. Only the secret metadata is fetched so that the secret data is never cached.
+func (m *openshiftMachineProvider) CheckReferencedSecrets(ctx context.Context, logger logr.Logger) error {
+	secretNames, err := m.providerConfig.ReferencedSecretNames()
+	if err != nil {
+		return fmt.Errorf("could not determine referenced secrets: %w", err)
+	}
+
+	for _, secretName := range secretNames {
+		secret := &metav1.PartialObjectMetadata{}
+		secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+
+		err := m.client.Get(ctx, client.ObjectKey{Namespace: m.namespace, Name: secretName}, secret)
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w: secret %s/%s does not exist", machineproviders.ErrMissingReferencedSecret, m.namespace, secretName)
+		} else if err != nil {
+			return fmt.Errorf("could not get secret %s/%s: %w", m.namespace, secretName, err)
+		}
+	}
+
+	logger.V(4).Info("Found referenced secrets", "secretNames", secretNames)
+
 	return nil
 }
 
+// getMachineOwnerReferences returns the owner references from the machine owner references annotation on the
+// ControlPlaneMachineSet, to be added to new Machines alongside the controller reference to the ControlPlaneMachineSet.
+// The owner references are never controller references, as a Machine can only have a single controller.
+func (m *openshiftMachineProvider) getMachineOwnerReferences() ([]metav1.OwnerReference, error) {
+	value, ok := m.ownerMetadata.Annotations[machineproviders.MachineOwnerReferencesAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	ownerReferences := []metav1.OwnerReference{}
+	if err := json.Unmarshal([]byte(value), &ownerReferences); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidMachineOwnerReferences, err)
+	}
+
+	for i := range ownerReferences {
+		ownerReferences[i].Controller = nil
+	}
+
+	return ownerReferences, nil
+}
+
 // getMachineName generates a machine name based on the index.
+// The name is prefixed with the machine name prefix annotation on the ControlPlaneMachineSet when it is set,
+// or with the cluster ID and machine role otherwise.
 func (m *openshiftMachineProvider) getMachineName(index int32) (string, error) {
+	if prefix, ok := m.ownerMetadata.Annotations[machineproviders.MachineNamePrefixAnnotation]; ok {
+		if errs := validation.IsDNS1123Label(prefix); len(errs) > 0 {
+			return "", fmt.Errorf("%w: %q: %s", errInvalidMachineNamePrefix, prefix, strings.Join(errs, ", "))
+		}
+
+		return fmt.Sprintf("%s-%s-%d", prefix, rand.String(5), index), nil
+	}
+
 	clusterID, ok := m.machineTemplate.ObjectMeta.Labels[machinev1beta1.MachineClusterIDLabel]
 	if !ok {
 		return "", errMissingClusterIDLabel
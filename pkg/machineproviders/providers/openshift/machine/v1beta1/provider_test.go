@@ -17,7 +17,9 @@ limitations under the License.
 package v1beta1
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -37,7 +39,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/pointer"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -52,6 +56,9 @@ var _ = Describe("MachineProvider", func() {
 	var logger testutils.TestLogger
 	var nilDiff []string
 	instanceDiff := []string{"InstanceType: m6i.xlarge != different"}
+	forceRollDiff := []string{"machine was created before the forced roll requested at 2100-01-01T00:00:00Z"}
+	remediationDiff := []string{"machine has been marked for remediation by a machine health check"}
+	nodeVersionDiff := []string{"node node-1 label feature.node.kubernetes.io/kernel-version.full: 5.14.0-284.el9 != 5.14.0-162.el9"}
 
 	usEast1aSubnet := machinev1.AWSResourceReference{
 		Type: machinev1.AWSFiltersReferenceType,
@@ -122,6 +129,22 @@ var _ = Describe("MachineProvider", func() {
 		},
 	}
 
+	// usEast1dFailureDomain matches the default subnet of the AWS provider spec builder in us-east-1d,
+	// which is not one of the failure domains configured on the ControlPlaneMachineSet in these tests.
+	usEast1dFailureDomain := failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1d").WithSubnet(
+		machinev1.AWSResourceReference{
+			Type: machinev1.AWSFiltersReferenceType,
+			Filters: &[]machinev1.AWSResourceFilter{
+				{
+					Name: "tag:Name",
+					Values: []string{
+						"aws-subnet-12345678",
+					},
+				},
+			},
+		},
+	).Build())
+
 	BeforeEach(OncePerOrdered, func() {
 		By("Setting up a namespace for the test")
 		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-controller-").Build()
@@ -169,13 +192,88 @@ var _ = Describe("MachineProvider", func() {
 			return fmt.Sprintf("%s-master-%s", resourcebuilder.TestClusterIDValue, suffix)
 		}
 
+		withErrorReason := func(machine *machinev1beta1.Machine, reason machinev1beta1.MachineStatusError) *machinev1beta1.Machine {
+			machine.Status.ErrorReason = &reason
+
+			return machine
+		}
+
+		withPreDrainHook := func(machine *machinev1beta1.Machine) *machinev1beta1.Machine {
+			machine.Spec.LifecycleHooks.PreDrain = []machinev1beta1.LifecycleHook{{Name: "EtcdQuorumOperator", Owner: "clusteroperator/etcd"}}
+
+			return machine
+		}
+
+		withDeleteMachineAnnotation := func(machine *machinev1beta1.Machine) *machinev1beta1.Machine {
+			machine.SetAnnotations(map[string]string{machineproviders.MachineDeleteAnnotation: ""})
+
+			return machine
+		}
+
+		withMachineProviderID := func(machine *machinev1beta1.Machine, providerID string) *machinev1beta1.Machine {
+			machine.Spec.ProviderID = &providerID
+
+			return machine
+		}
+
+		withMachineLastUpdated := func(machine *machinev1beta1.Machine, lastUpdated time.Time) *machinev1beta1.Machine {
+			machine.Status.LastUpdated = &metav1.Time{Time: lastUpdated}
+
+			return machine
+		}
+
+		// machineLastUpdated is the time at which the Machine status was last updated, in the tests that
+		// depend on how long ago the Machine was last observed.
+		machineLastUpdated := time.Date(2023, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+		withNodeProviderID := func(node *corev1.Node, providerID string) *corev1.Node {
+			node.Spec.ProviderID = providerID
+
+			return node
+		}
+
+		withNodeLabel := func(node *corev1.Node, key, value string) *corev1.Node {
+			// The builder shares its labels between the nodes it builds, so copy them before adding the label.
+			labels := map[string]string{key: value}
+			for k, v := range node.GetLabels() {
+				labels[k] = v
+			}
+
+			node.SetLabels(labels)
+
+			return node
+		}
+
+		indexedMasterLabels := func(index string) map[string]string {
+			labels := map[string]string{machineproviders.MachineIndexLabel: index}
+			for k, v := range masterLabels {
+				labels[k] = v
+			}
+
+			return labels
+		}
+
+		ownedMasterLabels := func(owner string) map[string]string {
+			labels := map[string]string{machineproviders.MachineOwnerLabel: owner}
+			for k, v := range masterLabels {
+				labels[k] = v
+			}
+
+			return labels
+		}
+
 		type getMachineInfosTableInput struct {
-			machines             []*machinev1beta1.Machine
-			nodes                []*corev1.Node
-			failureDomains       map[int32]failuredomain.FailureDomain
-			expectedError        error
-			expectedMachineInfos []machineproviders.MachineInfo
-			expectedLogs         []testutils.LogEntry
+			machines                 []*machinev1beta1.Machine
+			nodes                    []*corev1.Node
+			failureDomains           map[int32]failuredomain.FailureDomain
+			configuredFailureDomains []failuredomain.FailureDomain
+			instanceTypes            map[string]string
+			forceRollTime            time.Time
+			desiredNodeVersion       *desiredNodeVersion
+			now                      time.Time
+			expectedError            error
+			expectedMachineInfos     []machineproviders.MachineInfo
+			expectedLogs             []testutils.LogEntry
 		}
 
 		DescribeTable("builds machine info based on the cluster state", func(in getMachineInfosTableInput) {
@@ -223,12 +321,21 @@ var _ = Describe("MachineProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			provider := &openshiftMachineProvider{
-				client:               k8sClient,
-				indexToFailureDomain: in.failureDomains,
-				machineSelector:      cpms.Spec.Selector,
-				machineTemplate:      *template,
-				providerConfig:       providerConfig,
-				namespace:            namespaceName,
+				client:                  k8sClient,
+				indexToFailureDomain:    in.failureDomains,
+				failureDomains:          failuredomain.NewSet(in.configuredFailureDomains...),
+				machineSelector:         cpms.Spec.Selector,
+				machineTemplate:         *template,
+				ownerMetadata:           cpms.ObjectMeta,
+				providerConfig:          providerConfig,
+				namespace:               namespaceName,
+				instanceTypeEquivalence: providerconfig.NewInstanceTypeEquivalence(in.instanceTypes),
+				forceRollTime:           in.forceRollTime,
+				desiredNodeVersion:      in.desiredNodeVersion,
+			}
+
+			if !in.now.IsZero() {
+				provider.clock = clocktesting.NewFakePassiveClock(in.now)
 			}
 
 			machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
@@ -278,8 +385,8 @@ var _ = Describe("MachineProvider", func() {
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).Build(),
-					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).Build(),
+					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithProvisioning(true).Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithProvisioning(true).Build(),
 					unreadyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
@@ -324,39 +431,77 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with ready Machines", getMachineInfosTableInput{
+			Entry("with Machines labelled as owned by a different ControlPlaneMachineSet", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+						WithLabels(ownedMasterLabels(machinev1resourcebuilder.ControlPlaneMachineSetName)).WithPhase("").Build(),
 					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+						WithLabels(ownedMasterLabels("other")).WithPhase("").Build(),
 				},
-
-				nodes: []*corev1.Node{
-					masterNodeBuilder.WithName("node-0").Build(),
-					masterNodeBuilder.WithName("node-1").Build(),
-					masterNodeBuilder.WithName("node-2").Build(),
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).
+						WithMachineLabels(ownedMasterLabels(machinev1resourcebuilder.ControlPlaneMachineSetName)).WithProvisioning(true).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"owner", "other",
+						},
+						Message: "Ignoring machine owned by a different control plane machine set",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "",
+							"index", int32(0),
+							"ready", false,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with an owned Machine whose index label has been removed", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithLabels(indexedMasterLabels("0")).WithPhase("").Build(),
+					masterMachineBuilder.WithName(masterMachineName("fghij-1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithLabels(ownedMasterLabels(machinev1resourcebuilder.ControlPlaneMachineSetName)).WithPhase("").Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithLabels(indexedMasterLabels("2")).WithPhase("").Build(),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
 					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
+				// The owned Machine is mapped back to the index in its name, so that its label can be restored,
+				// rather than its index being reported as empty.
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).
+						WithMachineLabels(indexedMasterLabels("0")).WithProvisioning(true).Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("fghij-1")).
+						WithMachineLabels(ownedMasterLabels(machinev1resourcebuilder.ControlPlaneMachineSetName)).WithProvisioning(true).Build(),
+					unreadyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).
+						WithMachineLabels(indexedMasterLabels("2")).WithProvisioning(true).Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
 							"machineName", masterMachineName("0"),
-							"nodeName", "node-0",
+							"nodeName", "",
 							"index", int32(0),
-							"ready", true,
+							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
@@ -366,10 +511,10 @@ var _ = Describe("MachineProvider", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("1"),
-							"nodeName", "node-1",
+							"machineName", masterMachineName("fghij-1"),
+							"nodeName", "",
 							"index", int32(1),
-							"ready", true,
+							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
@@ -380,9 +525,9 @@ var _ = Describe("MachineProvider", func() {
 						Level: 4,
 						KeysAndValues: []interface{}{
 							"machineName", masterMachineName("2"),
-							"nodeName", "node-2",
+							"nodeName", "",
 							"index", int32(2),
-							"ready", true,
+							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
@@ -391,19 +536,18 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with ready Machine that has now been deleted", getMachineInfosTableInput{
+			Entry("with Machines whose providerID is inconsistent with their Node", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Deleting").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+					withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(), "aws:///us-east-1a/i-0"),
+					withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(), "aws:///us-east-1b/i-1"),
+					withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(), "aws:///us-east-1c/i-2"),
 				},
 				nodes: []*corev1.Node{
-					masterNodeBuilder.WithName("node-0").Build(),
-					masterNodeBuilder.WithName("node-1").Build(),
-					masterNodeBuilder.WithName("node-2").Build(),
+					withNodeProviderID(masterNodeBuilder.WithName("node-0").Build(), "aws:///us-east-1a/i-restored"),
+					withNodeProviderID(masterNodeBuilder.WithName("node-2").Build(), "aws:///us-east-1c/i-2"),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
@@ -411,8 +555,12 @@ var _ = Describe("MachineProvider", func() {
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithNeedsUpdate(true).WithDiff([]string{"machine providerID is inconsistent with its node: machine providerID aws:///us-east-1a/i-0 does not match node node-0 providerID aws:///us-east-1a/i-restored"}).
+						WithInconsistentProviderID("machine providerID aws:///us-east-1a/i-0 does not match node node-0 providerID aws:///us-east-1a/i-restored").Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").
+						WithNeedsUpdate(true).WithDiff([]string{"machine providerID is inconsistent with its node: node node-1 does not exist"}).
+						WithInconsistentProviderID("node node-1 does not exist").Build(),
 					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
@@ -423,8 +571,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"needsUpdate", true,
+							"diff", []string{"machine providerID is inconsistent with its node: machine providerID aws:///us-east-1a/i-0 does not match node node-0 providerID aws:///us-east-1a/i-restored"},
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -435,9 +583,9 @@ var _ = Describe("MachineProvider", func() {
 							"machineName", masterMachineName("1"),
 							"nodeName", "node-1",
 							"index", int32(1),
-							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"ready", false,
+							"needsUpdate", true,
+							"diff", []string{"machine providerID is inconsistent with its node: node node-1 does not exist"},
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -457,28 +605,25 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with a Machine that was never ready, and has now been deleted", getMachineInfosTableInput{
+			Entry("with a new Machine whose Node has not yet been observed", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Deleting").Build(),
+					withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(), "aws:///us-east-1a/i-0"),
+					withMachineLastUpdated(withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(), "aws:///us-east-1b/i-1"), machineLastUpdated),
 				},
+				now: machineLastUpdated.Add(time.Minute),
 				nodes: []*corev1.Node{
-					masterNodeBuilder.WithName("node-0").Build(),
-					masterNodeBuilder.WithName("node-1").Build(),
+					withNodeProviderID(masterNodeBuilder.WithName("node-0").Build(), "aws:///us-east-1a/i-0"),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
 					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
-					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
 					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
-					unreadyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").
+						WithNodePending(true).Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
@@ -500,37 +645,75 @@ var _ = Describe("MachineProvider", func() {
 							"machineName", masterMachineName("1"),
 							"nodeName", "node-1",
 							"index", int32(1),
-							"ready", true,
+							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
+				},
+			}),
+			Entry("with a Machine whose Node has been deleted", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(), "aws:///us-east-1a/i-0"),
+					withMachineLastUpdated(withMachineProviderID(masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(), "aws:///us-east-1b/i-1"), machineLastUpdated),
+				},
+				now: machineLastUpdated.Add(time.Hour),
+				nodes: []*corev1.Node{
+					withNodeProviderID(masterNodeBuilder.WithName("node-0").Build(), "aws:///us-east-1a/i-0"),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").
+						WithNeedsUpdate(true).WithDiff([]string{"machine providerID is inconsistent with its node: node node-1 does not exist"}).
+						WithInconsistentProviderID("node node-1 does not exist").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("2"),
-							"nodeName", "",
-							"index", int32(2),
-							"ready", false,
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", false,
+							"needsUpdate", true,
+							"diff", []string{"machine providerID is inconsistent with its node: node node-1 does not exist"},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
 				},
 			}),
-			Entry("with Machines using the random suffix pattern", getMachineInfosTableInput{
+			Entry("with ready Machines", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(masterMachineName("abcde-0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("fghij-1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
 					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
+
 				nodes: []*corev1.Node{
 					masterNodeBuilder.WithName("node-0").Build(),
 					masterNodeBuilder.WithName("node-1").Build(),
@@ -542,15 +725,15 @@ var _ = Describe("MachineProvider", func() {
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("abcde-0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("fghij-1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
 					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("abcde-0"),
+							"machineName", masterMachineName("0"),
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
@@ -563,7 +746,7 @@ var _ = Describe("MachineProvider", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("fghij-1"),
+							"machineName", masterMachineName("1"),
 							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
@@ -588,11 +771,49 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with one Machine with a different instance type", getMachineInfosTableInput{
+			Entry("with a ready Machine whose Node became ready at a known time", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithInstanceType("different").WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").WithConditions([]corev1.NodeCondition{
+						{
+							Type:               corev1.NodeReady,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: metav1.NewTime(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)),
+						},
+					}).Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					// The time is decoded from the API in the local time zone.
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithReadySince(time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC).Local()).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with ready Machines created before a forced roll was requested", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
 					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
@@ -607,11 +828,11 @@ var _ = Describe("MachineProvider", func() {
 					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
+				forceRollTime: time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC),
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").WithNeedsUpdate(true).WithDiff(
-						instanceDiff).Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").WithNeedsUpdate(true).WithDiff(forceRollDiff).Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").WithNeedsUpdate(true).WithDiff(forceRollDiff).Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).WithDiff(forceRollDiff).Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
@@ -621,8 +842,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"needsUpdate", true,
+							"diff", forceRollDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -635,7 +856,7 @@ var _ = Describe("MachineProvider", func() {
 							"index", int32(1),
 							"ready", true,
 							"needsUpdate", true,
-							"diff", instanceDiff,
+							"diff", forceRollDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -647,20 +868,20 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-2",
 							"index", int32(2),
 							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"needsUpdate", true,
+							"diff", forceRollDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
 				},
 			}),
-			Entry("with one Machine with an unknown failure domain", getMachineInfosTableInput{
+			Entry("with a ready Machine marked for remediation by a machine health check", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1d")).
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					withDeleteMachineAnnotation(masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build()),
 					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
@@ -675,14 +896,9 @@ var _ = Describe("MachineProvider", func() {
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
-						WithDiff(
-							[]string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
-								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
-							},
-						).WithNeedsUpdate(true).Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").
+						WithMachineAnnotations(map[string]string{machineproviders.MachineDeleteAnnotation: ""}).WithNeedsRemediation(true).WithDiff(remediationDiff).Build(),
 					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
@@ -693,11 +909,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
-							"needsUpdate", true,
-							"diff", []string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
-								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
-							},
+							"needsUpdate", false,
+							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -709,8 +922,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"needsUpdate", true,
+							"diff", remediationDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -730,34 +943,31 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with multiple Machines in an index in different states", getMachineInfosTableInput{
+			Entry("with a ready Machine whose node has an out of date version label", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
 					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithInstanceType("different")).
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("abcde-2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-replacement-2"}).Build(),
 				},
 				nodes: []*corev1.Node{
-					masterNodeBuilder.WithName("node-0").Build(),
-					masterNodeBuilder.WithName("node-1").Build(),
+					withNodeLabel(masterNodeBuilder.WithName("node-0").Build(), "feature.node.kubernetes.io/kernel-version.full", "5.14.0-284.el9"),
+					withNodeLabel(masterNodeBuilder.WithName("node-1").Build(), "feature.node.kubernetes.io/kernel-version.full", "5.14.0-162.el9"),
+					// A node without the version label is not replaced, as its version cannot be determined.
 					masterNodeBuilder.WithName("node-2").Build(),
-					masterNodeBuilder.WithName("node-replacement-2").Build(),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
 					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
+				desiredNodeVersion: &desiredNodeVersion{label: "feature.node.kubernetes.io/kernel-version.full", version: "5.14.0-284.el9"},
 				expectedMachineInfos: []machineproviders.MachineInfo{
 					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).
-						WithDiff([]string{"InstanceType: m6i.xlarge != different", "Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != aws-subnet-12345678"}).Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("abcde-2")).WithNodeName("node-replacement-2").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").WithNeedsUpdate(true).WithDiff(nodeVersionDiff).Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
@@ -780,8 +990,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"needsUpdate", true,
+							"diff", nodeVersionDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -793,22 +1003,6 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-2",
 							"index", int32(2),
 							"ready", true,
-							"needsUpdate", true,
-							"diff", []string{
-								"InstanceType: m6i.xlarge != different",
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != aws-subnet-12345678",
-							},
-							"errorMessage", "",
-						},
-						Message: "Gathered Machine Info",
-					},
-					{
-						Level: 4,
-						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("abcde-2"),
-							"nodeName", "node-replacement-2",
-							"index", int32(2),
-							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
@@ -817,14 +1011,14 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("when the failure domain mapping does not match, the mapping takes precedence for indexing", getMachineInfosTableInput{
+			Entry("with ready Machine that has now been deleted, and is held by a pre-drain hook", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
 					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+					withPreDrainHook(masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Deleting").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build()),
 				},
 				nodes: []*corev1.Node{
 					masterNodeBuilder.WithName("node-0").Build(),
@@ -832,31 +1026,14 @@ var _ = Describe("MachineProvider", func() {
 					masterNodeBuilder.WithName("node-2").Build(),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
-					// The failure domain mapping logic is trusted as the source of truth for the failure domain.
-					// It is responsible for mapping the machine indexes to failure domains.
-					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
-					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
-					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").WithNeedsUpdate(true).WithDiff(
-						[]string{
-							"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1b != subnet-us-east-1a",
-							"Placement.AvailabilityZone: us-east-1b != us-east-1a",
-						},
-					).Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").WithNeedsUpdate(true).WithDiff(
-						[]string{
-							"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1b",
-							"Placement.AvailabilityZone: us-east-1c != us-east-1b",
-						},
-					).Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).WithDiff(
-						[]string{
-							"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != subnet-us-east-1c",
-							"Placement.AvailabilityZone: us-east-1a != us-east-1c",
-						},
-					).Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
@@ -866,11 +1043,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
-							"needsUpdate", true,
-							"diff", []string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1b != subnet-us-east-1a",
-								"Placement.AvailabilityZone: us-east-1b != us-east-1a",
-							},
+							"needsUpdate", false,
+							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -882,11 +1056,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
-							"needsUpdate", true,
-							"diff", []string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1b",
-								"Placement.AvailabilityZone: us-east-1c != us-east-1b",
-							},
+							"needsUpdate", false,
+							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -898,25 +1069,22 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-2",
 							"index", int32(2),
 							"ready", true,
-							"needsUpdate", true,
-							"diff", []string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != subnet-us-east-1c",
-								"Placement.AvailabilityZone: us-east-1a != us-east-1c",
-							},
+							"needsUpdate", false,
+							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
 				},
 			}),
-			Entry("when the machine names do not fit the pattern, fall back to matching on failure domains", getMachineInfosTableInput{
+			Entry("with ready Machine stuck deleting, once its pre-drain hooks have been removed", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-a").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-0").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-master-c").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Deleting").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
 				nodes: []*corev1.Node{
 					masterNodeBuilder.WithName("node-0").Build(),
@@ -924,20 +1092,20 @@ var _ = Describe("MachineProvider", func() {
 					masterNodeBuilder.WithName("node-2").Build(),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
-					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
-					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
-					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(resourcebuilder.TestClusterIDValue + "-machine-0").WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(resourcebuilder.TestClusterIDValue + "-master-c").WithNodeName("node-2").Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(resourcebuilder.TestClusterIDValue + "-machine-a").WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					unreadyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithAwaitingRemoval(true).Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", resourcebuilder.TestClusterIDValue + "-machine-0",
+							"machineName", masterMachineName("0"),
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
@@ -950,8 +1118,8 @@ var _ = Describe("MachineProvider", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", resourcebuilder.TestClusterIDValue + "-master-c",
-							"nodeName", "node-2",
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
 							"needsUpdate", false,
@@ -963,10 +1131,10 @@ var _ = Describe("MachineProvider", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", resourcebuilder.TestClusterIDValue + "-machine-a",
-							"nodeName", "node-1",
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
 							"index", int32(2),
-							"ready", true,
+							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
@@ -975,46 +1143,18 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("when the machine names do not fit the pattern, and the failure domains are not recognised, returns an error", getMachineInfosTableInput{
-				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-a").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-1").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1f")).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-master-c").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1d")).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
-				},
-				nodes: []*corev1.Node{
-					masterNodeBuilder.WithName("node-0").Build(),
-					masterNodeBuilder.WithName("node-1").Build(),
-					masterNodeBuilder.WithName("node-2").Build(),
-				},
-				failureDomains: map[int32]failuredomain.FailureDomain{
-					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
-					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
-					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
-				},
-				expectedError:        errCouldNotDetermineMachineIndex,
-				expectedMachineInfos: []machineproviders.MachineInfo{},
-				expectedLogs: []testutils.LogEntry{
-					{
-						Error:   errCouldNotDetermineMachineIndex,
-						Message: "Could not gather Machine Info",
-					},
-				},
-			}),
-			Entry("with Machines that have errored in some way", getMachineInfosTableInput{
+			Entry("with a Machine that was never ready, and has now been deleted", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
-						WithPhase("Failed").WithErrorMessage("Node missing").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
 					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
-						WithPhase("Failed").WithErrorMessage("Cannot create VM").Build(),
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
 					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
-						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+						WithPhase("Deleting").Build(),
 				},
 				nodes: []*corev1.Node{
 					masterNodeBuilder.WithName("node-0").Build(),
-					masterNodeBuilder.WithName("node-2").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
 				},
 				failureDomains: map[int32]failuredomain.FailureDomain{
 					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
@@ -1022,9 +1162,9 @@ var _ = Describe("MachineProvider", func() {
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithReady(false).WithErrorMessage("Node missing").WithNodeName("node-0").Build(),
-					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithReady(false).WithErrorMessage("Cannot create VM").Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					unreadyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithAwaitingRemoval(true).Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
@@ -1033,10 +1173,10 @@ var _ = Describe("MachineProvider", func() {
 							"machineName", masterMachineName("0"),
 							"nodeName", "node-0",
 							"index", int32(0),
-							"ready", false,
+							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
-							"errorMessage", "Node missing",
+							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
@@ -1044,12 +1184,12 @@ var _ = Describe("MachineProvider", func() {
 						Level: 4,
 						KeysAndValues: []interface{}{
 							"machineName", masterMachineName("1"),
-							"nodeName", "",
+							"nodeName", "node-1",
 							"index", int32(1),
-							"ready", false,
+							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
-							"errorMessage", "Cannot create VM",
+							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
@@ -1057,9 +1197,9 @@ var _ = Describe("MachineProvider", func() {
 						Level: 4,
 						KeysAndValues: []interface{}{
 							"machineName", masterMachineName("2"),
-							"nodeName", "node-2",
+							"nodeName", "",
 							"index", int32(2),
-							"ready", true,
+							"ready", false,
 							"needsUpdate", false,
 							"diff", nilDiff,
 							"errorMessage", "",
@@ -1068,13 +1208,13 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with additional Machines, not matched by the selector, ignores them", getMachineInfosTableInput{
+			Entry("with Machines using the random suffix pattern", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("abcde-0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("fghij-1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					machinev1beta1resourcebuilder.Machine().AsWorker().WithNamespace(namespaceName).WithName("worker-abcde").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
 				nodes: []*corev1.Node{
@@ -1088,14 +1228,15 @@ var _ = Describe("MachineProvider", func() {
 					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("abcde-0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("fghij-1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("0"),
+							"machineName", masterMachineName("abcde-0"),
 							"nodeName", "node-0",
 							"index", int32(0),
 							"ready", true,
@@ -1108,7 +1249,7 @@ var _ = Describe("MachineProvider", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("1"),
+							"machineName", masterMachineName("fghij-1"),
 							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
@@ -1118,15 +1259,28 @@ var _ = Describe("MachineProvider", func() {
 						},
 						Message: "Gathered Machine Info",
 					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
 				},
 			}),
-			Entry("with a Machine whose failure domain does not match the mapping, should update the Machine", getMachineInfosTableInput{
+			Entry("with one Machine with a different instance type", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithInstanceType("different").WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
 				nodes: []*corev1.Node{
@@ -1141,14 +1295,9 @@ var _ = Describe("MachineProvider", func() {
 				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
 					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
-					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).
-						WithDiff(
-							[]string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1a",
-								"Placement.AvailabilityZone: us-east-1c != us-east-1a",
-							},
-						).Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").WithNeedsUpdate(true).WithDiff(
+						instanceDiff).Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
 				},
 				expectedLogs: []testutils.LogEntry{
 					{
@@ -1171,8 +1320,8 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-1",
 							"index", int32(1),
 							"ready", true,
-							"needsUpdate", false,
-							"diff", nilDiff,
+							"needsUpdate", true,
+							"diff", instanceDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
@@ -1184,24 +1333,21 @@ var _ = Describe("MachineProvider", func() {
 							"nodeName", "node-2",
 							"index", int32(2),
 							"ready", true,
-							"needsUpdate", true,
-							"diff", []string{
-								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1a",
-								"Placement.AvailabilityZone: us-east-1c != us-east-1a",
-							},
+							"needsUpdate", false,
+							"diff", nilDiff,
 							"errorMessage", "",
 						},
 						Message: "Gathered Machine Info",
 					},
 				},
 			}),
-			Entry("with no failure domain mapping and all Machines are in the correct availability zone", getMachineInfosTableInput{
+			Entry("with one Machine with an equivalent instance type", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
 					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithInstanceType("m5.xlarge").WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
 				nodes: []*corev1.Node{
@@ -1209,7 +1355,14 @@ var _ = Describe("MachineProvider", func() {
 					masterNodeBuilder.WithName("node-1").Build(),
 					masterNodeBuilder.WithName("node-2").Build(),
 				},
-				failureDomains: map[int32]failuredomain.FailureDomain{},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				instanceTypes: map[string]string{
+					"m5.xlarge": "m6i.xlarge",
+				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
 					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
 					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
@@ -1257,13 +1410,13 @@ var _ = Describe("MachineProvider", func() {
 					},
 				},
 			}),
-			Entry("with no failure domain mapping and not all Machines are in the correct availability zone", getMachineInfosTableInput{
+			Entry("with one Machine with an unknown failure domain", getMachineInfosTableInput{
 				machines: []*machinev1beta1.Machine{
-					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1d")).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
-					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1aSubnetbeta1)).
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
 						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
 				},
 				nodes: []*corev1.Node{
@@ -1271,10 +1424,757 @@ var _ = Describe("MachineProvider", func() {
 					masterNodeBuilder.WithName("node-1").Build(),
 					masterNodeBuilder.WithName("node-2").Build(),
 				},
-				failureDomains: map[int32]failuredomain.FailureDomain{},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
 				expectedMachineInfos: []machineproviders.MachineInfo{
-					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
-					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithDiff(
+							[]string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
+								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
+							},
+						).WithNeedsUpdate(true).Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
+								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with one Machine in a failure domain that is not defined on the control plane machine set", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1d")).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				configuredFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").
+						WithDiff(
+							[]string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
+								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
+							},
+						).WithNeedsUpdate(true).WithUnmatchedFailureDomain(usEast1dFailureDomain.String()).Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != aws-subnet-12345678",
+								"Placement.AvailabilityZone: us-east-1a != us-east-1d",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with multiple Machines in an index in different states", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithInstanceType("different")).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("abcde-2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-replacement-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+					masterNodeBuilder.WithName("node-replacement-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).
+						WithDiff([]string{"InstanceType: m6i.xlarge != different", "Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != aws-subnet-12345678"}).Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("abcde-2")).WithNodeName("node-replacement-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"InstanceType: m6i.xlarge != different",
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != aws-subnet-12345678",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("abcde-2"),
+							"nodeName", "node-replacement-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("when the failure domain mapping does not match, the mapping takes precedence for indexing", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					// The failure domain mapping logic is trusted as the source of truth for the failure domain.
+					// It is responsible for mapping the machine indexes to failure domains.
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").WithNeedsUpdate(true).WithDiff(
+						[]string{
+							"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1b != subnet-us-east-1a",
+							"Placement.AvailabilityZone: us-east-1b != us-east-1a",
+						},
+					).Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").WithNeedsUpdate(true).WithDiff(
+						[]string{
+							"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1b",
+							"Placement.AvailabilityZone: us-east-1c != us-east-1b",
+						},
+					).Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).WithDiff(
+						[]string{
+							"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != subnet-us-east-1c",
+							"Placement.AvailabilityZone: us-east-1a != us-east-1c",
+						},
+					).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1b != subnet-us-east-1a",
+								"Placement.AvailabilityZone: us-east-1b != us-east-1a",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1b",
+								"Placement.AvailabilityZone: us-east-1c != us-east-1b",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1a != subnet-us-east-1c",
+								"Placement.AvailabilityZone: us-east-1a != us-east-1c",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("when the machine names do not fit the pattern, fall back to matching on failure domains", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-a").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-0").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-master-c").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(resourcebuilder.TestClusterIDValue + "-machine-0").WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(resourcebuilder.TestClusterIDValue + "-master-c").WithNodeName("node-2").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(resourcebuilder.TestClusterIDValue + "-machine-a").WithNodeName("node-1").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", resourcebuilder.TestClusterIDValue + "-machine-0",
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", resourcebuilder.TestClusterIDValue + "-master-c",
+							"nodeName", "node-2",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", resourcebuilder.TestClusterIDValue + "-machine-a",
+							"nodeName", "node-1",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("when the machine names do not fit the pattern, and the failure domains are not recognised, returns an error", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-a").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-machine-1").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1f")).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(resourcebuilder.TestClusterIDValue + "-master-c").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1d")).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+				},
+				expectedError:        errCouldNotDetermineMachineIndex,
+				expectedMachineInfos: []machineproviders.MachineInfo{},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Error:   errCouldNotDetermineMachineIndex,
+						Message: "Could not gather Machine Info",
+					},
+				},
+			}),
+			Entry("with Machines that have errored in some way", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Failed").WithErrorMessage("Node missing").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Failed").WithErrorMessage("Cannot create VM").Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithReady(false).WithErrorMessage("Node missing").WithNodeName("node-0").Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithReady(false).WithErrorMessage("Cannot create VM").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", false,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "Node missing",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "",
+							"index", int32(1),
+							"ready", false,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "Cannot create VM",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with a Failed Machine carrying a provider error reason", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Failed").WithErrorMessage("Node missing").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					withErrorReason(masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Failed").WithErrorMessage("Quota exceeded for instance type m6i.xlarge").Build(), machinev1beta1.InsufficientResourcesMachineError),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					unreadyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithReady(false).WithErrorMessage("Node missing").WithNodeName("node-0").Build(),
+					unreadyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithReady(false).WithErrorReason("InsufficientResources").WithErrorMessage("Quota exceeded for instance type m6i.xlarge").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", false,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "Node missing",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "",
+							"index", int32(1),
+							"ready", false,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "Quota exceeded for instance type m6i.xlarge",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with additional Machines, not matched by the selector, ignores them", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					machinev1beta1resourcebuilder.Machine().AsWorker().WithNamespace(namespaceName).WithName("worker-abcde").WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with a Machine whose failure domain does not match the mapping, should update the Machine", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{
+					0: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnet).Build()),
+					1: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnet).Build()),
+					2: failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnet).Build()),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).
+						WithDiff(
+							[]string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1a",
+								"Placement.AvailabilityZone: us-east-1c != us-east-1a",
+							},
+						).Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", true,
+							"diff", []string{
+								"Subnet.Filters.slice[0].Values.slice[0]: subnet-us-east-1c != subnet-us-east-1a",
+								"Placement.AvailabilityZone: us-east-1c != us-east-1a",
+							},
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with no failure domain mapping and all Machines are in the correct availability zone", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("1"),
+							"nodeName", "node-1",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("2"),
+							"nodeName", "node-2",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with no failure domain mapping and not all Machines are in the correct availability zone", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("1")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				failureDomains: map[int32]failuredomain.FailureDomain{},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("0")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("1")).WithNodeName("node-1").Build(),
 					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("2")).WithNodeName("node-2").WithNeedsUpdate(true).
 						WithDiff([]string{"Placement.AvailabilityZone: us-east-1a != us-east-1b"}).Build(),
 				},
@@ -1440,9 +2340,164 @@ var _ = Describe("MachineProvider", func() {
 					{
 						Level: 4,
 						KeysAndValues: []interface{}{
-							"machineName", masterMachineName("4"),
-							"nodeName", "node-4",
-							"index", int32(4),
+							"machineName", masterMachineName("4"),
+							"nodeName", "node-4",
+							"index", int32(4),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with unlabeled Machines that cannot be indexed by name and no failure domains, adopts them in order of creation", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("c")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-c"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("a")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-a"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("b")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-b"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-a").Build(),
+					masterNodeBuilder.WithName("node-b").Build(),
+					masterNodeBuilder.WithName("node-c").Build(),
+				},
+				// The Machines are created within the same second, so they are adopted in name order.
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("a")).WithNodeName("node-a").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("b")).WithNodeName("node-b").Build(),
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("c")).WithNodeName("node-c").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("a"),
+							"index", int32(0),
+						},
+						Message: "Adopting machine into free index",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("b"),
+							"index", int32(1),
+						},
+						Message: "Adopting machine into free index",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("c"),
+							"index", int32(2),
+						},
+						Message: "Adopting machine into free index",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("a"),
+							"nodeName", "node-a",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("b"),
+							"nodeName", "node-b",
+							"index", int32(1),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("c"),
+							"nodeName", "node-c",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+				},
+			}),
+			Entry("with Machines that have an index label, the label takes precedence over the name", getMachineInfosTableInput{
+				machines: []*machinev1beta1.Machine{
+					masterMachineBuilder.WithName(masterMachineName("0")).WithLabels(indexedMasterLabels("2")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("a")).WithLabels(indexedMasterLabels("0")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-1"}).Build(),
+					masterMachineBuilder.WithName(masterMachineName("b")).WithProviderSpecBuilder(providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)).
+						WithPhase("Running").WithNodeRef(corev1.ObjectReference{Name: "node-2"}).Build(),
+				},
+				nodes: []*corev1.Node{
+					masterNodeBuilder.WithName("node-0").Build(),
+					masterNodeBuilder.WithName("node-1").Build(),
+					masterNodeBuilder.WithName("node-2").Build(),
+				},
+				expectedMachineInfos: []machineproviders.MachineInfo{
+					readyMachineInfoBuilder.WithIndex(2).WithMachineName(masterMachineName("0")).WithMachineLabels(indexedMasterLabels("2")).WithNodeName("node-0").Build(),
+					readyMachineInfoBuilder.WithIndex(0).WithMachineName(masterMachineName("a")).WithMachineLabels(indexedMasterLabels("0")).WithNodeName("node-1").Build(),
+					readyMachineInfoBuilder.WithIndex(1).WithMachineName(masterMachineName("b")).WithNodeName("node-2").Build(),
+				},
+				expectedLogs: []testutils.LogEntry{
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("b"),
+							"index", int32(1),
+						},
+						Message: "Adopting machine into free index",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("0"),
+							"nodeName", "node-0",
+							"index", int32(2),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("a"),
+							"nodeName", "node-1",
+							"index", int32(0),
+							"ready", true,
+							"needsUpdate", false,
+							"diff", nilDiff,
+							"errorMessage", "",
+						},
+						Message: "Gathered Machine Info",
+					},
+					{
+						Level: 4,
+						KeysAndValues: []interface{}{
+							"machineName", masterMachineName("b"),
+							"nodeName", "node-2",
+							"index", int32(1),
 							"ready", true,
 							"needsUpdate", false,
 							"diff", nilDiff,
@@ -1453,6 +2508,128 @@ var _ = Describe("MachineProvider", func() {
 				},
 			}),
 		)
+
+		Context("when the provider spec of a machine is edited in place", func() {
+			var provider *openshiftMachineProvider
+			var machine *machinev1beta1.Machine
+
+			providerSpec := providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)
+
+			BeforeEach(func() {
+				cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+
+				template := machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+					WithProviderSpecBuilder(providerSpec).
+					WithLabel(machinev1beta1.MachineClusterIDLabel, resourcebuilder.TestClusterIDValue).
+					BuildTemplate().OpenShiftMachineV1Beta1Machine
+				Expect(template).ToNot(BeNil())
+
+				providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(logger.Logger(), *template)
+				Expect(err).ToNot(HaveOccurred())
+
+				provider = &openshiftMachineProvider{
+					client:                  k8sClient,
+					machineSelector:         cpms.Spec.Selector,
+					machineTemplate:         *template,
+					providerConfig:          providerConfig,
+					namespace:               namespaceName,
+					instanceTypeEquivalence: providerconfig.NewInstanceTypeEquivalence(nil),
+				}
+
+				machine = masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpec).Build()
+				Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+			})
+
+			It("does not need an update before the edit", func() {
+				machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(machineInfos).To(ConsistOf(HaveField("NeedsUpdate", BeFalse())))
+			})
+
+			It("needs an update once the instance type of the live machine has been changed", func() {
+				Eventually(komega.Update(machine, func() {
+					machine.Spec.ProviderSpec.Value = providerSpec.WithInstanceType("m6i.2xlarge").BuildRawExtension()
+				})).Should(Succeed())
+
+				machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(machineInfos).To(ConsistOf(SatisfyAll(
+					HaveField("NeedsUpdate", BeTrue()),
+					HaveField("Diff", ConsistOf("InstanceType: m6i.xlarge != m6i.2xlarge")),
+				)))
+			})
+		})
+
+		Context("with an AMI filter template and a machine with a concrete AMI ID", func() {
+			var provider *openshiftMachineProvider
+			var machine *machinev1beta1.Machine
+
+			providerSpec := providerSpecBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)
+
+			BeforeEach(func() {
+				cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+
+				template := machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+					WithProviderSpecBuilder(providerSpec).
+					WithLabel(machinev1beta1.MachineClusterIDLabel, resourcebuilder.TestClusterIDValue).
+					BuildTemplate().OpenShiftMachineV1Beta1Machine
+				Expect(template).ToNot(BeNil())
+
+				template.Spec.ProviderSpec.Value = awsProviderSpecWithAMI(providerSpec, machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{{Name: "name", Values: []string{"rhcos-413.*"}}},
+				})
+
+				providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(logger.Logger(), *template)
+				Expect(err).ToNot(HaveOccurred())
+
+				provider = &openshiftMachineProvider{
+					client:                  k8sClient,
+					machineSelector:         cpms.Spec.Selector,
+					machineTemplate:         *template,
+					providerConfig:          providerConfig,
+					namespace:               namespaceName,
+					instanceTypeEquivalence: providerconfig.NewInstanceTypeEquivalence(nil),
+				}
+
+				machine = masterMachineBuilder.WithName(masterMachineName("0")).WithProviderSpecBuilder(providerSpec).Build()
+				machine.Spec.ProviderSpec.Value = awsProviderSpecWithAMI(providerSpec, machinev1beta1.AWSResourceReference{ID: pointer.String("ami-0123456789")})
+			})
+
+			It("needs an update when the AMI ID was not resolved from the filters", func() {
+				Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+
+				machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(machineInfos).To(ConsistOf(HaveField("NeedsUpdate", BeTrue())))
+			})
+
+			It("does not need an update when the AMI ID was resolved from the filters", func() {
+				machine.SetAnnotations(map[string]string{
+					machineproviders.MachineImageReferenceAnnotation: `[{"name":"name","values":["rhcos-413.*"]}]`,
+				})
+				Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+
+				machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(machineInfos).To(ConsistOf(HaveField("NeedsUpdate", BeFalse())))
+			})
+
+			It("needs an update when the AMI ID was resolved from different filters", func() {
+				machine.SetAnnotations(map[string]string{
+					machineproviders.MachineImageReferenceAnnotation: `[{"name":"name","values":["rhcos-412.*"]}]`,
+				})
+				Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+
+				machineInfos, err := provider.GetMachineInfos(ctx, logger.Logger())
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(machineInfos).To(ConsistOf(HaveField("NeedsUpdate", BeTrue())))
+			})
+		})
 	})
 
 	Context("CreateMachine", func() {
@@ -1468,10 +2645,11 @@ var _ = Describe("MachineProvider", func() {
 				// on the Machine state into separate containers.
 
 				var err error
+				var machineName string
 				var machine machinev1beta1.Machine
 
 				BeforeAll(func() {
-					err = provider.CreateMachine(ctx, logger.Logger(), index)
+					machineName, err = provider.CreateMachine(ctx, logger.Logger(), index)
 				})
 
 				It("should not error", func() {
@@ -1497,16 +2675,40 @@ var _ = Describe("MachineProvider", func() {
 						}
 					})
 
+					It("with the name that was returned", func() {
+						Expect(machineName).To(Equal(machine.Name))
+					})
+
 					It("with the labels from the Machine template", func() {
-						Expect(machine.Labels).To(Equal(
-							template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels,
-						))
+						for k, v := range template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels {
+							Expect(machine.Labels).To(HaveKeyWithValue(k, v))
+						}
+					})
+
+					It("with the index label", func() {
+						Expect(machine.Labels).To(HaveKeyWithValue(machineproviders.MachineIndexLabel, fmt.Sprintf("%d", index)))
+					})
+
+					It("with the owner label", func() {
+						Expect(machine.Labels).To(HaveKeyWithValue(machineproviders.MachineOwnerLabel, ownerName))
+						Expect(machine.Labels).To(HaveLen(len(template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Labels) + 2))
 					})
 
 					It("with annotations from the Machine template", func() {
-						Expect(machine.Annotations).To(Equal(
-							template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Annotations,
-						))
+						for k, v := range template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Annotations {
+							Expect(machine.Annotations).To(HaveKeyWithValue(k, v))
+						}
+
+						Expect(machine.Annotations).To(HaveLen(len(template.OpenShiftMachineV1Beta1Machine.ObjectMeta.Annotations) + 2))
+					})
+
+					It("with the generation the machine was created for", func() {
+						Expect(machine.Annotations).To(HaveKeyWithValue(machineproviders.MachineGenerationAnnotation, "3"))
+					})
+
+					It("with the failure domain the machine was assigned", func() {
+						Expect(machine.Annotations).To(HaveKeyWithValue(machineproviders.MachineFailureDomainAnnotation,
+							fmt.Sprintf("AWSFailureDomain{AvailabilityZone:%s, Subnet:{Type:Filters, Value:&[{Name:tag:Name Values:[subnet-%s]}]}}", failureDomain, failureDomain)))
 					})
 
 					It("with the correct owner reference", func() {
@@ -1570,8 +2772,9 @@ var _ = Describe("MachineProvider", func() {
 					machineSelector: machinev1resourcebuilder.ControlPlaneMachineSet().Build().Spec.Selector,
 					machineTemplate: *template.OpenShiftMachineV1Beta1Machine,
 					ownerMetadata: metav1.ObjectMeta{
-						Name: ownerName,
-						UID:  ownerUID,
+						Name:       ownerName,
+						UID:        ownerUID,
+						Generation: 3,
 					},
 					providerConfig:   providerConfig,
 					namespace:        namespaceName,
@@ -1583,6 +2786,36 @@ var _ = Describe("MachineProvider", func() {
 			assertCreatesMachine(1, providerConfigBuilder.WithAvailabilityZone("us-east-1b").WithSubnet(usEast1bSubnetbeta1), "cpms-aws-cluster-id", "us-east-1b")
 			assertCreatesMachine(2, providerConfigBuilder.WithAvailabilityZone("us-east-1c").WithSubnet(usEast1cSubnetbeta1), "cpms-aws-cluster-id", "us-east-1c")
 
+			Context("with an AMI filter template", func() {
+				var machine *machinev1beta1.Machine
+
+				BeforeEach(func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					filterProviderConfig, err := providerconfig.NewProviderConfigFromMachineSpec(logger.Logger(), machinev1beta1.MachineSpec{
+						ProviderSpec: machinev1beta1.ProviderSpec{
+							Value: awsProviderSpecWithAMI(providerConfigBuilder, machinev1beta1.AWSResourceReference{
+								Filters: []machinev1beta1.Filter{{Name: "name", Values: []string{"rhcos-413.*"}}},
+							}),
+						},
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					p.providerConfig = filterProviderConfig
+
+					machineName, err := provider.CreateMachine(ctx, logger.Logger(), 0)
+					Expect(err).ToNot(HaveOccurred())
+
+					machine = &machinev1beta1.Machine{}
+					Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: namespaceName, Name: machineName}, machine)).To(Succeed())
+				})
+
+				It("records the image reference the AMI is resolved from", func() {
+					Expect(machine.Annotations).To(HaveKeyWithValue(machineproviders.MachineImageReferenceAnnotation, `[{"name":"name","values":["rhcos-413.*"]}]`))
+				})
+			})
+
 			Context("if the Machine template is missing the cluster ID label", func() {
 				var err error
 
@@ -1592,7 +2825,7 @@ var _ = Describe("MachineProvider", func() {
 
 					delete(p.machineTemplate.ObjectMeta.Labels, machinev1beta1.MachineClusterIDLabel)
 
-					err = provider.CreateMachine(ctx, logger.Logger(), 0)
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 0)
 				})
 
 				It("returns an error", func() {
@@ -1613,7 +2846,7 @@ var _ = Describe("MachineProvider", func() {
 
 					delete(p.machineTemplate.ObjectMeta.Labels, openshiftMachineRoleLabel)
 
-					err = provider.CreateMachine(ctx, logger.Logger(), 0)
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 0)
 				})
 
 				It("returns an error", func() {
@@ -1625,6 +2858,142 @@ var _ = Describe("MachineProvider", func() {
 				})
 			})
 
+			Context("with a custom machine name prefix", func() {
+				var err error
+
+				BeforeEach(func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					p.ownerMetadata.Annotations = map[string]string{machineproviders.MachineNamePrefixAnnotation: "prod-control-plane"}
+
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 1)
+				})
+
+				It("should not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("creates a machine named with the prefix and labelled with its index", func() {
+					Eventually(komega.ObjectList(&machinev1beta1.MachineList{}, client.InNamespace(namespaceName))).Should(HaveField("Items", ConsistOf(SatisfyAll(
+						HaveField("ObjectMeta.Name", MatchRegexp("^prod-control-plane-[a-z0-9]{5}-1$")),
+						HaveField("ObjectMeta.Labels", HaveKeyWithValue(machineproviders.MachineIndexLabel, "1")),
+					))))
+				})
+			})
+
+			Context("with an invalid machine name prefix", func() {
+				var err error
+
+				BeforeEach(func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					p.ownerMetadata.Annotations = map[string]string{machineproviders.MachineNamePrefixAnnotation: "Prod_Control_Plane"}
+
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 0)
+				})
+
+				It("returns an error", func() {
+					Expect(err).To(MatchError(errInvalidMachineNamePrefix))
+				})
+
+				It("does not create any Machines", func() {
+					Consistently(komega.ObjectList(&machinev1beta1.MachineList{})).Should(HaveField("Items", BeEmpty()))
+				})
+			})
+
+			Context("with a provider ID in the Machine template", func() {
+				var err error
+
+				BeforeEach(func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					p.machineTemplate.Spec.ProviderID = pointer.String("aws:///us-east-1a/i-0123456789abcdef0")
+
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 0)
+				})
+
+				It("should not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("creates a machine without the provider ID", func() {
+					Eventually(komega.ObjectList(&machinev1beta1.MachineList{}, client.InNamespace(namespaceName))).Should(HaveField("Items", ConsistOf(
+						HaveField("Spec.ProviderID", BeNil()),
+					)))
+				})
+
+				It("does not modify the Machine template", func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					Expect(p.machineTemplate.Spec.ProviderID).To(Equal(pointer.String("aws:///us-east-1a/i-0123456789abcdef0")))
+				})
+			})
+
+			Context("with machine owner references", func() {
+				var err error
+
+				BeforeEach(func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					p.ownerMetadata.Annotations = map[string]string{
+						machineproviders.MachineOwnerReferencesAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"backup-policy","uid":"backup-policy-uid","controller":true}]`,
+					}
+
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 1)
+				})
+
+				It("should not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("creates a machine owned by the referenced owner and controlled by the ControlPlaneMachineSet", func() {
+					Eventually(komega.ObjectList(&machinev1beta1.MachineList{}, client.InNamespace(namespaceName))).Should(HaveField("Items", ConsistOf(
+						HaveField("ObjectMeta.OwnerReferences", ConsistOf(
+							metav1.OwnerReference{
+								APIVersion: "v1",
+								Kind:       "ConfigMap",
+								Name:       "backup-policy",
+								UID:        "backup-policy-uid",
+							},
+							metav1.OwnerReference{
+								APIVersion:         machinev1.GroupVersion.String(),
+								Kind:               "ControlPlaneMachineSet",
+								Name:               ownerName,
+								UID:                ownerUID,
+								Controller:         pointer.Bool(true),
+								BlockOwnerDeletion: pointer.Bool(true),
+							},
+						)),
+					)))
+				})
+			})
+
+			Context("with invalid machine owner references", func() {
+				var err error
+
+				BeforeEach(func() {
+					p, ok := provider.(*openshiftMachineProvider)
+					Expect(ok).To(BeTrue())
+
+					p.ownerMetadata.Annotations = map[string]string{machineproviders.MachineOwnerReferencesAnnotation: "backup-policy"}
+
+					_, err = provider.CreateMachine(ctx, logger.Logger(), 0)
+				})
+
+				It("returns an error", func() {
+					Expect(err).To(MatchError(errInvalidMachineOwnerReferences))
+				})
+
+				It("does not create any Machines", func() {
+					Consistently(komega.ObjectList(&machinev1beta1.MachineList{})).Should(HaveField("Items", BeEmpty()))
+				})
+			})
+
 			Context("if the MachineProvider has no failure domains configure", func() {
 				usEast1aBuilder := providerConfigBuilder.WithAvailabilityZone("us-east-1a").WithSubnet(usEast1aSubnetbeta1)
 
@@ -1652,6 +3021,61 @@ var _ = Describe("MachineProvider", func() {
 
 	})
 
+	Context("CheckReferencedSecrets", func() {
+		var provider machineproviders.MachineProvider
+
+		BeforeEach(func() {
+			template := machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+				WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec()).
+				BuildTemplate()
+
+			providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(logger.Logger(), *template.OpenShiftMachineV1Beta1Machine)
+			Expect(err).ToNot(HaveOccurred())
+
+			provider = &openshiftMachineProvider{
+				client:          k8sClient,
+				machineTemplate: *template.OpenShiftMachineV1Beta1Machine,
+				providerConfig:  providerConfig,
+				namespace:       namespaceName,
+			}
+
+			By("Creating the credentials secret")
+			Expect(k8sClient.Create(ctx, corev1resourcebuilder.Secret().WithName("aws-cloud-credentials").WithNamespace(namespaceName).Build())).To(Succeed())
+		})
+
+		AfterEach(func() {
+			testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+				&corev1.Secret{},
+			)
+		})
+
+		checkReferencedSecrets := func() error {
+			secretChecker, ok := provider.(machineproviders.SecretChecker)
+			Expect(ok).To(BeTrue(), "The provider should implement the referenced secrets preflight")
+
+			return secretChecker.CheckReferencedSecrets(ctx, logger.Logger())
+		}
+
+		Context("with a missing user data secret", func() {
+			It("returns a missing referenced secret error", func() {
+				err := checkReferencedSecrets()
+
+				Expect(err).To(MatchError(machineproviders.ErrMissingReferencedSecret))
+				Expect(err).To(MatchError(fmt.Sprintf("missing referenced secret: secret %s/aws-user-data-12345678 does not exist", namespaceName)))
+			})
+		})
+
+		Context("with all referenced secrets present", func() {
+			BeforeEach(func() {
+				Expect(k8sClient.Create(ctx, corev1resourcebuilder.Secret().WithName("aws-user-data-12345678").WithNamespace(namespaceName).Build())).To(Succeed())
+			})
+
+			It("does not error", func() {
+				Expect(checkReferencedSecrets()).To(Succeed())
+			})
+		})
+	})
+
 	Context("DeleteMachine", func() {
 		var machineName string
 		var machineRef *machineproviders.ObjectRef
@@ -1738,6 +3162,50 @@ var _ = Describe("MachineProvider", func() {
 				})
 			})
 
+			Context("with an existing machine with a pre-terminate lifecycle hook", func() {
+				var err error
+				var hookedMachineName string
+
+				preTerminateHook := machinev1beta1.LifecycleHook{
+					Name:  "BlockTermination",
+					Owner: "test-hook-owner",
+				}
+
+				BeforeEach(func() {
+					machine := machinev1beta1resourcebuilder.Machine().AsMaster().
+						WithGenerateName("control-plane-machine-").
+						WithNamespace(namespaceName).
+						Build()
+					// The finalizer stands in for the Machine controller, which holds the Machine
+					// until its lifecycle hooks are removed.
+					machine.SetFinalizers([]string{machinev1beta1.MachineFinalizer})
+					machine.Spec.LifecycleHooks.PreTerminate = []machinev1beta1.LifecycleHook{preTerminateHook}
+					Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+					hookedMachineName = machine.Name
+
+					machineRef.ObjectMeta.Name = hookedMachineName
+					machineRef.ObjectMeta.Namespace = namespaceName
+
+					err = machineProvider.DeleteMachine(ctx, logger.Logger(), machineRef)
+				})
+
+				It("does not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("marks the Machine for deletion without removing the lifecycle hook", func() {
+					machine := machinev1beta1resourcebuilder.Machine().
+						WithNamespace(namespaceName).
+						WithName(hookedMachineName).
+						Build()
+
+					Consistently(komega.Object(machine)).Should(SatisfyAll(
+						HaveField("ObjectMeta.DeletionTimestamp", Not(BeNil())),
+						HaveField("Spec.LifecycleHooks.PreTerminate", ConsistOf(preTerminateHook)),
+					))
+				})
+			})
+
 			Context("with an non-existent machine", func() {
 				var err error
 				const unknown = "unknown"
@@ -1812,3 +3280,129 @@ var _ = Describe("MachineProvider", func() {
 		})
 	})
 })
+
+// awsProviderSpecWithAMI returns the raw AWS provider spec built by the builder, with the AMI reference provided.
+func awsProviderSpecWithAMI(builder machinev1beta1resourcebuilder.AWSProviderSpecBuilder, ami machinev1beta1.AWSResourceReference) *runtime.RawExtension {
+	spec := builder.Build()
+	spec.AMI = ami
+
+	raw, err := json.Marshal(spec)
+	Expect(err).ToNot(HaveOccurred())
+
+	return &runtime.RawExtension{Raw: raw}
+}
+
+var _ = Describe("diffTaints", func() {
+	noScheduleTaint := corev1.Taint{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule}
+	dedicatedTaint := corev1.Taint{Key: "dedicated", Value: "control-plane", Effect: corev1.TaintEffectNoExecute}
+
+	DescribeTable("should compare the taints regardless of their ordering", func(desired, current []corev1.Taint, expectedDiff []string) {
+		Expect(diffTaints(desired, current)).To(Equal(expectedDiff))
+	},
+		Entry("with no taints", nil, nil, []string(nil)),
+		Entry("with matching taints", []corev1.Taint{noScheduleTaint}, []corev1.Taint{noScheduleTaint}, []string(nil)),
+		Entry("with a taint added to the template", []corev1.Taint{noScheduleTaint, dedicatedTaint}, []corev1.Taint{noScheduleTaint},
+			[]string{"Spec.Taints: [dedicated=control-plane:NoExecute, node-role.kubernetes.io/master:NoSchedule] != [node-role.kubernetes.io/master:NoSchedule]"},
+		),
+		Entry("with a taint removed from the template", []corev1.Taint{noScheduleTaint}, []corev1.Taint{noScheduleTaint, dedicatedTaint},
+			[]string{"Spec.Taints: [node-role.kubernetes.io/master:NoSchedule] != [dedicated=control-plane:NoExecute, node-role.kubernetes.io/master:NoSchedule]"},
+		),
+		Entry("with the taints reordered", []corev1.Taint{dedicatedTaint, noScheduleTaint}, []corev1.Taint{noScheduleTaint, dedicatedTaint}, []string(nil)),
+		Entry("with a taint that only differs in the time it was added", []corev1.Taint{noScheduleTaint},
+			[]corev1.Taint{{Key: noScheduleTaint.Key, Effect: noScheduleTaint.Effect, TimeAdded: &metav1.Time{Time: time.Date(2023, time.June, 1, 12, 0, 0, 0, time.UTC)}}},
+			[]string(nil),
+		),
+		Entry("with a taint whose effect has changed", []corev1.Taint{{Key: noScheduleTaint.Key, Effect: corev1.TaintEffectPreferNoSchedule}}, []corev1.Taint{noScheduleTaint},
+			[]string{"Spec.Taints: [node-role.kubernetes.io/master:PreferNoSchedule] != [node-role.kubernetes.io/master:NoSchedule]"},
+		),
+	)
+})
+
+var _ = Describe("getDesiredNodeVersion", func() {
+	type getDesiredNodeVersionTableInput struct {
+		annotations     map[string]string
+		expectedVersion *desiredNodeVersion
+		expectedLogs    []testutils.LogEntry
+	}
+
+	DescribeTable("should parse the desired node version annotation", func(in getDesiredNodeVersionTableInput) {
+		logger := testutils.NewTestLogger()
+
+		cpms := machinev1resourcebuilder.ControlPlaneMachineSet().Build()
+		cpms.SetAnnotations(in.annotations)
+
+		Expect(getDesiredNodeVersion(logger.Logger(), cpms)).To(Equal(in.expectedVersion))
+		Expect(logger.Entries()).To(ConsistOf(in.expectedLogs))
+	},
+		Entry("when the annotation is not set", getDesiredNodeVersionTableInput{}),
+		Entry("with a label and version", getDesiredNodeVersionTableInput{
+			annotations:     map[string]string{machineproviders.DesiredNodeVersionAnnotation: "feature.node.kubernetes.io/kernel-version.full=5.14.0-284.el9"},
+			expectedVersion: &desiredNodeVersion{label: "feature.node.kubernetes.io/kernel-version.full", version: "5.14.0-284.el9"},
+		}),
+		Entry("without a version", getDesiredNodeVersionTableInput{
+			annotations: map[string]string{machineproviders.DesiredNodeVersionAnnotation: "feature.node.kubernetes.io/kernel-version.full"},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error:         fmt.Errorf("%w: %q", errInvalidDesiredNodeVersion, "feature.node.kubernetes.io/kernel-version.full"),
+					KeysAndValues: []interface{}{"annotation", machineproviders.DesiredNodeVersionAnnotation},
+					Message:       "Ignoring invalid desired node version",
+				},
+			},
+		}),
+		Entry("with an invalid label", getDesiredNodeVersionTableInput{
+			annotations: map[string]string{machineproviders.DesiredNodeVersionAnnotation: "kernel version=5.14.0-284.el9"},
+			expectedLogs: []testutils.LogEntry{
+				{
+					Error:         fmt.Errorf("%w: %q", errInvalidDesiredNodeVersion, "kernel version=5.14.0-284.el9"),
+					KeysAndValues: []interface{}{"annotation", machineproviders.DesiredNodeVersionAnnotation},
+					Message:       "Ignoring invalid desired node version",
+				},
+			},
+		}),
+	)
+})
+
+var _ = Describe("isNodePending", func() {
+	lastUpdated := time.Date(2023, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	machineWithNodeRef := func(lastUpdated *metav1.Time) machinev1beta1.Machine {
+		machine := machinev1beta1resourcebuilder.Machine().WithNodeRef(corev1.ObjectReference{Name: "node-0"}).Build()
+		machine.Status.LastUpdated = lastUpdated
+
+		return *machine
+	}
+
+	type isNodePendingTableInput struct {
+		machine         machinev1beta1.Machine
+		node            *corev1.Node
+		now             time.Time
+		expectedPending bool
+	}
+
+	DescribeTable("should only consider a missing node pending within the grace period", func(in isNodePendingTableInput) {
+		Expect(isNodePending(in.machine, in.node, in.now)).To(Equal(in.expectedPending))
+	},
+		Entry("when the machine has no node reference", isNodePendingTableInput{
+			machine: *machinev1beta1resourcebuilder.Machine().Build(),
+			now:     lastUpdated,
+		}),
+		Entry("when the node exists", isNodePendingTableInput{
+			machine: machineWithNodeRef(&metav1.Time{Time: lastUpdated}),
+			node:    corev1resourcebuilder.Node().WithName("node-0").Build(),
+			now:     lastUpdated.Add(time.Minute),
+		}),
+		Entry("when the node is missing and the machine was recently updated", isNodePendingTableInput{
+			machine:         machineWithNodeRef(&metav1.Time{Time: lastUpdated}),
+			now:             lastUpdated.Add(nodeNotFoundGracePeriod - time.Second),
+			expectedPending: true,
+		}),
+		Entry("when the node is missing and the grace period has passed", isNodePendingTableInput{
+			machine: machineWithNodeRef(&metav1.Time{Time: lastUpdated}),
+			now:     lastUpdated.Add(nodeNotFoundGracePeriod),
+		}),
+		Entry("when the node is missing and the machine status has never been updated", isNodePendingTableInput{
+			machine: machineWithNodeRef(nil),
+			now:     lastUpdated,
+		}),
+	)
+})
@@ -17,13 +17,24 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// awsMarketTypeOnDemand is the market type of instances purchased On-Demand.
+	awsMarketTypeOnDemand = "OnDemand"
+
+	// awsMarketTypeSpot is the market type of instances purchased as Spot instances.
+	awsMarketTypeSpot = "Spot"
 )
 
 // AWSProviderConfig holds the provider spec of an AWS Machine.
@@ -31,6 +42,44 @@ import (
 // as well as gathering the stored config.
 type AWSProviderConfig struct {
 	providerConfig machinev1beta1.AWSMachineProviderConfig
+
+	// capacityOptions holds the capacity options that are not yet part of the vendored AWSMachineProviderConfig.
+	capacityOptions awsCapacityOptions
+
+	// placementOptions holds the placement group options that are not yet part of the vendored AWSMachineProviderConfig.
+	placementOptions awsPlacementOptions
+
+	// resolvedImageReference is the AMI reference from which the AMI of the Machine was resolved, as recorded when
+	// the Machine was created, if any.
+	resolvedImageReference string
+}
+
+// awsCapacityOptions are the options of an AWS provider spec that determine the capacity from which the instance
+// is launched. They are not yet part of the vendored AWSMachineProviderConfig, so are decoded from and encoded into
+// the raw provider spec alongside it, to preserve them when Machines are created.
+type awsCapacityOptions struct {
+	// CapacityReservationID is the ID of the capacity reservation in which the instance is launched.
+	CapacityReservationID string `json:"capacityReservationId,omitempty"`
+
+	// MarketType is the market from which the instance is purchased, such as OnDemand, Spot or CapacityBlock.
+	MarketType string `json:"marketType,omitempty"`
+}
+
+// awsPlacementOptions are the options of an AWS provider spec that determine the placement group in which the
+// instance is launched. Like the capacity options, they are not yet part of the vendored AWSMachineProviderConfig.
+type awsPlacementOptions struct {
+	// PlacementGroupName is the name of the placement group in which the instance is launched.
+	PlacementGroupName string `json:"placementGroupName,omitempty"`
+
+	// PlacementGroupPartition is the partition of a partition placement group in which the instance is launched.
+	PlacementGroupPartition int32 `json:"placementGroupPartition,omitempty"`
+}
+
+// awsProviderSpec is the AWS provider spec as it is stored on Machines.
+type awsProviderSpec struct {
+	machinev1beta1.AWSMachineProviderConfig `json:",inline"`
+	awsCapacityOptions                      `json:",inline"`
+	awsPlacementOptions                     `json:",inline"`
 }
 
 // InjectFailureDomain returns a new AWSProviderConfig configured with the failure domain
@@ -68,14 +117,16 @@ func newAWSProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (Provid
 		return nil, errNilProviderSpec
 	}
 
-	awsMachineProviderConfig := machinev1beta1.AWSMachineProviderConfig{}
+	spec := awsProviderSpec{}
 
-	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &awsMachineProviderConfig); err != nil {
+	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &spec); err != nil {
 		return nil, fmt.Errorf("failed to check for unknown fields in the provider spec: %w", err)
 	}
 
 	awsProviderConfig := AWSProviderConfig{
-		providerConfig: awsMachineProviderConfig,
+		providerConfig:   spec.AWSMachineProviderConfig,
+		capacityOptions:  spec.awsCapacityOptions,
+		placementOptions: spec.awsPlacementOptions,
 	}
 
 	config := providerConfig{
@@ -152,3 +203,107 @@ func convertAWSResourceReferenceV1ToV1Beta1(referenceV1 *machinev1.AWSResourceRe
 
 	return referenceV1Beta1
 }
+
+// awsAMIReference returns the AMI reference from which the AMI of a Machine is resolved when it is created, when the
+// AMI is identified only by filters. An empty string is returned when the AMI is identified by an ID or ARN.
+func awsAMIReference(ami machinev1beta1.AWSResourceReference) (string, error) {
+	if len(ami.Filters) == 0 || ami.ID != nil || ami.ARN != nil {
+		return "", nil
+	}
+
+	reference, err := json.Marshal(ami.Filters)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling AMI filters: %w", err)
+	}
+
+	return string(reference), nil
+}
+
+// resolveAMIReference returns the template AMI reference when the machine AMI reference is a concrete ID that
+// was resolved from the filters within the template AMI reference.
+// Only an ID recorded as resolved from the same filters, when the machine was created, is treated as matching.
+// An ID that was set explicitly, or that was resolved from different filters, is returned unchanged, so that a change
+// to the AMI reference is reported as a difference.
+func resolveAMIReference(template, machine machinev1beta1.AWSResourceReference, resolvedFrom string) (machinev1beta1.AWSResourceReference, error) {
+	if machine.ID == nil || resolvedFrom == "" {
+		return machine, nil
+	}
+
+	templateReference, err := awsAMIReference(template)
+	if err != nil {
+		return machinev1beta1.AWSResourceReference{}, err
+	}
+
+	if templateReference != resolvedFrom {
+		return machine, nil
+	}
+
+	return template, nil
+}
+
+// withAWSDefaults returns a copy of the AWSMachineProviderConfig with the placement fields that AWS defaults filled
+// in with their default values.
+// An unset tenancy launches the instance on shared hardware, the same as the default tenancy, so a template that
+// omits the tenancy is not reported as different from a Machine that sets it to default.
+func withAWSDefaults(config machinev1beta1.AWSMachineProviderConfig) machinev1beta1.AWSMachineProviderConfig {
+	if config.Placement.Tenancy == "" {
+		config.Placement.Tenancy = machinev1beta1.DefaultTenancy
+	}
+
+	return config
+}
+
+// withAWSCapacityDefaults returns a copy of the capacity options with the market type filled in when it is unset.
+// An unset market type launches a Spot instance when Spot market options are set, and an On-Demand instance otherwise.
+func withAWSCapacityDefaults(options awsCapacityOptions, config machinev1beta1.AWSMachineProviderConfig) awsCapacityOptions {
+	if options.MarketType == "" {
+		if config.SpotMarketOptions != nil {
+			options.MarketType = awsMarketTypeSpot
+		} else {
+			options.MarketType = awsMarketTypeOnDemand
+		}
+	}
+
+	return options
+}
+
+// sortAWSUnorderedFields returns a copy of the AWSMachineProviderConfig with the slices in which ordering has no
+// meaning sorted, so that a reordering is not reported as a difference.
+// Block devices are left as they are, as the order of the block devices determines the root volume.
+func sortAWSUnorderedFields(config machinev1beta1.AWSMachineProviderConfig) machinev1beta1.AWSMachineProviderConfig {
+	if config.Tags != nil {
+		config.Tags = append([]machinev1beta1.TagSpecification{}, config.Tags...)
+		sort.SliceStable(config.Tags, func(i, j int) bool {
+			if config.Tags[i].Name != config.Tags[j].Name {
+				return config.Tags[i].Name < config.Tags[j].Name
+			}
+
+			return config.Tags[i].Value < config.Tags[j].Value
+		})
+	}
+
+	if config.SecurityGroups != nil {
+		config.SecurityGroups = append([]machinev1beta1.AWSResourceReference{}, config.SecurityGroups...)
+		sort.SliceStable(config.SecurityGroups, func(i, j int) bool {
+			return awsResourceReferenceSortKey(config.SecurityGroups[i]) < awsResourceReferenceSortKey(config.SecurityGroups[j])
+		})
+	}
+
+	if config.LoadBalancers != nil {
+		config.LoadBalancers = append([]machinev1beta1.LoadBalancerReference{}, config.LoadBalancers...)
+		sort.SliceStable(config.LoadBalancers, func(i, j int) bool {
+			if config.LoadBalancers[i].Name != config.LoadBalancers[j].Name {
+				return config.LoadBalancers[i].Name < config.LoadBalancers[j].Name
+			}
+
+			return config.LoadBalancers[i].Type < config.LoadBalancers[j].Type
+		})
+	}
+
+	return config
+}
+
+// awsResourceReferenceSortKey returns a key by which AWSResourceReferences can be sorted.
+func awsResourceReferenceSortKey(ref machinev1beta1.AWSResourceReference) string {
+	return fmt.Sprintf("%s/%s/%v", pointer.StringDeref(ref.ID, ""), pointer.StringDeref(ref.ARN, ""), ref.Filters)
+}
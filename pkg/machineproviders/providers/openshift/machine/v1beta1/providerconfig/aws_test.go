@@ -17,6 +17,8 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -26,6 +28,7 @@ import (
 	"github.com/openshift/cluster-api-actuator-pkg/testutils"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 var _ = Describe("AWS Provider Config", func() {
@@ -160,6 +163,72 @@ var _ = Describe("AWS Provider Config", func() {
 		})
 	})
 
+	Context("newAWSProviderConfig with capacity options", func() {
+		var providerConfig ProviderConfig
+
+		BeforeEach(func() {
+			raw, err := json.Marshal(awsProviderSpec{
+				AWSMachineProviderConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().Build(),
+				awsCapacityOptions: awsCapacityOptions{
+					CapacityReservationID: "cr-0123456789",
+					MarketType:            "CapacityBlock",
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			providerConfig, err = newAWSProviderConfig(logger.Logger(), &runtime.RawExtension{Raw: raw})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not report the capacity options as unknown fields", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+
+		It("preserves the capacity options in the raw config", func() {
+			rawConfig, err := providerConfig.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			spec := map[string]interface{}{}
+			Expect(json.Unmarshal(rawConfig, &spec)).To(Succeed())
+
+			Expect(spec).To(HaveKeyWithValue("capacityReservationId", "cr-0123456789"))
+			Expect(spec).To(HaveKeyWithValue("marketType", "CapacityBlock"))
+		})
+	})
+
+	Context("newAWSProviderConfig with placement group options", func() {
+		var providerConfig ProviderConfig
+
+		BeforeEach(func() {
+			raw, err := json.Marshal(awsProviderSpec{
+				AWSMachineProviderConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().Build(),
+				awsPlacementOptions: awsPlacementOptions{
+					PlacementGroupName:      "control-plane-partition",
+					PlacementGroupPartition: 2,
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			providerConfig, err = newAWSProviderConfig(logger.Logger(), &runtime.RawExtension{Raw: raw})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not report the placement group options as unknown fields", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+
+		It("preserves the placement group options in the raw config", func() {
+			rawConfig, err := providerConfig.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			spec := map[string]interface{}{}
+			Expect(json.Unmarshal(rawConfig, &spec)).To(Succeed())
+
+			Expect(spec).To(HaveKeyWithValue("placementGroupName", "control-plane-partition"))
+			Expect(spec).To(HaveKeyWithValue("placementGroupPartition", BeNumerically("==", 2)))
+		})
+	})
+
 	Context("ConvertAWSResourceReference", func() {
 		type convertAWSResourceReferenceInput struct {
 			awsResourceV1    *machinev1.AWSResourceReference
@@ -18,6 +18,7 @@ package providerconfig
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/go-logr/logr"
 	v1 "github.com/openshift/api/config/v1"
@@ -32,6 +33,24 @@ import (
 // as well as gathering the stored config.
 type AzureProviderConfig struct {
 	providerConfig machinev1beta1.AzureMachineProviderSpec
+
+	// capacityOptions holds the capacity options that are not yet part of the vendored AzureMachineProviderSpec.
+	capacityOptions azureCapacityOptions
+}
+
+// azureCapacityOptions are the options of an Azure provider spec that determine the capacity from which the virtual
+// machine is allocated. They are not yet part of the vendored AzureMachineProviderSpec, so are decoded from and
+// encoded into the raw provider spec alongside it, to preserve them when Machines are created.
+type azureCapacityOptions struct {
+	// CapacityReservationGroupID is the ID of the capacity reservation group from which the virtual machine is
+	// allocated.
+	CapacityReservationGroupID string `json:"capacityReservationGroupID,omitempty"`
+}
+
+// azureProviderSpec is the Azure provider spec as it is stored on Machines.
+type azureProviderSpec struct {
+	machinev1beta1.AzureMachineProviderSpec `json:",inline"`
+	azureCapacityOptions                    `json:",inline"`
 }
 
 // InjectFailureDomain returns a new AzureProviderConfig configured with the failure domain
@@ -39,7 +58,11 @@ type AzureProviderConfig struct {
 func (a AzureProviderConfig) InjectFailureDomain(fd machinev1.AzureFailureDomain) AzureProviderConfig {
 	newAzureProviderConfig := a
 
-	newAzureProviderConfig.providerConfig.Zone = &fd.Zone
+	// An empty zone is extracted from a provider config without a zone, so keep the zone unset in that case.
+	if fd.Zone != "" || a.providerConfig.Zone != nil {
+		zone := fd.Zone
+		newAzureProviderConfig.providerConfig.Zone = &zone
+	}
 
 	return newAzureProviderConfig
 }
@@ -61,14 +84,15 @@ func (a AzureProviderConfig) Config() machinev1beta1.AzureMachineProviderSpec {
 // It should return an error if the provided RawExtension does not represent
 // an AzureMachineProviderConfig.
 func newAzureProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (ProviderConfig, error) {
-	azureMachineProviderSpec := machinev1beta1.AzureMachineProviderSpec{}
+	spec := azureProviderSpec{}
 
-	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &azureMachineProviderSpec); err != nil {
+	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &spec); err != nil {
 		return nil, fmt.Errorf("failed to check for unknown fields in the provider spec: %w", err)
 	}
 
 	azureProviderConfig := AzureProviderConfig{
-		providerConfig: azureMachineProviderSpec,
+		providerConfig:  spec.AzureMachineProviderSpec,
+		capacityOptions: spec.azureCapacityOptions,
 	}
 
 	config := providerConfig{
@@ -78,3 +102,63 @@ func newAzureProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (Prov
 
 	return config, nil
 }
+
+// withAzureDefaults returns a copy of the AzureMachineProviderSpec with the disk and
+// diagnostics fields that Azure defaults filled in with their default values.
+// This allows a template that omits these fields to be compared with a Machine
+// that has them set explicitly without reporting a difference.
+func withAzureDefaults(spec machinev1beta1.AzureMachineProviderSpec) machinev1beta1.AzureMachineProviderSpec {
+	if spec.OSDisk.ManagedDisk.StorageAccountType == "" {
+		spec.OSDisk.ManagedDisk.StorageAccountType = string(machinev1beta1.StorageAccountPremiumLRS)
+	}
+
+	if spec.OSDisk.CachingType == "" {
+		spec.OSDisk.CachingType = string(machinev1beta1.CachingTypeNone)
+	}
+
+	if spec.DataDisks != nil {
+		dataDisks := make([]machinev1beta1.DataDisk, len(spec.DataDisks))
+
+		for i, disk := range spec.DataDisks {
+			if disk.ManagedDisk.StorageAccountType == "" {
+				disk.ManagedDisk.StorageAccountType = machinev1beta1.StorageAccountPremiumLRS
+			}
+
+			if disk.CachingType == "" {
+				disk.CachingType = machinev1beta1.CachingTypeNone
+			}
+
+			dataDisks[i] = disk
+		}
+
+		spec.DataDisks = dataDisks
+	}
+
+	if boot := spec.Diagnostics.Boot; boot != nil && boot.StorageAccountType == machinev1beta1.AzureManagedAzureDiagnosticsStorage {
+		// The customer managed storage account is only used with the CustomerManaged storage type.
+		spec.Diagnostics.Boot = &machinev1beta1.AzureBootDiagnostics{
+			StorageAccountType: boot.StorageAccountType,
+		}
+	}
+
+	return spec
+}
+
+// sortAzureUnorderedFields returns a copy of the AzureMachineProviderSpec with the slices in which ordering has no
+// meaning sorted, so that a reordering is not reported as a difference.
+// Data disks are identified by their logical unit number, so they are sorted by it.
+func sortAzureUnorderedFields(spec machinev1beta1.AzureMachineProviderSpec) machinev1beta1.AzureMachineProviderSpec {
+	if spec.DataDisks != nil {
+		spec.DataDisks = append([]machinev1beta1.DataDisk{}, spec.DataDisks...)
+		sort.SliceStable(spec.DataDisks, func(i, j int) bool {
+			return spec.DataDisks[i].Lun < spec.DataDisks[j].Lun
+		})
+	}
+
+	if spec.ApplicationSecurityGroups != nil {
+		spec.ApplicationSecurityGroups = append([]string{}, spec.ApplicationSecurityGroups...)
+		sort.Strings(spec.ApplicationSecurityGroups)
+	}
+
+	return spec
+}
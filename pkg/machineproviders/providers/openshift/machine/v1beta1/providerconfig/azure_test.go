@@ -17,6 +17,8 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/openshift/cluster-api-actuator-pkg/testutils"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 var _ = Describe("Azure Provider Config", func() {
@@ -110,4 +113,35 @@ var _ = Describe("Azure Provider Config", func() {
 			Expect(providerConfig.Azure().Config()).To(Equal(expectedAzureConfig))
 		})
 	})
+
+	Context("newAzureProviderConfig with capacity options", func() {
+		var providerConfig ProviderConfig
+
+		BeforeEach(func() {
+			raw, err := json.Marshal(azureProviderSpec{
+				AzureMachineProviderSpec: *machinev1beta1resourcebuilder.AzureProviderSpec().Build(),
+				azureCapacityOptions: azureCapacityOptions{
+					CapacityReservationGroupID: "crg-a",
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			providerConfig, err = newAzureProviderConfig(logger.Logger(), &runtime.RawExtension{Raw: raw})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not report the capacity options as unknown fields", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+
+		It("preserves the capacity options in the raw config", func() {
+			rawConfig, err := providerConfig.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			spec := map[string]interface{}{}
+			Expect(json.Unmarshal(rawConfig, &spec)).To(Succeed())
+
+			Expect(spec).To(HaveKeyWithValue("capacityReservationGroupID", "crg-a"))
+		})
+	})
 })
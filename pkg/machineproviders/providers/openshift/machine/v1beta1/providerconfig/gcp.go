@@ -17,13 +17,17 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/go-logr/logr"
 	v1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // GCPProviderConfig holds the provider spec of a GCP Machine.
@@ -31,6 +35,35 @@ import (
 // as well as gathering the stored config.
 type GCPProviderConfig struct {
 	providerConfig machinev1beta1.GCPMachineProviderSpec
+
+	// schedulingOptions holds the scheduling options that are not yet part of the vendored GCPMachineProviderSpec.
+	schedulingOptions gcpSchedulingOptions
+
+	// resolvedImageReference is the disk image reference from which the disk images of the Machine were resolved,
+	// as recorded when the Machine was created, if any.
+	resolvedImageReference string
+}
+
+// gcpSchedulingOptions are the options of a GCP provider spec that determine the hosts on which the instance is
+// scheduled. They are not yet part of the vendored GCPMachineProviderSpec, so are decoded from and encoded into the
+// raw provider spec alongside it, to preserve them when Machines are created.
+type gcpSchedulingOptions struct {
+	// NodeAffinities restrict the instance to the sole-tenant nodes that match each of the affinities.
+	NodeAffinities []gcpNodeAffinity `json:"nodeAffinities,omitempty"`
+}
+
+// gcpNodeAffinity is a sole-tenant node affinity, matching the nodes whose label with the key has one of the values,
+// or, with the NOT_IN operator, none of the values.
+type gcpNodeAffinity struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// gcpProviderSpec is the GCP provider spec as it is stored on Machines.
+type gcpProviderSpec struct {
+	machinev1beta1.GCPMachineProviderSpec `json:",inline"`
+	gcpSchedulingOptions                  `json:",inline"`
 }
 
 // InjectFailureDomain returns a new GCPProviderConfig configured with the failure domain.
@@ -58,14 +91,15 @@ func (g GCPProviderConfig) Config() machinev1beta1.GCPMachineProviderSpec {
 // newGCPProviderConfig creates a GCP type ProviderConfig from the raw extension.
 // It should return an error if the provided RawExtension does not represent a GCPProviderConfig.
 func newGCPProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (ProviderConfig, error) {
-	var gcpMachineProviderSpec machinev1beta1.GCPMachineProviderSpec
+	spec := gcpProviderSpec{}
 
-	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &gcpMachineProviderSpec); err != nil {
+	if err := checkForUnknownFieldsInProviderSpecAndUnmarshal(logger, raw, &spec); err != nil {
 		return nil, fmt.Errorf("failed to check for unknown fields in the provider spec: %w", err)
 	}
 
 	gcpProviderConfig := GCPProviderConfig{
-		providerConfig: gcpMachineProviderSpec,
+		providerConfig:    spec.GCPMachineProviderSpec,
+		schedulingOptions: spec.gcpSchedulingOptions,
 	}
 
 	config := providerConfig{
@@ -75,3 +109,195 @@ func newGCPProviderConfig(logger logr.Logger, raw *runtime.RawExtension) (Provid
 
 	return config, nil
 }
+
+// gcpImageFamilySeparator separates the image project path from the family name
+// in a GCP image family reference, e.g. projects/rhcos-cloud/global/images/family/rhcos.
+const gcpImageFamilySeparator = "family/"
+
+// gcpImageReference returns the disk image reference from which the disk images of a Machine are resolved when it is
+// created, when any disk image is an image family. The reference lists the image family of each disk, in order, with
+// an empty entry for each disk with a concrete image. An empty string is returned when no disk image is a family.
+func gcpImageReference(disks []*machinev1beta1.GCPDisk) (string, error) {
+	families := make([]string, len(disks))
+	hasFamily := false
+
+	for i, disk := range disks {
+		if disk != nil && strings.Contains(disk.Image, gcpImageFamilySeparator) {
+			families[i] = disk.Image
+			hasFamily = true
+		}
+	}
+
+	if !hasFamily {
+		return "", nil
+	}
+
+	reference, err := json.Marshal(families)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling image families: %w", err)
+	}
+
+	return string(reference), nil
+}
+
+// resolveImageFamily returns the template image when the template image is an image family reference and the
+// machine image is a concrete image from the same project, as the concrete image was resolved from the family
+// when the machine was created.
+// The machine image is returned unchanged when it is itself a family reference or belongs to a different project.
+func resolveImageFamily(template, machine string) string {
+	projectPath, _, isFamily := strings.Cut(template, gcpImageFamilySeparator)
+	if !isFamily || strings.Contains(machine, gcpImageFamilySeparator) {
+		return machine
+	}
+
+	if imageName := strings.TrimPrefix(machine, projectPath); imageName == machine || imageName == "" || strings.Contains(imageName, "/") {
+		return machine
+	}
+
+	return template
+}
+
+// resolveDiskImages returns a copy of the machine disks with any image resolved from an image family
+// in the corresponding template disk replaced by the template image.
+// Images are only treated as resolved from the template image families when the machine was recorded, when it was
+// created, as resolving its images from the same families. Otherwise, the machine disks are returned unchanged, so
+// that a change to the image references is reported as a difference.
+func resolveDiskImages(template, machine []*machinev1beta1.GCPDisk, resolvedFrom string) ([]*machinev1beta1.GCPDisk, error) {
+	if machine == nil || resolvedFrom == "" {
+		return machine, nil
+	}
+
+	templateReference, err := gcpImageReference(template)
+	if err != nil {
+		return nil, err
+	}
+
+	if templateReference != resolvedFrom {
+		return machine, nil
+	}
+
+	disks := make([]*machinev1beta1.GCPDisk, len(machine))
+
+	for i, disk := range machine {
+		if disk == nil || i >= len(template) || template[i] == nil {
+			disks[i] = disk
+			continue
+		}
+
+		resolvedDisk := *disk
+		resolvedDisk.Image = resolveImageFamily(template[i].Image, disk.Image)
+		disks[i] = &resolvedDisk
+	}
+
+	return disks, nil
+}
+
+// sortGCPUnorderedFields returns a copy of the GCPMachineProviderSpec with the slices in which ordering has no
+// meaning sorted, so that a reordering is not reported as a difference.
+// Disks and network interfaces are left as they are, as the order of these determines the boot disk
+// and the primary network interface.
+func sortGCPUnorderedFields(spec machinev1beta1.GCPMachineProviderSpec) machinev1beta1.GCPMachineProviderSpec {
+	if spec.Tags != nil {
+		spec.Tags = append([]string{}, spec.Tags...)
+		sort.Strings(spec.Tags)
+	}
+
+	if spec.TargetPools != nil {
+		spec.TargetPools = append([]string{}, spec.TargetPools...)
+		sort.Strings(spec.TargetPools)
+	}
+
+	if spec.Metadata != nil {
+		spec.Metadata = append([]*machinev1beta1.GCPMetadata{}, spec.Metadata...)
+		sort.SliceStable(spec.Metadata, func(i, j int) bool {
+			return gcpMetadataKey(spec.Metadata[i]) < gcpMetadataKey(spec.Metadata[j])
+		})
+	}
+
+	return spec
+}
+
+// gcpInjectedMetadataKeys are the metadata keys that the GCP machine controller adds to the metadata of a Machine
+// when it creates the instance. These are not expected on the template.
+var gcpInjectedMetadataKeys = sets.New[string]("user-data")
+
+// removeInjectedGCPMetadata returns a copy of the machine metadata without any entry injected by the GCP machine
+// controller, unless the template also sets the same key, so that injected entries are not reported as a difference.
+func removeInjectedGCPMetadata(template, machine []*machinev1beta1.GCPMetadata) []*machinev1beta1.GCPMetadata {
+	if machine == nil {
+		return nil
+	}
+
+	templateKeys := sets.New[string]()
+
+	for _, metadata := range template {
+		templateKeys.Insert(gcpMetadataKey(metadata))
+	}
+
+	metadata := []*machinev1beta1.GCPMetadata{}
+
+	for _, entry := range machine {
+		if key := gcpMetadataKey(entry); gcpInjectedMetadataKeys.Has(key) && !templateKeys.Has(key) {
+			continue
+		}
+
+		metadata = append(metadata, entry)
+	}
+
+	if len(metadata) == 0 && template == nil {
+		return nil
+	}
+
+	return metadata
+}
+
+// gcpMetadataKey returns the key of the GCPMetadata, or an empty string if the metadata is nil.
+func gcpMetadataKey(metadata *machinev1beta1.GCPMetadata) string {
+	if metadata == nil {
+		return ""
+	}
+
+	return metadata.Key
+}
+
+// withGCPDefaults returns a copy of the GCPMachineProviderSpec with the fields that GCP defaults filled in with their
+// default values, so that a template that omits these fields is not reported as different from a Machine that has
+// them set explicitly.
+func withGCPDefaults(spec machinev1beta1.GCPMachineProviderSpec) machinev1beta1.GCPMachineProviderSpec {
+	if spec.ConfidentialCompute == "" {
+		spec.ConfidentialCompute = machinev1beta1.ConfidentialComputePolicyDisabled
+	}
+
+	return spec
+}
+
+// sortGCPSchedulingOptions returns a copy of the gcpSchedulingOptions with the node affinities, and the values of
+// each, sorted. Every affinity must match, so their order has no meaning.
+func sortGCPSchedulingOptions(options gcpSchedulingOptions) gcpSchedulingOptions {
+	if options.NodeAffinities == nil {
+		return options
+	}
+
+	affinities := make([]gcpNodeAffinity, len(options.NodeAffinities))
+
+	for i, affinity := range options.NodeAffinities {
+		if affinity.Values != nil {
+			affinity.Values = append([]string{}, affinity.Values...)
+			sort.Strings(affinity.Values)
+		}
+
+		affinities[i] = affinity
+	}
+
+	sort.SliceStable(affinities, func(i, j int) bool {
+		if affinities[i].Key != affinities[j].Key {
+			return affinities[i].Key < affinities[j].Key
+		}
+
+		return affinities[i].Operator < affinities[j].Operator
+	})
+
+	options.NodeAffinities = affinities
+
+	return options
+}
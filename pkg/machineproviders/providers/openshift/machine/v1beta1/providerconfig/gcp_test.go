@@ -17,6 +17,8 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/openshift/cluster-api-actuator-pkg/testutils"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 var _ = Describe("GCP Provider Config", func() {
@@ -110,4 +113,43 @@ var _ = Describe("GCP Provider Config", func() {
 			Expect(providerConfig.GCP().Config()).To(Equal(expectedGCPConfig))
 		})
 	})
+
+	Context("newGCPProviderConfig with scheduling options", func() {
+		var providerConfig ProviderConfig
+
+		BeforeEach(func() {
+			raw, err := json.Marshal(gcpProviderSpec{
+				GCPMachineProviderSpec: *machinev1beta1resourcebuilder.GCPProviderSpec().Build(),
+				gcpSchedulingOptions: gcpSchedulingOptions{
+					NodeAffinities: []gcpNodeAffinity{{
+						Key:      "compute.googleapis.com/node-group-name",
+						Operator: "IN",
+						Values:   []string{"control-plane-group"},
+					}},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			providerConfig, err = newGCPProviderConfig(logger.Logger(), &runtime.RawExtension{Raw: raw})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not report the scheduling options as unknown fields", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+
+		It("preserves the scheduling options in the raw config", func() {
+			rawConfig, err := providerConfig.RawConfig()
+			Expect(err).ToNot(HaveOccurred())
+
+			spec := gcpProviderSpec{}
+			Expect(json.Unmarshal(rawConfig, &spec)).To(Succeed())
+
+			Expect(spec.NodeAffinities).To(ConsistOf(gcpNodeAffinity{
+				Key:      "compute.googleapis.com/node-group-name",
+				Operator: "IN",
+				Values:   []string{"control-plane-group"},
+			}))
+		})
+	})
 })
@@ -17,6 +17,10 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-test/deep"
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,3 +49,57 @@ func newGenericProviderConfig(providerSpec *runtime.RawExtension, platform confi
 
 	return config, nil
 }
+
+// diffGenericProviderSpecs compares two raw provider specs once they have been decoded, so that differences in the
+// formatting of the raw provider spec, such as whitespace, the order of keys or fields explicitly set to null, are not
+// reported. The type of the provider spec is not known, so it is decoded into its JSON representation, which also
+// preserves any fields that would not be known to a typed provider spec.
+func diffGenericProviderSpecs(base, other *runtime.RawExtension) ([]string, error) {
+	config, err := decodeGenericProviderSpec(base)
+	if err != nil {
+		return nil, err
+	}
+
+	otherConfig, err := decodeGenericProviderSpec(other)
+	if err != nil {
+		return nil, err
+	}
+
+	return deep.Equal(config, otherConfig), nil
+}
+
+// decodeGenericProviderSpec decodes a raw provider spec into its JSON representation, without any null fields.
+func decodeGenericProviderSpec(raw *runtime.RawExtension) (interface{}, error) {
+	if raw == nil || len(raw.Raw) == 0 {
+		return nil, nil
+	}
+
+	var spec interface{}
+	if err := json.Unmarshal(raw.Raw, &spec); err != nil {
+		return nil, fmt.Errorf("could not unmarshal provider spec: %w", err)
+	}
+
+	return removeNullFields(spec), nil
+}
+
+// removeNullFields removes the fields set to null from the decoded JSON value, as a null field
+// has the same meaning as a field that is not set.
+func removeNullFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if field == nil {
+				delete(v, key)
+				continue
+			}
+
+			v[key] = removeNullFields(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = removeNullFields(v[i])
+		}
+	}
+
+	return value
+}
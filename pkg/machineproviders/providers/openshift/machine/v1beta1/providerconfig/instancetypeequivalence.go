@@ -0,0 +1,72 @@
+/*
+Copyright 2022 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// InstanceTypeEquivalence describes which instance types should be treated as
+// interchangeable when comparing provider configs.
+// Each instance type maps to the set of instance types declared equivalent to it.
+// An empty InstanceTypeEquivalence means instance types are compared strictly.
+type InstanceTypeEquivalence map[string]sets.Set[string]
+
+// NewInstanceTypeEquivalence builds an InstanceTypeEquivalence from ConfigMap data.
+// Each key in the data is an instance type and each value is a comma separated
+// list of instance types that are equivalent to it.
+// Equivalence is symmetric, so an entry need only be declared once.
+func NewInstanceTypeEquivalence(data map[string]string) InstanceTypeEquivalence {
+	equivalence := InstanceTypeEquivalence{}
+
+	for instanceType, equivalents := range data {
+		instanceType = strings.TrimSpace(instanceType)
+
+		for _, equivalent := range strings.Split(equivalents, ",") {
+			equivalent = strings.TrimSpace(equivalent)
+			if instanceType == "" || equivalent == "" || equivalent == instanceType {
+				continue
+			}
+
+			equivalence.insert(instanceType, equivalent)
+			equivalence.insert(equivalent, instanceType)
+		}
+	}
+
+	return equivalence
+}
+
+// Equivalent returns true when the two instance types are identical,
+// or when they have been declared as equivalent.
+func (e InstanceTypeEquivalence) Equivalent(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	return e[a].Has(b)
+}
+
+// insert records that the equivalent instance type may be used in place of the instance type.
+func (e InstanceTypeEquivalence) insert(instanceType, equivalent string) {
+	if _, ok := e[instanceType]; !ok {
+		e[instanceType] = sets.New[string]()
+	}
+
+	e[instanceType].Insert(equivalent)
+}
@@ -27,12 +27,18 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/yaml"
 )
 
+const (
+	// unknownPlatformType is the platform type used when the platform cannot be inferred from the providerSpec kind.
+	unknownPlatformType configv1.PlatformType = "UnknownPlatform"
+)
+
 var (
 	// errMismatchedPlatformTypes is an error used when two provider configs
 	// are being compared but are from different platform types.
@@ -65,11 +71,30 @@ type ProviderConfig interface {
 
 	// Diff compares two ProviderConfigs and returns a list of differences,
 	// or nil if there are none.
+	// Instance types declared equivalent via WithInstanceTypeEquivalence are not reported as differences.
 	Diff(ProviderConfig) ([]string, error)
 
+	// WithInstanceTypeEquivalence returns a copy of the ProviderConfig that considers
+	// the equivalent instance types provided as equal when computing a Diff.
+	WithInstanceTypeEquivalence(InstanceTypeEquivalence) ProviderConfig
+
 	// RawConfig marshalls the configuration into a JSON byte slice.
 	RawConfig() ([]byte, error)
 
+	// ImageReference returns the image reference, such as AWS AMI filters or GCP image families, from which the
+	// image of a Machine created from the ProviderConfig is resolved.
+	// An empty string is returned when the image is concrete.
+	ImageReference() (string, error)
+
+	// WithResolvedImageReference returns a copy of the ProviderConfig that records the image reference from which
+	// its image was resolved. When computing a Diff, a concrete image is only considered up to date with an image
+	// reference when it was resolved from that same reference.
+	WithResolvedImageReference(string) ProviderConfig
+
+	// ReferencedSecretNames returns the names of the user data and credentials secrets
+	// referenced by the provider config.
+	ReferencedSecretNames() ([]string, error)
+
 	// Type returns the platform type of the provider config.
 	Type() configv1.PlatformType
 
@@ -138,6 +163,10 @@ type providerConfig struct {
 	gcp          GCPProviderConfig
 	nutanix      NutanixProviderConfig
 	generic      GenericProviderConfig
+
+	// instanceTypeEquivalence is consulted by Diff to ignore differences
+	// between instance types that are interchangeable.
+	instanceTypeEquivalence InstanceTypeEquivalence
 }
 
 // InjectFailureDomain is used to inject a failure domain into the ProviderConfig.
@@ -193,18 +222,107 @@ func (p providerConfig) Diff(other ProviderConfig) ([]string, error) {
 
 	switch p.platformType {
 	case configv1.AWSPlatformType:
-		return deep.Equal(p.aws.providerConfig, other.AWS().providerConfig), nil
+		config := sortAWSUnorderedFields(withAWSDefaults(p.aws.providerConfig))
+		otherConfig := sortAWSUnorderedFields(withAWSDefaults(other.AWS().providerConfig))
+
+		if p.instanceTypeEquivalence.Equivalent(config.InstanceType, otherConfig.InstanceType) {
+			otherConfig.InstanceType = config.InstanceType
+		}
+
+		resolvedAMI, err := resolveAMIReference(config.AMI, otherConfig.AMI, other.AWS().resolvedImageReference)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving AMI reference: %w", err)
+		}
+
+		otherConfig.AMI = resolvedAMI
+
+		diff := deep.Equal(config, otherConfig)
+		diff = append(diff, deep.Equal(
+			withAWSCapacityDefaults(p.aws.capacityOptions, p.aws.providerConfig),
+			withAWSCapacityDefaults(other.AWS().capacityOptions, other.AWS().providerConfig),
+		)...)
+		diff = append(diff, deep.Equal(p.aws.placementOptions, other.AWS().placementOptions)...)
+
+		return diff, nil
 	case configv1.AzurePlatformType:
-		return deep.Equal(p.azure.providerConfig, other.Azure().providerConfig), nil
+		config := sortAzureUnorderedFields(withAzureDefaults(p.azure.providerConfig))
+		otherConfig := sortAzureUnorderedFields(withAzureDefaults(other.Azure().providerConfig))
+
+		if p.instanceTypeEquivalence.Equivalent(config.VMSize, otherConfig.VMSize) {
+			otherConfig.VMSize = config.VMSize
+		}
+
+		diff := deep.Equal(config, otherConfig)
+		diff = append(diff, deep.Equal(p.azure.capacityOptions, other.Azure().capacityOptions)...)
+
+		return diff, nil
 	case configv1.GCPPlatformType:
-		return deep.Equal(p.gcp.providerConfig, other.GCP().providerConfig), nil
+		config := sortGCPUnorderedFields(withGCPDefaults(p.gcp.providerConfig))
+		otherConfig := sortGCPUnorderedFields(withGCPDefaults(other.GCP().providerConfig))
+
+		if p.instanceTypeEquivalence.Equivalent(config.MachineType, otherConfig.MachineType) {
+			otherConfig.MachineType = config.MachineType
+		}
+
+		resolvedDisks, err := resolveDiskImages(config.Disks, otherConfig.Disks, other.GCP().resolvedImageReference)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving disk images: %w", err)
+		}
+
+		otherConfig.Disks = resolvedDisks
+		otherConfig.Metadata = removeInjectedGCPMetadata(config.Metadata, otherConfig.Metadata)
+
+		diff := deep.Equal(config, otherConfig)
+		diff = append(diff, deep.Equal(sortGCPSchedulingOptions(p.gcp.schedulingOptions), sortGCPSchedulingOptions(other.GCP().schedulingOptions))...)
+
+		return diff, nil
 	case configv1.NutanixPlatformType:
 		return deep.Equal(p.nutanix.providerConfig, other.Nutanix().providerConfig), nil
+	case configv1.VSpherePlatformType:
+		return diffVSphereProviderSpecs(p.generic.providerSpec, other.Generic().providerSpec)
 	case configv1.NonePlatformType:
 		return nil, errUnsupportedPlatformType
 	default:
-		return deep.Equal(p.generic.providerSpec, other.Generic().providerSpec), nil
+		return diffGenericProviderSpecs(p.generic.providerSpec, other.Generic().providerSpec)
+	}
+}
+
+// WithInstanceTypeEquivalence returns a copy of the ProviderConfig that considers
+// the equivalent instance types provided as equal when computing a Diff.
+func (p providerConfig) WithInstanceTypeEquivalence(equivalence InstanceTypeEquivalence) ProviderConfig {
+	newConfig := p
+	newConfig.instanceTypeEquivalence = equivalence
+
+	return newConfig
+}
+
+// ImageReference returns the image reference, such as AWS AMI filters or GCP image families, from which the
+// image of a Machine created from the ProviderConfig is resolved.
+// An empty string is returned when the image is concrete, or the platform has no image references.
+func (p providerConfig) ImageReference() (string, error) {
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		return awsAMIReference(p.aws.providerConfig.AMI)
+	case configv1.GCPPlatformType:
+		return gcpImageReference(p.gcp.providerConfig.Disks)
+	default:
+		return "", nil
+	}
+}
+
+// WithResolvedImageReference returns a copy of the ProviderConfig that records the image reference from which
+// its image was resolved.
+func (p providerConfig) WithResolvedImageReference(reference string) ProviderConfig {
+	newConfig := p
+
+	switch p.platformType {
+	case configv1.AWSPlatformType:
+		newConfig.aws.resolvedImageReference = reference
+	case configv1.GCPPlatformType:
+		newConfig.gcp.resolvedImageReference = reference
 	}
+
+	return newConfig
 }
 
 // Equal compares two ProviderConfigs to determine whether or not they are equal.
@@ -219,17 +337,22 @@ func (p providerConfig) Equal(other ProviderConfig) (bool, error) {
 
 	switch p.platformType {
 	case configv1.AWSPlatformType:
-		return reflect.DeepEqual(p.aws.providerConfig, other.AWS().providerConfig), nil
+		return reflect.DeepEqual(p.aws, other.AWS()), nil
 	case configv1.AzurePlatformType:
-		return reflect.DeepEqual(p.azure.providerConfig, other.Azure().providerConfig), nil
+		return reflect.DeepEqual(p.azure, other.Azure()), nil
 	case configv1.GCPPlatformType:
-		return reflect.DeepEqual(p.gcp.providerConfig, other.GCP().providerConfig), nil
+		return reflect.DeepEqual(p.gcp, other.GCP()), nil
 	case configv1.NutanixPlatformType:
 		return reflect.DeepEqual(p.nutanix.providerConfig, other.Nutanix().providerConfig), nil
 	case configv1.NonePlatformType:
 		return false, errUnsupportedPlatformType
 	default:
-		return reflect.DeepEqual(p.generic.providerSpec, other.Generic().providerSpec), nil
+		diff, err := diffGenericProviderSpecs(p.generic.providerSpec, other.Generic().providerSpec)
+		if err != nil {
+			return false, err
+		}
+
+		return len(diff) == 0, nil
 	}
 }
 
@@ -242,11 +365,21 @@ func (p providerConfig) RawConfig() ([]byte, error) {
 
 	switch p.platformType {
 	case configv1.AWSPlatformType:
-		rawConfig, err = json.Marshal(p.aws.providerConfig)
+		rawConfig, err = json.Marshal(awsProviderSpec{
+			AWSMachineProviderConfig: p.aws.providerConfig,
+			awsCapacityOptions:       p.aws.capacityOptions,
+			awsPlacementOptions:      p.aws.placementOptions,
+		})
 	case configv1.AzurePlatformType:
-		rawConfig, err = json.Marshal(p.azure.providerConfig)
+		rawConfig, err = json.Marshal(azureProviderSpec{
+			AzureMachineProviderSpec: p.azure.providerConfig,
+			azureCapacityOptions:     p.azure.capacityOptions,
+		})
 	case configv1.GCPPlatformType:
-		rawConfig, err = json.Marshal(p.gcp.providerConfig)
+		rawConfig, err = json.Marshal(gcpProviderSpec{
+			GCPMachineProviderSpec: p.gcp.providerConfig,
+			gcpSchedulingOptions:   p.gcp.schedulingOptions,
+		})
 	case configv1.NutanixPlatformType:
 		rawConfig, err = json.Marshal(p.nutanix.providerConfig)
 	case configv1.NonePlatformType:
@@ -262,6 +395,42 @@ func (p providerConfig) RawConfig() ([]byte, error) {
 	return rawConfig, nil
 }
 
+// secretReferences holds the secret references common to the provider specs of all platforms.
+type secretReferences struct {
+	UserDataSecret    *secretReference `json:"userDataSecret,omitempty"`
+	CredentialsSecret *secretReference `json:"credentialsSecret,omitempty"`
+}
+
+// secretReference is a reference to a secret by name.
+type secretReference struct {
+	Name string `json:"name"`
+}
+
+// ReferencedSecretNames returns the names of the user data and credentials secrets
+// referenced by the provider config.
+// The raw config is used so that the secrets of platforms using the generic provider config are also found.
+func (p providerConfig) ReferencedSecretNames() ([]string, error) {
+	rawConfig, err := p.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := secretReferences{}
+	if err := json.Unmarshal(rawConfig, &refs); err != nil {
+		return nil, fmt.Errorf("could not unmarshal secret references: %w", err)
+	}
+
+	names := []string{}
+
+	for _, ref := range []*secretReference{refs.UserDataSecret, refs.CredentialsSecret} {
+		if ref != nil && ref.Name != "" {
+			names = append(names, ref.Name)
+		}
+	}
+
+	return names, nil
+}
+
 // Type returns the platform type of the provider config.
 func (p providerConfig) Type() configv1.PlatformType {
 	return p.platformType
@@ -300,13 +469,15 @@ func getPlatformTypeFromProviderSpecKind(kind string) configv1.PlatformType {
 		"AzureMachineProviderSpec":     configv1.AzurePlatformType,
 		"GCPMachineProviderSpec":       configv1.GCPPlatformType,
 		"NutanixMachineProviderConfig": configv1.NutanixPlatformType,
+		"OpenstackProviderSpec":        configv1.OpenStackPlatformType,
+		"VSphereMachineProviderSpec":   configv1.VSpherePlatformType,
 	}
 
 	platformType, ok := providerSpecKindToPlatformType[kind]
 
 	// Attempt to operate on unknown platforms. This should work if the platform does not require failure domains support.
 	if !ok {
-		return "UnknownPlatform"
+		return unknownPlatformType
 	}
 
 	return platformType
@@ -325,6 +496,24 @@ func getPlatformTypeFromMachineTemplate(tmpl machinev1.OpenShiftMachineV1Beta1Ma
 	return getPlatformTypeFromProviderSpec(tmpl.Spec.ProviderSpec)
 }
 
+// CheckFailureDomainsPlatform checks that, when failure domains are defined within the Machine template, their platform
+// matches the platform inferred from the providerSpec kind. When they do not match, the error returned wraps
+// machineproviders.ErrFailureDomainsPlatformMismatch.
+// Errors determining the platform of the providerSpec are not reported here, as they are reported when the
+// ProviderConfig is created, and platforms that cannot be inferred from the providerSpec kind are not checked.
+func CheckFailureDomainsPlatform(tmpl machinev1.OpenShiftMachineV1Beta1MachineTemplate) error {
+	if tmpl.FailureDomains.Platform == "" {
+		return nil
+	}
+
+	platformType, err := getPlatformTypeFromProviderSpec(tmpl.Spec.ProviderSpec)
+	if err == nil && platformType != unknownPlatformType && platformType != tmpl.FailureDomains.Platform {
+		return fmt.Errorf("%w: failure domains are for %s, provider spec is for %s", machineproviders.ErrFailureDomainsPlatformMismatch, tmpl.FailureDomains.Platform, platformType)
+	}
+
+	return nil
+}
+
 // getPlatformTypeFromProviderSpec determines machine platform from the providerSpec.
 // The providerSpec object's kind field is unmarshalled and the platform type is inferred from it.
 func getPlatformTypeFromProviderSpec(providerSpec machinev1beta1.ProviderSpec) (configv1.PlatformType, error) {
@@ -348,15 +537,11 @@ func getPlatformTypeFromProviderSpec(providerSpec machinev1beta1.ProviderSpec) (
 
 // ExtractFailureDomainsFromMachines creates list of FailureDomains extracted from the provided list of machines.
 func ExtractFailureDomainsFromMachines(logger logr.Logger, machines []machinev1beta1.Machine) ([]failuredomain.FailureDomain, error) {
-	machineFailureDomains := failuredomain.NewSet()
-
-	for _, machine := range machines {
-		providerconfig, err := NewProviderConfigFromMachineSpec(logger, machine.Spec)
-		if err != nil {
-			return nil, fmt.Errorf("error getting failure domain from machine %s: %w", machine.Name, err)
-		}
-
-		machineFailureDomains.Insert(providerconfig.ExtractFailureDomain())
+	machineFailureDomains, err := failuredomain.NewSetFromMachines(machines, func(machine machinev1beta1.Machine) (failuredomain.FailureDomain, error) {
+		return ExtractFailureDomainFromMachine(logger, machine)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return machineFailureDomains.List(), nil
@@ -17,6 +17,8 @@ limitations under the License.
 package providerconfig
 
 import (
+	"encoding/json"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
@@ -28,7 +30,9 @@ import (
 	"github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // stringPtr returns a pointer to the string.
@@ -120,6 +124,49 @@ var _ = Describe("Provider Config", func() {
 		)
 	})
 
+	Context("CheckFailureDomainsPlatform", func() {
+		type checkFailureDomainsPlatformTableInput struct {
+			failureDomainsBuilder resourcebuilder.OpenShiftMachineV1Beta1FailureDomainsBuilder
+			providerSpecBuilder   resourcebuilder.RawExtensionBuilder
+			expectedError         string
+		}
+
+		DescribeTable("should check the failure domains platform matches the provider spec", func(in checkFailureDomainsPlatformTableInput) {
+			tmpl := machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().
+				WithFailureDomainsBuilder(in.failureDomainsBuilder).
+				WithProviderSpecBuilder(in.providerSpecBuilder).
+				BuildTemplate()
+
+			err := CheckFailureDomainsPlatform(*tmpl.OpenShiftMachineV1Beta1Machine)
+			if in.expectedError != "" {
+				Expect(err).To(MatchError(machineproviders.ErrFailureDomainsPlatformMismatch))
+				Expect(err).To(MatchError(ContainSubstring(in.expectedError)))
+
+				return
+			}
+
+			Expect(err).ToNot(HaveOccurred())
+		},
+			Entry("with an AWS config with AWS failure domains", checkFailureDomainsPlatformTableInput{
+				failureDomainsBuilder: machinev1resourcebuilder.AWSFailureDomains(),
+				providerSpecBuilder:   machinev1beta1resourcebuilder.AWSProviderSpec(),
+			}),
+			Entry("with an AWS config without failure domains", checkFailureDomainsPlatformTableInput{
+				providerSpecBuilder: machinev1beta1resourcebuilder.AWSProviderSpec(),
+			}),
+			Entry("with an AWS config with Azure failure domains", checkFailureDomainsPlatformTableInput{
+				failureDomainsBuilder: machinev1resourcebuilder.AzureFailureDomains(),
+				providerSpecBuilder:   machinev1beta1resourcebuilder.AWSProviderSpec(),
+				expectedError:         "failure domains are for Azure, provider spec is for AWS",
+			}),
+			Entry("with an Azure config with GCP failure domains", checkFailureDomainsPlatformTableInput{
+				failureDomainsBuilder: machinev1resourcebuilder.GCPFailureDomains(),
+				providerSpecBuilder:   machinev1beta1resourcebuilder.AzureProviderSpec(),
+				expectedError:         "failure domains are for GCP, provider spec is for Azure",
+			}),
+		)
+	})
+
 	Context("InjectFailureDomain", func() {
 		type injectFailureDomainTableInput struct {
 			providerConfig   ProviderConfig
@@ -372,6 +419,18 @@ var _ = Describe("Provider Config", func() {
 					failuredomain.NewAWSFailureDomain(machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("us-east-1c").WithSubnet(awsSubnet).Build()),
 				},
 			}),
+			Entry("with vSphere machines across two topologies", extractFailureDomainsFromMachinesTableInput{
+				// vSphere does not support failure domains, so machines in different topologies share the generic failure domain.
+				machines: []machinev1beta1.Machine{
+					*machinev1beta1resourcebuilder.Machine().WithProviderSpecBuilder(machinev1beta1resourcebuilder.VSphereProviderSpec().WithTemplate("datacenter-1-rhcos")).Build(),
+					*machinev1beta1resourcebuilder.Machine().WithProviderSpecBuilder(machinev1beta1resourcebuilder.VSphereProviderSpec().WithTemplate("datacenter-2-rhcos")).Build(),
+					*machinev1beta1resourcebuilder.Machine().WithProviderSpecBuilder(machinev1beta1resourcebuilder.VSphereProviderSpec().WithTemplate("datacenter-1-rhcos")).Build(),
+				},
+				expectedError: nil,
+				expectedFailureDomains: []failuredomain.FailureDomain{
+					failuredomain.NewGenericFailureDomain(),
+				},
+			}),
 		)
 
 	})
@@ -449,6 +508,117 @@ var _ = Describe("Provider Config", func() {
 		)
 	})
 
+	Context("ExtractFailureDomain and InjectFailureDomain round trip", func() {
+		// Each entry provides a constructor so that the expected provider config does not share any
+		// memory with the provider config that the failure domain is injected into.
+		awsConfig := func(builder machinev1beta1resourcebuilder.AWSProviderSpecBuilder) func() providerConfig {
+			return func() providerConfig {
+				return providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *builder.Build(),
+					},
+				}
+			}
+		}
+
+		azureConfig := func(mutate func(*machinev1beta1.AzureMachineProviderSpec)) func() providerConfig {
+			return func() providerConfig {
+				spec := machinev1beta1resourcebuilder.AzureProviderSpec().Build()
+				mutate(spec)
+
+				return providerConfig{
+					platformType: configv1.AzurePlatformType,
+					azure: AzureProviderConfig{
+						providerConfig: *spec,
+					},
+				}
+			}
+		}
+
+		gcpConfig := func(builder machinev1beta1resourcebuilder.GCPProviderSpecBuilder) func() providerConfig {
+			return func() providerConfig {
+				return providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *builder.Build(),
+					},
+				}
+			}
+		}
+
+		DescribeTable("should leave the provider config unchanged when injecting the extracted failure domain", func(newConfig func() providerConfig) {
+			pc := newConfig()
+			original := newConfig()
+
+			fd := pc.ExtractFailureDomain()
+
+			injected, err := pc.InjectFailureDomain(fd)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(injected).To(Equal(original), "injecting the extracted failure domain should not change the provider config")
+			Expect(injected.ExtractFailureDomain().Equal(fd)).To(BeTrue(), "re-extracting the failure domain should give the same failure domain")
+
+			diff, err := original.Diff(injected)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diff).To(BeEmpty())
+		},
+			Entry("with an AWS config with a subnet filter", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a"),
+			)),
+			Entry("with an AWS config with an empty availability zone", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone(""),
+			)),
+			Entry("with an AWS config with a subnet ID", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(machinev1beta1.AWSResourceReference{
+					ID: stringPtr("subnet-0123456789abcdef0"),
+				}),
+			)),
+			Entry("with an AWS config with a subnet ARN", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(machinev1beta1.AWSResourceReference{
+					ARN: stringPtr("arn:aws:ec2:us-east-1:123456789012:subnet/subnet-0123456789abcdef0"),
+				}),
+			)),
+			Entry("with an AWS config with filters matching multiple subnets", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{
+						{
+							Name:   "tag:Name",
+							Values: []string{"subnet-private-us-east-1a", "subnet-public-us-east-1a"},
+						},
+						{
+							Name:   "vpc-id",
+							Values: []string{"vpc-0123456789abcdef0"},
+						},
+					},
+				}),
+			)),
+			Entry("with an AWS config with an empty subnet filter list", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1a").WithSubnet(machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{},
+				}),
+			)),
+			Entry("with an AWS config without a subnet or availability zone", awsConfig(
+				machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("").WithSubnet(machinev1beta1.AWSResourceReference{}),
+			)),
+			Entry("with an Azure config with a zone", azureConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+				spec.Zone = stringPtr("2")
+			})),
+			Entry("with an Azure config with an empty zone", azureConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+				spec.Zone = stringPtr("")
+			})),
+			Entry("with an Azure config without a zone", azureConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+				spec.Zone = nil
+			})),
+			Entry("with a GCP config with a zone", gcpConfig(
+				machinev1beta1resourcebuilder.GCPProviderSpec().WithZone("us-central1-a"),
+			)),
+			Entry("with a GCP config with an empty zone", gcpConfig(
+				machinev1beta1resourcebuilder.GCPProviderSpec().WithZone(""),
+			)),
+		)
+	})
+
 	Context("Equal", func() {
 		type equalTableInput struct {
 			basePC        ProviderConfig
@@ -624,6 +794,798 @@ var _ = Describe("Provider Config", func() {
 		)
 	})
 
+	Context("Diff", func() {
+		type diffTableInput struct {
+			basePC        ProviderConfig
+			comparePC     ProviderConfig
+			equivalence   InstanceTypeEquivalence
+			expectedDiff  types.GomegaMatcher
+			expectedError error
+		}
+
+		azureProviderConfig := func(mutate func(*machinev1beta1.AzureMachineProviderSpec)) ProviderConfig {
+			spec := machinev1beta1resourcebuilder.AzureProviderSpec().Build()
+			mutate(spec)
+
+			return &providerConfig{
+				platformType: configv1.AzurePlatformType,
+				azure: AzureProviderConfig{
+					providerConfig: *spec,
+				},
+			}
+		}
+
+		azureCapacityProviderConfig := func(options azureCapacityOptions, mutate func(*machinev1beta1.AzureMachineProviderSpec)) ProviderConfig {
+			pc := azureProviderConfig(mutate).(*providerConfig)
+			pc.azure.capacityOptions = options
+
+			return pc
+		}
+
+		awsProviderConfig := func(mutate func(*machinev1beta1.AWSMachineProviderConfig)) ProviderConfig {
+			spec := machinev1beta1resourcebuilder.AWSProviderSpec().Build()
+			mutate(spec)
+
+			return &providerConfig{
+				platformType: configv1.AWSPlatformType,
+				aws: AWSProviderConfig{
+					providerConfig: *spec,
+				},
+			}
+		}
+
+		awsCapacityProviderConfig := func(options awsCapacityOptions, mutate func(*machinev1beta1.AWSMachineProviderConfig)) ProviderConfig {
+			pc := awsProviderConfig(mutate).(*providerConfig)
+			pc.aws.capacityOptions = options
+
+			return pc
+		}
+
+		awsPlacementProviderConfig := func(options awsPlacementOptions) ProviderConfig {
+			pc := awsProviderConfig(func(*machinev1beta1.AWSMachineProviderConfig) {}).(*providerConfig)
+			pc.aws.placementOptions = options
+
+			return pc
+		}
+
+		gcpProviderConfig := func(mutate func(*machinev1beta1.GCPMachineProviderSpec)) ProviderConfig {
+			spec := machinev1beta1resourcebuilder.GCPProviderSpec().Build()
+			mutate(spec)
+
+			return &providerConfig{
+				platformType: configv1.GCPPlatformType,
+				gcp: GCPProviderConfig{
+					providerConfig: *spec,
+				},
+			}
+		}
+
+		gcpSchedulingProviderConfig := func(options gcpSchedulingOptions, mutate func(*machinev1beta1.GCPMachineProviderSpec)) ProviderConfig {
+			pc := gcpProviderConfig(mutate).(*providerConfig)
+			pc.gcp.schedulingOptions = options
+
+			return pc
+		}
+
+		vsphereProviderConfig := func(mutate func(*vsphereProviderSpec)) ProviderConfig {
+			spec := vsphereProviderSpec{VSphereMachineProviderSpec: *machinev1beta1resourcebuilder.VSphereProviderSpec().Build()}
+			mutate(&spec)
+
+			raw, err := json.Marshal(spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			return &providerConfig{
+				platformType: configv1.VSpherePlatformType,
+				generic: GenericProviderConfig{
+					providerSpec: &runtime.RawExtension{Raw: raw},
+				},
+			}
+		}
+
+		withVSphereResourcePool := func(resourcePool string) func(*vsphereProviderSpec) {
+			return func(spec *vsphereProviderSpec) {
+				spec.Workspace = &machinev1beta1.Workspace{
+					Server:       "vcenter.example.com",
+					Datacenter:   "datacenter",
+					ResourcePool: resourcePool,
+				}
+			}
+		}
+
+		rawProviderConfig := func(platformType configv1.PlatformType, raw string) ProviderConfig {
+			providerSpec := machinev1beta1.ProviderSpec{Value: &runtime.RawExtension{Raw: []byte(raw)}}
+
+			pc, err := newProviderConfigFromProviderSpec(testutils.NewTestLogger().Logger(), providerSpec, platformType)
+			Expect(err).ToNot(HaveOccurred())
+
+			return pc
+		}
+
+		withAMI := func(ami machinev1beta1.AWSResourceReference) func(*machinev1beta1.AWSMachineProviderConfig) {
+			return func(spec *machinev1beta1.AWSMachineProviderConfig) {
+				spec.AMI = ami
+			}
+		}
+
+		withBootImage := func(image string) func(*machinev1beta1.GCPMachineProviderSpec) {
+			return func(spec *machinev1beta1.GCPMachineProviderSpec) {
+				spec.Disks[0].Image = image
+			}
+		}
+
+		withAWSTenancy := func(tenancy machinev1beta1.InstanceTenancy) func(*machinev1beta1.AWSMachineProviderConfig) {
+			return func(spec *machinev1beta1.AWSMachineProviderConfig) {
+				spec.Placement.Tenancy = tenancy
+			}
+		}
+
+		withAWSTags := func(tags ...machinev1beta1.TagSpecification) func(*machinev1beta1.AWSMachineProviderConfig) {
+			return func(spec *machinev1beta1.AWSMachineProviderConfig) {
+				spec.Tags = tags
+			}
+		}
+
+		rhcosFilters := []machinev1beta1.Filter{{Name: "name", Values: []string{"rhcos-413.*"}}}
+
+		// The image references recorded on Machines created from a template with the RHCOS AMI filters, or with the
+		// RHCOS image family on the boot disk.
+		rhcosFiltersReference := `[{"name":"name","values":["rhcos-413.*"]}]`
+		rhcosFamilyReference := `["projects/rhcos-cloud/global/images/family/rhcos-413"]`
+
+		DescribeTable("should compare provider configs", func(in diffTableInput) {
+			basePC := in.basePC
+			if in.equivalence != nil {
+				basePC = basePC.WithInstanceTypeEquivalence(in.equivalence)
+			}
+
+			diff, err := basePC.Diff(in.comparePC)
+
+			if in.expectedError != nil {
+				Expect(err).To(MatchError(in.expectedError))
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			Expect(diff).To(in.expectedDiff)
+		},
+			Entry("with different AWS instance types and no equivalence", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.xlarge").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m5.xlarge").Build(),
+					},
+				},
+				expectedDiff: ConsistOf("InstanceType: m6i.xlarge != m5.xlarge"),
+			}),
+			Entry("with equivalent AWS instance types", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.xlarge").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m5.xlarge").Build(),
+					},
+				},
+				equivalence:  NewInstanceTypeEquivalence(map[string]string{"m5.xlarge": "m5a.xlarge, m6i.xlarge"}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with equivalent AWS instance types and another difference", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.xlarge").WithAvailabilityZone("us-east-1a").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m5.xlarge").WithAvailabilityZone("us-east-1b").Build(),
+					},
+				},
+				equivalence:  NewInstanceTypeEquivalence(map[string]string{"m5.xlarge": "m6i.xlarge"}),
+				expectedDiff: ConsistOf("Placement.AvailabilityZone: us-east-1a != us-east-1b"),
+			}),
+			Entry("with AWS instance types that are not declared equivalent", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.2xlarge").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m5.xlarge").Build(),
+					},
+				},
+				equivalence:  NewInstanceTypeEquivalence(map[string]string{"m5.xlarge": "m6i.xlarge"}),
+				expectedDiff: ConsistOf("InstanceType: m6i.2xlarge != m5.xlarge"),
+			}),
+			Entry("with different Azure VM sizes and no equivalence", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+					azure: AzureProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AzureProviderSpec().WithVMSize("Standard_D8s_v5").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+					azure: AzureProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AzureProviderSpec().WithVMSize("Standard_D8s_v3").Build(),
+					},
+				},
+				expectedDiff: ConsistOf("VMSize: Standard_D8s_v5 != Standard_D8s_v3"),
+			}),
+			Entry("with equivalent Azure VM sizes", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+					azure: AzureProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AzureProviderSpec().WithVMSize("Standard_D8s_v5").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+					azure: AzureProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AzureProviderSpec().WithVMSize("Standard_D8s_v3").Build(),
+					},
+				},
+				equivalence:  NewInstanceTypeEquivalence(map[string]string{"Standard_D8s_v3": "Standard_D8s_v5"}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with an increased Azure OS disk size", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.OSDisk.DiskSizeGB = 256
+				}),
+				comparePC:    azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {}),
+				expectedDiff: ConsistOf("OSDisk.DiskSizeGB: 256 != 128"),
+			}),
+			Entry("with a different Azure OS disk storage account type", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.OSDisk.ManagedDisk.StorageAccountType = "StandardSSD_LRS"
+				}),
+				comparePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.OSDisk.ManagedDisk.StorageAccountType = "Premium_LRS"
+				}),
+				expectedDiff: ConsistOf("OSDisk.ManagedDisk.StorageAccountType: StandardSSD_LRS != Premium_LRS"),
+			}),
+			Entry("with Azure OS disk defaults omitted from the template", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.OSDisk.ManagedDisk.StorageAccountType = ""
+					spec.OSDisk.CachingType = ""
+				}),
+				comparePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.OSDisk.ManagedDisk.StorageAccountType = "Premium_LRS"
+					spec.OSDisk.CachingType = "None"
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a different Azure data disk size", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.DataDisks = []machinev1beta1.DataDisk{{NameSuffix: "etcd", DiskSizeGB: 512, Lun: 0}}
+				}),
+				comparePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.DataDisks = []machinev1beta1.DataDisk{{NameSuffix: "etcd", DiskSizeGB: 256, Lun: 0}}
+				}),
+				expectedDiff: ConsistOf("DataDisks.slice[0].DiskSizeGB: 512 != 256"),
+			}),
+			Entry("with Azure data disk defaults omitted from the template", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.DataDisks = []machinev1beta1.DataDisk{{NameSuffix: "etcd", DiskSizeGB: 256, Lun: 0}}
+				}),
+				comparePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.DataDisks = []machinev1beta1.DataDisk{{
+						NameSuffix:  "etcd",
+						DiskSizeGB:  256,
+						Lun:         0,
+						CachingType: machinev1beta1.CachingTypeNone,
+						ManagedDisk: machinev1beta1.DataDiskManagedDiskParameters{
+							StorageAccountType: machinev1beta1.StorageAccountPremiumLRS,
+						},
+					}}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with Azure boot diagnostics enabled in the template", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.Diagnostics.Boot = &machinev1beta1.AzureBootDiagnostics{
+						StorageAccountType: machinev1beta1.AzureManagedAzureDiagnosticsStorage,
+					}
+				}),
+				comparePC:    azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {}),
+				expectedDiff: HaveLen(1),
+			}),
+			Entry("with a changed Azure capacity reservation group", diffTableInput{
+				basePC:       azureCapacityProviderConfig(azureCapacityOptions{CapacityReservationGroupID: "crg-a"}, func(*machinev1beta1.AzureMachineProviderSpec) {}),
+				comparePC:    azureCapacityProviderConfig(azureCapacityOptions{CapacityReservationGroupID: "crg-b"}, func(*machinev1beta1.AzureMachineProviderSpec) {}),
+				expectedDiff: ConsistOf("CapacityReservationGroupID: crg-a != crg-b"),
+			}),
+			Entry("with an Azure capacity reservation group added to the template", diffTableInput{
+				basePC:       azureCapacityProviderConfig(azureCapacityOptions{CapacityReservationGroupID: "crg-a"}, func(*machinev1beta1.AzureMachineProviderSpec) {}),
+				comparePC:    azureCapacityProviderConfig(azureCapacityOptions{}, func(*machinev1beta1.AzureMachineProviderSpec) {}),
+				expectedDiff: ConsistOf("CapacityReservationGroupID: crg-a != "),
+			}),
+			Entry("with Azure spot VM options added to the template", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.SpotVMOptions = &machinev1beta1.SpotVMOptions{}
+				}),
+				comparePC:    azureProviderConfig(func(*machinev1beta1.AzureMachineProviderSpec) {}),
+				expectedDiff: ConsistOf("SpotVMOptions: v1beta1.SpotVMOptions != <nil pointer>"),
+			}),
+			Entry("with equivalent GCP machine types", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.GCPProviderSpec().WithMachineType("n2-standard-4").Build(),
+					},
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.GCPPlatformType,
+					gcp: GCPProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.GCPProviderSpec().WithMachineType("n1-standard-4").Build(),
+					},
+				},
+				equivalence:  NewInstanceTypeEquivalence(map[string]string{"n1-standard-4": "n2-standard-4"}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with an AWS AMI filter template and a machine with the AMI ID resolved from the filters", diffTableInput{
+				basePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{Filters: rhcosFilters})),
+				comparePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{ID: stringPtr("ami-0123456789")})).
+					WithResolvedImageReference(rhcosFiltersReference),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with an AWS AMI filter template and a machine with the same filters and the AMI ID resolved from the filters", diffTableInput{
+				basePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{Filters: rhcosFilters})),
+				comparePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{ID: stringPtr("ami-0123456789"), Filters: rhcosFilters})).
+					WithResolvedImageReference(rhcosFiltersReference),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with an AWS AMI filter template and a machine with an AMI ID that was not resolved from filters", diffTableInput{
+				// The template was changed from an AMI ID to filters, so the machine must be replaced.
+				basePC:       awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{Filters: rhcosFilters})),
+				comparePC:    awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{ID: stringPtr("ami-0123456789")})),
+				expectedDiff: ConsistOf("AMI.ID: <nil pointer> != string", "AMI.Filters: [{name [rhcos-413.*]}] != <nil slice>"),
+			}),
+			Entry("with an AWS AMI filter template and a machine with an AMI ID resolved from different filters", diffTableInput{
+				basePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{Filters: rhcosFilters})),
+				comparePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{ID: stringPtr("ami-0123456789")})).
+					WithResolvedImageReference(`[{"name":"name","values":["rhcos-412.*"]}]`),
+				expectedDiff: ConsistOf("AMI.ID: <nil pointer> != string", "AMI.Filters: [{name [rhcos-413.*]}] != <nil slice>"),
+			}),
+			Entry("with an AWS AMI filter template and a machine with different filters", diffTableInput{
+				basePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{Filters: rhcosFilters})),
+				comparePC: awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{
+					ID:      stringPtr("ami-0123456789"),
+					Filters: []machinev1beta1.Filter{{Name: "name", Values: []string{"rhcos-412.*"}}},
+				})).WithResolvedImageReference(`[{"name":"name","values":["rhcos-412.*"]}]`),
+				expectedDiff: HaveLen(2),
+			}),
+			Entry("with AWS AMI IDs that differ", diffTableInput{
+				basePC:       awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{ID: stringPtr("ami-0123456789")})),
+				comparePC:    awsProviderConfig(withAMI(machinev1beta1.AWSResourceReference{ID: stringPtr("ami-9876543210")})),
+				expectedDiff: ConsistOf("AMI.ID: ami-0123456789 != ami-9876543210"),
+			}),
+			Entry("with a GCP image family template and a machine with an image resolved from the family", diffTableInput{
+				basePC: gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/family/rhcos-413")),
+				comparePC: gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/rhcos-413-86-202306132230-0-gcp-x86-64")).
+					WithResolvedImageReference(rhcosFamilyReference),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a GCP image family template and a machine with an image that was not resolved from the family", diffTableInput{
+				basePC:       gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/family/rhcos-413")),
+				comparePC:    gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/rhcos-413-86-202306132230-0-gcp-x86-64")),
+				expectedDiff: ConsistOf("Disks.slice[0].Image: projects/rhcos-cloud/global/images/family/rhcos-413 != projects/rhcos-cloud/global/images/rhcos-413-86-202306132230-0-gcp-x86-64"),
+			}),
+			Entry("with a GCP image family template and a machine with an image from another project", diffTableInput{
+				basePC: gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/family/rhcos-413")),
+				comparePC: gcpProviderConfig(withBootImage("projects/other-cloud/global/images/rhcos-413-86-202306132230-0-gcp-x86-64")).
+					WithResolvedImageReference(rhcosFamilyReference),
+				expectedDiff: ConsistOf("Disks.slice[0].Image: projects/rhcos-cloud/global/images/family/rhcos-413 != projects/other-cloud/global/images/rhcos-413-86-202306132230-0-gcp-x86-64"),
+			}),
+			Entry("with GCP image families that differ", diffTableInput{
+				basePC:       gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/family/rhcos-413")),
+				comparePC:    gcpProviderConfig(withBootImage("projects/rhcos-cloud/global/images/family/rhcos-412")),
+				expectedDiff: ConsistOf("Disks.slice[0].Image: projects/rhcos-cloud/global/images/family/rhcos-413 != projects/rhcos-cloud/global/images/family/rhcos-412"),
+			}),
+			Entry("with AWS tags in a different order", diffTableInput{
+				basePC: awsProviderConfig(withAWSTags(
+					machinev1beta1.TagSpecification{Name: "team", Value: "control-plane"},
+					machinev1beta1.TagSpecification{Name: "environment", Value: "production"},
+				)),
+				comparePC: awsProviderConfig(withAWSTags(
+					machinev1beta1.TagSpecification{Name: "environment", Value: "production"},
+					machinev1beta1.TagSpecification{Name: "team", Value: "control-plane"},
+				)),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a changed AWS tag", diffTableInput{
+				basePC: awsProviderConfig(withAWSTags(
+					machinev1beta1.TagSpecification{Name: "team", Value: "control-plane"},
+					machinev1beta1.TagSpecification{Name: "environment", Value: "production"},
+				)),
+				comparePC: awsProviderConfig(withAWSTags(
+					machinev1beta1.TagSpecification{Name: "environment", Value: "staging"},
+					machinev1beta1.TagSpecification{Name: "team", Value: "control-plane"},
+				)),
+				expectedDiff: ConsistOf("Tags.slice[0].Value: production != staging"),
+			}),
+			Entry("with a changed AWS tenancy", diffTableInput{
+				basePC:       awsProviderConfig(withAWSTenancy(machinev1beta1.DedicatedTenancy)),
+				comparePC:    awsProviderConfig(withAWSTenancy(machinev1beta1.DefaultTenancy)),
+				expectedDiff: ConsistOf("Placement.Tenancy: dedicated != default"),
+			}),
+			Entry("with an AWS tenancy changed from dedicated to host", diffTableInput{
+				basePC:       awsProviderConfig(withAWSTenancy(machinev1beta1.HostTenancy)),
+				comparePC:    awsProviderConfig(withAWSTenancy(machinev1beta1.DedicatedTenancy)),
+				expectedDiff: ConsistOf("Placement.Tenancy: host != dedicated"),
+			}),
+			Entry("with a dedicated AWS tenancy template and a machine without a tenancy", diffTableInput{
+				basePC:       awsProviderConfig(withAWSTenancy(machinev1beta1.DedicatedTenancy)),
+				comparePC:    awsProviderConfig(withAWSTenancy("")),
+				expectedDiff: ConsistOf("Placement.Tenancy: dedicated != default"),
+			}),
+			Entry("with the AWS tenancy omitted from the template and a machine with the default tenancy", diffTableInput{
+				basePC:       awsProviderConfig(withAWSTenancy("")),
+				comparePC:    awsProviderConfig(withAWSTenancy(machinev1beta1.DefaultTenancy)),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a changed AWS placement group", diffTableInput{
+				basePC:       awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-spread"}),
+				comparePC:    awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-cluster"}),
+				expectedDiff: ConsistOf("PlacementGroupName: control-plane-spread != control-plane-cluster"),
+			}),
+			Entry("with an AWS placement group added to the template", diffTableInput{
+				basePC:       awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-spread"}),
+				comparePC:    awsPlacementProviderConfig(awsPlacementOptions{}),
+				expectedDiff: ConsistOf("PlacementGroupName: control-plane-spread != "),
+			}),
+			Entry("with a changed AWS placement group partition", diffTableInput{
+				basePC:       awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-partition", PlacementGroupPartition: 1}),
+				comparePC:    awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-partition", PlacementGroupPartition: 2}),
+				expectedDiff: ConsistOf("PlacementGroupPartition: 1 != 2"),
+			}),
+			Entry("with the same AWS placement group", diffTableInput{
+				basePC:       awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-spread"}),
+				comparePC:    awsPlacementProviderConfig(awsPlacementOptions{PlacementGroupName: "control-plane-spread"}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a changed AWS capacity reservation", diffTableInput{
+				basePC:       awsCapacityProviderConfig(awsCapacityOptions{CapacityReservationID: "cr-0123456789"}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				comparePC:    awsCapacityProviderConfig(awsCapacityOptions{CapacityReservationID: "cr-9876543210"}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				expectedDiff: ConsistOf("CapacityReservationID: cr-0123456789 != cr-9876543210"),
+			}),
+			Entry("with an AWS capacity reservation added to the template", diffTableInput{
+				basePC:       awsCapacityProviderConfig(awsCapacityOptions{CapacityReservationID: "cr-0123456789"}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				comparePC:    awsCapacityProviderConfig(awsCapacityOptions{}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				expectedDiff: ConsistOf("CapacityReservationID: cr-0123456789 != "),
+			}),
+			Entry("with a changed AWS market type", diffTableInput{
+				basePC:       awsCapacityProviderConfig(awsCapacityOptions{MarketType: "CapacityBlock"}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				comparePC:    awsCapacityProviderConfig(awsCapacityOptions{MarketType: "OnDemand"}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				expectedDiff: ConsistOf("MarketType: CapacityBlock != OnDemand"),
+			}),
+			Entry("with the AWS market type omitted from the template and a machine with the OnDemand market type", diffTableInput{
+				basePC:       awsCapacityProviderConfig(awsCapacityOptions{}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				comparePC:    awsCapacityProviderConfig(awsCapacityOptions{MarketType: "OnDemand"}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with the AWS market type omitted from a template with spot market options and a machine with the Spot market type", diffTableInput{
+				basePC: awsCapacityProviderConfig(awsCapacityOptions{}, func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.SpotMarketOptions = &machinev1beta1.SpotMarketOptions{}
+				}),
+				comparePC: awsCapacityProviderConfig(awsCapacityOptions{MarketType: "Spot"}, func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.SpotMarketOptions = &machinev1beta1.SpotMarketOptions{}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with AWS spot market options removed from the template", diffTableInput{
+				basePC: awsCapacityProviderConfig(awsCapacityOptions{}, func(*machinev1beta1.AWSMachineProviderConfig) {}),
+				comparePC: awsCapacityProviderConfig(awsCapacityOptions{}, func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.SpotMarketOptions = &machinev1beta1.SpotMarketOptions{}
+				}),
+				expectedDiff: ConsistOf("SpotMarketOptions: <nil pointer> != v1beta1.SpotMarketOptions", "MarketType: OnDemand != Spot"),
+			}),
+			Entry("with AWS security groups in a different order", diffTableInput{
+				basePC: awsProviderConfig(func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.SecurityGroups = []machinev1beta1.AWSResourceReference{{ID: stringPtr("sg-1")}, {ID: stringPtr("sg-2")}}
+				}),
+				comparePC: awsProviderConfig(func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.SecurityGroups = []machinev1beta1.AWSResourceReference{{ID: stringPtr("sg-2")}, {ID: stringPtr("sg-1")}}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with AWS block devices in a different order", diffTableInput{
+				basePC: awsProviderConfig(func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.BlockDevices = []machinev1beta1.BlockDeviceMappingSpec{{DeviceName: stringPtr("/dev/xvda")}, {DeviceName: stringPtr("/dev/xvdb")}}
+				}),
+				comparePC: awsProviderConfig(func(spec *machinev1beta1.AWSMachineProviderConfig) {
+					spec.BlockDevices = []machinev1beta1.BlockDeviceMappingSpec{{DeviceName: stringPtr("/dev/xvdb")}, {DeviceName: stringPtr("/dev/xvda")}}
+				}),
+				expectedDiff: HaveLen(2),
+			}),
+			Entry("with Azure data disks in a different order", diffTableInput{
+				basePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.DataDisks = []machinev1beta1.DataDisk{{NameSuffix: "etcd", DiskSizeGB: 256, Lun: 0}, {NameSuffix: "logs", DiskSizeGB: 64, Lun: 1}}
+				}),
+				comparePC: azureProviderConfig(func(spec *machinev1beta1.AzureMachineProviderSpec) {
+					spec.DataDisks = []machinev1beta1.DataDisk{{NameSuffix: "logs", DiskSizeGB: 64, Lun: 1}, {NameSuffix: "etcd", DiskSizeGB: 256, Lun: 0}}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with GCP network tags in a different order", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Tags = []string{"control-plane", "cluster-12345678"}
+				}),
+				comparePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Tags = []string{"cluster-12345678", "control-plane"}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a changed GCP network tag", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Tags = []string{"control-plane", "cluster-12345678"}
+				}),
+				comparePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Tags = []string{"cluster-12345678", "worker"}
+				}),
+				expectedDiff: ConsistOf("Tags.slice[1]: control-plane != worker"),
+			}),
+			Entry("with a changed GCP service account scope", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.ServiceAccounts = []machinev1beta1.GCPServiceAccount{{
+						Email:  "control-plane@openshift-cpms-unit-tests.iam.gserviceaccount.com",
+						Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+					}}
+				}),
+				comparePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.ServiceAccounts = []machinev1beta1.GCPServiceAccount{{
+						Email:  "control-plane@openshift-cpms-unit-tests.iam.gserviceaccount.com",
+						Scopes: []string{"https://www.googleapis.com/auth/compute"},
+					}}
+				}),
+				expectedDiff: ConsistOf("ServiceAccounts.slice[0].Scopes.slice[0]: https://www.googleapis.com/auth/cloud-platform != https://www.googleapis.com/auth/compute"),
+			}),
+			Entry("with a changed GCP custom metadata entry", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Metadata = []*machinev1beta1.GCPMetadata{{Key: "team", Value: stringPtr("control-plane")}}
+				}),
+				comparePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Metadata = []*machinev1beta1.GCPMetadata{{Key: "team", Value: stringPtr("infrastructure")}}
+				}),
+				expectedDiff: ConsistOf("Metadata.slice[0].Value: control-plane != infrastructure"),
+			}),
+			Entry("with GCP metadata injected by the machine controller", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Metadata = []*machinev1beta1.GCPMetadata{{Key: "team", Value: stringPtr("control-plane")}}
+				}),
+				comparePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.Metadata = []*machinev1beta1.GCPMetadata{
+						{Key: "user-data", Value: stringPtr("e30=")},
+						{Key: "team", Value: stringPtr("control-plane")},
+					}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with an added GCP sole-tenant node affinity", diffTableInput{
+				basePC: gcpSchedulingProviderConfig(gcpSchedulingOptions{
+					NodeAffinities: []gcpNodeAffinity{{Key: "compute.googleapis.com/node-group-name", Operator: "IN", Values: []string{"control-plane-group"}}},
+				}, func(spec *machinev1beta1.GCPMachineProviderSpec) {}),
+				comparePC:    gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {}),
+				expectedDiff: Not(BeEmpty()),
+			}),
+			Entry("with GCP sole-tenant node affinities in a different order", diffTableInput{
+				basePC: gcpSchedulingProviderConfig(gcpSchedulingOptions{
+					NodeAffinities: []gcpNodeAffinity{
+						{Key: "compute.googleapis.com/node-group-name", Operator: "IN", Values: []string{"group-a", "group-b"}},
+						{Key: "environment", Operator: "NOT_IN", Values: []string{"test"}},
+					},
+				}, func(spec *machinev1beta1.GCPMachineProviderSpec) {}),
+				comparePC: gcpSchedulingProviderConfig(gcpSchedulingOptions{
+					NodeAffinities: []gcpNodeAffinity{
+						{Key: "environment", Operator: "NOT_IN", Values: []string{"test"}},
+						{Key: "compute.googleapis.com/node-group-name", Operator: "IN", Values: []string{"group-b", "group-a"}},
+					},
+				}, func(spec *machinev1beta1.GCPMachineProviderSpec) {}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a GCP confidential compute policy defaulted on the machine", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {}),
+				comparePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.ConfidentialCompute = machinev1beta1.ConfidentialComputePolicyDisabled
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with GCP confidential compute enabled", diffTableInput{
+				basePC: gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {
+					spec.ConfidentialCompute = machinev1beta1.ConfidentialComputePolicyEnabled
+				}),
+				comparePC:    gcpProviderConfig(func(spec *machinev1beta1.GCPMachineProviderSpec) {}),
+				expectedDiff: ConsistOf("ConfidentialCompute: Enabled != Disabled"),
+			}),
+			Entry("with a changed vSphere CPU count", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.NumCPUs = 8
+				}),
+				expectedDiff: ConsistOf("NumCPUs: 4 != 8"),
+			}),
+			Entry("with a changed vSphere template name", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.Template = "rhcos-413"
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.Template = "rhcos-414"
+				}),
+				expectedDiff: ConsistOf("Template: rhcos-413 != rhcos-414"),
+			}),
+			Entry("with a vSphere template referenced by name and by its path within the datacenter", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					withVSphereResourcePool("/datacenter/host/cluster/Resources")(spec)
+					spec.Template = "rhcos-413"
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					withVSphereResourcePool("/datacenter/host/cluster/Resources")(spec)
+					spec.Template = "/datacenter/vm/rhcos-413"
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a vSphere template referenced by name and by its path within another folder", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					withVSphereResourcePool("/datacenter/host/cluster/Resources")(spec)
+					spec.Template = "rhcos-413"
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					withVSphereResourcePool("/datacenter/host/cluster/Resources")(spec)
+					spec.Template = "/datacenter/vm/templates/rhcos-413"
+				}),
+				expectedDiff: ConsistOf("Template: rhcos-413 != /datacenter/vm/templates/rhcos-413"),
+			}),
+			Entry("with a vSphere template referenced by instance UUID in a different case", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.Template = "4217d5a8-3b1c-4e5f-9a6b-7c8d9e0f1a2b"
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.Template = "4217D5A8-3B1C-4E5F-9A6B-7C8D9E0F1A2B"
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a vSphere template referenced by name and by instance UUID", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					withVSphereResourcePool("/datacenter/host/cluster/Resources")(spec)
+					spec.Template = "rhcos-413"
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					withVSphereResourcePool("/datacenter/host/cluster/Resources")(spec)
+					spec.Template = "4217d5a8-3b1c-4e5f-9a6b-7c8d9e0f1a2b"
+				}),
+				expectedDiff: ConsistOf("Template: rhcos-413 != 4217d5a8-3b1c-4e5f-9a6b-7c8d9e0f1a2b"),
+			}),
+			Entry("with a changed vSphere resource pool", diffTableInput{
+				basePC:       vsphereProviderConfig(withVSphereResourcePool("/datacenter/host/cluster/Resources/control-plane")),
+				comparePC:    vsphereProviderConfig(withVSphereResourcePool("/datacenter/host/cluster/Resources/other")),
+				expectedDiff: ConsistOf("Workspace.ResourcePool: /datacenter/host/cluster/Resources/control-plane != /datacenter/host/cluster/Resources/other"),
+			}),
+			Entry("with a changed vSphere tag", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.TagIDs = []string{"urn:vmomi:InventoryServiceTag:a"}
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.TagIDs = []string{"urn:vmomi:InventoryServiceTag:b"}
+				}),
+				expectedDiff: ConsistOf("TagIDs.slice[0]: urn:vmomi:InventoryServiceTag:a != urn:vmomi:InventoryServiceTag:b"),
+			}),
+			Entry("with vSphere tags in a different order", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.TagIDs = []string{"urn:vmomi:InventoryServiceTag:a", "urn:vmomi:InventoryServiceTag:b"}
+				}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.TagIDs = []string{"urn:vmomi:InventoryServiceTag:b", "urn:vmomi:InventoryServiceTag:a"}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a defaulted vSphere clone mode and an empty workspace", diffTableInput{
+				basePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {}),
+				comparePC: vsphereProviderConfig(func(spec *vsphereProviderSpec) {
+					spec.CloneMode = machinev1beta1.FullClone
+					spec.Workspace = &machinev1beta1.Workspace{}
+				}),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a changed vSphere field that is not known to the vendored API", diffTableInput{
+				basePC: rawProviderConfig(configv1.VSpherePlatformType,
+					`{"template":"rhcos","numCPUs":4,"customVMXKeys":{"guestinfo.domain":"example.com"}}`),
+				comparePC: rawProviderConfig(configv1.VSpherePlatformType,
+					`{"template":"rhcos","numCPUs":4,"customVMXKeys":{"guestinfo.domain":"example.org"}}`),
+				expectedDiff: ConsistOf("map[customVMXKeys].map[guestinfo.domain]: example.com != example.org"),
+			}),
+			Entry("with a changed vSphere network device field that is not known to the vendored API", diffTableInput{
+				basePC: rawProviderConfig(configv1.VSpherePlatformType,
+					`{"template":"rhcos","network":{"devices":[{"networkName":"vm-network","ipAddrs":["192.168.0.10/24"]}]}}`),
+				comparePC: rawProviderConfig(configv1.VSpherePlatformType,
+					`{"template":"rhcos","network":{"devices":[{"networkName":"vm-network","ipAddrs":["192.168.0.11/24"]}]}}`),
+				expectedDiff: ConsistOf("map[network].map[devices].slice[0].map[ipAddrs].slice[0]: 192.168.0.10/24 != 192.168.0.11/24"),
+			}),
+			Entry("with an unchanged vSphere field that is not known to the vendored API", diffTableInput{
+				basePC: rawProviderConfig(configv1.VSpherePlatformType,
+					`{"template":"rhcos","numCPUs":4,"customVMXKeys":{"guestinfo.domain":"example.com"}}`),
+				comparePC: rawProviderConfig(configv1.VSpherePlatformType, `{
+					"customVMXKeys": {"guestinfo.domain": "example.com"},
+					"numCPUs": 8,
+					"template": "rhcos"
+				}`),
+				expectedDiff: ConsistOf("NumCPUs: 4 != 8"),
+			}),
+			Entry("with AWS provider specs serialised differently", diffTableInput{
+				basePC: rawProviderConfig(configv1.AWSPlatformType,
+					`{"instanceType":"m6i.xlarge","placement":{"region":"us-east-1","availabilityZone":"us-east-1a"},"spotMarketOptions":null}`),
+				comparePC: rawProviderConfig(configv1.AWSPlatformType, `{
+					"placement": {"availabilityZone": "us-east-1a", "region": "us-east-1"},
+					"instanceType": "m6i.xlarge"
+				}`),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with BareMetal provider specs serialised differently", diffTableInput{
+				basePC: rawProviderConfig(configv1.BareMetalPlatformType,
+					`{"image":{"url":"http://example.com/rhcos.qcow2","checksum":"abc"},"customDeploy":null,"userData":{"name":"master-user-data"}}`),
+				comparePC: rawProviderConfig(configv1.BareMetalPlatformType, `{
+					"userData": {"name": "master-user-data"},
+					"image": {"checksum": "abc", "url": "http://example.com/rhcos.qcow2"}
+				}`),
+				expectedDiff: BeEmpty(),
+			}),
+			Entry("with a changed BareMetal provider spec field", diffTableInput{
+				basePC: rawProviderConfig(configv1.BareMetalPlatformType,
+					`{"image":{"url":"http://example.com/rhcos.qcow2","checksum":"abc"}}`),
+				comparePC: rawProviderConfig(configv1.BareMetalPlatformType,
+					`{"image":{"url":"http://example.com/rhcos-new.qcow2","checksum":"abc"}}`),
+				expectedDiff: ConsistOf("map[image].map[url]: http://example.com/rhcos.qcow2 != http://example.com/rhcos-new.qcow2"),
+			}),
+			Entry("with different platform types", diffTableInput{
+				basePC: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+				},
+				comparePC: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+				},
+				expectedDiff:  BeNil(),
+				expectedError: errMismatchedPlatformTypes,
+			}),
+		)
+	})
+
+	Context("NewInstanceTypeEquivalence", func() {
+		It("should treat declared instance types as equivalent in both directions", func() {
+			equivalence := NewInstanceTypeEquivalence(map[string]string{"m5.xlarge": "m5a.xlarge, m6i.xlarge"})
+
+			Expect(equivalence.Equivalent("m5.xlarge", "m6i.xlarge")).To(BeTrue())
+			Expect(equivalence.Equivalent("m6i.xlarge", "m5.xlarge")).To(BeTrue())
+			Expect(equivalence.Equivalent("m5a.xlarge", "m5.xlarge")).To(BeTrue())
+		})
+
+		It("should not treat undeclared instance types as equivalent", func() {
+			equivalence := NewInstanceTypeEquivalence(map[string]string{"m5.xlarge": "m5a.xlarge, m6i.xlarge"})
+
+			Expect(equivalence.Equivalent("m5a.xlarge", "m6i.xlarge")).To(BeFalse())
+			Expect(equivalence.Equivalent("m5.xlarge", "m5.2xlarge")).To(BeFalse())
+		})
+
+		It("should compare strictly when no data is provided", func() {
+			equivalence := NewInstanceTypeEquivalence(nil)
+
+			Expect(equivalence.Equivalent("m5.xlarge", "m5.xlarge")).To(BeTrue())
+			Expect(equivalence.Equivalent("m5.xlarge", "m6i.xlarge")).To(BeFalse())
+		})
+	})
+
 	Context("RawConfig", func() {
 		type rawConfigTableInput struct {
 			providerConfig ProviderConfig
@@ -681,4 +1643,51 @@ var _ = Describe("Provider Config", func() {
 		)
 	})
 
+	Context("ReferencedSecretNames", func() {
+		type referencedSecretNamesTableInput struct {
+			providerConfig ProviderConfig
+			expectedNames  []string
+		}
+
+		DescribeTable("should return the referenced secrets", func(in referencedSecretNamesTableInput) {
+			names, err := in.providerConfig.ReferencedSecretNames()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(names).To(Equal(in.expectedNames))
+		},
+			Entry("with an AWS config", referencedSecretNamesTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AWSProviderSpec().Build(),
+					},
+				},
+				expectedNames: []string{"aws-user-data-12345678", "aws-cloud-credentials"},
+			}),
+			Entry("with an Azure config", referencedSecretNamesTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AzurePlatformType,
+					azure: AzureProviderConfig{
+						providerConfig: *machinev1beta1resourcebuilder.AzureProviderSpec().Build(),
+					},
+				},
+				expectedNames: []string{"worker-user-data", "azure-cloud-credentials"},
+			}),
+			Entry("with an AWS config without a user data secret", referencedSecretNamesTableInput{
+				providerConfig: &providerConfig{
+					platformType: configv1.AWSPlatformType,
+					aws: AWSProviderConfig{
+						providerConfig: func() machinev1beta1.AWSMachineProviderConfig {
+							config := *machinev1beta1resourcebuilder.AWSProviderSpec().Build()
+							config.UserDataSecret = nil
+
+							return config
+						}(),
+					},
+				},
+				expectedNames: []string{"aws-cloud-credentials"},
+			}),
+		)
+	})
+
 })
@@ -0,0 +1,241 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-test/deep"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// vsphereProviderSpec is the vSphere provider spec as it is compared by Diff.
+// vSphere is otherwise handled by the generic provider config, so the raw provider spec, including any fields
+// not yet known to the vendored API, is what is used to create Machines. Those fields are compared separately,
+// as they are dropped when the provider spec is decoded.
+type vsphereProviderSpec struct {
+	machinev1beta1.VSphereMachineProviderSpec `json:",inline"`
+
+	// TagIDs are the IDs of the vCenter tags attached to the virtual machine.
+	// They are not yet part of the vendored VSphereMachineProviderSpec.
+	TagIDs []string `json:"tagIDs,omitempty"`
+}
+
+// vsphereUUIDRegexp matches a template referenced by its instance UUID, which is not case sensitive.
+var vsphereUUIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// diffVSphereProviderSpecs compares two raw vSphere provider specs once they have been decoded and normalised,
+// so that changes to fields such as the template, CPU count, memory, resource pool or tags are reported, while
+// differences in the formatting of the raw provider spec, or in fields left to their defaults, are not.
+// Any fields not known to vsphereProviderSpec are compared as they would be by the generic provider config.
+func diffVSphereProviderSpecs(base, other *runtime.RawExtension) ([]string, error) {
+	config, err := decodeVSphereProviderSpec(base)
+	if err != nil {
+		return nil, err
+	}
+
+	otherConfig, err := decodeVSphereProviderSpec(other)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := deep.Equal(config, otherConfig)
+
+	// Report the fields of the embedded provider spec as they would be reported for the other platforms.
+	for i := range diff {
+		diff[i] = strings.TrimPrefix(diff[i], "VSphereMachineProviderSpec.")
+	}
+
+	unknownFields, err := decodeUnknownVSphereFields(base)
+	if err != nil {
+		return nil, err
+	}
+
+	otherUnknownFields, err := decodeUnknownVSphereFields(other)
+	if err != nil {
+		return nil, err
+	}
+
+	diff = append(diff, deep.Equal(unknownFields, otherUnknownFields)...)
+
+	return diff, nil
+}
+
+// decodeUnknownVSphereFields decodes a raw vSphere provider spec into its JSON representation, keeping only the
+// fields that are not known to vsphereProviderSpec, and so would be dropped when the provider spec is decoded.
+func decodeUnknownVSphereFields(raw *runtime.RawExtension) (interface{}, error) {
+	spec, err := decodeGenericProviderSpec(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec == nil {
+		return nil, nil
+	}
+
+	return removeKnownFields(spec, reflect.TypeOf(vsphereProviderSpec{})), nil
+}
+
+// removeKnownFields removes the fields of the given type from the decoded JSON value, so that only the fields not
+// known to the type remain. Objects and lists of objects left without any field are removed altogether.
+func removeKnownFields(value interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+
+		for name, fieldType := range jsonFields(t) {
+			field, ok := v[name]
+			if !ok {
+				continue
+			}
+
+			if unknown := removeKnownFields(field, fieldType); unknown != nil {
+				v[name] = unknown
+			} else {
+				delete(v, name)
+			}
+		}
+
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		empty := true
+
+		for i := range v {
+			v[i] = removeKnownFields(v[i], t)
+			empty = empty && v[i] == nil
+		}
+
+		if empty {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return value
+}
+
+// jsonFields returns the type of each field of the struct type, by the name of the field in JSON.
+// The fields of inlined structs are promoted, as they are by the JSON encoding.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || (name == "" && !field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for inlineName, inlineType := range jsonFields(field.Type) {
+				fields[inlineName] = inlineType
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+// decodeVSphereProviderSpec decodes a raw vSphere provider spec and normalises it for comparison.
+func decodeVSphereProviderSpec(raw *runtime.RawExtension) (vsphereProviderSpec, error) {
+	spec := vsphereProviderSpec{}
+
+	if raw == nil || len(raw.Raw) == 0 {
+		return spec, nil
+	}
+
+	if err := json.Unmarshal(raw.Raw, &spec); err != nil {
+		return vsphereProviderSpec{}, fmt.Errorf("could not unmarshal vSphere provider spec: %w", err)
+	}
+
+	return withVSphereDefaults(spec), nil
+}
+
+// withVSphereDefaults sets the fields that are defaulted when unset, so that leaving a field unset
+// compares equal to setting it to its default. Tag IDs are sorted, as their order has no meaning.
+func withVSphereDefaults(spec vsphereProviderSpec) vsphereProviderSpec {
+	spec.Template = normaliseVSphereTemplate(spec.Template, spec.Workspace)
+
+	if spec.CloneMode == "" {
+		spec.CloneMode = machinev1beta1.FullClone
+	}
+
+	if spec.Workspace != nil && *spec.Workspace == (machinev1beta1.Workspace{}) {
+		spec.Workspace = nil
+	}
+
+	if len(spec.TagIDs) == 0 {
+		spec.TagIDs = nil
+	} else {
+		tagIDs := append([]string{}, spec.TagIDs...)
+		sort.Strings(tagIDs)
+		spec.TagIDs = tagIDs
+	}
+
+	return spec
+}
+
+// normaliseVSphereTemplate normalises the reference to the template that Machines are cloned from, so that two
+// references that resolve to the same template, without needing to look them up in vCenter, compare equal.
+// A template name is looked up within the virtual machine folder of the workspace datacenter, so an inventory path to
+// a template within that folder is reduced to the template name. An instance UUID is not case sensitive.
+// A template referenced by name in one provider spec, and by instance UUID in the other, is still reported as a
+// difference, as the two can only be related by vCenter, and the status of a vSphere Machine does not record the
+// template that it was cloned from.
+func normaliseVSphereTemplate(template string, workspace *machinev1beta1.Workspace) string {
+	if vsphereUUIDRegexp.MatchString(template) {
+		return strings.ToLower(template)
+	}
+
+	if workspace == nil || workspace.Datacenter == "" {
+		return template
+	}
+
+	folder := path.Join("/", workspace.Datacenter, "vm") + "/"
+
+	name := strings.TrimPrefix(template, folder)
+	if name == template || name == "" || strings.Contains(name, "/") {
+		return template
+	}
+
+	return name
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-logr/logr"
+	machinev1 "github.com/openshift/api/machine/v1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ValidateControlPlaneMachineSet computes the MachineInfos for the existing control plane Machines
+// as if the proposed ControlPlaneMachineSet had been applied to the cluster.
+// The ControlPlaneMachineSet is only read, so this can be used to check whether applying it
+// would immediately cause the Machines to be replaced.
+// The returned MachineInfos are sorted by index.
+func ValidateControlPlaneMachineSet(ctx context.Context, logger logr.Logger, cl client.Client, cpms *machinev1.ControlPlaneMachineSet) ([]machineproviders.MachineInfo, error) {
+	provider, err := NewMachineProvider(ctx, logger, cl, cpms, pointer.Int32Deref(cpms.Spec.Replicas, 0))
+	if err != nil {
+		return nil, fmt.Errorf("error constructing machine provider: %w", err)
+	}
+
+	machineInfos, err := provider.GetMachineInfos(ctx, logger)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching machine info: %w", err)
+	}
+
+	sort.SliceStable(machineInfos, func(i, j int) bool {
+		return machineInfos[i].Index < machineInfos[j].Index
+	})
+
+	return machineInfos, nil
+}
+
+// WriteValidationResults writes a human readable summary of the MachineInfos returned by
+// ValidateControlPlaneMachineSet, listing for each index whether the Machine would be
+// updated and the differences that would cause the update.
+func WriteValidationResults(w io.Writer, machineInfos []machineproviders.MachineInfo) error {
+	for _, machineInfo := range machineInfos {
+		name := "<none>"
+		if machineInfo.MachineRef != nil {
+			name = machineInfo.MachineRef.ObjectMeta.Name
+		}
+
+		status := "up to date"
+		if machineInfo.NeedsUpdate {
+			status = "needs update"
+		}
+
+		if _, err := fmt.Fprintf(w, "Index %d (machine %s): %s\n", machineInfo.Index, name, status); err != nil {
+			return fmt.Errorf("error writing validation results: %w", err)
+		}
+
+		for _, diff := range machineInfo.Diff {
+			if _, err := fmt.Fprintf(w, "  - %s\n", diff); err != nil {
+				return fmt.Errorf("error writing validation results: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
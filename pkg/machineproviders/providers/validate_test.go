@@ -0,0 +1,136 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
+	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
+	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+)
+
+var _ = Describe("ValidateControlPlaneMachineSet", func() {
+	var cpmsBuilder machinev1resourcebuilder.ControlPlaneMachineSetBuilder
+	var logger testutils.TestLogger
+	var namespaceName string
+
+	BeforeEach(func() {
+		By("Setting up a namespace for the test")
+		ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-validate-").Build()
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespaceName = ns.GetName()
+
+		cpmsBuilder = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName)
+
+		logger = testutils.NewTestLogger()
+
+		By("Creating some master machines")
+		machineBuilder := machinev1beta1resourcebuilder.Machine().AsMaster().WithNamespace(namespaceName)
+		for i := 0; i < 3; i++ {
+			machine := machineBuilder.WithName(fmt.Sprintf("master-%d", i)).
+				WithProviderSpecBuilder(machinev1beta1resourcebuilder.AWSProviderSpec()).Build()
+			Expect(k8sClient.Create(ctx, machine)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&machinev1beta1.Machine{},
+		)
+	})
+
+	Context("with a proposed ControlPlaneMachineSet matching the Machines", func() {
+		var machineInfos []machineproviders.MachineInfo
+		var err error
+
+		BeforeEach(func() {
+			cpms := cpmsBuilder.WithMachineTemplateBuilder(
+				machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(
+					machinev1beta1resourcebuilder.AWSProviderSpec(),
+				),
+			).Build()
+
+			machineInfos, err = ValidateControlPlaneMachineSet(ctx, logger.Logger(), k8sClient, cpms)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("reports no index as needing an update", func() {
+			Expect(machineInfos).To(HaveLen(3))
+
+			for _, machineInfo := range machineInfos {
+				Expect(machineInfo.NeedsUpdate).To(BeFalse(), "index %d should not need an update", machineInfo.Index)
+				Expect(machineInfo.Diff).To(BeEmpty())
+			}
+		})
+	})
+
+	Context("with a proposed ControlPlaneMachineSet that changes the instance type", func() {
+		var machineInfos []machineproviders.MachineInfo
+		var err error
+
+		instanceDiff := []string{"InstanceType: m6i.2xlarge != m6i.xlarge"}
+
+		BeforeEach(func() {
+			cpms := cpmsBuilder.WithMachineTemplateBuilder(
+				machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(
+					machinev1beta1resourcebuilder.AWSProviderSpec().WithInstanceType("m6i.2xlarge"),
+				),
+			).Build()
+
+			machineInfos, err = ValidateControlPlaneMachineSet(ctx, logger.Logger(), k8sClient, cpms)
+		})
+
+		It("does not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("reports every index as needing an update with the instance type diff", func() {
+			Expect(machineInfos).To(HaveLen(3))
+
+			for i, machineInfo := range machineInfos {
+				Expect(machineInfo.Index).To(BeEquivalentTo(i))
+				Expect(machineInfo.NeedsUpdate).To(BeTrue(), "index %d should need an update", machineInfo.Index)
+				Expect(machineInfo.Diff).To(Equal(instanceDiff))
+			}
+		})
+
+		It("writes the diff for every index", func() {
+			buf := &bytes.Buffer{}
+			Expect(WriteValidationResults(buf, machineInfos)).To(Succeed())
+
+			Expect(buf.String()).To(Equal(
+				"Index 0 (machine master-0): needs update\n" +
+					"  - InstanceType: m6i.2xlarge != m6i.xlarge\n" +
+					"Index 1 (machine master-1): needs update\n" +
+					"  - InstanceType: m6i.2xlarge != m6i.xlarge\n" +
+					"Index 2 (machine master-2): needs update\n" +
+					"  - InstanceType: m6i.2xlarge != m6i.xlarge\n",
+			))
+		})
+	})
+})
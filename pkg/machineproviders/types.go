@@ -18,6 +18,8 @@ package machineproviders
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,6 +27,96 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// MachineIndexLabel is the label used to record the Control Plane Machine index on the Machines
+	// managed by the ControlPlaneMachineSet.
+	// When present, the label takes precedence over any other means of determining the index of a Machine.
+	MachineIndexLabel = "controlplanemachineset.machine.openshift.io/index"
+
+	// MachineOwnerLabel is the label used to record the name of the ControlPlaneMachineSet that created a Machine.
+	// Machines labelled as owned by a different ControlPlaneMachineSet are not associated with this one,
+	// even when they match its selector.
+	MachineOwnerLabel = "controlplanemachineset.machine.openshift.io/owner"
+
+	// InstanceTypeEquivalenceConfigMapName is the name of the optional ConfigMap, within the
+	// ControlPlaneMachineSet namespace, that declares which instance types are interchangeable.
+	// Machines whose instance type is equivalent to the desired instance type are not replaced.
+	InstanceTypeEquivalenceConfigMapName = "control-plane-machine-set-instance-type-equivalence"
+
+	// PersistedStateAnnotation is set on the ControlPlaneMachineSet by the controller to record, as a JSON encoded
+	// PersistedState, the progress of updates that must survive an operator restart.
+	// It is not intended to be set by users.
+	PersistedStateAnnotation = "controlplanemachineset.machine.openshift.io/state"
+
+	// DesiredNodeVersionAnnotation may be set on the ControlPlaneMachineSet to replace Control Plane Machines whose
+	// Node runs an out of date version, as reported by a Node label, independently of the provider spec.
+	// It holds a label=version pair, for example "feature.node.kubernetes.io/kernel-version.full=5.14.0-284.el9".
+	// Machines whose Node carries the label with a different value are reported as needing an update.
+	DesiredNodeVersionAnnotation = "controlplanemachineset.machine.openshift.io/desired-node-version"
+
+	// FailureDomainWeightsAnnotation may be set on the ControlPlaneMachineSet to bias the distribution of
+	// Machine indexes across failure domains. It holds a comma separated list of zone=weight pairs,
+	// for example "us-east-1a=2,us-east-1b=1". Failure domains without a weight have a weight of 1.
+	FailureDomainWeightsAnnotation = "controlplanemachineset.machine.openshift.io/failure-domain-weights"
+
+	// ScalePreferenceAnnotation may be set on the ControlPlaneMachineSet to choose the failure domain of new indexes
+	// when scaling up, and which indexes to remove when scaling down.
+	// With "Index", the default, new indexes follow the failure domain order and the highest indexes are removed first.
+	// With "BalanceZones", new indexes are placed in the least crowded failure domain and indexes are removed from the
+	// most crowded failure domain first, where crowding accounts for any failure domain weights.
+	ScalePreferenceAnnotation = "controlplanemachineset.machine.openshift.io/scale-preference"
+
+	// MachineNamePrefixAnnotation may be set on the ControlPlaneMachineSet to override the prefix of the names
+	// of new Machines, which is otherwise the cluster ID followed by the machine role.
+	// New Machines are named "<prefix>-<random suffix>-<index>", and are labelled with their index.
+	// The prefix must be a valid RFC1123 label.
+	MachineNamePrefixAnnotation = "controlplanemachineset.machine.openshift.io/machine-name-prefix"
+
+	// MachineOwnerReferencesAnnotation may be set on the ControlPlaneMachineSet to add owner references to new
+	// Machines, for example to coordinate garbage collection with another controller, as the Machine template
+	// metadata does not allow owner references to be set. It holds a JSON list of owner references.
+	// The ControlPlaneMachineSet remains the controller of each Machine, so the owner references must not be
+	// controller references.
+	MachineOwnerReferencesAnnotation = "controlplanemachineset.machine.openshift.io/machine-owner-references"
+
+	// ReplicasPolicyAnnotation may be set on the ControlPlaneMachineSet to the name of a ConfigMap, within the
+	// ControlPlaneMachineSet namespace, from which the desired number of Control Plane Machines is read in place
+	// of spec.replicas. This allows the control plane size to be managed by a higher level policy.
+	ReplicasPolicyAnnotation = "controlplanemachineset.machine.openshift.io/replicas-policy"
+
+	// MachineGenerationAnnotation is set on each Machine created by the ControlPlaneMachineSet to record the
+	// generation of the ControlPlaneMachineSet that the Machine was created for.
+	MachineGenerationAnnotation = "controlplanemachineset.machine.openshift.io/generation"
+
+	// MachineFailureDomainAnnotation is set on each Machine created by the ControlPlaneMachineSet to record the
+	// failure domain that the Machine was assigned when it was created.
+	MachineFailureDomainAnnotation = "controlplanemachineset.machine.openshift.io/failure-domain"
+
+	// MachineImageReferenceAnnotation is set on each Machine created by the ControlPlaneMachineSet from a template
+	// whose image is a reference, such as AWS AMI filters or GCP image families, to record the reference from which
+	// the image of the Machine is resolved. A concrete image on the Machine is only considered up to date with the
+	// image reference of the template when it was resolved from the same reference.
+	MachineImageReferenceAnnotation = "controlplanemachineset.machine.openshift.io/image-reference"
+
+	// MachineDeleteAnnotation is set on a Machine by a MachineHealthCheck when the Machine is unhealthy.
+	// Rather than deleting Control Plane Machines, which could cause a loss of quorum, the MachineHealthCheck
+	// leaves the annotation for the ControlPlaneMachineSet to replace the Machine before it is removed.
+	MachineDeleteAnnotation = "machine.openshift.io/delete-machine"
+)
+
+// ErrInsufficientQuota is returned by a CapacityChecker when the cloud provider does not have enough quota or
+// capacity available to create a new Machine.
+var ErrInsufficientQuota = errors.New("insufficient quota")
+
+// ErrMissingReferencedSecret is returned by a SecretChecker when a secret referenced by the Machine template
+// does not exist.
+var ErrMissingReferencedSecret = errors.New("missing referenced secret")
+
+// ErrFailureDomainsPlatformMismatch is returned when the platform of the failure domains within the Machine template
+// does not match the platform of the provider spec within the Machine template, for example, because the failure
+// domains were copied from a cluster on a different platform.
+var ErrFailureDomainsPlatformMismatch = errors.New("failure domains platform does not match provider spec platform")
+
 // MachineInfo collates information about a Control Plane Machine and Node.
 // This is used by the core of the ControlPlaneMachineSet controller to determine
 // actions required to be taken on the Machines within its control.
@@ -55,6 +147,52 @@ type MachineInfo struct {
 	// ErrorMessage is used to provide information about any errors that have occurred with the Machine. For example, if
 	// the Machine has an error state within its status, it should be propagated up via this error message.
 	ErrorMessage string
+
+	// ErrorReason is the machine readable reason for any errors that have occurred with the Machine, as reported by
+	// the provider within the Machine status. For example, this may indicate that the cloud provider rejected the
+	// Machine due to insufficient quota or an invalid configuration.
+	ErrorReason string
+
+	// NeedsRemediation is set true when the Machine has been marked for deletion by a MachineHealthCheck.
+	// A Machine needing remediation is also reported as needing an update, so that the update strategy
+	// replaces it as it would any other outdated Machine.
+	NeedsRemediation bool
+
+	// UnmatchedFailureDomain describes the failure domain of the Machine when it does not match any of the failure
+	// domains defined on the ControlPlaneMachineSet, for example, because the failure domain has since been removed.
+	// This is empty when the failure domain matches, or when no failure domains are defined.
+	// This is reported separately from NeedsUpdate, as the fix is usually to correct the failure domains.
+	UnmatchedFailureDomain string
+
+	// InconsistentProviderID describes how the providerID of the Machine is inconsistent with its Node, for example,
+	// because the Node has a different providerID after a restore, or because the Node no longer exists.
+	// This is empty when the Machine and its Node agree, or when the Machine has not yet been linked to a Node.
+	// A Machine with an inconsistent providerID is also reported as needing an update, so that it is replaced.
+	InconsistentProviderID string
+
+	// NodePending is set true when the Machine references a Node that cannot yet be found, and the Machine status
+	// was updated recently enough that the Node is expected to appear shortly, for example, while the Node object is
+	// still being propagated after the Machine was linked to it.
+	// Such a Machine is not Ready, but is not reported as having an inconsistent providerID, so it is not replaced.
+	// Once the grace period has passed, the Node is considered to have gone away.
+	NodePending bool
+
+	// AwaitingRemoval is set true when the Machine is being deleted and no pre-drain lifecycle hook is holding its
+	// deletion, so that it is only waiting to be drained and for its finalizers to complete.
+	// Such a Machine is not Ready, and its replacement is not created until it has been removed, to avoid surging
+	// while it is still present. A Machine held by a pre-drain hook, such as the etcd quorum hook, is not awaiting
+	// removal, as its deletion relies on the replacement being created.
+	AwaitingRemoval bool
+
+	// Provisioning is set true when the Machine has not yet reached the Provisioned phase, that is, the instance
+	// backing the Machine has not yet been created by the cloud provider.
+	// A Machine that has been linked to a Node, or that is being deleted, is not provisioning.
+	Provisioning bool
+
+	// ReadySince is the time from which the Node of the Machine has been continuously Ready, as reported by the last
+	// transition of the Node Ready condition. It moves forward whenever the Node flaps NotReady and back.
+	// This is zero when the Machine is not Ready.
+	ReadySince time.Time
 }
 
 // ObjectRef allows you to uniquely identify a resource within a cluster.
@@ -84,10 +222,49 @@ type MachineProvider interface {
 	// CreateMachine is used to instruct the Machine Provider to create a new Machine. The only input is the index for
 	// the new Machine. During construction of the MachineProvider, it should map indexes to failure domains so that it
 	// has all the required information for creating a new Machine stored, based solely on the index.
-	CreateMachine(context.Context, logr.Logger, int32) error
+	// It returns the name of the Machine that was created.
+	CreateMachine(context.Context, logr.Logger, int32) (string, error)
 
 	// DeleteMachine is used to instruct the Machine Provider to delete a particular Machine. This is used by the
 	// RollingUpdate strategy of the ControlPlaneMachineSet so that it can remove old Machines once they have been
 	// replaced.
 	DeleteMachine(context.Context, logr.Logger, *ObjectRef) error
 }
+
+// CapacityChecker is an optional interface that a MachineProvider may implement when the platform allows the
+// available quota or capacity to be queried, using the cloud credentials available to the Machine API.
+// When implemented, it is consulted before each Machine is created so that no Machine is created that is
+// certain to fail.
+type CapacityChecker interface {
+	// CheckCapacity is used to determine whether a new Machine can be created for the given index.
+	// When there is not enough quota or capacity, the error returned should wrap ErrInsufficientQuota.
+	// Any other error means that the check itself could not be performed.
+	CheckCapacity(context.Context, logr.Logger, int32) error
+}
+
+// SecretChecker is an optional interface that a MachineProvider may implement when the Machine template can
+// reference secrets, such as the user data and credentials secrets, that the new Machine depends on.
+// When implemented, it is consulted before each Machine is created so that no Machine is created that
+// cannot start.
+type SecretChecker interface {
+	// CheckReferencedSecrets is used to determine whether the secrets referenced by the Machine template exist.
+	// When a secret does not exist, the error returned should wrap ErrMissingReferencedSecret.
+	// Any other error means that the check itself could not be performed.
+	CheckReferencedSecrets(context.Context, logr.Logger) error
+}
+
+// ScaleDownSelector is an optional interface that a MachineProvider may implement when it knows the failure domain
+// of each index. When implemented, it is consulted to choose which indexes should be removed when there are more
+// indexes than the desired number of replicas.
+type ScaleDownSelector interface {
+	// SelectScaleDownIndexes is used to choose the given number of indexes to remove from the indexes provided.
+	// The indexes returned are sorted in ascending order.
+	SelectScaleDownIndexes(logr.Logger, []int32, int) []int32
+}
+
+// FailureDomainMapper is an optional interface that a MachineProvider may implement when it maps indexes to failure
+// domains. When implemented, it is used to describe the mapping, for example, when collecting diagnostics.
+type FailureDomainMapper interface {
+	// FailureDomainMapping returns a description of the failure domain mapped to each index.
+	FailureDomainMapping() map[int32]string
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package machineproviders
 
 import (
+	"time"
+
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -34,6 +36,7 @@ func MachineInfo() MachineInfoBuilder {
 type MachineInfoBuilder struct {
 	machineDeletiontimestamp *metav1.Time
 	machineCreationtimestamp metav1.Time
+	machineAnnotations       map[string]string
 	machineGVR               schema.GroupVersionResource
 	machineName              string
 	machineNamespace         string
@@ -44,26 +47,45 @@ type MachineInfoBuilder struct {
 	nodeName string
 
 	errorMessage string
+	errorReason  string
 	index        int32
 	needsUpdate  bool
 	ready        bool
 	diff         []string
+
+	needsRemediation       bool
+	unmatchedFailureDomain string
+	inconsistentProviderID string
+	nodePending            bool
+	awaitingRemoval        bool
+	provisioning           bool
+	readySince             time.Time
 }
 
 // Build builds a new machineinfo based on the configuration provided.
 func (m MachineInfoBuilder) Build() machineproviders.MachineInfo {
 	info := machineproviders.MachineInfo{
 		ErrorMessage: m.errorMessage,
+		ErrorReason:  m.errorReason,
 		Index:        m.index,
 		Ready:        m.ready,
 		NeedsUpdate:  m.needsUpdate,
 		Diff:         m.diff,
+
+		NeedsRemediation:       m.needsRemediation,
+		UnmatchedFailureDomain: m.unmatchedFailureDomain,
+		InconsistentProviderID: m.inconsistentProviderID,
+		NodePending:            m.nodePending,
+		AwaitingRemoval:        m.awaitingRemoval,
+		Provisioning:           m.provisioning,
+		ReadySince:             m.readySince,
 	}
 
 	if m.machineName != "" {
 		info.MachineRef = &machineproviders.ObjectRef{
 			GroupVersionResource: m.machineGVR,
 			ObjectMeta: metav1.ObjectMeta{
+				Annotations:       m.machineAnnotations,
 				DeletionTimestamp: m.machineDeletiontimestamp,
 				CreationTimestamp: m.machineCreationtimestamp,
 				Labels:            m.machineLabels,
@@ -101,6 +123,12 @@ func (m MachineInfoBuilder) WithDiff(diff []string) MachineInfoBuilder {
 	return m
 }
 
+// WithMachineAnnotations sets the machine annotations for the machineinfo builder.
+func (m MachineInfoBuilder) WithMachineAnnotations(annotations map[string]string) MachineInfoBuilder {
+	m.machineAnnotations = annotations
+	return m
+}
+
 // WithMachineCreationTimestamp sets the machine creation timestamp for the machineinfo builder.
 func (m MachineInfoBuilder) WithMachineCreationTimestamp(creation metav1.Time) MachineInfoBuilder {
 	m.machineCreationtimestamp = creation
@@ -167,6 +195,12 @@ func (m MachineInfoBuilder) WithErrorMessage(errorMsg string) MachineInfoBuilder
 	return m
 }
 
+// WithErrorReason sets the error reason for the machineinfo builder.
+func (m MachineInfoBuilder) WithErrorReason(errorReason string) MachineInfoBuilder {
+	m.errorReason = errorReason
+	return m
+}
+
 // WithIndex sets the index for the machineinfo builder.
 func (m MachineInfoBuilder) WithIndex(index int32) MachineInfoBuilder {
 	m.index = index
@@ -179,8 +213,50 @@ func (m MachineInfoBuilder) WithNeedsUpdate(needsUpdate bool) MachineInfoBuilder
 	return m
 }
 
+// WithNeedsRemediation sets the needsremediation for the machineinfo builder.
+func (m MachineInfoBuilder) WithNeedsRemediation(needsRemediation bool) MachineInfoBuilder {
+	m.needsRemediation = needsRemediation
+	return m
+}
+
+// WithUnmatchedFailureDomain sets the unmatchedfailuredomain for the machineinfo builder.
+func (m MachineInfoBuilder) WithUnmatchedFailureDomain(unmatchedFailureDomain string) MachineInfoBuilder {
+	m.unmatchedFailureDomain = unmatchedFailureDomain
+	return m
+}
+
+// WithInconsistentProviderID sets the inconsistentproviderid for the machineinfo builder.
+func (m MachineInfoBuilder) WithInconsistentProviderID(inconsistentProviderID string) MachineInfoBuilder {
+	m.inconsistentProviderID = inconsistentProviderID
+	return m
+}
+
+// WithNodePending sets the nodepending for the machineinfo builder.
+func (m MachineInfoBuilder) WithNodePending(nodePending bool) MachineInfoBuilder {
+	m.nodePending = nodePending
+	return m
+}
+
+// WithAwaitingRemoval sets the awaitingremoval for the machineinfo builder.
+func (m MachineInfoBuilder) WithAwaitingRemoval(awaitingRemoval bool) MachineInfoBuilder {
+	m.awaitingRemoval = awaitingRemoval
+	return m
+}
+
+// WithProvisioning sets the provisioning for the machineinfo builder.
+func (m MachineInfoBuilder) WithProvisioning(provisioning bool) MachineInfoBuilder {
+	m.provisioning = provisioning
+	return m
+}
+
 // WithReady sets the ready for the machineinfo builder.
 func (m MachineInfoBuilder) WithReady(ready bool) MachineInfoBuilder {
 	m.ready = ready
 	return m
 }
+
+// WithReadySince sets the readysince for the machineinfo builder.
+func (m MachineInfoBuilder) WithReadySince(readySince time.Time) MachineInfoBuilder {
+	m.readySince = readySince
+	return m
+}
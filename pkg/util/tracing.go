@@ -0,0 +1,117 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation name of the spans recorded by the operator.
+	TracerName = "github.com/openshift/cluster-control-plane-machine-set-operator"
+
+	// tracesExporterEnvVar is the standard OpenTelemetry environment variable that selects the span exporter.
+	tracesExporterEnvVar = "OTEL_TRACES_EXPORTER"
+
+	// tracesExporterNone disables tracing.
+	tracesExporterNone = "none"
+
+	// tracesExporterConsole writes each span to the operator log.
+	tracesExporterConsole = "console"
+)
+
+// errUnsupportedTracesExporter is used to inform users that the span exporter they have selected is not supported.
+var errUnsupportedTracesExporter = errors.New("unsupported traces exporter")
+
+// NewTracerProviderFromEnv returns the tracer provider selected by the standard OpenTelemetry environment variables,
+// along with a function to flush and stop it once the operator exits.
+// OTEL_TRACES_EXPORTER selects the exporter: when unset or "none", no spans are recorded, and when "console", each span
+// is written to the operator log. The sampler, the batching of spans and the resource describing the operator are
+// configured by the SDK from the remaining OTEL_* environment variables, such as OTEL_TRACES_SAMPLER,
+// OTEL_BSP_SCHEDULE_DELAY and OTEL_SERVICE_NAME.
+func NewTracerProviderFromEnv(logger logr.Logger) (trace.TracerProvider, func(context.Context) error, error) {
+	switch exporter := os.Getenv(tracesExporterEnvVar); exporter {
+	case "", tracesExporterNone:
+		return trace.NewNoopTracerProvider(), func(context.Context) error { return nil }, nil
+	case tracesExporterConsole:
+		tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(&logSpanExporter{logger: logger}))
+
+		return tracerProvider, tracerProvider.Shutdown, nil
+	default:
+		return nil, nil, fmt.Errorf("%w %q in %s, supported values are %q and %q",
+			errUnsupportedTracesExporter, exporter, tracesExporterEnvVar, tracesExporterNone, tracesExporterConsole)
+	}
+}
+
+// logSpanExporter exports spans by writing each of them to the log.
+type logSpanExporter struct {
+	logger logr.Logger
+}
+
+// ExportSpans writes each of the spans to the log, along with its attributes.
+func (e *logSpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		keysAndValues := []interface{}{
+			"name", span.Name(),
+			"traceID", span.SpanContext().TraceID().String(),
+			"spanID", span.SpanContext().SpanID().String(),
+			"parentSpanID", span.Parent().SpanID().String(),
+			"duration", span.EndTime().Sub(span.StartTime()).String(),
+			"status", span.Status().Code.String(),
+		}
+
+		for _, attr := range span.Attributes() {
+			keysAndValues = append(keysAndValues, string(attr.Key), attr.Value.Emit())
+		}
+
+		e.logger.Info("Span", keysAndValues...)
+	}
+
+	return nil
+}
+
+// Shutdown stops the exporter. As spans are written as they are exported, there is nothing to flush.
+func (e *logSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// StartSpan starts a span as a child of the span in the context, using the same tracer provider.
+// When the context has no span, the span is not recorded.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(TracerName)
+
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error, if any, on the span and then ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("NewTracerProviderFromEnv", func() {
+	var logger testutils.TestLogger
+
+	// setTracesExporter sets the traces exporter environment variable for the duration of the spec.
+	setTracesExporter := func(value string) {
+		previous, ok := os.LookupEnv(tracesExporterEnvVar)
+		Expect(os.Setenv(tracesExporterEnvVar, value)).To(Succeed())
+
+		DeferCleanup(func() {
+			if ok {
+				Expect(os.Setenv(tracesExporterEnvVar, previous)).To(Succeed())
+			} else {
+				Expect(os.Unsetenv(tracesExporterEnvVar)).To(Succeed())
+			}
+		})
+	}
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+	})
+
+	DescribeTable("when tracing is disabled", func(value string) {
+		setTracesExporter(value)
+
+		tracerProvider, shutdown, err := NewTracerProviderFromEnv(logger.Logger())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, span := tracerProvider.Tracer(TracerName).Start(context.Background(), "Reconcile")
+		Expect(span.IsRecording()).To(BeFalse())
+		span.End()
+
+		Expect(shutdown(context.Background())).To(Succeed())
+	},
+		Entry("with no exporter", ""),
+		Entry("with the none exporter", tracesExporterNone),
+	)
+
+	Context("with the console exporter", func() {
+		var span trace.Span
+
+		BeforeEach(func() {
+			setTracesExporter(tracesExporterConsole)
+
+			tracerProvider, shutdown, err := NewTracerProviderFromEnv(logger.Logger())
+			Expect(err).ToNot(HaveOccurred())
+
+			var ctx context.Context
+			ctx, span = tracerProvider.Tracer(TracerName).Start(context.Background(), "Reconcile")
+
+			_, child := StartSpan(ctx, "CreateMachine", attribute.Int("index", 1))
+			EndSpan(child, nil)
+			span.End()
+
+			Expect(shutdown(context.Background())).To(Succeed())
+		})
+
+		It("records the spans", func() {
+			Expect(span.IsRecording()).To(BeFalse(), "the span should have ended")
+			Expect(span.SpanContext().IsValid()).To(BeTrue())
+		})
+
+		It("writes each span to the log once the tracer provider is shut down", func() {
+			Expect(logger.Entries()).To(ConsistOf(
+				SatisfyAll(
+					HaveField("Message", Equal("Span")),
+					HaveField("KeysAndValues", ContainElements("name", "CreateMachine", "parentSpanID", span.SpanContext().SpanID().String(), "index", "1")),
+				),
+				SatisfyAll(
+					HaveField("Message", Equal("Span")),
+					HaveField("KeysAndValues", ContainElements("name", "Reconcile", "traceID", span.SpanContext().TraceID().String())),
+				),
+			))
+		})
+	})
+
+	It("rejects an unsupported exporter", func() {
+		setTracesExporter("otlp")
+
+		_, _, err := NewTracerProviderFromEnv(logger.Logger())
+		Expect(err).To(MatchError(ContainSubstring(`unsupported traces exporter "otlp" in OTEL_TRACES_EXPORTER`)))
+	})
+})
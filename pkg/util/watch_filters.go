@@ -86,6 +86,58 @@ func FilterClusterOperator(name string) predicate.Predicate {
 	})
 }
 
+// FilterConfigMap filters config map requests
+// to just the one with the name and namespace provided.
+func FilterConfigMap(name, namespace string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		configMap, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			panic(fmt.Sprintf("expected to get an of object of type corev1.ConfigMap: got type %T", obj))
+		}
+
+		return configMap.GetNamespace() == namespace && configMap.GetName() == name
+	})
+}
+
+// FilterFeatureGate filters feature gate requests
+// to just the one with the name provided.
+func FilterFeatureGate(name string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		featureGate, ok := obj.(*configv1.FeatureGate)
+		if !ok {
+			panic(fmt.Sprintf("expected to get an of object of type configv1.FeatureGate: got type %T", obj))
+		}
+
+		return featureGate.GetName() == name
+	})
+}
+
+// FilterInfrastructure filters infrastructure requests
+// to just the one with the name provided.
+func FilterInfrastructure(name string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		infrastructure, ok := obj.(*configv1.Infrastructure)
+		if !ok {
+			panic(fmt.Sprintf("expected to get an of object of type configv1.Infrastructure: got type %T", obj))
+		}
+
+		return infrastructure.GetName() == name
+	})
+}
+
+// FilterClusterVersion filters cluster version requests
+// to just the one with the name provided.
+func FilterClusterVersion(name string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		clusterVersion, ok := obj.(*configv1.ClusterVersion)
+		if !ok {
+			panic(fmt.Sprintf("expected to get an of object of type configv1.ClusterVersion: got type %T", obj))
+		}
+
+		return clusterVersion.GetName() == name
+	})
+}
+
 // FilterControlPlaneMachineSet filters control plane machine set requests
 // to just the singleton within the namespace provided.
 func FilterControlPlaneMachineSet(controlPlaneMachineSetName, namespace string) predicate.Predicate {
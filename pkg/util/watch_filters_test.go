@@ -21,12 +21,14 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/api/config/v1"
 	configv1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/config/v1"
 	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -63,6 +65,35 @@ var _ = Describe("Watch Filters", func() {
 				},
 			}))
 		})
+
+		It("returns a correct request for the cluster ControlPlaneMachineSet when a control plane node becomes ready", func() {
+			node := corev1resourcebuilder.Node().WithName("master-0").WithConditions(
+				[]corev1.NodeCondition{
+					{
+						Type:   corev1.NodeReady,
+						Status: corev1.ConditionTrue,
+					},
+				},
+			).AsMaster().Build()
+
+			Expect(clusterOperatorFilter(ctx, node)).To(ConsistOf(reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: testNamespace,
+					Name:      clusterControlPlaneMachineSetName,
+				},
+			}))
+		})
+
+		It("returns a correct request for the cluster ControlPlaneMachineSet when the Infrastructure changes", func() {
+			infrastructure := &configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+			Expect(clusterOperatorFilter(ctx, infrastructure)).To(ConsistOf(reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: testNamespace,
+					Name:      clusterControlPlaneMachineSetName,
+				},
+			}))
+		})
 	})
 
 	// createEvent is used to pass objects to the predicate Create function.
@@ -150,6 +181,158 @@ var _ = Describe("Watch Filters", func() {
 		})
 	})
 
+	Context("filterConfigMap", func() {
+		const testNamespace = "test"
+		const configMapName = "instance-type-equivalence"
+
+		var configMapPredicate predicate.Predicate
+
+		BeforeEach(func() {
+			configMapPredicate = FilterConfigMap(configMapName, testNamespace)
+		})
+
+		It("Panics with the wrong object kind", func() {
+			expectedMessage := "expected to get an of object of type corev1.ConfigMap: got type *v1beta1.Machine"
+			machine := machinev1beta1resourcebuilder.Machine().Build()
+
+			Expect(func() {
+				configMapPredicate.Create(createEvent(machine))
+			}).To(PanicWith(expectedMessage), "A programming error occurs when passing the wrong object, the function should panic")
+		})
+
+		It("returns false when a config map with a different name is provided", func() {
+			configMap := corev1resourcebuilder.ConfigMap().WithName("other").WithNamespace(testNamespace).Build()
+
+			Expect(configMapPredicate.Create(createEvent(configMap))).To(BeFalse())
+			Expect(configMapPredicate.Update(updateEvent(configMap))).To(BeFalse())
+			Expect(configMapPredicate.Delete(deleteEvent(configMap))).To(BeFalse())
+			Expect(configMapPredicate.Generic(genericEvent(configMap))).To(BeFalse())
+		})
+
+		It("returns false when the config map is in a different namespace", func() {
+			configMap := corev1resourcebuilder.ConfigMap().WithName(configMapName).WithNamespace("other").Build()
+
+			Expect(configMapPredicate.Create(createEvent(configMap))).To(BeFalse())
+			Expect(configMapPredicate.Update(updateEvent(configMap))).To(BeFalse())
+			Expect(configMapPredicate.Delete(deleteEvent(configMap))).To(BeFalse())
+			Expect(configMapPredicate.Generic(genericEvent(configMap))).To(BeFalse())
+		})
+
+		It("returns true when the correct config map is provided", func() {
+			configMap := corev1resourcebuilder.ConfigMap().WithName(configMapName).WithNamespace(testNamespace).Build()
+
+			Expect(configMapPredicate.Create(createEvent(configMap))).To(BeTrue())
+			Expect(configMapPredicate.Update(updateEvent(configMap))).To(BeTrue())
+			Expect(configMapPredicate.Delete(deleteEvent(configMap))).To(BeTrue())
+			Expect(configMapPredicate.Generic(genericEvent(configMap))).To(BeTrue())
+		})
+	})
+
+	Context("filterFeatureGate", func() {
+		var featureGatePredicate predicate.Predicate
+
+		BeforeEach(func() {
+			featureGatePredicate = FilterFeatureGate("cluster")
+		})
+
+		It("Panics with the wrong object kind", func() {
+			expectedMessage := "expected to get an of object of type configv1.FeatureGate: got type *v1beta1.Machine"
+			machine := machinev1beta1resourcebuilder.Machine().Build()
+
+			Expect(func() {
+				featureGatePredicate.Create(createEvent(machine))
+			}).To(PanicWith(expectedMessage), "A programming error occurs when passing the wrong object, the function should panic")
+		})
+
+		It("returns false when a feature gate with a different name is provided", func() {
+			featureGate := &configv1.FeatureGate{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+
+			Expect(featureGatePredicate.Create(createEvent(featureGate))).To(BeFalse())
+			Expect(featureGatePredicate.Update(updateEvent(featureGate))).To(BeFalse())
+			Expect(featureGatePredicate.Delete(deleteEvent(featureGate))).To(BeFalse())
+			Expect(featureGatePredicate.Generic(genericEvent(featureGate))).To(BeFalse())
+		})
+
+		It("returns true when the correct feature gate is provided", func() {
+			featureGate := &configv1.FeatureGate{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+			Expect(featureGatePredicate.Create(createEvent(featureGate))).To(BeTrue())
+			Expect(featureGatePredicate.Update(updateEvent(featureGate))).To(BeTrue())
+			Expect(featureGatePredicate.Delete(deleteEvent(featureGate))).To(BeTrue())
+			Expect(featureGatePredicate.Generic(genericEvent(featureGate))).To(BeTrue())
+		})
+	})
+
+	Context("FilterInfrastructure", func() {
+		var infrastructurePredicate predicate.Predicate
+
+		BeforeEach(func() {
+			infrastructurePredicate = FilterInfrastructure("cluster")
+		})
+
+		It("Panics with the wrong object kind", func() {
+			expectedMessage := "expected to get an of object of type configv1.Infrastructure: got type *v1beta1.Machine"
+			machine := machinev1beta1resourcebuilder.Machine().Build()
+
+			Expect(func() {
+				infrastructurePredicate.Create(createEvent(machine))
+			}).To(PanicWith(expectedMessage), "A programming error occurs when passing the wrong object, the function should panic")
+		})
+
+		It("returns false when an infrastructure with a different name is provided", func() {
+			infrastructure := &configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+
+			Expect(infrastructurePredicate.Create(createEvent(infrastructure))).To(BeFalse())
+			Expect(infrastructurePredicate.Update(updateEvent(infrastructure))).To(BeFalse())
+			Expect(infrastructurePredicate.Delete(deleteEvent(infrastructure))).To(BeFalse())
+			Expect(infrastructurePredicate.Generic(genericEvent(infrastructure))).To(BeFalse())
+		})
+
+		It("returns true when the correct infrastructure is provided", func() {
+			infrastructure := &configv1.Infrastructure{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+			Expect(infrastructurePredicate.Create(createEvent(infrastructure))).To(BeTrue())
+			Expect(infrastructurePredicate.Update(updateEvent(infrastructure))).To(BeTrue())
+			Expect(infrastructurePredicate.Delete(deleteEvent(infrastructure))).To(BeTrue())
+			Expect(infrastructurePredicate.Generic(genericEvent(infrastructure))).To(BeTrue())
+		})
+	})
+
+	Context("FilterClusterVersion", func() {
+		var clusterVersionPredicate predicate.Predicate
+
+		BeforeEach(func() {
+			clusterVersionPredicate = FilterClusterVersion("version")
+		})
+
+		It("Panics with the wrong object kind", func() {
+			expectedMessage := "expected to get an of object of type configv1.ClusterVersion: got type *v1beta1.Machine"
+			machine := machinev1beta1resourcebuilder.Machine().Build()
+
+			Expect(func() {
+				clusterVersionPredicate.Create(createEvent(machine))
+			}).To(PanicWith(expectedMessage), "A programming error occurs when passing the wrong object, the function should panic")
+		})
+
+		It("returns false when a cluster version with a different name is provided", func() {
+			clusterVersion := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+
+			Expect(clusterVersionPredicate.Create(createEvent(clusterVersion))).To(BeFalse())
+			Expect(clusterVersionPredicate.Update(updateEvent(clusterVersion))).To(BeFalse())
+			Expect(clusterVersionPredicate.Delete(deleteEvent(clusterVersion))).To(BeFalse())
+			Expect(clusterVersionPredicate.Generic(genericEvent(clusterVersion))).To(BeFalse())
+		})
+
+		It("returns true when the correct cluster version is provided", func() {
+			clusterVersion := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}}
+
+			Expect(clusterVersionPredicate.Create(createEvent(clusterVersion))).To(BeTrue())
+			Expect(clusterVersionPredicate.Update(updateEvent(clusterVersion))).To(BeTrue())
+			Expect(clusterVersionPredicate.Delete(deleteEvent(clusterVersion))).To(BeTrue())
+			Expect(clusterVersionPredicate.Generic(genericEvent(clusterVersion))).To(BeTrue())
+		})
+	})
+
 	Context("filterControlPlaneMachineSet", func() {
 		const testNamespace = "test"
 
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+const (
+	// servingCheckInterval is the interval at which the webhook server is checked to be serving.
+	servingCheckInterval = time.Minute
+
+	// webhookServerNotServing is a log message used to warn the user that the webhook server is not serving.
+	// The webhook fails closed, so the API server rejects any create or update of the ControlPlaneMachineSet
+	// until the webhook server is serving again.
+	webhookServerNotServing = "Webhook server is not serving, creating or updating the control plane machine set will fail until it is"
+
+	// webhookServerServing is a log message used to inform the user that the webhook server is serving again.
+	webhookServerServing = "Webhook server is serving again"
+)
+
+// servingCheck periodically checks that the webhook server of the operator is accepting connections
+// and warns when it is not.
+// It runs on every replica of the operator, as each replica serves the webhook.
+type servingCheck struct {
+	checker  healthz.Checker
+	interval time.Duration
+	logger   logr.Logger
+
+	// serving records the result of the previous check, so that recovery is only logged once.
+	serving bool
+}
+
+// newServingCheck creates a new serving check for the webhook server checker.
+func newServingCheck(logger logr.Logger, checker healthz.Checker) *servingCheck {
+	return &servingCheck{
+		checker:  checker,
+		interval: servingCheckInterval,
+		logger:   logger,
+		serving:  true,
+	}
+}
+
+// Start checks the webhook server at each interval until the context is cancelled.
+// The first check happens after a full interval, to give the webhook server time to start.
+func (s *servingCheck) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that the check runs on every replica.
+func (s *servingCheck) NeedLeaderElection() bool {
+	return false
+}
+
+// check runs the checker once, warning while the webhook server is not serving
+// and logging when it resumes serving.
+func (s *servingCheck) check() {
+	if err := s.checker(nil); err != nil {
+		s.logger.Error(err, webhookServerNotServing)
+		s.serving = false
+
+		return
+	}
+
+	if !s.serving {
+		s.logger.Info(webhookServerServing)
+	}
+
+	s.serving = true
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controlplanemachineset
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/cluster-api-actuator-pkg/testutils"
+)
+
+var _ = Describe("Webhook serving check", func() {
+	var logger testutils.TestLogger
+	var checkErr error
+	var check *servingCheck
+
+	errNotReachable := errors.New("webhook server is not reachable: connection refused")
+
+	BeforeEach(func() {
+		logger = testutils.NewTestLogger()
+		checkErr = nil
+
+		check = newServingCheck(logger.Logger(), func(_ *http.Request) error {
+			return checkErr
+		})
+	})
+
+	Context("when the webhook server is serving", func() {
+		BeforeEach(func() {
+			check.check()
+		})
+
+		It("does not log", func() {
+			Expect(logger.Entries()).To(BeEmpty())
+		})
+	})
+
+	Context("when the webhook server is not serving", func() {
+		BeforeEach(func() {
+			checkErr = errNotReachable
+
+			check.check()
+		})
+
+		It("warns that the webhook server is not serving", func() {
+			Expect(logger.Entries()).To(ConsistOf(
+				testutils.LogEntry{
+					Error:   errNotReachable,
+					Message: webhookServerNotServing,
+				},
+			))
+		})
+
+		Context("and then resumes serving", func() {
+			BeforeEach(func() {
+				checkErr = nil
+
+				check.check()
+				check.check()
+			})
+
+			It("logs once that the webhook server is serving again", func() {
+				Expect(logger.Entries()).To(ConsistOf(
+					testutils.LogEntry{
+						Error:   errNotReachable,
+						Message: webhookServerNotServing,
+					},
+					testutils.LogEntry{
+						Message: webhookServerServing,
+					},
+				))
+			})
+		})
+	})
+
+	Context("when started", func() {
+		var startErr error
+
+		BeforeEach(func() {
+			checkCtx, cancel := context.WithCancel(context.Background())
+			check.interval = 100 * time.Millisecond
+
+			// Cancel the context from within the first check, so that Start returns once it has checked.
+			check.checker = func(_ *http.Request) error {
+				cancel()
+				return errNotReachable
+			}
+
+			startErr = check.Start(checkCtx)
+		})
+
+		It("checks the webhook server until the context is cancelled", func() {
+			Expect(startErr).ToNot(HaveOccurred())
+			Expect(logger.Entries()).To(ConsistOf(
+				testutils.LogEntry{
+					Error:   errNotReachable,
+					Message: webhookServerNotServing,
+				},
+			))
+		})
+	})
+})
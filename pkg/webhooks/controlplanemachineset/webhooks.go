@@ -18,19 +18,25 @@ package controlplanemachineset
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/failuredomain"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders/providers/openshift/machine/v1beta1/providerconfig"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -54,6 +60,14 @@ const (
 	// clusterSingletonName is the OpenShift standard name, "cluster", for singleton
 	// resources. All ControlPlaneMachineSet resources must use this name.
 	clusterSingletonName = "cluster"
+
+	// minimumControlPlaneCPUs is the fewest virtual CPUs with which a control plane machine may be configured.
+	// This is well below the resources recommended for a control plane machine, so that only provider specs with which
+	// the machine could never become a working control plane member are rejected.
+	minimumControlPlaneCPUs = 2
+
+	// minimumControlPlaneMemoryMiB is the least memory, in MiB, with which a control plane machine may be configured.
+	minimumControlPlaneMemoryMiB = 8 * 1024
 )
 
 var (
@@ -69,6 +83,10 @@ var (
 type ControlPlaneMachineSetWebhook struct {
 	client client.Client
 	logger logr.Logger
+
+	// Namespace is the Machine API namespace, in which the ControlPlaneMachineSet controller operates.
+	// Machines are created within this namespace, so the template machine namespace, when set, must match it.
+	Namespace string
 }
 
 // SetupWebhookWithManager sets up a new ControlPlaneMachineSet webhook with the manager.
@@ -81,6 +99,10 @@ func (r *ControlPlaneMachineSetWebhook) SetupWebhookWithManager(mgr ctrl.Manager
 		return fmt.Errorf("error constructing ControlPlaneMachineSet webhook: %w", err)
 	}
 
+	if err := mgr.Add(newServingCheck(logger, mgr.GetWebhookServer().StartedChecker())); err != nil {
+		return fmt.Errorf("error adding ControlPlaneMachineSet webhook serving check: %w", err)
+	}
+
 	return nil
 }
 
@@ -91,7 +113,6 @@ var _ webhook.CustomValidator = &ControlPlaneMachineSetWebhook{}
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
 func (r *ControlPlaneMachineSetWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	var errs []error
-	// TODO: actually plug in admission warnings.
 	var warnings []string
 
 	cpms, ok := obj.(*machinev1.ControlPlaneMachineSet)
@@ -100,9 +121,12 @@ func (r *ControlPlaneMachineSetWebhook) ValidateCreate(ctx context.Context, obj
 	}
 
 	errs = append(errs, validateMetadata(field.NewPath("metadata"), cpms.ObjectMeta)...)
-	errs = append(errs, validateSpec(r.logger, field.NewPath("spec"), cpms)...)
+	errs = append(errs, validateSpec(r.logger, field.NewPath("spec"), cpms, r.Namespace)...)
 	errs = append(errs, r.validateSpecOnCreate(ctx, field.NewPath("spec"), cpms)...)
 
+	warnings = append(warnings, r.warnOnMissingReferencedSecrets(ctx, field.NewPath("spec", "template"), cpms)...)
+	warnings = append(warnings, r.warnOnSpotControlPlaneMachines(field.NewPath("spec", "template"), cpms)...)
+
 	if len(errs) > 0 {
 		return warnings, utilerrors.NewAggregate(errs)
 	}
@@ -113,7 +137,6 @@ func (r *ControlPlaneMachineSetWebhook) ValidateCreate(ctx context.Context, obj
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
 func (r *ControlPlaneMachineSetWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
 	var errs []error
-	// TODO: actually plug in admission warnings.
 	var warnings []string
 
 	if oldObj == nil {
@@ -126,7 +149,10 @@ func (r *ControlPlaneMachineSetWebhook) ValidateUpdate(ctx context.Context, oldO
 	}
 
 	errs = append(errs, validateMetadata(field.NewPath("metadata"), cpms.ObjectMeta)...)
-	errs = append(errs, validateSpec(r.logger, field.NewPath("spec"), cpms)...)
+	errs = append(errs, validateSpec(r.logger, field.NewPath("spec"), cpms, r.Namespace)...)
+
+	warnings = append(warnings, r.warnOnMissingReferencedSecrets(ctx, field.NewPath("spec", "template"), cpms)...)
+	warnings = append(warnings, r.warnOnSpotControlPlaneMachines(field.NewPath("spec", "template"), cpms)...)
 
 	if len(errs) > 0 {
 		return warnings, utilerrors.NewAggregate(errs)
@@ -173,20 +199,62 @@ func validateMetadata(parentPath *field.Path, metadata metav1.ObjectMeta) []erro
 		errs = append(errs, field.Invalid(parentPath.Child("name"), metadata.Name, "control plane machine set name must be cluster"))
 	}
 
+	if prefix, ok := metadata.Annotations[machineproviders.MachineNamePrefixAnnotation]; ok {
+		if msgs := validation.IsDNS1123Label(prefix); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(parentPath.Child("annotations").Key(machineproviders.MachineNamePrefixAnnotation), prefix,
+				fmt.Sprintf("machine name prefix must be a valid RFC1123 label: %s", strings.Join(msgs, ", "))))
+		}
+	}
+
+	if value, ok := metadata.Annotations[machineproviders.MachineOwnerReferencesAnnotation]; ok {
+		errs = append(errs, validateMachineOwnerReferences(parentPath.Child("annotations").Key(machineproviders.MachineOwnerReferencesAnnotation), value)...)
+	}
+
+	if name, ok := metadata.Annotations[machineproviders.ReplicasPolicyAnnotation]; ok {
+		if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(parentPath.Child("annotations").Key(machineproviders.ReplicasPolicyAnnotation), name,
+				fmt.Sprintf("replicas policy must be the name of a config map: %s", strings.Join(msgs, ", "))))
+		}
+	}
+
+	return errs
+}
+
+// validateMachineOwnerReferences validates that the machine owner references annotation holds a list of
+// complete owner references, none of which are controller references.
+func validateMachineOwnerReferences(fldPath *field.Path, value string) []error {
+	ownerReferences := []metav1.OwnerReference{}
+	if err := json.Unmarshal([]byte(value), &ownerReferences); err != nil {
+		return []error{field.Invalid(fldPath, value, fmt.Sprintf("machine owner references must be a JSON list of owner references: %v", err))}
+	}
+
+	errs := []error{}
+
+	for i, ref := range ownerReferences {
+		if ref.APIVersion == "" || ref.Kind == "" || ref.Name == "" || ref.UID == "" {
+			errs = append(errs, field.Invalid(fldPath, value, fmt.Sprintf("machine owner reference %d must specify an apiVersion, kind, name and uid", i)))
+		}
+
+		if ref.Controller != nil && *ref.Controller {
+			errs = append(errs, field.Invalid(fldPath, value, fmt.Sprintf("machine owner reference %d must not be a controller reference, the control plane machine set is the controller of its machines", i)))
+		}
+	}
+
 	return errs
 }
 
 // validateSpec validates that the spec of the ControlPlaneMachineSet resource is valid.
-func validateSpec(logger logr.Logger, parentPath *field.Path, cpms *machinev1.ControlPlaneMachineSet) []error {
+// The namespace is the Machine API namespace in which Machines are created.
+func validateSpec(logger logr.Logger, parentPath *field.Path, cpms *machinev1.ControlPlaneMachineSet, namespace string) []error {
 	errs := []error{}
 
-	errs = append(errs, validateTemplate(logger, parentPath.Child("template"), cpms.Spec.Template, cpms.Spec.Selector)...)
+	errs = append(errs, validateTemplate(logger, parentPath.Child("template"), cpms.Spec.Template, cpms.Spec.Selector, namespace)...)
 
 	return errs
 }
 
 // validateTemplate validates the common (on create and update) checks for the ControlPlaneMachineSet template.
-func validateTemplate(logger logr.Logger, parentPath *field.Path, template machinev1.ControlPlaneMachineSetTemplate, selector metav1.LabelSelector) []error {
+func validateTemplate(logger logr.Logger, parentPath *field.Path, template machinev1.ControlPlaneMachineSetTemplate, selector metav1.LabelSelector, namespace string) []error {
 	switch template.MachineType {
 	case machinev1.OpenShiftMachineV1Beta1MachineType:
 		openshiftMachineTemplatePath := parentPath.Child(string(machinev1.OpenShiftMachineV1Beta1MachineType))
@@ -197,7 +265,7 @@ func validateTemplate(logger logr.Logger, parentPath *field.Path, template machi
 			return []error{field.Required(openshiftMachineTemplatePath, fmt.Sprintf("%s is required when machine type is %s", machinev1.OpenShiftMachineV1Beta1MachineType, machinev1.OpenShiftMachineV1Beta1MachineType))}
 		}
 
-		return validateOpenShiftMachineV1BetaTemplate(logger, openshiftMachineTemplatePath, *template.OpenShiftMachineV1Beta1Machine, selector)
+		return validateOpenShiftMachineV1BetaTemplate(logger, openshiftMachineTemplatePath, *template.OpenShiftMachineV1Beta1Machine, selector, namespace)
 	default:
 		return []error{field.NotSupported(parentPath.Child("machineType"), template.MachineType, []string{string(machinev1.OpenShiftMachineV1Beta1MachineType)})}
 	}
@@ -223,11 +291,59 @@ func validateTemplateOnCreate(logger logr.Logger, parentPath *field.Path, templa
 }
 
 // validateOpenShiftMachineV1BetaTemplate validates the OpenShift Machine API v1beta1 template.
-func validateOpenShiftMachineV1BetaTemplate(logger logr.Logger, parentPath *field.Path, template machinev1.OpenShiftMachineV1Beta1MachineTemplate, selector metav1.LabelSelector) []error {
+func validateOpenShiftMachineV1BetaTemplate(logger logr.Logger, parentPath *field.Path, template machinev1.OpenShiftMachineV1Beta1MachineTemplate, selector metav1.LabelSelector, namespace string) []error {
 	errs := []error{}
 
 	errs = append(errs, validateTemplateLabels(parentPath.Child("metadata", "labels"), template.ObjectMeta.Labels, selector)...)
-	errs = append(errs, validateOpenShiftProviderConfig(logger, parentPath, template)...)
+	errs = append(errs, validateTemplateNamespace(parentPath.Child("spec", "metadata", "namespace"), template.Spec.ObjectMeta.Namespace, namespace)...)
+	errs = append(errs, validateTemplatePerMachineFields(parentPath.Child("spec"), template.Spec)...)
+	// When the platforms do not match, the provider config is decoded for the wrong platform,
+	// so any further errors about the provider config would be misleading.
+	if err := providerconfig.CheckFailureDomainsPlatform(template); err != nil {
+		errs = append(errs, field.Invalid(parentPath.Child("failureDomains", "platform"), template.FailureDomains.Platform, err.Error()))
+	} else {
+		errs = append(errs, validateOpenShiftProviderConfig(logger, parentPath, template)...)
+	}
+
+	errs = append(errs, validateFailureDomainsUnique(parentPath.Child("failureDomains"), template.FailureDomains)...)
+
+	return errs
+}
+
+// validateFailureDomainsUnique ensures that no failure domain is listed more than once within the failure domains.
+// A duplicated failure domain would be mapped to more than one index, so that the control plane machines would appear
+// to be spread across more failure domains than they are. Failure domains that share a zone, but differ otherwise,
+// for example, in their subnet, are distinct failure domains.
+func validateFailureDomainsUnique(parentPath *field.Path, failureDomains machinev1.FailureDomains) []error {
+	var platformPath *field.Path
+
+	switch failureDomains.Platform {
+	case configv1.AWSPlatformType:
+		platformPath = parentPath.Child("aws")
+	case configv1.AzurePlatformType:
+		platformPath = parentPath.Child("azure")
+	case configv1.GCPPlatformType:
+		platformPath = parentPath.Child("gcp")
+	default:
+		return nil
+	}
+
+	fds, err := failuredomain.NewFailureDomains(failureDomains)
+	if err != nil {
+		// Failure domains that cannot be constructed are reported by the failure domains validation.
+		return nil
+	}
+
+	errs := []error{}
+
+	for i, fd := range fds {
+		for _, previous := range fds[:i] {
+			if fd.Equal(previous) {
+				errs = append(errs, field.Duplicate(platformPath.Index(i), fd.String()))
+				break
+			}
+		}
+	}
 
 	return errs
 }
@@ -264,6 +380,44 @@ func validateTemplateLabels(labelsPath *field.Path, templateLabels map[string]st
 	return errs
 }
 
+// validateTemplateNamespace validates that, when set, the namespace within the template machine metadata matches
+// the Machine API namespace. Machines are always created within the Machine API namespace, and any objects they
+// reference, such as secrets, must also exist there.
+func validateTemplateNamespace(namespacePath *field.Path, templateNamespace, namespace string) []error {
+	if templateNamespace == "" || templateNamespace == namespace {
+		return []error{}
+	}
+
+	return []error{field.Invalid(namespacePath, templateNamespace, fmt.Sprintf("namespace must be empty or match the machine API namespace (%s)", namespace))}
+}
+
+// validateTemplatePerMachineFields validates that the template machine spec does not set fields that cannot be shared
+// by every Machine created from the template. The replica count belongs to spec.replicas of the ControlPlaneMachineSet,
+// and the provider ID is set on each Machine by the machine controller once its instance exists.
+func validateTemplatePerMachineFields(specPath *field.Path, spec machinev1beta1.MachineSpec) []error {
+	errs := []error{}
+
+	if spec.ProviderID != nil {
+		errs = append(errs, field.Forbidden(specPath.Child("providerID"), "provider ID is set on each machine by the machine controller and must not be set in the template"))
+	}
+
+	if spec.ProviderSpec.Value == nil {
+		return errs
+	}
+
+	// Errors decoding the provider spec are reported when validating the provider config.
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(spec.ProviderSpec.Value.Raw, &fields); err != nil {
+		return errs
+	}
+
+	if _, ok := fields["replicas"]; ok {
+		errs = append(errs, field.Forbidden(specPath.Child("providerSpec", "value", "replicas"), "replicas must be set in spec.replicas of the control plane machine set, not within the machine template"))
+	}
+
+	return errs
+}
+
 // validateOpenShiftProviderConfig checks the provider config on the ControlPlaneMachineSet to ensure that the
 // ControlPlaneMachineSet can safely replace control plane machines.
 func validateOpenShiftProviderConfig(logger logr.Logger, parentPath *field.Path, template machinev1.OpenShiftMachineV1Beta1MachineTemplate) []error {
@@ -275,15 +429,71 @@ func validateOpenShiftProviderConfig(logger logr.Logger, parentPath *field.Path,
 	}
 
 	switch providerConfig.Type() {
+	case configv1.AWSPlatformType:
+		errs := validateOpenShiftAWSProviderConfig(providerSpecPath.Child("value"), providerConfig.AWS())
+
+		return append(errs, validateAWSAvailabilityZonesRegion(parentPath, providerConfig.AWS().Config(), template.FailureDomains.AWS)...)
 	case configv1.AzurePlatformType:
 		return validateOpenShiftAzureProviderConfig(providerSpecPath.Child("value"), providerConfig.Azure())
 	case configv1.GCPPlatformType:
 		return validateOpenShiftGCPProviderConfig(providerSpecPath.Child("value"), providerConfig.GCP())
+	case configv1.NutanixPlatformType:
+		return validateOpenShiftNutanixProviderConfig(providerSpecPath.Child("value"), providerConfig.Nutanix())
+	case configv1.OpenStackPlatformType:
+		return validateOpenShiftOpenStackProviderSpec(providerSpecPath.Child("value"), template.Spec.ProviderSpec.Value)
+	case configv1.VSpherePlatformType:
+		return validateOpenShiftVSphereProviderSpec(providerSpecPath.Child("value"), template.Spec.ProviderSpec.Value)
 	}
 
 	return []error{}
 }
 
+// validateOpenShiftAWSProviderConfig runs AWS specific checks on the provider config on the ControlPlaneMachineSet.
+// This ensure that the ControlPlaneMachineSet can safely replace AWS control plane machines.
+func validateOpenShiftAWSProviderConfig(parentPath *field.Path, providerConfig providerconfig.AWSProviderConfig) []error {
+	errs := []error{}
+
+	config := providerConfig.Config()
+
+	if config.AMI.ID == nil && config.AMI.ARN == nil && len(config.AMI.Filters) == 0 {
+		errs = append(errs, field.Required(parentPath.Child("ami"), "ami must specify an id, arn or filters for control plane machines"))
+	}
+
+	return errs
+}
+
+// validateAWSAvailabilityZonesRegion ensures that the availability zone of each failure domain is within the region
+// of the template. A Machine placed in an availability zone of another region would never launch.
+func validateAWSAvailabilityZonesRegion(parentPath *field.Path, config machinev1beta1.AWSMachineProviderConfig, failureDomains *[]machinev1.AWSFailureDomain) []error {
+	region := config.Placement.Region
+	if region == "" || failureDomains == nil {
+		return []error{}
+	}
+
+	errs := []error{}
+
+	for i, fd := range *failureDomains {
+		if az := fd.Placement.AvailabilityZone; az != "" && !isAWSAvailabilityZoneInRegion(az, region) {
+			errs = append(errs, field.Invalid(parentPath.Child("failureDomains", "aws").Index(i).Child("placement", "availabilityZone"), az,
+				fmt.Sprintf("availability zone is not within the template region %s", region)))
+		}
+	}
+
+	return errs
+}
+
+// isAWSAvailabilityZoneInRegion returns true when the availability zone belongs to the region.
+// AWS availability zones, including Local Zones and Wavelength Zones, are named after their region, followed by a
+// letter, for example us-east-1a, or by a hyphen, for example us-east-1-bos-1a.
+func isAWSAvailabilityZoneInRegion(az, region string) bool {
+	suffix := strings.TrimPrefix(az, region)
+	if suffix == az || suffix == "" {
+		return false
+	}
+
+	return suffix[0] == '-' || (suffix[0] >= 'a' && suffix[0] <= 'z')
+}
+
 // validateOpenShiftAzureProviderConfig runs Azure specific checks on the provider config on the ControlPlaneMachineSet.
 // This ensure that the ControlPlaneMachineSet can safely replace Azure control plane machines.
 func validateOpenShiftAzureProviderConfig(parentPath *field.Path, providerConfig providerconfig.AzureProviderConfig) []error {
@@ -295,6 +505,10 @@ func validateOpenShiftAzureProviderConfig(parentPath *field.Path, providerConfig
 		errs = append(errs, field.Required(parentPath.Child("internalLoadBalancer"), "internalLoadBalancer is required for control plane machines"))
 	}
 
+	if config.VMSize == "" {
+		errs = append(errs, field.Required(parentPath.Child("vmSize"), "vmSize is required for control plane machines"))
+	}
+
 	return errs
 }
 
@@ -304,6 +518,153 @@ func validateOpenShiftGCPProviderConfig(parentPath *field.Path, providerConfig p
 	return []error{}
 }
 
+// validateOpenShiftNutanixProviderConfig runs Nutanix specific checks on the provider config on the ControlPlaneMachineSet.
+// This ensures that control plane machines are not created with resources with which they could never become ready.
+func validateOpenShiftNutanixProviderConfig(parentPath *field.Path, providerConfig providerconfig.NutanixProviderConfig) []error {
+	errs := []error{}
+
+	config := providerConfig.Config()
+
+	if config.VCPUSockets < 1 {
+		errs = append(errs, field.Invalid(parentPath.Child("vcpuSockets"), config.VCPUSockets, "vcpuSockets must be at least 1"))
+	}
+
+	if config.VCPUsPerSocket < 1 {
+		errs = append(errs, field.Invalid(parentPath.Child("vcpusPerSocket"), config.VCPUsPerSocket, "vcpusPerSocket must be at least 1"))
+	} else if config.VCPUSockets >= 1 && config.VCPUSockets*config.VCPUsPerSocket < minimumControlPlaneCPUs {
+		errs = append(errs, field.Invalid(parentPath.Child("vcpusPerSocket"), config.VCPUsPerSocket, fmt.Sprintf("control plane machines require at least %d vCPUs across all sockets", minimumControlPlaneCPUs)))
+	}
+
+	if config.MemorySize.Value() < minimumControlPlaneMemoryMiB*1024*1024 {
+		errs = append(errs, field.Invalid(parentPath.Child("memorySize"), config.MemorySize.String(), fmt.Sprintf("control plane machines require at least %dMi of memory", minimumControlPlaneMemoryMiB)))
+	}
+
+	return errs
+}
+
+// validateOpenShiftOpenStackProviderSpec runs OpenStack specific checks on the raw provider spec on the
+// ControlPlaneMachineSet, as OpenStack is handled by the generic provider config.
+// This ensures that control plane machines are not created without a flavor, as the instance could never be created.
+func validateOpenShiftOpenStackProviderSpec(parentPath *field.Path, providerSpec *runtime.RawExtension) []error {
+	spec := struct {
+		Flavor string `json:"flavor"`
+	}{}
+
+	// Errors decoding the provider spec are reported when the provider config is created.
+	if providerSpec == nil || json.Unmarshal(providerSpec.Raw, &spec) != nil {
+		return []error{}
+	}
+
+	if spec.Flavor == "" {
+		return []error{field.Required(parentPath.Child("flavor"), "flavor is required for control plane machines")}
+	}
+
+	return []error{}
+}
+
+// validateOpenShiftVSphereProviderSpec runs vSphere specific checks on the raw provider spec on the
+// ControlPlaneMachineSet, as vSphere is handled by the generic provider config.
+// The CPU, memory and disk sizes default to those of the template when they are not set, so only sizes that are
+// explicitly set are checked. This ensures that control plane machines are not created with resources with which
+// they could never become ready.
+func validateOpenShiftVSphereProviderSpec(parentPath *field.Path, providerSpec *runtime.RawExtension) []error {
+	spec := struct {
+		NumCPUs           *int32 `json:"numCPUs"`
+		NumCoresPerSocket *int32 `json:"numCoresPerSocket"`
+		MemoryMiB         *int64 `json:"memoryMiB"`
+		DiskGiB           *int32 `json:"diskGiB"`
+	}{}
+
+	// Errors decoding the provider spec are reported when the provider config is created.
+	if providerSpec == nil || json.Unmarshal(providerSpec.Raw, &spec) != nil {
+		return []error{}
+	}
+
+	errs := []error{}
+
+	if spec.NumCPUs != nil && *spec.NumCPUs < minimumControlPlaneCPUs {
+		errs = append(errs, field.Invalid(parentPath.Child("numCPUs"), *spec.NumCPUs, fmt.Sprintf("control plane machines require at least %d CPUs", minimumControlPlaneCPUs)))
+	}
+
+	if spec.NumCoresPerSocket != nil && *spec.NumCoresPerSocket < 0 {
+		errs = append(errs, field.Invalid(parentPath.Child("numCoresPerSocket"), *spec.NumCoresPerSocket, "numCoresPerSocket must not be negative"))
+	}
+
+	if spec.MemoryMiB != nil && *spec.MemoryMiB < minimumControlPlaneMemoryMiB {
+		errs = append(errs, field.Invalid(parentPath.Child("memoryMiB"), *spec.MemoryMiB, fmt.Sprintf("control plane machines require at least %d MiB of memory", minimumControlPlaneMemoryMiB)))
+	}
+
+	if spec.DiskGiB != nil && *spec.DiskGiB < 0 {
+		errs = append(errs, field.Invalid(parentPath.Child("diskGiB"), *spec.DiskGiB, "diskGiB must not be negative"))
+	}
+
+	return errs
+}
+
+// warnOnMissingReferencedSecrets returns a warning for each secret referenced by the Machine template that does not
+// exist in the ControlPlaneMachineSet namespace. The ControlPlaneMachineSet will not create Machines until the
+// secrets exist, so this is not an error, as the secrets may be created after the ControlPlaneMachineSet.
+func (r *ControlPlaneMachineSetWebhook) warnOnMissingReferencedSecrets(ctx context.Context, parentPath *field.Path, cpms *machinev1.ControlPlaneMachineSet) []string {
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil {
+		return nil
+	}
+
+	// Invalid provider configuration is reported by the validation of the template.
+	providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(r.logger, *template)
+	if err != nil {
+		return nil
+	}
+
+	secretNames, err := providerConfig.ReferencedSecretNames()
+	if err != nil {
+		return nil
+	}
+
+	providerSpecPath := parentPath.Child(string(machinev1.OpenShiftMachineV1Beta1MachineType), "spec", "providerSpec", "value")
+	warnings := []string{}
+
+	for _, secretName := range secretNames {
+		secret := &metav1.PartialObjectMetadata{}
+		secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+
+		err := r.client.Get(ctx, client.ObjectKey{Namespace: cpms.Namespace, Name: secretName}, secret)
+		if apierrors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("%s: referenced secret %s/%s does not exist, no control plane machines will be created until it does",
+				providerSpecPath, cpms.Namespace, secretName))
+		} else if err != nil {
+			r.logger.Error(err, "Could not check referenced secret", "namespace", cpms.Namespace, "secretName", secretName)
+		}
+	}
+
+	return warnings
+}
+
+// warnOnSpotControlPlaneMachines returns a warning when the Machine template requests spot virtual machines.
+// Spot virtual machines may be evicted at any time, and the eviction of several control plane machines at once
+// would lose etcd quorum, so this is rarely intended, though it is not an error.
+func (r *ControlPlaneMachineSetWebhook) warnOnSpotControlPlaneMachines(parentPath *field.Path, cpms *machinev1.ControlPlaneMachineSet) []string {
+	template := cpms.Spec.Template.OpenShiftMachineV1Beta1Machine
+	if template == nil {
+		return nil
+	}
+
+	// Invalid provider configuration is reported by the validation of the template.
+	providerConfig, err := providerconfig.NewProviderConfigFromMachineTemplate(r.logger, *template)
+	if err != nil {
+		return nil
+	}
+
+	providerSpecPath := parentPath.Child(string(machinev1.OpenShiftMachineV1Beta1MachineType), "spec", "providerSpec", "value")
+
+	if providerConfig.Type() == configv1.AzurePlatformType && providerConfig.Azure().Config().SpotVMOptions != nil {
+		return []string{fmt.Sprintf("%s: control plane machines will be created as spot virtual machines, "+
+			"which may be evicted at any time and risk the loss of etcd quorum", providerSpecPath.Child("spotVMOptions"))}
+	}
+
+	return nil
+}
+
 // fetchControlPlaneMachines returns all control plane machines in the cluster.
 func (r *ControlPlaneMachineSetWebhook) fetchControlPlaneMachines(ctx context.Context) ([]machinev1beta1.Machine, error) {
 	machineList := machinev1beta1.MachineList{}
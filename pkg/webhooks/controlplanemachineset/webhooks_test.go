@@ -18,6 +18,7 @@ package controlplanemachineset
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -31,7 +32,10 @@ import (
 	corev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/core/v1"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1"
 	machinev1beta1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	"github.com/openshift/cluster-control-plane-machine-set-operator/pkg/machineproviders"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/komega"
@@ -68,7 +72,7 @@ var _ = Describe("Webhooks", func() {
 		})
 		Expect(err).ToNot(HaveOccurred(), "Manager should be able to be created")
 
-		wh := &ControlPlaneMachineSetWebhook{}
+		wh := &ControlPlaneMachineSetWebhook{Namespace: namespaceName}
 		Expect(wh.SetupWebhookWithManager(mgr, mgr.GetLogger())).To(Succeed(), "Webhook should be able to register with manager")
 
 		By("Starting the manager")
@@ -91,6 +95,7 @@ var _ = Describe("Webhooks", func() {
 		<-mgrDone
 
 		testutils.CleanupResources(Default, ctx, cfg, k8sClient, namespaceName,
+			&corev1.Secret{},
 			&machinev1beta1.Machine{},
 			&machinev1.ControlPlaneMachineSet{},
 		)
@@ -126,6 +131,138 @@ var _ = Describe("Webhooks", func() {
 				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("metadata.name: Invalid value: \"disallowed\": control plane machine set name must be cluster")))
 			})
 
+			It("with a valid machine name prefix", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{machineproviders.MachineNamePrefixAnnotation: "prod-control-plane"})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with an invalid machine name prefix", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{machineproviders.MachineNamePrefixAnnotation: "Prod_Control_Plane"})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"metadata.annotations[controlplanemachineset.machine.openshift.io/machine-name-prefix]: Invalid value: \"Prod_Control_Plane\": machine name prefix must be a valid RFC1123 label",
+				)))
+			})
+
+			It("with a valid replicas policy", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{machineproviders.ReplicasPolicyAnnotation: "control-plane-size"})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with an invalid replicas policy", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{machineproviders.ReplicasPolicyAnnotation: "Control_Plane_Size"})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"metadata.annotations[controlplanemachineset.machine.openshift.io/replicas-policy]: Invalid value: \"Control_Plane_Size\": replicas policy must be the name of a config map",
+				)))
+			})
+
+			It("with valid machine owner references", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{
+					machineproviders.MachineOwnerReferencesAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"backup-policy","uid":"backup-policy-uid"}]`,
+				})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with machine owner references that are not a JSON list", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{machineproviders.MachineOwnerReferencesAnnotation: "backup-policy"})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"metadata.annotations[controlplanemachineset.machine.openshift.io/machine-owner-references]: Invalid value: \"backup-policy\": machine owner references must be a JSON list of owner references",
+				)))
+			})
+
+			It("with a controller machine owner reference", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{
+					machineproviders.MachineOwnerReferencesAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"backup-policy","uid":"backup-policy-uid","controller":true}]`,
+				})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"machine owner reference 0 must not be a controller reference, the control plane machine set is the controller of its machines",
+				)))
+			})
+
+			It("with an incomplete machine owner reference", func() {
+				cpms := builder.Build()
+				cpms.SetAnnotations(map[string]string{
+					machineproviders.MachineOwnerReferencesAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"backup-policy"}]`,
+				})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"machine owner reference 0 must specify an apiVersion, kind, name and uid",
+				)))
+			})
+
+			It("without an AMI", func() {
+				providerConfig := machinev1beta1resourcebuilder.AWSProviderSpec().WithAvailabilityZone("us-east-1").Build()
+				providerConfig.AMI = machinev1beta1.AWSResourceReference{}
+
+				rawProviderConfig, err := json.Marshal(providerConfig)
+				Expect(err).ToNot(HaveOccurred())
+
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: rawProviderConfig}
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(
+					ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.ami: Required value: ami must specify an id, arn or filters for control plane machines"),
+				))
+			})
+
+			It("with an AWS provider spec and Azure failure domains", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(machinev1resourcebuilder.AzureFailureDomains())).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(
+					"spec.template.machines_v1beta1_machine_openshift_io.failureDomains.platform: Invalid value: \"Azure\": failure domains platform does not match provider spec platform: failure domains are for Azure, provider spec is for AWS",
+				)))
+			})
+
+			It("with a missing user data secret", func() {
+				Expect(k8sClient.Create(ctx, corev1resourcebuilder.Secret().WithName("aws-cloud-credentials").WithNamespace(namespaceName).Build())).To(Succeed())
+
+				wh := &ControlPlaneMachineSetWebhook{client: k8sClient, Namespace: namespaceName}
+
+				warnings, err := wh.ValidateCreate(ctx, builder.Build())
+				Expect(err).ToNot(HaveOccurred(), "A missing secret should not prevent the control plane machine set from being created")
+				Expect(warnings).To(ConsistOf(fmt.Sprintf(
+					"spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value: referenced secret %s/aws-user-data-12345678 does not exist, no control plane machines will be created until it does",
+					namespaceName,
+				)))
+			})
+
+			Context("with an existing control plane machine set", func() {
+				BeforeEach(func() {
+					Expect(k8sClient.Create(ctx, builder.Build())).To(Succeed())
+				})
+
+				// The singleton name means a second control plane machine set can never be created alongside
+				// the first, so two control plane machine sets can never compete for the same machines.
+				It("with an overlapping selector", func() {
+					cpms := builder.WithName("overlapping").Build()
+					Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("metadata.name: Invalid value: \"overlapping\": control plane machine set name must be cluster")))
+				})
+
+				It("with a disjoint selector", func() {
+					cpms := builder.WithName("disjoint").WithSelector(metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							openshiftMachineRoleLabel:            masterMachineRole,
+							openshiftMachineTypeLabel:            masterMachineRole,
+							machinev1beta1.MachineClusterIDLabel: "other-cluster",
+						},
+					}).WithMachineTemplateBuilder(machineTemplate.WithLabel(machinev1beta1.MachineClusterIDLabel, "other-cluster")).Build()
+					Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("metadata.name: Invalid value: \"disjoint\": control plane machine set name must be cluster")))
+				})
+			})
+
 			It("with 4 replicas", func() {
 				// This is an openapi validation but it makes sense to include it here as well
 				cpms := builder.WithReplicas(4).Build()
@@ -150,6 +287,53 @@ var _ = Describe("Webhooks", func() {
 				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.metadata.labels: Invalid value: map[string]string{\"machine.openshift.io/cluster-api-cluster\":\"different-id\", \"machine.openshift.io/cluster-api-machine-role\":\"master\", \"machine.openshift.io/cluster-api-machine-type\":\"master\"}: selector does not match template labels")))
 			})
 
+			It("with a template machine namespace that does not match the machine API namespace", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ObjectMeta.Namespace = "default"
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(fmt.Sprintf("spec.template.machines_v1beta1_machine_openshift_io.spec.metadata.namespace: Invalid value: \"default\": namespace must be empty or match the machine API namespace (%s)", namespaceName))))
+			})
+
+			It("with a template machine namespace that matches a control plane machine set outside the machine API namespace", func() {
+				ns := corev1resourcebuilder.Namespace().WithGenerateName("control-plane-machine-set-webhook-").Build()
+				Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+				cpms := builder.WithNamespace(ns.GetName()).Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ObjectMeta.Namespace = ns.GetName()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring(fmt.Sprintf("spec.template.machines_v1beta1_machine_openshift_io.spec.metadata.namespace: Invalid value: \"%s\": namespace must be empty or match the machine API namespace (%s)", ns.GetName(), namespaceName))))
+			})
+
+			It("with replicas set within the template provider spec", func() {
+				cpms := builder.Build()
+
+				providerSpec := map[string]interface{}{}
+				Expect(json.Unmarshal(cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value.Raw, &providerSpec)).To(Succeed())
+
+				providerSpec["replicas"] = 3
+
+				rawProviderSpec, err := json.Marshal(providerSpec)
+				Expect(err).ToNot(HaveOccurred())
+
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: rawProviderSpec}
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.replicas: Forbidden: replicas must be set in spec.replicas of the control plane machine set, not within the machine template")))
+			})
+
+			It("with a provider ID set within the template", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderID = pointer.String("aws:///us-east-1a/i-0123456789abcdef0")
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerID: Forbidden: provider ID is set on each machine by the machine controller and must not be set in the template")))
+			})
+
+			It("with a template machine namespace that matches the control plane machine set namespace", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ObjectMeta.Namespace = namespaceName
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
 			It("with no cluster ID label is set", func() {
 				cpms := builder.WithSelector(metav1.LabelSelector{
 					MatchLabels: map[string]string{
@@ -436,6 +620,51 @@ var _ = Describe("Webhooks", func() {
 					Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
 				})
 
+				It("with an availability zone in another region", func() {
+					cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+						machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+							usEast1aBuilder,
+							usEast1bBuilder,
+							usEast1cBuilder,
+							machinev1resourcebuilder.AWSFailureDomain().WithAvailabilityZone("eu-west-1a").WithSubnet(filterSubnet),
+						),
+					)).Build()
+
+					Expect(k8sClient.Create(ctx, cpms)).To(MatchError(
+						ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.aws[3].placement.availabilityZone: Invalid value: \"eu-west-1a\": availability zone is not within the template region us-east-1"),
+					))
+				})
+
+				It("with a duplicated availability zone", func() {
+					cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+						machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+							usEast1aBuilder,
+							usEast1bBuilder,
+							usEast1cBuilder,
+							usEast1aBuilder,
+						),
+					)).Build()
+
+					Expect(k8sClient.Create(ctx, cpms)).To(MatchError(
+						ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.failureDomains.aws[3]: Duplicate value: " +
+							"\"AWSFailureDomain{AvailabilityZone:us-east-1a, Subnet:{Type:Filters, Value:&[{Name:tag:Name Values:[aws-subnet-12345678]}]}}\""),
+					))
+				})
+
+				It("with the same availability zone on two subnets", func() {
+					cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+						machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
+							usEast1aBuilder,
+							usEast1bBuilder,
+							usEast1cBuilder,
+							usEast1cBuilderWithSubnet,
+						),
+					)).Build()
+
+					// Each subnet is a distinct failure domain, even though both are in the same availability zone.
+					Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+				})
+
 				It("when the availability zones don't match", func() {
 					cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
 						machinev1resourcebuilder.AWSFailureDomains().WithFailureDomainBuilders(
@@ -621,6 +850,48 @@ var _ = Describe("Webhooks", func() {
 					ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.internalLoadBalancer: Required value: internalLoadBalancer is required for control plane machines"),
 				))
 			})
+
+			It("without a VM size", func() {
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					machinev1resourcebuilder.AzureFailureDomains().WithFailureDomainBuilders(
+						zone1Builder,
+						zone2Builder,
+						zone3Builder,
+					),
+				).WithProviderSpecBuilder(
+					machinev1beta1resourcebuilder.AzureProviderSpec().WithVMSize(""), // Set to the empty string to remove it.
+				)).Build()
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(
+					ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.vmSize: Required value: vmSize is required for control plane machines"),
+				))
+			})
+
+			It("with spot VM options", func() {
+				providerConfig := machinev1beta1resourcebuilder.AzureProviderSpec().Build()
+				providerConfig.SpotVMOptions = &machinev1beta1.SpotVMOptions{}
+
+				rawProviderConfig, err := json.Marshal(providerConfig)
+				Expect(err).ToNot(HaveOccurred())
+
+				cpms := builder.WithMachineTemplateBuilder(machineTemplate.WithFailureDomainsBuilder(
+					machinev1resourcebuilder.AzureFailureDomains().WithFailureDomainBuilders(
+						zone1Builder,
+						zone2Builder,
+						zone3Builder,
+					),
+				)).Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: rawProviderConfig}
+
+				wh := &ControlPlaneMachineSetWebhook{client: k8sClient, Namespace: namespaceName}
+
+				warnings, err := wh.ValidateCreate(ctx, cpms)
+				Expect(err).ToNot(HaveOccurred(), "Spot VM options should not prevent the control plane machine set from being created")
+				Expect(warnings).To(ContainElement(
+					"spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.spotVMOptions: control plane machines will be created as spot virtual machines, " +
+						"which may be evicted at any time and risk the loss of etcd quorum",
+				))
+			})
 		})
 
 		Context("on GCP", func() {
@@ -698,6 +969,79 @@ var _ = Describe("Webhooks", func() {
 				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
 			})
 		})
+
+		Context("on vSphere", func() {
+			// vSphereProviderSpecWith returns a vSphere provider spec with the given fields overridden.
+			vSphereProviderSpecWith := func(fields map[string]interface{}) *runtime.RawExtension {
+				providerSpec := map[string]interface{}{}
+				Expect(json.Unmarshal(machinev1beta1resourcebuilder.VSphereProviderSpec().BuildRawExtension().Raw, &providerSpec)).To(Succeed())
+
+				for key, value := range fields {
+					if value == nil {
+						delete(providerSpec, key)
+						continue
+					}
+
+					providerSpec[key] = value
+				}
+
+				raw, err := json.Marshal(providerSpec)
+				Expect(err).ToNot(HaveOccurred())
+
+				return &runtime.RawExtension{Raw: raw}
+			}
+
+			BeforeEach(func() {
+				providerSpec := machinev1beta1resourcebuilder.VSphereProviderSpec()
+				machineTemplate = machinev1resourcebuilder.OpenShiftMachineV1Beta1Template().WithProviderSpecBuilder(providerSpec)
+				// Default CPMS builder should be valid, individual tests will override to make it invalid
+				builder = machinev1resourcebuilder.ControlPlaneMachineSet().WithNamespace(namespaceName).WithMachineTemplateBuilder(machineTemplate)
+
+				machineBuilder := machinev1beta1resourcebuilder.Machine().WithNamespace(namespaceName)
+
+				By("Creating a selection of Machines")
+				for i := 0; i < 3; i++ {
+					controlPlaneMachine := machineBuilder.WithGenerateName("control-plane-machine-").AsMaster().WithProviderSpecBuilder(providerSpec).Build()
+					Expect(k8sClient.Create(ctx, controlPlaneMachine)).To(Succeed())
+				}
+			})
+
+			It("with a valid provider spec", func() {
+				Expect(k8sClient.Create(ctx, builder.Build())).To(Succeed())
+			})
+
+			It("with sizes left to the template defaults", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = vSphereProviderSpecWith(map[string]interface{}{
+					"numCPUs":   nil,
+					"memoryMiB": nil,
+				})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(Succeed())
+			})
+
+			It("with 0 CPUs", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = vSphereProviderSpecWith(map[string]interface{}{
+					"numCPUs": 0,
+				})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(
+					ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.numCPUs: Invalid value: 0: control plane machines require at least 2 CPUs"),
+				))
+			})
+
+			It("with too little memory", func() {
+				cpms := builder.Build()
+				cpms.Spec.Template.OpenShiftMachineV1Beta1Machine.Spec.ProviderSpec.Value = vSphereProviderSpecWith(map[string]interface{}{
+					"memoryMiB": -1024,
+				})
+
+				Expect(k8sClient.Create(ctx, cpms)).To(MatchError(
+					ContainSubstring("spec.template.machines_v1beta1_machine_openshift_io.spec.providerSpec.value.memoryMiB: Invalid value: -1024: control plane machines require at least 8192 MiB of memory"),
+				))
+			})
+		})
 	})
 
 	Context("on update", func() {